@@ -0,0 +1,329 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"gosmooth/config"
+	"gosmooth/handlers"
+	"gosmooth/migrations"
+	"gosmooth/seed"
+	"gosmooth/sso/ssotest"
+)
+
+// newFlagSet returns a flag set that reports errors instead of exiting
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet("gosmooth "+name, flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	return fs
+}
+
+func configCommand(ctx context.Context, cfg *config.Config, db *mongo.Database, args []string) error {
+	if err := newFlagSet("config").Parse(args); err != nil {
+		return err
+	}
+	cfg.WriteRedacted(os.Stdout)
+	return nil
+}
+
+func migrateCommand(ctx context.Context, cfg *config.Config, db *mongo.Database, args []string) error {
+	action := "up"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		action, args = args[0], args[1:]
+	}
+
+	fs := newFlagSet("migrate " + action)
+	steps := fs.Int("steps", 1, "number of migrations to revert (down only)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	switch action {
+	case "up":
+		ran, err := migrations.Up(ctx, db)
+		for _, m := range ran {
+			fmt.Printf("applied %04d_%s\n", m.Version, m.Name)
+		}
+		if err == nil && len(ran) == 0 {
+			fmt.Println("database is up to date")
+		}
+		return err
+	case "down":
+		if *steps < 1 {
+			return errors.New("-steps must be at least 1")
+		}
+		reverted, err := migrations.Down(ctx, db, *steps)
+		for _, m := range reverted {
+			fmt.Printf("reverted %04d_%s\n", m.Version, m.Name)
+		}
+		return err
+	case "status":
+		entries, err := migrations.Status(ctx, db)
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			state := "pending"
+			if e.Applied {
+				state = "applied " + e.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d_%-30s %s\n", e.Version, e.Name, state)
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown migrate action %q (want up, down or status)", action)
+}
+
+func seedCommand(ctx context.Context, cfg *config.Config, db *mongo.Database, args []string) error {
+	if err := newFlagSet("seed").Parse(args); err != nil {
+		return err
+	}
+	if err := seed.Run(ctx, db, cfg.Env); err != nil {
+		return err
+	}
+	fmt.Println("demo data loaded")
+	return nil
+}
+
+func userCreateCommand(ctx context.Context, cfg *config.Config, db *mongo.Database, args []string) error {
+	fs := newFlagSet("user create")
+	email := fs.String("email", "", "email address (required)")
+	name := fs.String("name", "", "display name (required)")
+	password := fs.String("password", "", "password; a random one is generated and printed if omitted")
+	role := fs.String("role", "user", "user or admin")
+	temporary := fs.Bool("temporary", false, "require a password change at first login (always on for generated passwords)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	generated := *password == ""
+	if generated {
+		*password = randomPassword()
+	}
+	user, err := handlers.CreateUserAccount(ctx, *email, *name, *password, *role, *temporary || generated)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("created %s %s (%s)\n", user.Role, user.Email, user.ID.Hex())
+	if generated {
+		fmt.Printf("password: %s\n", *password)
+	}
+	return nil
+}
+
+func userPromoteCommand(ctx context.Context, cfg *config.Config, db *mongo.Database, args []string) error {
+	fs := newFlagSet("user promote")
+	email := fs.String("email", "", "email address (required)")
+	role := fs.String("role", "admin", "new role: user or admin")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *email == "" {
+		return errors.New("-email is required")
+	}
+	if err := handlers.SetUserRole(ctx, *email, *role); err != nil {
+		return err
+	}
+	fmt.Printf("%s is now %s\n", *email, *role)
+	return nil
+}
+
+func userResetPasswordCommand(ctx context.Context, cfg *config.Config, db *mongo.Database, args []string) error {
+	fs := newFlagSet("user reset-password")
+	email := fs.String("email", "", "email address (required)")
+	password := fs.String("password", "", "new password; a random one is generated and printed if omitted")
+	temporary := fs.Bool("temporary", true, "require the user to change the password at next login")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *email == "" {
+		return errors.New("-email is required")
+	}
+
+	generated := *password == ""
+	if generated {
+		*password = randomPassword()
+	}
+	if err := handlers.ResetUserPassword(ctx, *email, *password, *temporary || generated); err != nil {
+		return err
+	}
+	fmt.Printf("password for %s has been reset\n", *email)
+	if generated {
+		fmt.Printf("password: %s\n", *password)
+	}
+	return nil
+}
+
+func userExpirePasswordCommand(ctx context.Context, cfg *config.Config, db *mongo.Database, args []string) error {
+	fs := newFlagSet("user expire-password")
+	email := fs.String("email", "", "email address (required)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *email == "" {
+		return errors.New("-email is required")
+	}
+	if err := handlers.RequirePasswordChangeByEmail(ctx, *email); err != nil {
+		return err
+	}
+	fmt.Printf("%s must change their password at next login\n", *email)
+	return nil
+}
+
+func placesImportCommand(ctx context.Context, cfg *config.Config, db *mongo.Database, args []string) error {
+	fs := newFlagSet("places import")
+	file := fs.String("file", "", "CSV or GeoJSON file to import (required)")
+	format := fs.String("format", "", "csv or geojson; inferred from the file extension if omitted")
+	dryRun := fs.Bool("dry-run", false, "validate and report without writing")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *file == "" {
+		return errors.New("-file is required")
+	}
+
+	f, err := os.Open(*file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var records []handlers.PlaceRecord
+	switch {
+	case *format == "csv" || (*format == "" && strings.HasSuffix(strings.ToLower(*file), ".csv")):
+		records, err = handlers.ParsePlacesCSV(f)
+	case *format == "geojson" || (*format == "" && (strings.HasSuffix(strings.ToLower(*file), ".geojson") || strings.HasSuffix(strings.ToLower(*file), ".json"))):
+		records, err = handlers.ParsePlacesGeoJSON(f)
+	default:
+		return errors.New("-format must be csv or geojson")
+	}
+	if err != nil {
+		return err
+	}
+
+	report, err := handlers.ImportPlaces(ctx, records, *dryRun)
+	if err != nil {
+		return err
+	}
+	out, _ := json.MarshalIndent(report, "", "  ")
+	fmt.Println(string(out))
+	if report.Invalid > 0 {
+		return fmt.Errorf("%d invalid rows, nothing was imported", report.Invalid)
+	}
+	return nil
+}
+
+func ratingsRebuildCommand(ctx context.Context, cfg *config.Config, db *mongo.Database, args []string) error {
+	if err := newFlagSet("ratings rebuild").Parse(args); err != nil {
+		return err
+	}
+	n, err := handlers.RebuildPlaceRatings(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("updated ratings for %d places\n", n)
+	return nil
+}
+
+func likesRepairCommand(ctx context.Context, cfg *config.Config, db *mongo.Database, args []string) error {
+	if err := newFlagSet("likes repair").Parse(args); err != nil {
+		return err
+	}
+	n, err := handlers.RepairLikeCounts(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("repaired vote counts on %d reviews and comments\n", n)
+	if n > 0 {
+		fmt.Println(`run "gosmooth reviews rerank" to bring rank scores up to date`)
+	}
+	return nil
+}
+
+func reviewsRerankCommand(ctx context.Context, cfg *config.Config, db *mongo.Database, args []string) error {
+	if err := newFlagSet("reviews rerank").Parse(args); err != nil {
+		return err
+	}
+	n, err := handlers.RerankReviews(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("ranked %d reviews\n", n)
+	return nil
+}
+
+// oidcMockProviderCommand serves ssotest.Provider so social login can be
+// tried locally: set OIDC_ISSUER to the printed issuer and the client id and
+// secret to the flag values.
+func oidcMockProviderCommand(ctx context.Context, cfg *config.Config, db *mongo.Database, args []string) error {
+	fs := newFlagSet("oidc mock-provider")
+	addr := fs.String("addr", "localhost:9999", "listen address")
+	clientID := fs.String("client-id", "gosmooth", "accepted client id")
+	clientSecret := fs.String("client-secret", "gosmooth-secret", "accepted client secret")
+	subject := fs.String("subject", "mock-user-1", "subject of the signed-in user")
+	email := fs.String("email", "mock.user@example.com", "email of the signed-in user")
+	name := fs.String("name", "Mock User", "name of the signed-in user")
+	unverified := fs.Bool("unverified", false, "report the email as unverified")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	issuer := "http://" + *addr
+	provider, err := ssotest.New(issuer, *clientID, *clientSecret)
+	if err != nil {
+		return err
+	}
+	provider.SetUser(ssotest.User{Subject: *subject, Email: *email, EmailVerified: !*unverified, Name: *name})
+
+	srv := &http.Server{Addr: *addr, Handler: provider, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		srv.Close()
+	}()
+	fmt.Printf("mock OpenID Connect provider listening; set OIDC_ISSUER=%s OIDC_CLIENT_ID=%s OIDC_CLIENT_SECRET=%s\n",
+		issuer, *clientID, *clientSecret)
+	if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// randomPassword returns a 16 character password that satisfies the password
+// policy: it always mixes lowercase, uppercase, digits and symbols.
+func randomPassword() string {
+	sets := []string{"abcdefghijkmnopqrstuvwxyz", "ABCDEFGHJKLMNPQRSTUVWXYZ", "23456789", "!@#$%*-_+="}
+	alphabet := strings.Join(sets, "")
+	b := make([]byte, 16)
+	for i := range b {
+		set := alphabet
+		if i < len(sets) {
+			set = sets[i] // one of each class first
+		}
+		b[i] = set[randomInt(len(set))]
+	}
+	// สลับตำแหน่งไม่ให้ 4 ตัวแรกเดาได้ว่าเป็นชนิดไหน
+	for i := len(b) - 1; i > 0; i-- {
+		j := randomInt(i + 1)
+		b[i], b[j] = b[j], b[i]
+	}
+	return string(b)
+}
+
+func randomInt(n int) int {
+	v, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		panic(err)
+	}
+	return int(v.Int64())
+}
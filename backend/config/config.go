@@ -0,0 +1,509 @@
+// Package config loads the typed application configuration shared by the
+// server and the CLI.
+//
+// Values are resolved with this precedence, highest first:
+//
+//  1. command-line flags (-port 9000)
+//  2. environment variables (PORT=9000)
+//  3. an optional dotenv file (-config path, CONFIG_FILE, or ./.env if present)
+//  4. built-in defaults
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/url"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+
+	"gosmooth/ratelimit"
+)
+
+// Config is the effective configuration. Each field is bound to an
+// environment variable through its env tag; the flag name is derived from it.
+type Config struct {
+	Env          string `env:"APP_ENV" default:"development" usage:"environment: development, test or production"`
+	Port         string `env:"PORT" default:"8080" usage:"HTTP listen port"`
+	MongoURI     string `env:"MONGODB_URI" default:"mongodb://localhost:27017" secret:"uri" usage:"MongoDB connection string"`
+	DBName       string `env:"DB_NAME" default:"gosmooth" usage:"MongoDB database name"`
+	JWTSecret    string `env:"JWT_SECRET" secret:"true" usage:"HMAC secret used to sign access tokens (at least 32 characters)"`
+	SeedDemoData bool   `env:"SEED_DEMO_DATA" default:"true" usage:"load demo data at startup (never in production)"`
+
+	LogLevel  string `env:"LOG_LEVEL" default:"info" usage:"minimum log level: debug, info, warn or error"`
+	LogFormat string `env:"LOG_FORMAT" default:"json" usage:"log output format: json or text"`
+	LogFile   string `env:"LOG_FILE" usage:"append logs to this file instead of stderr"`
+
+	MetricsEnabled bool   `env:"METRICS_ENABLED" default:"true" usage:"expose Prometheus metrics on /metrics"`
+	MetricsToken   string `env:"METRICS_TOKEN" secret:"true" usage:"bearer token required to scrape /metrics (optional)"`
+
+	TraceExporter     string  `env:"TRACE_EXPORTER" default:"none" usage:"trace exporter: none, otlp, stdout or file"`
+	TraceOTLPEndpoint string  `env:"TRACE_OTLP_ENDPOINT" usage:"OTLP/HTTP collector URL, e.g. http://localhost:4318 (falls back to OTEL_EXPORTER_OTLP_ENDPOINT)"`
+	TraceFile         string  `env:"TRACE_FILE" usage:"file for the file exporter, also used when otlp has no endpoint"`
+	TraceSampleRatio  float64 `env:"TRACE_SAMPLE_RATIO" default:"1" usage:"fraction of new traces to sample, 0 to 1"`
+
+	SMTPHost     string `env:"SMTP_HOST" usage:"SMTP server for outgoing mail; empty disables the mailer"`
+	SMTPPort     int64  `env:"SMTP_PORT" default:"587" usage:"SMTP server port"`
+	SMTPUsername string `env:"SMTP_USERNAME" usage:"SMTP login"`
+	SMTPPassword string `env:"SMTP_PASSWORD" secret:"true" usage:"SMTP password"`
+	MailFrom     string `env:"MAIL_FROM" usage:"sender address for outgoing mail"`
+
+	CORSOrigins []string `env:"CORS_ORIGINS" default:"http://localhost:5173" usage:"comma separated list of allowed CORS origins"`
+	// TrustedProxies decides whose X-Forwarded-For is believed, which matters
+	// for rate limits keyed by client IP
+	TrustedProxies []string `env:"TRUSTED_PROXIES" usage:"comma separated reverse proxy IPs or CIDRs allowed to set X-Forwarded-For; empty trusts none"`
+
+	PublicURL   string `env:"PUBLIC_URL" default:"http://localhost:8080" usage:"externally reachable base URL of the API, used for OIDC callbacks"`
+	FrontendURL string `env:"FRONTEND_URL" default:"http://localhost:5173" usage:"base URL of the web app that OIDC logins return to"`
+
+	GoogleClientID     string `env:"GOOGLE_CLIENT_ID" usage:"Google OAuth client ID; empty disables Google login"`
+	GoogleClientSecret string `env:"GOOGLE_CLIENT_SECRET" secret:"true" usage:"Google OAuth client secret"`
+	LineClientID       string `env:"LINE_CLIENT_ID" usage:"LINE Login channel ID; empty disables LINE login"`
+	LineClientSecret   string `env:"LINE_CLIENT_SECRET" secret:"true" usage:"LINE Login channel secret"`
+	OIDCIssuer         string `env:"OIDC_ISSUER" usage:"issuer URL of an additional OpenID Connect provider; empty disables it"`
+	OIDCClientID       string `env:"OIDC_CLIENT_ID" usage:"client ID at OIDC_ISSUER"`
+	OIDCClientSecret   string `env:"OIDC_CLIENT_SECRET" secret:"true" usage:"client secret at OIDC_ISSUER"`
+	OIDCName           string `env:"OIDC_NAME" default:"oidc" usage:"URL name of the OIDC_ISSUER provider, e.g. keycloak"`
+	OIDCDisplayName    string `env:"OIDC_DISPLAY_NAME" default:"Single sign-on" usage:"button label for the OIDC_ISSUER provider"`
+
+	AccessTokenTTL     time.Duration `env:"ACCESS_TOKEN_TTL" default:"24h" usage:"lifetime of a normal access token"`
+	RememberMeTokenTTL time.Duration `env:"REMEMBER_ME_TOKEN_TTL" default:"168h" usage:"lifetime of a remember-me access token"`
+
+	PasswordMinLength    int64         `env:"PASSWORD_MIN_LENGTH" default:"10" usage:"minimum length of new passwords"`
+	PasswordMinClasses   int64         `env:"PASSWORD_MIN_CLASSES" default:"2" usage:"how many of lowercase, uppercase, digits and symbols a new password must mix (1-4)"`
+	PasswordDenyListFile string        `env:"PASSWORD_DENYLIST_FILE" usage:"extra common passwords to reject, one per line"`
+	PasswordBreachFile   string        `env:"PASSWORD_BREACH_FILE" usage:"SHA-1 breached password corpus ordered by hash, e.g. the Pwned Passwords download"`
+	AdminPasswordMaxAge  time.Duration `env:"ADMIN_PASSWORD_MAX_AGE" default:"0s" usage:"force admins to change passwords older than this; 0 disables expiry"`
+
+	APIKeyDefaultTTL time.Duration `env:"API_KEY_DEFAULT_TTL" default:"2160h" usage:"lifetime of an API key when none is requested"`
+	APIKeyMaxTTL     time.Duration `env:"API_KEY_MAX_TTL" default:"8760h" usage:"longest lifetime an API key may be issued with"`
+	APIKeyRateLimit  int64         `env:"API_KEY_RATE_LIMIT" default:"60" usage:"requests per minute allowed per API key unless the key sets its own"`
+
+	RateLimitEnabled      bool   `env:"RATE_LIMIT_ENABLED" default:"true" usage:"throttle requests per route group"`
+	RateLimitStore        string `env:"RATE_LIMIT_STORE" default:"memory" usage:"where rate limit buckets are kept: memory (per instance) or mongodb (shared by all instances)"`
+	RateLimitExemptAdmins bool   `env:"RATE_LIMIT_EXEMPT_ADMINS" default:"true" usage:"skip route rate limits for signed-in admins"`
+	RateLimitPolicies     string `env:"RATE_LIMIT_POLICIES" usage:"override route policies as NAME=LIMIT/PERIOD[/BURST], e.g. login=20/1m,report=5/1h/2"`
+
+	UploadDir      string `env:"UPLOAD_DIR" default:"./uploads" usage:"directory for uploaded images"`
+	UploadMaxBytes int64  `env:"UPLOAD_MAX_BYTES" default:"10485760" usage:"maximum size of a single upload in bytes"`
+
+	ImageThumbnailSize int64 `env:"IMAGE_THUMBNAIL_SIZE" default:"400" usage:"longest edge of generated thumbnails in pixels"`
+	ImageMaxPixels     int64 `env:"IMAGE_MAX_PIXELS" default:"40000000" usage:"largest width × height accepted for an uploaded image"`
+	ImageKeepLocation  bool  `env:"IMAGE_KEEP_LOCATION" default:"false" usage:"keep GPS and XMP metadata in uploaded images instead of stripping it"`
+	ReviewPhotoMax     int64 `env:"REVIEW_PHOTO_MAX" default:"6" usage:"maximum number of photos attached to one review"`
+
+	ReviewRankHalfLife time.Duration `env:"REVIEW_RANK_HALF_LIFE" default:"720h" usage:"age at which a review's weight in the relevant sort halves; run reviews rerank after changing it"`
+
+	ScreeningEnabled       bool          `env:"SCREENING_ENABLED" default:"true" usage:"hold new reviews and comments that look like spam or abuse for moderation"`
+	ScreeningWordsFile     string        `env:"SCREENING_WORDS_FILE" usage:"extra words that hold a review or comment for moderation, one per line"`
+	ScreeningBurstLimit    int64         `env:"SCREENING_BURST_LIMIT" default:"5" usage:"reviews and comments a user may post within SCREENING_BURST_WINDOW before further ones are held"`
+	ScreeningBurstWindow   time.Duration `env:"SCREENING_BURST_WINDOW" default:"10m" usage:"period over which SCREENING_BURST_LIMIT is counted"`
+	ScreeningNewAccountAge time.Duration `env:"SCREENING_NEW_ACCOUNT_AGE" default:"72h" usage:"accounts younger than this have ratings far from a place's average held"`
+
+	CheckInRadius   int64         `env:"CHECK_IN_RADIUS" default:"300" usage:"how close to a place, in meters, a user must be to check in"`
+	CheckInInterval time.Duration `env:"CHECK_IN_INTERVAL" default:"1h" usage:"how long a user must wait before checking in at the same place again"`
+
+	ReadTimeout         time.Duration `env:"HTTP_READ_TIMEOUT" default:"15s" usage:"HTTP server read timeout"`
+	WriteTimeout        time.Duration `env:"HTTP_WRITE_TIMEOUT" default:"15s" usage:"HTTP server write timeout"`
+	IdleTimeout         time.Duration `env:"HTTP_IDLE_TIMEOUT" default:"60s" usage:"HTTP server idle timeout"`
+	ShutdownTimeout     time.Duration `env:"SHUTDOWN_TIMEOUT" default:"10s" usage:"graceful shutdown timeout"`
+	MongoConnectTimeout time.Duration `env:"MONGODB_CONNECT_TIMEOUT" default:"10s" usage:"MongoDB connect and ping timeout"`
+
+	AccountDeletionGracePeriod time.Duration `env:"ACCOUNT_DELETION_GRACE_PERIOD" default:"720h" usage:"time before a deleted account is anonymized"`
+
+	// File is the dotenv file that was loaded, if any
+	File string `env:"-"`
+}
+
+// providerName is the form of an OIDC provider's name in URLs
+var providerName = regexp.MustCompile(`^[a-z0-9-]{1,32}$`)
+
+// minSecretLength is the shortest JWT secret accepted (256 bits of HMAC key)
+const minSecretLength = 32
+
+// weakSecrets are placeholder values that must never sign real tokens
+var weakSecrets = map[string]bool{
+	"your-secret-key-here": true,
+	"secret":               true,
+	"changeme":             true,
+	"change-me":            true,
+	"jwt-secret":           true,
+}
+
+// Load resolves the configuration from args (global flags), the environment
+// and an optional dotenv file, then validates it. It returns the remaining
+// non-flag arguments, which the CLI treats as the command.
+func Load(args []string) (*Config, []string, error) {
+	cfg := &Config{}
+	fs := flag.NewFlagSet("gosmooth", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	configFile := fs.String("config", os.Getenv("CONFIG_FILE"), "dotenv file to load")
+	flagValues := map[string]*string{}
+	eachField(cfg, func(f reflect.StructField, _ reflect.Value) {
+		flagValues[f.Tag.Get("env")] = fs.String(flagName(f), "", f.Tag.Get("usage"))
+	})
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			fs.SetOutput(os.Stderr)
+			fs.PrintDefaults()
+		}
+		return nil, nil, err
+	}
+	setFlags := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { setFlags[f.Name] = true })
+
+	// The dotenv file is optional; an explicitly named one must exist
+	fileValues := map[string]string{}
+	path := *configFile
+	if path == "" {
+		if _, err := os.Stat(".env"); err == nil {
+			path = ".env"
+		}
+	}
+	if path != "" {
+		values, err := godotenv.Read(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("config: reading %s: %w", path, err)
+		}
+		fileValues = values
+		cfg.File = path
+	}
+
+	var errs []string
+	eachField(cfg, func(f reflect.StructField, v reflect.Value) {
+		key := f.Tag.Get("env")
+		raw, ok := f.Tag.Lookup("default")
+		if value, found := fileValues[key]; found {
+			raw, ok = value, true
+		}
+		if value, found := os.LookupEnv(key); found {
+			raw, ok = value, true
+		}
+		if setFlags[flagName(f)] {
+			raw, ok = *flagValues[key], true
+		}
+		if !ok {
+			return
+		}
+		if err := setValue(v, raw); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", key, err))
+		}
+	})
+	if len(errs) > 0 {
+		return nil, nil, fmt.Errorf("config: %s", strings.Join(errs, "; "))
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, nil, err
+	}
+	return cfg, fs.Args(), nil
+}
+
+// Validate checks required values and the strength of secrets
+func (c *Config) Validate() error {
+	var problems []string
+	switch c.Env {
+	case "development", "test", "production":
+	default:
+		problems = append(problems, fmt.Sprintf("APP_ENV must be development, test or production, got %q", c.Env))
+	}
+	if c.Port == "" {
+		problems = append(problems, "PORT is required")
+	}
+	if c.MongoURI == "" {
+		problems = append(problems, "MONGODB_URI is required")
+	}
+	if c.DBName == "" {
+		problems = append(problems, "DB_NAME is required")
+	}
+
+	switch {
+	case c.JWTSecret == "":
+		problems = append(problems, "JWT_SECRET is required")
+	case weakSecrets[strings.ToLower(c.JWTSecret)]:
+		problems = append(problems, "JWT_SECRET is a well-known placeholder, generate a random one (e.g. openssl rand -hex 32)")
+	case len(c.JWTSecret) < minSecretLength:
+		problems = append(problems, fmt.Sprintf("JWT_SECRET must be at least %d characters", minSecretLength))
+	case distinctBytes(c.JWTSecret) < 10:
+		problems = append(problems, "JWT_SECRET is too repetitive")
+	}
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.LogLevel)); err != nil {
+		problems = append(problems, fmt.Sprintf("LOG_LEVEL must be debug, info, warn or error, got %q", c.LogLevel))
+	}
+	if c.LogFormat != "json" && c.LogFormat != "text" {
+		problems = append(problems, fmt.Sprintf("LOG_FORMAT must be json or text, got %q", c.LogFormat))
+	}
+
+	switch c.TraceExporter {
+	case "none", "otlp", "stdout":
+	case "file":
+		if c.TraceFile == "" {
+			problems = append(problems, "TRACE_FILE is required when TRACE_EXPORTER=file")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("TRACE_EXPORTER must be none, otlp, stdout or file, got %q", c.TraceExporter))
+	}
+	if c.TraceSampleRatio < 0 || c.TraceSampleRatio > 1 {
+		problems = append(problems, "TRACE_SAMPLE_RATIO must be between 0 and 1")
+	}
+
+	if c.SMTPHost != "" {
+		if c.MailFrom == "" {
+			problems = append(problems, "MAIL_FROM is required when SMTP_HOST is set")
+		}
+		if c.SMTPPort <= 0 || c.SMTPPort > 65535 {
+			problems = append(problems, "SMTP_PORT must be a valid port")
+		}
+	}
+
+	if len(c.CORSOrigins) == 0 {
+		problems = append(problems, "CORS_ORIGINS must list at least one origin")
+	}
+	for _, origin := range c.CORSOrigins {
+		if origin == "*" {
+			// credentials are allowed, so a wildcard origin would expose every user's session
+			problems = append(problems, "CORS_ORIGINS must not contain * because credentials are allowed")
+		} else if u, err := url.Parse(origin); err != nil || u.Scheme == "" || u.Host == "" {
+			problems = append(problems, fmt.Sprintf("CORS_ORIGINS entry %q is not an origin like https://example.com", origin))
+		}
+	}
+
+	for key, value := range map[string]string{"PUBLIC_URL": c.PublicURL, "FRONTEND_URL": c.FrontendURL} {
+		if u, err := url.Parse(value); err != nil || u.Scheme == "" || u.Host == "" {
+			problems = append(problems, fmt.Sprintf("%s must be an absolute URL, got %q", key, value))
+		}
+	}
+	if c.GoogleClientID != "" && c.GoogleClientSecret == "" {
+		problems = append(problems, "GOOGLE_CLIENT_SECRET is required when GOOGLE_CLIENT_ID is set")
+	}
+	if c.LineClientID != "" && c.LineClientSecret == "" {
+		problems = append(problems, "LINE_CLIENT_SECRET is required when LINE_CLIENT_ID is set")
+	}
+	if (c.OIDCIssuer == "") != (c.OIDCClientID == "") {
+		problems = append(problems, "OIDC_ISSUER and OIDC_CLIENT_ID must be set together")
+	}
+	if c.OIDCIssuer != "" {
+		if !providerName.MatchString(c.OIDCName) || c.OIDCName == "google" || c.OIDCName == "line" {
+			problems = append(problems, fmt.Sprintf("OIDC_NAME must be lowercase letters, digits or dashes and not google or line, got %q", c.OIDCName))
+		}
+	}
+
+	positive := map[string]time.Duration{
+		"ACCESS_TOKEN_TTL":              c.AccessTokenTTL,
+		"REMEMBER_ME_TOKEN_TTL":         c.RememberMeTokenTTL,
+		"HTTP_READ_TIMEOUT":             c.ReadTimeout,
+		"HTTP_WRITE_TIMEOUT":            c.WriteTimeout,
+		"HTTP_IDLE_TIMEOUT":             c.IdleTimeout,
+		"SHUTDOWN_TIMEOUT":              c.ShutdownTimeout,
+		"MONGODB_CONNECT_TIMEOUT":       c.MongoConnectTimeout,
+		"ACCOUNT_DELETION_GRACE_PERIOD": c.AccountDeletionGracePeriod,
+		"API_KEY_DEFAULT_TTL":           c.APIKeyDefaultTTL,
+		"API_KEY_MAX_TTL":               c.APIKeyMaxTTL,
+	}
+	for key, d := range positive {
+		if d <= 0 {
+			problems = append(problems, key+" must be positive")
+		}
+	}
+	if c.PasswordMinLength < 8 || c.PasswordMinLength > 72 {
+		problems = append(problems, "PASSWORD_MIN_LENGTH must be between 8 and 72")
+	}
+	if c.PasswordMinClasses < 1 || c.PasswordMinClasses > 4 {
+		problems = append(problems, "PASSWORD_MIN_CLASSES must be between 1 and 4")
+	}
+	if c.AdminPasswordMaxAge < 0 {
+		problems = append(problems, "ADMIN_PASSWORD_MAX_AGE must not be negative")
+	}
+	if c.APIKeyDefaultTTL > c.APIKeyMaxTTL {
+		problems = append(problems, "API_KEY_DEFAULT_TTL must not exceed API_KEY_MAX_TTL")
+	}
+	if c.APIKeyRateLimit <= 0 {
+		problems = append(problems, "API_KEY_RATE_LIMIT must be positive")
+	}
+	if c.RateLimitStore != "memory" && c.RateLimitStore != "mongodb" {
+		problems = append(problems, fmt.Sprintf("RATE_LIMIT_STORE must be memory or mongodb, got %q", c.RateLimitStore))
+	}
+	if _, err := ratelimit.ParseOverrides(c.RateLimitPolicies); err != nil {
+		problems = append(problems, "RATE_LIMIT_POLICIES: "+err.Error())
+	}
+	for _, proxy := range c.TrustedProxies {
+		if net.ParseIP(proxy) == nil {
+			if _, _, err := net.ParseCIDR(proxy); err != nil {
+				problems = append(problems, fmt.Sprintf("TRUSTED_PROXIES entry %q is not an IP address or CIDR", proxy))
+			}
+		}
+	}
+	if c.UploadMaxBytes <= 0 {
+		problems = append(problems, "UPLOAD_MAX_BYTES must be positive")
+	}
+	if c.UploadDir == "" {
+		problems = append(problems, "UPLOAD_DIR is required")
+	}
+	if c.ImageThumbnailSize < 32 || c.ImageThumbnailSize > 2048 {
+		problems = append(problems, "IMAGE_THUMBNAIL_SIZE must be between 32 and 2048")
+	}
+	if c.ImageMaxPixels <= 0 {
+		problems = append(problems, "IMAGE_MAX_PIXELS must be positive")
+	}
+	if c.ReviewPhotoMax < 0 || c.ReviewPhotoMax > 20 {
+		problems = append(problems, "REVIEW_PHOTO_MAX must be between 0 and 20")
+	}
+	if c.ReviewRankHalfLife < time.Hour {
+		problems = append(problems, "REVIEW_RANK_HALF_LIFE must be at least 1h")
+	}
+	if c.ScreeningBurstLimit < 1 {
+		problems = append(problems, "SCREENING_BURST_LIMIT must be at least 1")
+	}
+	if c.ScreeningBurstWindow <= 0 {
+		problems = append(problems, "SCREENING_BURST_WINDOW must be positive")
+	}
+	if c.ScreeningNewAccountAge < 0 {
+		problems = append(problems, "SCREENING_NEW_ACCOUNT_AGE must not be negative")
+	}
+	if c.CheckInRadius < 10 || c.CheckInRadius > 5000 {
+		problems = append(problems, "CHECK_IN_RADIUS must be between 10 and 5000")
+	}
+	if c.CheckInInterval < time.Minute {
+		problems = append(problems, "CHECK_IN_INTERVAL must be at least 1m")
+	}
+
+	if len(problems) > 0 {
+		return errors.New("config: " + strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// IsProduction reports whether the app runs in the production environment
+func (c *Config) IsProduction() bool {
+	return c.Env == "production"
+}
+
+// MailerConfigured reports whether outgoing mail can be sent
+func (c *Config) MailerConfigured() bool {
+	return c.SMTPHost != "" && c.MailFrom != ""
+}
+
+// WriteRedacted prints the effective configuration with secrets masked
+func (c *Config) WriteRedacted(w io.Writer) {
+	if c.File != "" {
+		fmt.Fprintf(w, "# loaded from %s\n", c.File)
+	}
+	eachField(c, func(f reflect.StructField, v reflect.Value) {
+		fmt.Fprintf(w, "%s=%s\n", f.Tag.Get("env"), redact(f.Tag.Get("secret"), formatValue(v)))
+	})
+}
+
+// Redacted returns the effective configuration as a map with secrets masked
+func (c *Config) Redacted() map[string]string {
+	out := map[string]string{}
+	eachField(c, func(f reflect.StructField, v reflect.Value) {
+		out[f.Tag.Get("env")] = redact(f.Tag.Get("secret"), formatValue(v))
+	})
+	return out
+}
+
+func redact(kind, value string) string {
+	switch {
+	case value == "":
+		return ""
+	case kind == "true":
+		return "********"
+	case kind == "uri":
+		// keep the host visible but hide credentials in the connection string
+		if u, err := url.Parse(value); err == nil && u.User != nil {
+			u.User = url.UserPassword("********", "********")
+			return u.String()
+		}
+	}
+	return value
+}
+
+func eachField(cfg *Config, fn func(reflect.StructField, reflect.Value)) {
+	v := reflect.ValueOf(cfg).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if env := f.Tag.Get("env"); env == "" || env == "-" {
+			continue
+		}
+		fn(f, v.Field(i))
+	}
+}
+
+// flagName turns MONGODB_URI into mongodb-uri
+func flagName(f reflect.StructField) string {
+	return strings.ToLower(strings.ReplaceAll(f.Tag.Get("env"), "_", "-"))
+}
+
+func setValue(v reflect.Value, raw string) error {
+	raw = strings.TrimSpace(raw)
+	switch v.Interface().(type) {
+	case string:
+		v.SetString(raw)
+	case bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not a boolean", raw)
+		}
+		v.SetBool(b)
+	case int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not an integer", raw)
+		}
+		v.SetInt(n)
+	case float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", raw)
+		}
+		v.SetFloat(f)
+	case time.Duration:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not a duration like 30s or 24h", raw)
+		}
+		v.SetInt(int64(d))
+	case []string:
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported config type %s", v.Type())
+	}
+	return nil
+}
+
+func formatValue(v reflect.Value) string {
+	switch x := v.Interface().(type) {
+	case []string:
+		return strings.Join(x, ",")
+	case time.Duration:
+		return x.String()
+	default:
+		return fmt.Sprint(x)
+	}
+}
+
+func distinctBytes(s string) int {
+	seen := map[byte]bool{}
+	for i := 0; i < len(s); i++ {
+		seen[s[i]] = true
+	}
+	return len(seen)
+}
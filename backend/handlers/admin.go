@@ -1,21 +1,19 @@
 package handlers
 
 import (
-	"context"
-	"fmt"
+	"errors"
+	"log/slog"
 	"net/http"
-	"os"
-	"path/filepath"
-	"strings"
 	"time"
 
-	"github.com/google/uuid"
-
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 
+	"gosmooth/metrics"
 	"gosmooth/models"
+	"gosmooth/problem"
 )
 
 // GetPlaces handles getting all places (admin only)
@@ -23,19 +21,19 @@ func GetPlaces(c *gin.Context) {
 	var places []models.Place
 	cursor, err := db.Collection("places").Find(c, bson.M{})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch places"})
+		problem.Internal(c, err)
 		return
 	}
 	defer cursor.Close(c)
 
 	if err = cursor.All(c, &places); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to decode places"})
+		problem.Internal(c, err)
 		return
 	}
 
 	// --- เพิ่ม logic คำนวณ rating จาก reviews ---
 	for i, place := range places {
-		reviewCursor, err := db.Collection("reviews").Find(c, bson.M{"place_id": place.ID})
+		reviewCursor, err := db.Collection("reviews").Find(c, bson.M{"place_id": place.ID, "status": models.ReviewPublished})
 		if err == nil {
 			var reviews []struct {
 				Rating int `bson:"rating"`
@@ -60,11 +58,9 @@ func GetPlaces(c *gin.Context) {
 func CreatePlace(c *gin.Context) {
 	var input models.UpdatePlaceInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		fmt.Printf("[DEBUG] CreatePlace ShouldBindJSON error: %v\n", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		problem.Validation(c, err)
 		return
 	}
-	fmt.Printf("[DEBUG] CreatePlace input: %+v\n", input)
 
 	place := models.Place{
 		ID:              primitive.NewObjectID().Hex(),
@@ -86,12 +82,9 @@ func CreatePlace(c *gin.Context) {
 	place.Coordinates.Lat = input.Coordinates.Lat
 	place.Coordinates.Lng = input.Coordinates.Lng
 
-	fmt.Printf("[DEBUG] CreatePlace mapped place: %+v\n", place)
-
 	_, err := db.Collection("places").InsertOne(c, place)
 	if err != nil {
-		fmt.Printf("[DEBUG] CreatePlace InsertOne error: %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create place"})
+		problem.Internal(c, err)
 		return
 	}
 
@@ -103,17 +96,15 @@ func UpdatePlace(c *gin.Context) {
 	id := c.Param("id")
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid place ID"})
+		problem.Abort(c, http.StatusBadRequest, problem.CodeInvalidID)
 		return
 	}
 
 	var input models.UpdatePlaceInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		fmt.Printf("[DEBUG] UpdatePlace ShouldBindJSON error: %v\n", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		problem.Validation(c, err)
 		return
 	}
-	fmt.Printf("[DEBUG] UpdatePlace input: %+v\n", input)
 
 	update := bson.M{
 		"$set": bson.M{
@@ -134,13 +125,12 @@ func UpdatePlace(c *gin.Context) {
 
 	result, err := db.Collection("places").UpdateOne(c, bson.M{"_id": objectID}, update)
 	if err != nil {
-		fmt.Printf("[DEBUG] UpdatePlace UpdateOne error: %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update place"})
+		problem.Internal(c, err)
 		return
 	}
 
 	if result.MatchedCount == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "place not found"})
+		problem.Abort(c, http.StatusNotFound, problem.CodePlaceNotFound)
 		return
 	}
 
@@ -152,20 +142,32 @@ func DeletePlace(c *gin.Context) {
 	id := c.Param("id")
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid place ID"})
+		problem.Abort(c, http.StatusBadRequest, problem.CodeInvalidID)
 		return
 	}
 
-	result, err := db.Collection("places").DeleteOne(c, bson.M{"_id": objectID})
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete place"})
+	var place models.Place
+	err = db.Collection("places").FindOneAndDelete(c, bson.M{"_id": objectID}).Decode(&place)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		problem.Abort(c, http.StatusNotFound, problem.CodePlaceNotFound)
 		return
 	}
-
-	if result.DeletedCount == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "place not found"})
+	if err != nil {
+		problem.Internal(c, err)
 		return
 	}
+	if _, err := db.Collection("collections").UpdateMany(c,
+		bson.M{"places.place_id": place.ID},
+		bson.M{"$pull": bson.M{"places": bson.M{"place_id": place.ID}}},
+	); err != nil {
+		slog.WarnContext(c.Request.Context(), "could not remove deleted place from collections", "place_id", place.ID, "error", err)
+	}
+	if _, err := db.Collection("trips").UpdateMany(c,
+		bson.M{"days.stops.place_id": place.ID},
+		bson.M{"$pull": bson.M{"days.$[].stops": bson.M{"place_id": place.ID}}},
+	); err != nil {
+		slog.WarnContext(c.Request.Context(), "could not remove deleted place from trips", "place_id", place.ID, "error", err)
+	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "place deleted successfully"})
 }
@@ -173,15 +175,15 @@ func DeletePlace(c *gin.Context) {
 // GetUsers handles getting all users
 func GetUsers(c *gin.Context) {
 	var users []models.User
-	cursor, err := db.Collection("users").Find(context.Background(), bson.M{})
+	cursor, err := db.Collection("users").Find(c.Request.Context(), bson.M{})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get users"})
+		problem.Internal(c, err)
 		return
 	}
-	defer cursor.Close(context.Background())
+	defer cursor.Close(c.Request.Context())
 
-	if err = cursor.All(context.Background(), &users); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to decode users"})
+	if err = cursor.All(c.Request.Context(), &users); err != nil {
+		problem.Internal(c, err)
 		return
 	}
 
@@ -193,14 +195,14 @@ func GetUser(c *gin.Context) {
 	id := c.Param("id")
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		problem.Abort(c, http.StatusBadRequest, problem.CodeInvalidID)
 		return
 	}
 
 	var user models.User
-	err = db.Collection("users").FindOne(context.Background(), bson.M{"_id": objectID}).Decode(&user)
+	err = db.Collection("users").FindOne(c.Request.Context(), bson.M{"_id": objectID}).Decode(&user)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		problem.Abort(c, http.StatusNotFound, problem.CodeUserNotFound)
 		return
 	}
 
@@ -212,13 +214,13 @@ func UpdateUser(c *gin.Context) {
 	id := c.Param("id")
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		problem.Abort(c, http.StatusBadRequest, problem.CodeInvalidID)
 		return
 	}
 
 	var input models.UpdateUserInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		problem.Validation(c, err)
 		return
 	}
 
@@ -230,9 +232,9 @@ func UpdateUser(c *gin.Context) {
 		},
 	}
 
-	_, err = db.Collection("users").UpdateOne(context.Background(), bson.M{"_id": objectID}, update)
+	_, err = db.Collection("users").UpdateOne(c.Request.Context(), bson.M{"_id": objectID}, update)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update user"})
+		problem.Internal(c, err)
 		return
 	}
 
@@ -244,86 +246,46 @@ func DeleteUser(c *gin.Context) {
 	id := c.Param("id")
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		problem.Abort(c, http.StatusBadRequest, problem.CodeInvalidID)
 		return
 	}
 
-	_, err = db.Collection("users").DeleteOne(context.Background(), bson.M{"_id": objectID})
+	_, err = db.Collection("users").DeleteOne(c.Request.Context(), bson.M{"_id": objectID})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete user"})
+		problem.Internal(c, err)
 		return
 	}
-
-	c.JSON(http.StatusOK, gin.H{"message": "user deleted successfully"})
-}
-
-// GetStats handles getting system statistics
-func GetStats(c *gin.Context) {
-	// Get total users count
-	usersCount, err := db.Collection("users").CountDocuments(context.Background(), bson.M{})
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get users count"})
-		return
-	}
-
-	// Get total reviews count
-	reviewsCount, err := db.Collection("reviews").CountDocuments(context.Background(), bson.M{})
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get reviews count"})
+	if err := deleteUserAPIKeys(c.Request.Context(), id); err != nil {
+		problem.Internal(c, err)
 		return
 	}
-
-	// Get total route suggestions count
-	routesCount, err := db.Collection("route_suggestions").CountDocuments(context.Background(), bson.M{})
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get routes count"})
+	if err := deleteUserPlaceClaims(c.Request.Context(), id); err != nil {
+		problem.Internal(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"total_users":   usersCount,
-		"total_reviews": reviewsCount,
-		"total_routes":  routesCount,
-		"last_updated":  time.Now(),
-	})
+	c.JSON(http.StatusOK, gin.H{"message": "user deleted successfully"})
 }
 
 // UploadImage handles image upload for places/locations (admin only)
 func UploadImage(c *gin.Context) {
-	file, err := c.FormFile("image")
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "No file is received"})
-		return
-	}
 	imgType := c.Query("imgType") // "cover" หรือ "highlight"
 	if imgType == "" {
 		imgType = "cover"
 	}
-	var uploadPath string
+	dir := "HighlightImages"
 	if imgType == "cover" {
-		uploadPath = "./uploads/CoverImage"
+		dir = "CoverImage"
 	} else {
-		uploadPath = "./uploads/HighlightImages"
+		imgType = "highlight"
 	}
-	os.MkdirAll(uploadPath, os.ModePerm)
-
-	ext := filepath.Ext(file.Filename)
-	base := strings.TrimSuffix(file.Filename, ext)
-	uniqueName := fmt.Sprintf("%s-%d-%s%s", base, time.Now().Unix(), uuid.New().String(), ext)
-	filePath := fmt.Sprintf("%s/%s", uploadPath, uniqueName)
-
-	if err := c.SaveUploadedFile(file, filePath); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save file"})
+	stored, ok := saveUploadedImage(c, "image", dir)
+	if !ok {
 		return
 	}
+	metrics.Uploads.WithLabelValues(imgType).Inc()
 	// ส่ง path กลับไป (frontend จะเอา path นี้ไปเก็บใน DB)
-	var relativePath string
-	if imgType == "cover" {
-		relativePath = fmt.Sprintf("uploads/CoverImage/%s", uniqueName)
-	} else {
-		relativePath = fmt.Sprintf("uploads/HighlightImages/%s", uniqueName)
-	}
-	c.JSON(http.StatusOK, gin.H{"imageUrl": relativePath})
+	c.JSON(http.StatusOK, gin.H{"imageUrl": stored.Path, "thumbnailUrl": stored.ThumbnailPath})
 }
 
 // BanUser handles banning a user (admin only)
@@ -331,14 +293,12 @@ func BanUser(c *gin.Context) {
 	id := c.Param("id")
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		problem.Abort(c, http.StatusBadRequest, problem.CodeInvalidID)
 		return
 	}
-	var input struct {
-		Reason string `json:"reason"`
-	}
+	var input models.BanUserInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		problem.Validation(c, err)
 		return
 	}
 	update := bson.M{
@@ -348,11 +308,12 @@ func BanUser(c *gin.Context) {
 			"updated_at": time.Now(),
 		},
 	}
-	_, err = db.Collection("users").UpdateOne(context.Background(), bson.M{"_id": objectID}, update)
+	_, err = db.Collection("users").UpdateOne(c.Request.Context(), bson.M{"_id": objectID}, update)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to ban user"})
+		problem.Internal(c, err)
 		return
 	}
+	metrics.Bans.Inc()
 	c.JSON(http.StatusOK, gin.H{"message": "user banned"})
 }
 
@@ -361,7 +322,7 @@ func UnbanUser(c *gin.Context) {
 	id := c.Param("id")
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		problem.Abort(c, http.StatusBadRequest, problem.CodeInvalidID)
 		return
 	}
 	update := bson.M{
@@ -371,9 +332,9 @@ func UnbanUser(c *gin.Context) {
 			"updated_at": time.Now(),
 		},
 	}
-	_, err = db.Collection("users").UpdateOne(context.Background(), bson.M{"_id": objectID}, update)
+	_, err = db.Collection("users").UpdateOne(c.Request.Context(), bson.M{"_id": objectID}, update)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to unban user"})
+		problem.Internal(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "user unbanned"})
@@ -387,14 +348,14 @@ func GetAllReviewReports(c *gin.Context) {
 		filter["status"] = status
 	}
 	var reports []models.ReviewReport
-	cursor, err := db.Collection("review_reports").Find(context.Background(), filter)
+	cursor, err := db.Collection("review_reports").Find(c.Request.Context(), filter)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get reports"})
+		problem.Internal(c, err)
 		return
 	}
-	defer cursor.Close(context.Background())
-	if err = cursor.All(context.Background(), &reports); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to decode reports"})
+	defer cursor.Close(c.Request.Context())
+	if err = cursor.All(c.Request.Context(), &reports); err != nil {
+		problem.Internal(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"reports": reports})
@@ -405,20 +366,18 @@ func UpdateReviewReportStatus(c *gin.Context) {
 	reportID := c.Param("id")
 	objectID, err := primitive.ObjectIDFromHex(reportID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid report ID"})
+		problem.Abort(c, http.StatusBadRequest, problem.CodeInvalidID)
 		return
 	}
-	var input struct {
-		Status string `json:"status" binding:"required"`
-	}
-	if err := c.ShouldBindJSON(&input); err != nil || input.Status == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "status is required"})
+	var input models.UpdateReportStatusInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		problem.Validation(c, err)
 		return
 	}
 	update := bson.M{"$set": bson.M{"status": input.Status, "resolved_at": time.Now()}}
-	_, err = db.Collection("review_reports").UpdateOne(context.Background(), bson.M{"_id": objectID}, update)
+	_, err = db.Collection("review_reports").UpdateOne(c.Request.Context(), bson.M{"_id": objectID}, update)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update report status"})
+		problem.Internal(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "report status updated"})
@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"gosmooth/middleware"
+	"gosmooth/models"
+	"gosmooth/problem"
+)
+
+// API key lifetimes, overridden by Configure
+var (
+	apiKeyDefaultTTL = 90 * 24 * time.Hour
+	apiKeyMaxTTL     = 365 * 24 * time.Hour
+)
+
+// ListAPIKeys handles GET /api/profile/api-keys
+func ListAPIKeys(c *gin.Context) {
+	listAPIKeys(c, bson.M{"user_id": c.GetString("userID")})
+}
+
+// CreateAPIKey handles POST /api/profile/api-keys. The key is only ever
+// returned in this response. Users cannot choose the key's rate limit; it
+// always gets API_KEY_RATE_LIMIT.
+func CreateAPIKey(c *gin.Context) {
+	createAPIKey(c, c.GetString("userID"), false)
+}
+
+// RotateAPIKey handles POST /api/profile/api-keys/:id/rotate
+func RotateAPIKey(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		problem.Abort(c, http.StatusBadRequest, problem.CodeInvalidID)
+		return
+	}
+	rotateAPIKey(c, bson.M{"_id": objectID, "user_id": c.GetString("userID")})
+}
+
+// RevokeAPIKey handles DELETE /api/profile/api-keys/:id
+func RevokeAPIKey(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		problem.Abort(c, http.StatusBadRequest, problem.CodeInvalidID)
+		return
+	}
+	revokeAPIKey(c, bson.M{"_id": objectID, "user_id": c.GetString("userID")})
+}
+
+// GetAllAPIKeys handles GET /api/admin/api-keys, optionally for one ?userId
+func GetAllAPIKeys(c *gin.Context) {
+	filter := bson.M{}
+	if userID := c.Query("userId"); userID != "" {
+		filter["user_id"] = userID
+	}
+	listAPIKeys(c, filter)
+}
+
+// CreateUserAPIKey handles POST /api/admin/users/:id/api-keys, for partner
+// accounts that are set up by an admin
+func CreateUserAPIKey(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		problem.Abort(c, http.StatusBadRequest, problem.CodeInvalidID)
+		return
+	}
+	count, err := db.Collection("users").CountDocuments(c.Request.Context(), bson.M{"_id": objectID})
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	if count == 0 {
+		problem.Abort(c, http.StatusNotFound, problem.CodeUserNotFound)
+		return
+	}
+	createAPIKey(c, objectID.Hex(), true)
+}
+
+// AdminRevokeAPIKey handles DELETE /api/admin/api-keys/:id
+func AdminRevokeAPIKey(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		problem.Abort(c, http.StatusBadRequest, problem.CodeInvalidID)
+		return
+	}
+	revokeAPIKey(c, bson.M{"_id": objectID})
+}
+
+func listAPIKeys(c *gin.Context, filter bson.M) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}).SetLimit(500)
+	cursor, err := db.Collection("api_keys").Find(c.Request.Context(), filter, opts)
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	keys := []models.APIKey{}
+	if err := cursor.All(c.Request.Context(), &keys); err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"apiKeys": keys})
+}
+
+// createAPIKey issues a key for userID. Only admins may set rateLimit.
+func createAPIKey(c *gin.Context, userID string, allowRateLimit bool) {
+	var input models.CreateAPIKeyInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		problem.Validation(c, err)
+		return
+	}
+	if !allowRateLimit {
+		input.RateLimit = 0
+	}
+	ttl := apiKeyDefaultTTL
+	if input.ExpiresInDays > 0 {
+		ttl = time.Duration(input.ExpiresInDays) * 24 * time.Hour
+	}
+	if ttl > apiKeyMaxTTL {
+		maxDays := strconv.Itoa(int(apiKeyMaxTTL / (24 * time.Hour)))
+		problem.Abort(c, http.StatusBadRequest, problem.CodeValidation, problem.Field("expiresInDays", "max", maxDays))
+		return
+	}
+
+	rawKey, prefix, hash, err := middleware.GenerateAPIKey()
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	now := time.Now()
+	key := models.APIKey{
+		ID:        primitive.NewObjectID(),
+		UserID:    userID,
+		Name:      input.Name,
+		Prefix:    prefix,
+		Hash:      hash,
+		Scopes:    input.Scopes,
+		RateLimit: input.RateLimit,
+		ExpiresAt: now.Add(ttl),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if _, err := db.Collection("api_keys").InsertOne(c.Request.Context(), key); err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"key": rawKey, "apiKey": key})
+}
+
+// rotateAPIKey replaces the key's secret; the old one stops working at once
+func rotateAPIKey(c *gin.Context, filter bson.M) {
+	key, ok := findActiveAPIKey(c, filter)
+	if !ok {
+		return
+	}
+	rawKey, prefix, hash, err := middleware.GenerateAPIKey()
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	now := time.Now()
+	filter["revoked_at"] = bson.M{"$exists": false}
+	err = db.Collection("api_keys").FindOneAndUpdate(c.Request.Context(), filter, bson.M{
+		"$set": bson.M{"prefix": prefix, "hash": hash, "rotated_at": now, "updated_at": now},
+	}, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&key)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		// ถูกเพิกถอนระหว่างทาง
+		problem.Abort(c, http.StatusConflict, problem.CodeAPIKeyRevoked)
+		return
+	}
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"key": rawKey, "apiKey": key})
+}
+
+func revokeAPIKey(c *gin.Context, filter bson.M) {
+	if _, ok := findActiveAPIKey(c, filter); !ok {
+		return
+	}
+	now := time.Now()
+	_, err := db.Collection("api_keys").UpdateOne(c.Request.Context(), filter, bson.M{
+		"$set": bson.M{"revoked_at": now, "updated_at": now},
+	})
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "API key revoked"})
+}
+
+// findActiveAPIKey loads the key matching filter, aborting if it is missing
+// or already revoked
+func findActiveAPIKey(c *gin.Context, filter bson.M) (models.APIKey, bool) {
+	var key models.APIKey
+	err := db.Collection("api_keys").FindOne(c.Request.Context(), filter).Decode(&key)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		problem.Abort(c, http.StatusNotFound, problem.CodeAPIKeyNotFound)
+		return key, false
+	}
+	if err != nil {
+		problem.Internal(c, err)
+		return key, false
+	}
+	if key.RevokedAt != nil {
+		problem.Abort(c, http.StatusConflict, problem.CodeAPIKeyRevoked)
+		return key, false
+	}
+	return key, true
+}
+
+// deleteUserAPIKeys removes every key owned by userID, used when the account
+// itself goes away
+func deleteUserAPIKeys(ctx context.Context, userID string) error {
+	_, err := db.Collection("api_keys").DeleteMany(ctx, bson.M{"user_id": userID})
+	return err
+}
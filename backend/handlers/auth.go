@@ -1,24 +1,26 @@
 package handlers
 
 import (
-	"context"
-	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/go-playground/validator/v10"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"golang.org/x/crypto/bcrypt"
 
+	"gosmooth/config"
+	"gosmooth/imaging"
+	"gosmooth/metrics"
 	"gosmooth/middleware"
 	"gosmooth/models"
-	"gosmooth/utils"
+	"gosmooth/problem"
+	"gosmooth/ranking"
+	"gosmooth/sso"
+	"gosmooth/validation"
 )
 
-var validate = validator.New()
 var db *mongo.Database
 
 // SetDB sets the database instance
@@ -26,89 +28,150 @@ func SetDB(database *mongo.Database) {
 	db = database
 }
 
+// appConfig is the loaded configuration; nil until Configure is called
+var appConfig *config.Config
+
+// Upload settings, overridden by Configure
+var (
+	uploadDir            = "./uploads"
+	maxUploadBytes int64 = 10 << 20
+	imageOptions         = imaging.Options{ThumbnailSize: 400, MaxPixels: 40_000_000}
+	reviewPhotoMax       = 6
+)
+
+// rankHalfLife is the decay used for review rank scores, overridden by Configure
+var rankHalfLife = ranking.DefaultHalfLife
+
+// Configure applies the loaded configuration to the handlers
+func Configure(cfg *config.Config) {
+	appConfig = cfg
+	uploadDir = cfg.UploadDir
+	maxUploadBytes = cfg.UploadMaxBytes
+	imageOptions = imaging.Options{
+		ThumbnailSize: int(cfg.ImageThumbnailSize),
+		MaxPixels:     int(cfg.ImageMaxPixels),
+		KeepLocation:  cfg.ImageKeepLocation,
+	}
+	reviewPhotoMax = int(cfg.ReviewPhotoMax)
+	rankHalfLife = cfg.ReviewRankHalfLife
+	screeningEnabled = cfg.ScreeningEnabled
+	burstLimit = int(cfg.ScreeningBurstLimit)
+	burstWindow = cfg.ScreeningBurstWindow
+	newAccountAge = cfg.ScreeningNewAccountAge
+	checkInRadius = float64(cfg.CheckInRadius)
+	checkInInterval = cfg.CheckInInterval
+	AccountDeletionGracePeriod = cfg.AccountDeletionGracePeriod
+	AdminPasswordMaxAge = cfg.AdminPasswordMaxAge
+	apiKeyDefaultTTL = cfg.APIKeyDefaultTTL
+	apiKeyMaxTTL = cfg.APIKeyMaxTTL
+	frontendURL = cfg.FrontendURL
+	ssoProviders = sso.NewRegistry(cfg.PublicURL, sso.FromConfig(cfg))
+}
+
 func Register(c *gin.Context) {
 	var input models.RegisterInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(400, gin.H{"error": err.Error()})
+		problem.Validation(c, err)
 		return
 	}
 
 	// Check if email already exists
 	var existingUser models.User
-	err := db.Collection("users").FindOne(context.Background(), bson.M{"email": input.Email}).Decode(&existingUser)
+	err := db.Collection("users").FindOne(c.Request.Context(), bson.M{"email": input.Email}).Decode(&existingUser)
 	if err == nil {
-		c.JSON(400, gin.H{"error": "Email already exists"})
+		problem.Abort(c, http.StatusConflict, problem.CodeEmailTaken)
 		return
 	}
 
-	if !utils.IsValidPassword(input.Password) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Password must be at least 7 characters and contain at least one letter"})
+	if err := validation.CheckPassword(input.Password, input.Email, input.Name); err != nil {
+		abortWeakPassword(c, err)
 		return
 	}
 
 	// Hash password
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
 	if err != nil {
-		c.JSON(500, gin.H{"error": "Error hashing password"})
+		problem.Internal(c, err)
 		return
 	}
 
 	// Create user
+	now := time.Now()
 	user := models.User{
-		ID:        primitive.NewObjectID(),
-		Email:     input.Email,
-		Password:  string(hashedPassword),
-		Name:      input.Name,
-		Role:      "user",
-		Address:   input.Address,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
-		Status:    "active",
-	}
-
-	_, err = db.Collection("users").InsertOne(context.Background(), user)
+		ID:                primitive.NewObjectID(),
+		Email:             input.Email,
+		Password:          string(hashedPassword),
+		Name:              input.Name,
+		Role:              "user",
+		Address:           input.Address,
+		PasswordChangedAt: &now,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+		Status:            "active",
+	}
+
+	_, err = db.Collection("users").InsertOne(c.Request.Context(), user)
 	if err != nil {
-		c.JSON(500, gin.H{"error": "Error creating user"})
+		problem.Internal(c, err)
 		return
 	}
 
+	metrics.Registrations.Inc()
 	c.JSON(201, gin.H{"message": "User registered successfully"})
 }
 
 func Login(c *gin.Context) {
 	var credentials models.LoginCredentials
 	if err := c.ShouldBindJSON(&credentials); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		problem.Validation(c, err)
 		return
 	}
 
 	var user models.User
-	err := db.Collection("users").FindOne(context.Background(), bson.M{"email": credentials.Email}).Decode(&user)
+	err := db.Collection("users").FindOne(c.Request.Context(), bson.M{"email": credentials.Email}).Decode(&user)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
+		metrics.Logins.WithLabelValues("failure").Inc()
+		problem.Abort(c, http.StatusUnauthorized, problem.CodeInvalidCredentials)
 		return
 	}
 
 	// เช็คว่าถูกแบนหรือไม่
 	if user.Status == "banned" {
-		c.JSON(http.StatusForbidden, gin.H{
-			"error":     "Your account has been banned",
-			"banReason": user.BanReason,
-		})
+		metrics.Logins.WithLabelValues("failure").Inc()
+		problem.Abort(c, http.StatusForbidden, problem.CodeAccountBanned, problem.With("banReason", user.BanReason))
 		return
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(credentials.Password)); err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
+		metrics.Logins.WithLabelValues("failure").Inc()
+		problem.Abort(c, http.StatusUnauthorized, problem.CodeInvalidCredentials)
+		return
+	}
+
+	// รหัสผ่านหมดอายุหรือถูก admin รีเซ็ต: ให้ token ที่ใช้ได้แค่เปลี่ยนรหัสผ่าน
+	if reason := passwordChangeReason(user); reason != "" {
+		token, err := middleware.GeneratePasswordChangeToken(user.ID.Hex())
+		if err != nil {
+			problem.Internal(c, err)
+			return
+		}
+		metrics.Logins.WithLabelValues("password_change").Inc()
+		c.JSON(http.StatusOK, gin.H{
+			"token":                  token,
+			"user":                   user,
+			"passwordChangeRequired": true,
+			"passwordChangeReason":   reason,
+		})
 		return
 	}
 
 	token, err := middleware.GenerateToken(user.ID.Hex(), credentials.RememberMe)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
+		problem.Internal(c, err)
 		return
 	}
 
+	metrics.Logins.WithLabelValues("success").Inc()
 	c.JSON(http.StatusOK, gin.H{
 		"token": token,
 		"user":  user,
@@ -120,60 +183,62 @@ func ChangePassword(c *gin.Context) {
 	userID := c.GetString("userID")
 	objectID, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		problem.Abort(c, http.StatusBadRequest, problem.CodeInvalidID)
 		return
 	}
 
-	var input struct {
-		CurrentPassword string `json:"currentPassword" binding:"required"`
-		NewPassword     string `json:"newPassword" binding:"required"`
-	}
+	var input models.ChangePasswordInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		fmt.Println("DEBUG: ChangePassword input bind error:", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		problem.Validation(c, err)
 		return
 	}
-	fmt.Printf("DEBUG: ChangePassword input: currentPassword=%s, newPassword=%s\n", input.CurrentPassword, input.NewPassword)
 
 	// ดึง user
 	var user models.User
-	err = db.Collection("users").FindOne(context.Background(), bson.M{"_id": objectID}).Decode(&user)
+	err = db.Collection("users").FindOne(c.Request.Context(), bson.M{"_id": objectID}).Decode(&user)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		problem.Abort(c, http.StatusNotFound, problem.CodeUserNotFound)
 		return
 	}
 
-	// ตรวจสอบรหัสผ่านเดิม
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(input.CurrentPassword)); err != nil {
-		fmt.Println("DEBUG: ChangePassword password mismatch error:", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "current password is incorrect"})
-		return
+	// ตรวจสอบรหัสผ่านเดิม (บัญชีที่สมัครผ่าน social login อาจยังไม่มีรหัสผ่าน)
+	if user.Password != "" {
+		if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(input.CurrentPassword)); err != nil {
+			problem.Abort(c, http.StatusBadRequest, problem.CodePasswordIncorrect)
+			return
+		}
 	}
 
-	// Validate รหัสผ่านใหม่
-	if !utils.IsValidPassword(input.NewPassword) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Password must be at least 7 characters and contain at least one letter"})
+	if input.NewPassword == input.CurrentPassword {
+		problem.Abort(c, http.StatusBadRequest, problem.CodePasswordUnchanged)
 		return
 	}
-
-	// Hash รหัสผ่านใหม่
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.NewPassword), bcrypt.DefaultCost)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to hash new password"})
+	if err := validation.CheckPassword(input.NewPassword, user.Email, user.Name); err != nil {
+		abortWeakPassword(c, err)
 		return
 	}
 
 	// อัปเดตรหัสผ่าน
-	_, err = db.Collection("users").UpdateOne(context.Background(), bson.M{"_id": objectID}, bson.M{
-		"$set": bson.M{
-			"password":   string(hashedPassword),
-			"updated_at": time.Now(),
-		},
-	})
+	set, err := passwordUpdate(input.NewPassword, false)
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	_, err = db.Collection("users").UpdateOne(c.Request.Context(), bson.M{"_id": objectID}, bson.M{"$set": set})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update password"})
+		problem.Internal(c, err)
 		return
 	}
 
+	// token แบบจำกัดสิทธิ์ใช้ต่อไม่ได้แล้ว จึงออก token ปกติให้แทน
+	if c.GetBool("passwordChangeOnly") {
+		token, err := middleware.GenerateToken(userID, false)
+		if err != nil {
+			problem.Internal(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Password changed successfully", "token": token})
+		return
+	}
 	c.JSON(http.StatusOK, gin.H{"message": "Password changed successfully"})
 }
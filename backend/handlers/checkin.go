@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"gosmooth/models"
+	"gosmooth/problem"
+)
+
+// Check-in rules, overridden by Configure
+var (
+	// checkInRadius is how close to a place, in meters, a check-in must be
+	checkInRadius = 300.0
+	// checkInInterval is how long before a user can check in at the same
+	// place again
+	checkInInterval = time.Hour
+)
+
+// CheckInPlace handles POST /api/places/:id/check-ins. The user sends where
+// they are and is checked in if that is within checkInRadius of the place;
+// only the distance and the reported accuracy are stored, not the position.
+// Their review of the place, now or later, shows a verified visit.
+func CheckInPlace(c *gin.Context) {
+	var input models.CheckInInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		problem.Validation(c, err)
+		return
+	}
+	place, err := findPlace(c.Request.Context(), c.Param("id"))
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		problem.Abort(c, http.StatusNotFound, problem.CodePlaceNotFound)
+		return
+	}
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+
+	userID := c.GetString("userID")
+	// ตำแหน่งจากเครื่องจริงแทบไม่มีทางตรงกับพิกัดที่บันทึกไว้ทุกตำแหน่งทศนิยม
+	// ค่าที่ตรงกันเป๊ะคือการคัดลอกพิกัดจากหน้าสถานที่มาส่งเอง
+	if input.Lat == place.Coordinates.Lat && input.Lng == place.Coordinates.Lng {
+		slog.InfoContext(c.Request.Context(), "check-in refused: position equals the place's", "place_id", place.ID, "user_id", userID)
+		problem.Abort(c, http.StatusUnprocessableEntity, problem.CodeLocationImplausible)
+		return
+	}
+	distance := distanceMeters(input.Lat, input.Lng, place.Coordinates.Lat, place.Coordinates.Lng)
+	if distance > checkInRadius {
+		problem.Abort(c, http.StatusUnprocessableEntity, problem.CodeTooFarFromPlace, problem.Args(int(checkInRadius)))
+		return
+	}
+
+	recent, err := db.Collection("check_ins").CountDocuments(c.Request.Context(), bson.M{
+		"user_id":    userID,
+		"place_id":   place.ID,
+		"created_at": bson.M{"$gt": time.Now().Add(-checkInInterval)},
+	}, options.Count().SetLimit(1))
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	if recent > 0 {
+		problem.Abort(c, http.StatusTooManyRequests, problem.CodeCheckedInRecently)
+		return
+	}
+
+	checkIn := models.CheckIn{
+		ID:        primitive.NewObjectID(),
+		UserID:    userID,
+		PlaceID:   place.ID,
+		PlaceName: place.Name,
+		Distance:  math.Round(distance),
+		Accuracy:  math.Round(input.Accuracy),
+		CreatedAt: time.Now(),
+	}
+	if _, err := db.Collection("check_ins").InsertOne(c.Request.Context(), checkIn); err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	if _, err := db.Collection("reviews").UpdateOne(c.Request.Context(),
+		bson.M{"user_id": userID, "place_id": place.ID},
+		bson.M{"$set": bson.M{"verified_visit": true}},
+	); err != nil {
+		slog.WarnContext(c.Request.Context(), "could not mark review as verified visit", "place_id", place.ID, "user_id", userID, "error", err)
+	}
+	c.JSON(http.StatusCreated, gin.H{"checkIn": checkIn})
+}
+
+// GetMyCheckIns handles GET /api/profile/check-ins, newest first
+func GetMyCheckIns(c *gin.Context) {
+	page, limit, ok := pageQuery(c)
+	if !ok {
+		return
+	}
+	filter := bson.M{"user_id": c.GetString("userID")}
+	total, err := db.Collection("check_ins").CountDocuments(c.Request.Context(), filter)
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+	cursor, err := db.Collection("check_ins").Find(c.Request.Context(), filter, opts)
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	checkIns := []models.CheckIn{}
+	if err := cursor.All(c.Request.Context(), &checkIns); err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"checkIns": checkIns, "total": total, "page": page, "limit": limit})
+}
+
+// visitedPlace reports whether userID has checked in at placeID, or has a
+// trip that took them there on a day that is over and was planned before it
+func visitedPlace(ctx context.Context, userID, placeID string) (bool, error) {
+	err := db.Collection("check_ins").FindOne(ctx,
+		bson.M{"user_id": userID, "place_id": placeID},
+		options.FindOne().SetProjection(bson.M{"_id": 1}),
+	).Err()
+	if err == nil {
+		return true, nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return false, err
+	}
+
+	today := time.Now().In(tripZone).Format("2006-01-02")
+	cursor, err := db.Collection("trips").Find(ctx, bson.M{
+		"user_id":             userID,
+		"days.stops.place_id": placeID,
+		"start_date":          bson.M{"$lt": today},
+	}, options.Find().SetProjection(bson.M{"start_date": 1, "created_at": 1, "days.stops.place_id": 1}))
+	if err != nil {
+		return false, err
+	}
+	var trips []models.Trip
+	if err := cursor.All(ctx, &trips); err != nil {
+		return false, err
+	}
+	for _, trip := range trips {
+		for _, id := range tripVisitedPlaces(trip, today) {
+			if id == placeID {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// earthRadius is the mean radius of the earth in meters
+const earthRadius = 6_371_000
+
+// distanceMeters returns the great-circle distance between two points
+func distanceMeters(lat1, lng1, lat2, lng2 float64) float64 {
+	const rad = math.Pi / 180
+	dLat := (lat2 - lat1) * rad
+	dLng := (lng2 - lng1) * rad
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*rad)*math.Cos(lat2*rad)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(a))
+}
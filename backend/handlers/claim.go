@@ -0,0 +1,281 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"gosmooth/models"
+	"gosmooth/problem"
+)
+
+// ClaimPlace handles POST /api/places/:id/claims, a business asking to be
+// verified as the owner of a place. A user can have one pending claim per
+// place, and places that already have an owner cannot be claimed.
+func ClaimPlace(c *gin.Context) {
+	var input models.PlaceClaimInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		problem.Validation(c, err)
+		return
+	}
+	place, err := findPlace(c.Request.Context(), c.Param("id"))
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		problem.Abort(c, http.StatusNotFound, problem.CodePlaceNotFound)
+		return
+	}
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	if place.OwnerID != "" {
+		problem.Abort(c, http.StatusConflict, problem.CodePlaceAlreadyClaimed)
+		return
+	}
+
+	userID := c.GetString("userID")
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		problem.Abort(c, http.StatusUnauthorized, problem.CodeInvalidToken)
+		return
+	}
+	var user models.User
+	if err := db.Collection("users").FindOne(c.Request.Context(), bson.M{"_id": userObjID}).Decode(&user); err != nil {
+		problem.Abort(c, http.StatusUnauthorized, problem.CodeInvalidToken)
+		return
+	}
+
+	claim := models.PlaceClaim{
+		ID:           primitive.NewObjectID(),
+		PlaceID:      place.ID,
+		PlaceName:    place.Name,
+		UserID:       userID,
+		Username:     user.Name,
+		BusinessName: input.BusinessName,
+		Position:     input.Position,
+		ContactPhone: input.ContactPhone,
+		Evidence:     input.Evidence,
+		EvidenceURLs: input.EvidenceURLs,
+		Status:       models.ClaimPending,
+		CreatedAt:    time.Now(),
+	}
+	if _, err := db.Collection("place_claims").InsertOne(c.Request.Context(), claim); err != nil {
+		// unique index pending_claim_unique ให้มีคำขอที่รอตรวจได้คนละหนึ่งรายการต่อสถานที่
+		if mongo.IsDuplicateKeyError(err) {
+			problem.Abort(c, http.StatusConflict, problem.CodeClaimPending)
+			return
+		}
+		problem.Internal(c, err)
+		return
+	}
+	slog.InfoContext(c.Request.Context(), "place claimed", "claim_id", claim.ID.Hex(), "place_id", place.ID, "user_id", userID)
+	c.JSON(http.StatusCreated, gin.H{"claim": claim})
+}
+
+// GetMyPlaceClaims handles GET /api/profile/place-claims, newest first
+func GetMyPlaceClaims(c *gin.Context) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := db.Collection("place_claims").Find(c.Request.Context(), bson.M{"user_id": c.GetString("userID")}, opts)
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	claims := []models.PlaceClaim{}
+	if err := cursor.All(c.Request.Context(), &claims); err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"claims": claims})
+}
+
+// GetPlaceClaims handles GET /api/admin/place-claims, oldest first so the
+// queue is worked in order. ?status filters by claim status.
+func GetPlaceClaims(c *gin.Context) {
+	filter := bson.M{}
+	switch status := c.Query("status"); status {
+	case "":
+	case models.ClaimPending, models.ClaimApproved, models.ClaimRejected:
+		filter["status"] = status
+	default:
+		problem.Abort(c, http.StatusBadRequest, problem.CodeInvalidParameter,
+			problem.Field("status", "oneof", "pending approved rejected"))
+		return
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := db.Collection("place_claims").Find(c.Request.Context(), filter, opts)
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	claims := []models.PlaceClaim{}
+	if err := cursor.All(c.Request.Context(), &claims); err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"claims": claims})
+}
+
+// ApprovePlaceClaim handles POST /api/admin/place-claims/:id/approve. The
+// claimant becomes the place's owner and any other pending claims for the
+// place are rejected.
+func ApprovePlaceClaim(c *gin.Context) {
+	claimID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		problem.Abort(c, http.StatusBadRequest, problem.CodeInvalidID)
+		return
+	}
+	ctx := c.Request.Context()
+	adminID := c.GetString("userID")
+	now := time.Now()
+
+	// เปลี่ยนสถานะคำขอก่อน ถ้ากำหนดเจ้าของไม่สำเร็จจึงคืนเป็น pending
+	var claim models.PlaceClaim
+	err = db.Collection("place_claims").FindOneAndUpdate(ctx,
+		bson.M{"_id": claimID, "status": models.ClaimPending},
+		bson.M{"$set": bson.M{"status": models.ClaimApproved, "reviewed_by": adminID, "reviewed_at": now}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&claim)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		abortClaimNotPending(c, claimID)
+		return
+	}
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+
+	result, err := db.Collection("places").UpdateOne(ctx,
+		bson.M{"place_id": claim.PlaceID, "owner_id": bson.M{"$in": bson.A{nil, ""}}},
+		bson.M{"$set": bson.M{"owner_id": claim.UserID, "updated_at": now}},
+	)
+	if err != nil || result.MatchedCount == 0 {
+		_, revertErr := db.Collection("place_claims").UpdateOne(ctx,
+			bson.M{"_id": claimID},
+			bson.M{"$set": bson.M{"status": models.ClaimPending}, "$unset": bson.M{"reviewed_by": "", "reviewed_at": ""}},
+		)
+		if revertErr != nil {
+			slog.ErrorContext(ctx, "could not reopen place claim", "claim_id", claimID.Hex(), "error", revertErr)
+		}
+		if err != nil {
+			problem.Internal(c, err)
+			return
+		}
+		if _, err := findPlace(ctx, claim.PlaceID); errors.Is(err, mongo.ErrNoDocuments) {
+			problem.Abort(c, http.StatusNotFound, problem.CodePlaceNotFound)
+			return
+		}
+		problem.Abort(c, http.StatusConflict, problem.CodePlaceAlreadyClaimed)
+		return
+	}
+
+	_, err = db.Collection("place_claims").UpdateMany(ctx,
+		bson.M{"place_id": claim.PlaceID, "status": models.ClaimPending},
+		bson.M{"$set": bson.M{
+			"status":      models.ClaimRejected,
+			"review_note": "Another claim for this place was approved.",
+			"reviewed_by": adminID,
+			"reviewed_at": now,
+		}},
+	)
+	if err != nil {
+		slog.ErrorContext(ctx, "could not close competing place claims", "place_id", claim.PlaceID, "error", err)
+	}
+	slog.InfoContext(ctx, "place claim approved",
+		"claim_id", claimID.Hex(), "place_id", claim.PlaceID, "owner_id", claim.UserID, "admin_id", adminID)
+	c.JSON(http.StatusOK, gin.H{"claim": claim})
+}
+
+// RejectPlaceClaim handles POST /api/admin/place-claims/:id/reject with the
+// reason in "note"
+func RejectPlaceClaim(c *gin.Context) {
+	claimID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		problem.Abort(c, http.StatusBadRequest, problem.CodeInvalidID)
+		return
+	}
+	var input models.RejectClaimInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		problem.Validation(c, err)
+		return
+	}
+	var claim models.PlaceClaim
+	err = db.Collection("place_claims").FindOneAndUpdate(c.Request.Context(),
+		bson.M{"_id": claimID, "status": models.ClaimPending},
+		bson.M{"$set": bson.M{
+			"status":      models.ClaimRejected,
+			"review_note": input.Note,
+			"reviewed_by": c.GetString("userID"),
+			"reviewed_at": time.Now(),
+		}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&claim)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		abortClaimNotPending(c, claimID)
+		return
+	}
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"claim": claim})
+}
+
+// RemovePlaceOwner handles DELETE /api/admin/places/:id/owner, revoking a
+// verified ownership. Responses the owner already posted stay on the reviews.
+func RemovePlaceOwner(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		problem.Abort(c, http.StatusBadRequest, problem.CodeInvalidID)
+		return
+	}
+	result, err := db.Collection("places").UpdateOne(c.Request.Context(),
+		bson.M{"_id": objectID},
+		bson.M{"$unset": bson.M{"owner_id": ""}, "$set": bson.M{"updated_at": time.Now()}},
+	)
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	if result.MatchedCount == 0 {
+		problem.Abort(c, http.StatusNotFound, problem.CodePlaceNotFound)
+		return
+	}
+	slog.InfoContext(c.Request.Context(), "place owner removed", "place_id", objectID.Hex(), "admin_id", c.GetString("userID"))
+	c.JSON(http.StatusOK, gin.H{"message": "place owner removed"})
+}
+
+// abortClaimNotPending answers a decision on a claim that is not pending:
+// 404 if it does not exist, 409 if it was already decided
+func abortClaimNotPending(c *gin.Context, claimID primitive.ObjectID) {
+	count, err := db.Collection("place_claims").CountDocuments(c.Request.Context(), bson.M{"_id": claimID})
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	if count == 0 {
+		problem.Abort(c, http.StatusNotFound, problem.CodeClaimNotFound)
+		return
+	}
+	problem.Abort(c, http.StatusConflict, problem.CodeClaimNotPending)
+}
+
+// deleteUserPlaceClaims removes userID's claims, which hold business contact
+// details, and releases the places they own, used when the account itself
+// goes away
+func deleteUserPlaceClaims(ctx context.Context, userID string) error {
+	if _, err := db.Collection("place_claims").DeleteMany(ctx, bson.M{"user_id": userID}); err != nil {
+		return err
+	}
+	_, err := db.Collection("places").UpdateMany(ctx,
+		bson.M{"owner_id": userID},
+		bson.M{"$unset": bson.M{"owner_id": ""}, "$set": bson.M{"updated_at": time.Now()}},
+	)
+	return err
+}
@@ -0,0 +1,509 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"gosmooth/models"
+	"gosmooth/problem"
+)
+
+// Limits on what a user can save
+const (
+	maxCollections      = 50
+	maxCollectionPlaces = 500
+)
+
+// ListMyCollections handles GET /api/profile/collections, Favorites first
+// and then the others in the order they were made
+func ListMyCollections(c *gin.Context) {
+	userID := c.GetString("userID")
+	if err := ensureFavorites(c.Request.Context(), userID); err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "is_default", Value: -1}, {Key: "created_at", Value: 1}})
+	cursor, err := db.Collection("collections").Find(c.Request.Context(), bson.M{"user_id": userID}, opts)
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	collections := []models.PlaceCollection{}
+	if err := cursor.All(c.Request.Context(), &collections); err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	for i := range collections {
+		setShareURL(&collections[i])
+	}
+	c.JSON(http.StatusOK, gin.H{"collections": collections})
+}
+
+// CreateCollection handles POST /api/profile/collections. New collections
+// are private unless asked otherwise.
+func CreateCollection(c *gin.Context) {
+	var input models.CollectionInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		problem.Validation(c, err)
+		return
+	}
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		problem.Abort(c, http.StatusBadRequest, problem.CodeValidation, problem.Field("name", "required", ""))
+		return
+	}
+	userID := c.GetString("userID")
+	if err := ensureFavorites(c.Request.Context(), userID); err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	count, err := db.Collection("collections").CountDocuments(c.Request.Context(), bson.M{"user_id": userID})
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	if count >= maxCollections {
+		problem.Abort(c, http.StatusConflict, problem.CodeTooManyCollections, problem.Args(maxCollections))
+		return
+	}
+
+	collection := models.PlaceCollection{
+		ID:          primitive.NewObjectID(),
+		UserID:      userID,
+		Name:        name,
+		Description: input.Description,
+		Visibility:  input.Visibility,
+		Places:      []models.CollectionPlace{},
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+	if collection.Visibility == "" {
+		collection.Visibility = models.CollectionPrivate
+	}
+	if _, err := db.Collection("collections").InsertOne(c.Request.Context(), collection); err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	setShareURL(&collection)
+	c.JSON(http.StatusCreated, gin.H{"collection": collection})
+}
+
+// GetMyCollection handles GET /api/profile/collections/:id
+func GetMyCollection(c *gin.Context) {
+	collection, ok := findMyCollection(c)
+	if !ok {
+		return
+	}
+	setShareURL(&collection)
+	c.JSON(http.StatusOK, gin.H{"collection": collection})
+}
+
+// UpdateCollection handles PATCH /api/profile/collections/:id. Favorites can
+// change its description and visibility but keeps its name.
+func UpdateCollection(c *gin.Context) {
+	collectionID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		problem.Abort(c, http.StatusBadRequest, problem.CodeInvalidID)
+		return
+	}
+	var input models.UpdateCollectionInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		problem.Validation(c, err)
+		return
+	}
+
+	set := bson.M{"updated_at": time.Now()}
+	filter := bson.M{"_id": collectionID, "user_id": c.GetString("userID")}
+	if input.Name != nil {
+		name := strings.TrimSpace(*input.Name)
+		if name == "" {
+			problem.Abort(c, http.StatusBadRequest, problem.CodeValidation, problem.Field("name", "required", ""))
+			return
+		}
+		set["name"] = name
+		filter["is_default"] = false
+	}
+	if input.Description != nil {
+		set["description"] = *input.Description
+	}
+	if input.Visibility != nil {
+		set["visibility"] = *input.Visibility
+	}
+
+	var collection models.PlaceCollection
+	err = db.Collection("collections").FindOneAndUpdate(c.Request.Context(), filter,
+		bson.M{"$set": set},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&collection)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		collectionMissing(c, collectionID)
+		return
+	}
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	setShareURL(&collection)
+	c.JSON(http.StatusOK, gin.H{"collection": collection})
+}
+
+// DeleteCollection handles DELETE /api/profile/collections/:id
+func DeleteCollection(c *gin.Context) {
+	collectionID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		problem.Abort(c, http.StatusBadRequest, problem.CodeInvalidID)
+		return
+	}
+	result, err := db.Collection("collections").DeleteOne(c.Request.Context(),
+		bson.M{"_id": collectionID, "user_id": c.GetString("userID"), "is_default": false})
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	if result.DeletedCount == 0 {
+		collectionMissing(c, collectionID)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "collection deleted successfully"})
+}
+
+// SaveCollectionPlace handles PUT /api/profile/collections/:id/places/:placeId,
+// adding the place to the end of the collection or, if it is already there,
+// replacing its note. :id may be "favorites" for the default collection.
+func SaveCollectionPlace(c *gin.Context) {
+	var input models.CollectionPlaceInput
+	// ไม่มี body ก็บันทึกได้ โน้ตเป็นค่าว่าง
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			problem.Validation(c, err)
+			return
+		}
+	}
+	filter, ok := myCollectionFilter(c)
+	if !ok {
+		return
+	}
+	place, err := findPlace(c.Request.Context(), c.Param("placeId"))
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		problem.Abort(c, http.StatusNotFound, problem.CodePlaceNotFound)
+		return
+	}
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+
+	now := time.Now()
+	collections := db.Collection("collections")
+	saved := bson.M{"places.place_id": place.ID}
+	for k, v := range filter {
+		saved[k] = v
+	}
+	result, err := collections.UpdateOne(c.Request.Context(), saved, bson.M{"$set": bson.M{
+		"places.$.note":       input.Note,
+		"places.$.place_name": place.Name,
+		"updated_at":          now,
+	}})
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	if result.MatchedCount == 0 {
+		// places.N ไม่มีอยู่ แปลว่ายังไม่เต็ม
+		notFull := bson.M{
+			"places.place_id": bson.M{"$ne": place.ID},
+			"places." + strconv.Itoa(maxCollectionPlaces-1): bson.M{"$exists": false},
+		}
+		for k, v := range filter {
+			notFull[k] = v
+		}
+		result, err = collections.UpdateOne(c.Request.Context(), notFull, bson.M{
+			"$push": bson.M{"places": models.CollectionPlace{
+				PlaceID:   place.ID,
+				PlaceName: place.Name,
+				Note:      input.Note,
+				AddedAt:   now,
+			}},
+			"$set": bson.M{"updated_at": now},
+		})
+		if err != nil {
+			problem.Internal(c, err)
+			return
+		}
+		if result.MatchedCount == 0 {
+			n, err := collections.CountDocuments(c.Request.Context(), filter)
+			if err != nil {
+				problem.Internal(c, err)
+				return
+			}
+			if n == 0 {
+				problem.Abort(c, http.StatusNotFound, problem.CodeCollectionNotFound)
+				return
+			}
+			problem.Abort(c, http.StatusConflict, problem.CodeCollectionFull, problem.Args(maxCollectionPlaces))
+			return
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "place saved", "placeId": place.ID, "saved": true})
+}
+
+// RemoveCollectionPlace handles DELETE /api/profile/collections/:id/places/:placeId.
+// :id may be "favorites" for the default collection.
+func RemoveCollectionPlace(c *gin.Context) {
+	filter, ok := myCollectionFilter(c)
+	if !ok {
+		return
+	}
+	// สถานที่อาจถูกลบไปแล้ว จึงใช้รหัสตามที่ส่งมาถ้าหาไม่เจอ
+	placeID := c.Param("placeId")
+	if place, err := findPlace(c.Request.Context(), placeID); err == nil {
+		placeID = place.ID
+	}
+	result, err := db.Collection("collections").UpdateOne(c.Request.Context(), filter, bson.M{
+		"$pull": bson.M{"places": bson.M{"place_id": placeID}},
+		"$set":  bson.M{"updated_at": time.Now()},
+	})
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	if result.MatchedCount == 0 {
+		problem.Abort(c, http.StatusNotFound, problem.CodeCollectionNotFound)
+		return
+	}
+	saved, err := savedPlaceIDs(c.Request.Context(), c.GetString("userID"))
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "place removed", "placeId": placeID, "saved": saved[placeID]})
+}
+
+// ReorderCollection handles PUT /api/profile/collections/:id/order, which
+// lists every place of the collection in its new order
+func ReorderCollection(c *gin.Context) {
+	var input models.ReorderCollectionInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		problem.Validation(c, err)
+		return
+	}
+	collection, ok := findMyCollection(c)
+	if !ok {
+		return
+	}
+	if len(input.PlaceIDs) != len(collection.Places) {
+		problem.Abort(c, http.StatusUnprocessableEntity, problem.CodeCollectionOrder)
+		return
+	}
+	byID := make(map[string]models.CollectionPlace, len(collection.Places))
+	for _, p := range collection.Places {
+		byID[p.PlaceID] = p
+	}
+	places := make([]models.CollectionPlace, 0, len(input.PlaceIDs))
+	for _, id := range input.PlaceIDs {
+		p, ok := byID[id]
+		if !ok {
+			problem.Abort(c, http.StatusUnprocessableEntity, problem.CodeCollectionOrder)
+			return
+		}
+		places = append(places, p)
+	}
+
+	// updated_at กันไม่ให้ทับการแก้ไขที่เกิดขึ้นระหว่างนั้น
+	now := time.Now()
+	result, err := db.Collection("collections").UpdateOne(c.Request.Context(),
+		bson.M{"_id": collection.ID, "updated_at": collection.UpdatedAt},
+		bson.M{"$set": bson.M{"places": places, "updated_at": now}},
+	)
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	if result.MatchedCount == 0 {
+		problem.Abort(c, http.StatusConflict, problem.CodeConflict)
+		return
+	}
+	collection.Places, collection.UpdatedAt = places, now
+	setShareURL(&collection)
+	c.JSON(http.StatusOK, gin.H{"collection": collection})
+}
+
+// GetSharedCollection handles GET /api/collections/:id, the public view of a
+// shared collection with its places. Private collections are not found.
+func GetSharedCollection(c *gin.Context) {
+	collectionID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		problem.Abort(c, http.StatusBadRequest, problem.CodeInvalidID)
+		return
+	}
+	var collection models.PlaceCollection
+	err = db.Collection("collections").FindOne(c.Request.Context(),
+		bson.M{"_id": collectionID, "visibility": models.CollectionPublic}).Decode(&collection)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		problem.Abort(c, http.StatusNotFound, problem.CodeCollectionNotFound)
+		return
+	}
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+
+	placeIDs := make([]string, len(collection.Places))
+	for i, p := range collection.Places {
+		placeIDs[i] = p.PlaceID
+	}
+	cursor, err := db.Collection("places").Find(c.Request.Context(), bson.M{"place_id": bson.M{"$in": placeIDs}})
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	var found []models.Place
+	if err := cursor.All(c.Request.Context(), &found); err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	byID := make(map[string]models.Place, len(found))
+	for _, p := range found {
+		byID[p.ID] = p
+	}
+	// เรียงตามลำดับในคอลเลกชัน และข้ามสถานที่ที่ถูกลบไปแล้ว
+	places := []models.Place{}
+	for _, id := range placeIDs {
+		if p, ok := byID[id]; ok {
+			places = append(places, p)
+		}
+	}
+
+	setShareURL(&collection)
+	c.JSON(http.StatusOK, gin.H{"collection": collection, "places": places, "owner": ownerName(c.Request.Context(), collection.UserID)})
+}
+
+// ownerName returns the name of the user who shared something, or
+// deletedUserName when they are gone
+func ownerName(ctx context.Context, userID string) string {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return deletedUserName
+	}
+	var user models.User
+	if err := db.Collection("users").FindOne(ctx, bson.M{"_id": userObjID}).Decode(&user); err != nil {
+		return deletedUserName
+	}
+	return user.Name
+}
+
+// ensureFavorites creates the user's Favorites collection if it is missing
+func ensureFavorites(ctx context.Context, userID string) error {
+	now := time.Now()
+	_, err := db.Collection("collections").UpdateOne(ctx,
+		bson.M{"user_id": userID, "is_default": true},
+		bson.M{"$setOnInsert": bson.M{
+			"name":        models.FavoritesName,
+			"description": "",
+			"visibility":  models.CollectionPrivate,
+			"places":      bson.A{},
+			"created_at":  now,
+			"updated_at":  now,
+		}},
+		options.Update().SetUpsert(true),
+	)
+	// unique index default_per_user: a concurrent request made it first
+	if mongo.IsDuplicateKeyError(err) {
+		return nil
+	}
+	return err
+}
+
+// myCollectionFilter matches the caller's collection named by :id, which is
+// an ObjectID or "favorites"
+func myCollectionFilter(c *gin.Context) (bson.M, bool) {
+	userID := c.GetString("userID")
+	if c.Param("id") == "favorites" {
+		if err := ensureFavorites(c.Request.Context(), userID); err != nil {
+			problem.Internal(c, err)
+			return nil, false
+		}
+		return bson.M{"user_id": userID, "is_default": true}, true
+	}
+	collectionID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		problem.Abort(c, http.StatusBadRequest, problem.CodeInvalidID)
+		return nil, false
+	}
+	return bson.M{"_id": collectionID, "user_id": userID}, true
+}
+
+// findMyCollection loads the caller's collection named by :id
+func findMyCollection(c *gin.Context) (models.PlaceCollection, bool) {
+	var collection models.PlaceCollection
+	filter, ok := myCollectionFilter(c)
+	if !ok {
+		return collection, false
+	}
+	err := db.Collection("collections").FindOne(c.Request.Context(), filter).Decode(&collection)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		problem.Abort(c, http.StatusNotFound, problem.CodeCollectionNotFound)
+		return collection, false
+	}
+	if err != nil {
+		problem.Internal(c, err)
+		return collection, false
+	}
+	return collection, true
+}
+
+// collectionMissing answers an update or delete that matched nothing: the
+// collection is Favorites, which keeps its name, or it is not the caller's
+func collectionMissing(c *gin.Context, collectionID primitive.ObjectID) {
+	n, err := db.Collection("collections").CountDocuments(c.Request.Context(),
+		bson.M{"_id": collectionID, "user_id": c.GetString("userID"), "is_default": true})
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	if n > 0 {
+		problem.Abort(c, http.StatusConflict, problem.CodeDefaultCollection)
+		return
+	}
+	problem.Abort(c, http.StatusNotFound, problem.CodeCollectionNotFound)
+}
+
+// savedPlaceIDs returns the place_ids in any of userID's collections
+func savedPlaceIDs(ctx context.Context, userID string) (map[string]bool, error) {
+	saved := map[string]bool{}
+	if userID == "" {
+		return saved, nil
+	}
+	cursor, err := db.Collection("collections").Find(ctx, bson.M{"user_id": userID},
+		options.Find().SetProjection(bson.M{"places.place_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var collections []models.PlaceCollection
+	if err := cursor.All(ctx, &collections); err != nil {
+		return nil, err
+	}
+	for _, collection := range collections {
+		for _, p := range collection.Places {
+			saved[p.PlaceID] = true
+		}
+	}
+	return saved, nil
+}
+
+// setShareURL fills in the link to a public collection's page on the frontend
+func setShareURL(collection *models.PlaceCollection) {
+	if collection.Visibility == models.CollectionPublic {
+		collection.ShareURL = strings.TrimRight(frontendURL, "/") + "/collections/" + collection.ID.Hex()
+	}
+}
@@ -0,0 +1,458 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"gosmooth/models"
+	"gosmooth/problem"
+)
+
+// maxPageSize caps ?limit on paged listings
+const maxPageSize = 100
+
+// publicCommentFields leaves out who flagged a comment and why it was held
+var publicCommentFields = bson.M{"flags": 0, "screening": 0, "fingerprint": 0, "fingerprint_bands": 0}
+
+// ListComments handles GET /api/reviews/:id/comments. It lists the top-level
+// comments of a review, or the replies to ?parentId, oldest first. Deleted or
+// hidden comments are only listed while they still have replies, with their
+// text removed, so a thread keeps its shape. Comments on an unpublished
+// review are only listed for its author.
+func ListComments(c *gin.Context) {
+	reviewID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		problem.Abort(c, http.StatusBadRequest, problem.CodeInvalidID)
+		return
+	}
+	page, limit, ok := pageQuery(c)
+	if !ok {
+		return
+	}
+	visible := []bson.M{{"status": models.ReviewPublished}}
+	if userID := c.GetString("userID"); userID != "" {
+		visible = append(visible, bson.M{"user_id": userID})
+	}
+	count, err := db.Collection("reviews").CountDocuments(c.Request.Context(), bson.M{"_id": reviewID, "$or": visible})
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	if count == 0 {
+		problem.Abort(c, http.StatusNotFound, problem.CodeReviewNotFound)
+		return
+	}
+	filter := bson.M{
+		"review_id": reviewID,
+		"parent_id": nil,
+		"$or": []bson.M{
+			{"status": models.CommentVisible},
+			{"reply_count": bson.M{"$gt": 0}},
+		},
+	}
+	if parent := c.Query("parentId"); parent != "" {
+		parentID, err := primitive.ObjectIDFromHex(parent)
+		if err != nil {
+			problem.Abort(c, http.StatusBadRequest, problem.CodeInvalidParameter, problem.Field("parentId", "objectid", ""))
+			return
+		}
+		filter["parent_id"] = parentID
+	}
+
+	total, err := db.Collection("comments").CountDocuments(c.Request.Context(), filter)
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit)).
+		SetProjection(publicCommentFields)
+	cursor, err := db.Collection("comments").Find(c.Request.Context(), filter, opts)
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	comments := []models.Comment{}
+	if err := cursor.All(c.Request.Context(), &comments); err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	for i := range comments {
+		comments[i].FlagCount = 0
+		if comments[i].Status != models.CommentVisible {
+			comments[i].Text = ""
+			comments[i].Username = ""
+			comments[i].UserID = ""
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"comments": comments, "total": total, "page": page, "limit": limit})
+}
+
+// AddComment handles POST /api/reviews/:id/comments
+func AddComment(c *gin.Context) {
+	reviewID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		problem.Abort(c, http.StatusBadRequest, problem.CodeInvalidID)
+		return
+	}
+
+	var input models.CommentInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		problem.Validation(c, err)
+		return
+	}
+
+	userID := c.GetString("userID")
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		problem.Abort(c, http.StatusUnauthorized, problem.CodeInvalidToken)
+		return
+	}
+
+	var user models.User
+	if err := db.Collection("users").FindOne(c.Request.Context(), bson.M{"_id": userObjID}).Decode(&user); err != nil {
+		problem.Abort(c, http.StatusUnauthorized, problem.CodeInvalidToken)
+		return
+	}
+
+	count, err := db.Collection("reviews").CountDocuments(c.Request.Context(), bson.M{"_id": reviewID, "status": models.ReviewPublished})
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	if count == 0 {
+		problem.Abort(c, http.StatusNotFound, problem.CodeReviewNotFound)
+		return
+	}
+
+	comment := models.Comment{
+		ID:        primitive.NewObjectID(),
+		ReviewID:  reviewID,
+		UserID:    userID,
+		Username:  user.Name,
+		Text:      input.Text,
+		Status:    models.CommentVisible,
+		LikedBy:   []string{},
+		CreatedAt: time.Now(),
+	}
+	if input.ParentID != "" {
+		parentID, _ := primitive.ObjectIDFromHex(input.ParentID)
+		// ตอบกลับได้เฉพาะความคิดเห็นที่ยังแสดงอยู่ในรีวิวเดียวกัน
+		count, err := db.Collection("comments").CountDocuments(c.Request.Context(), bson.M{
+			"_id": parentID, "review_id": reviewID, "status": models.CommentVisible,
+		})
+		if err != nil {
+			problem.Internal(c, err)
+			return
+		}
+		if count == 0 {
+			problem.Abort(c, http.StatusUnprocessableEntity, problem.CodeInvalidParentComment)
+			return
+		}
+		comment.ParentID = &parentID
+	}
+
+	flags, sig, err := screenPost(c.Request.Context(), post{collection: "comments", author: user, text: comment.Text})
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	comment.Fingerprint, comment.FingerprintBands = sig, sig.Bands()
+	if len(flags) > 0 {
+		comment.Status = models.CommentHeld
+		comment.Screening = flags
+	}
+
+	if _, err := db.Collection("comments").InsertOne(c.Request.Context(), comment); err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	if comment.Status == models.CommentHeld {
+		recordHeld(c.Request.Context(), "comment", comment.ID, flags)
+	} else if err := adjustCommentCounts(c.Request.Context(), comment, 1); err != nil {
+		problem.Internal(c, err)
+		return
+	}
+
+	comment.Screening = nil
+	c.JSON(http.StatusOK, gin.H{"message": "comment added", "comment": comment})
+}
+
+// UpdateComment handles PUT /api/reviews/:id/comments/:commentId; only the
+// author may edit, and only while the comment is visible
+func UpdateComment(c *gin.Context) {
+	comment, ok := findComment(c)
+	if !ok {
+		return
+	}
+	var input models.UpdateCommentInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		problem.Validation(c, err)
+		return
+	}
+	if comment.UserID != c.GetString("userID") {
+		problem.Abort(c, http.StatusForbidden, problem.CodeNotOwner)
+		return
+	}
+
+	flags, sig, err := screenText(c.Request.Context(), "comments", comment.UserID, input.Text)
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	set := bson.M{"text": input.Text, "edited_at": time.Now()}
+	update := bson.M{"$set": set}
+	if sig != nil {
+		set["fingerprint"], set["fingerprint_bands"] = sig, sig.Bands()
+	} else {
+		update["$unset"] = bson.M{"fingerprint": "", "fingerprint_bands": ""}
+	}
+	if len(flags) > 0 {
+		set["status"] = models.CommentHeld
+		set["screening"] = flags
+	}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After).SetProjection(publicCommentFields)
+	err = db.Collection("comments").FindOneAndUpdate(c.Request.Context(),
+		bson.M{"_id": comment.ID, "user_id": comment.UserID, "status": models.CommentVisible},
+		update,
+		opts,
+	).Decode(&comment)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		// ถูกลบหรือซ่อนไประหว่างทาง
+		problem.Abort(c, http.StatusNotFound, problem.CodeCommentNotFound)
+		return
+	}
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	if comment.Status == models.CommentHeld {
+		recordHeld(c.Request.Context(), "comment", comment.ID, flags)
+		if err := adjustCommentCounts(c.Request.Context(), comment, -1); err != nil {
+			problem.Internal(c, err)
+			return
+		}
+	}
+	comment.FlagCount = 0
+	c.JSON(http.StatusOK, gin.H{"message": "comment updated", "comment": comment})
+}
+
+// DeleteComment handles DELETE /api/reviews/:id/comments/:commentId. The
+// comment is kept as a placeholder without its text so replies stay threaded.
+func DeleteComment(c *gin.Context) {
+	comment, ok := findComment(c)
+	if !ok {
+		return
+	}
+	if comment.UserID != c.GetString("userID") {
+		problem.Abort(c, http.StatusForbidden, problem.CodeNotOwner)
+		return
+	}
+
+	var before models.Comment
+	err := db.Collection("comments").FindOneAndUpdate(c.Request.Context(),
+		bson.M{"_id": comment.ID, "status": bson.M{"$ne": models.CommentDeleted}},
+		bson.M{
+			"$set":   bson.M{"status": models.CommentDeleted, "text": ""},
+			"$unset": bson.M{"flags": "", "edited_at": "", "fingerprint": "", "fingerprint_bands": ""},
+		},
+	).Decode(&before)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		problem.Abort(c, http.StatusNotFound, problem.CodeCommentNotFound)
+		return
+	}
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	if before.Status == models.CommentVisible {
+		if err := adjustCommentCounts(c.Request.Context(), before, -1); err != nil {
+			problem.Internal(c, err)
+			return
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "comment deleted"})
+}
+
+// FlagComment handles POST /api/reviews/:id/comments/:commentId/flag. Each
+// user flags a comment at most once; flagging again changes nothing.
+func FlagComment(c *gin.Context) {
+	comment, ok := findComment(c)
+	if !ok {
+		return
+	}
+	var input models.FlagCommentInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		problem.Validation(c, err)
+		return
+	}
+	if comment.Status != models.CommentVisible {
+		problem.Abort(c, http.StatusNotFound, problem.CodeCommentNotFound)
+		return
+	}
+
+	userID := c.GetString("userID")
+	flag := models.CommentFlag{UserID: userID, Reason: input.Reason, Detail: input.Detail, CreatedAt: time.Now()}
+	_, err := db.Collection("comments").UpdateOne(c.Request.Context(),
+		bson.M{"_id": comment.ID, "flags.user_id": bson.M{"$ne": userID}},
+		bson.M{"$push": bson.M{"flags": flag}, "$inc": bson.M{"flag_count": 1}},
+	)
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "comment flagged"})
+}
+
+// GetFlaggedComments handles GET /api/admin/comments/flagged: comments users
+// flagged, most flagged first, and those screening held
+func GetFlaggedComments(c *gin.Context) {
+	page, limit, ok := pageQuery(c)
+	if !ok {
+		return
+	}
+	filter := bson.M{"$or": []bson.M{
+		{"flag_count": bson.M{"$gt": 0}},
+		{"status": models.CommentHeld},
+	}}
+	total, err := db.Collection("comments").CountDocuments(c.Request.Context(), filter)
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "flag_count", Value: -1}, {Key: "created_at", Value: 1}}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+	cursor, err := db.Collection("comments").Find(c.Request.Context(), filter, opts)
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	comments := []models.Comment{}
+	if err := cursor.All(c.Request.Context(), &comments); err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"comments": comments, "total": total, "page": page, "limit": limit})
+}
+
+// ModerateComment handles PATCH /api/admin/comments/:id/status. Deciding on
+// a comment clears its flags, whichever way the decision goes; the rules
+// that held it, if any, are kept as a record.
+func ModerateComment(c *gin.Context) {
+	commentID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		problem.Abort(c, http.StatusBadRequest, problem.CodeInvalidID)
+		return
+	}
+	var input models.ModerateCommentInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		problem.Validation(c, err)
+		return
+	}
+
+	var before models.Comment
+	err = db.Collection("comments").FindOneAndUpdate(c.Request.Context(),
+		bson.M{"_id": commentID, "status": bson.M{"$ne": models.CommentDeleted}},
+		bson.M{
+			"$set": bson.M{
+				"status":       input.Status,
+				"flag_count":   0,
+				"moderated_at": time.Now(),
+				"moderated_by": c.GetString("userID"),
+			},
+			"$unset": bson.M{"flags": ""},
+		},
+	).Decode(&before)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		problem.Abort(c, http.StatusNotFound, problem.CodeCommentNotFound)
+		return
+	}
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+
+	switch {
+	case before.Status == models.CommentVisible && input.Status != models.CommentVisible:
+		err = adjustCommentCounts(c.Request.Context(), before, -1)
+	case before.Status != models.CommentVisible && input.Status == models.CommentVisible:
+		err = adjustCommentCounts(c.Request.Context(), before, 1)
+	}
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "comment status updated", "status": input.Status})
+}
+
+// findComment loads the comment named by :commentId on review :id, aborting
+// with 404 if there is none or it was deleted
+func findComment(c *gin.Context) (models.Comment, bool) {
+	var comment models.Comment
+	reviewID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		problem.Abort(c, http.StatusBadRequest, problem.CodeInvalidID)
+		return comment, false
+	}
+	commentID, err := primitive.ObjectIDFromHex(c.Param("commentId"))
+	if err != nil {
+		problem.Abort(c, http.StatusBadRequest, problem.CodeInvalidID)
+		return comment, false
+	}
+	err = db.Collection("comments").FindOne(c.Request.Context(), bson.M{"_id": commentID, "review_id": reviewID}).Decode(&comment)
+	if errors.Is(err, mongo.ErrNoDocuments) || (err == nil && comment.Status == models.CommentDeleted) {
+		problem.Abort(c, http.StatusNotFound, problem.CodeCommentNotFound)
+		return comment, false
+	}
+	if err != nil {
+		problem.Internal(c, err)
+		return comment, false
+	}
+	return comment, true
+}
+
+// adjustCommentCounts moves the review's comment_count, and the parent's
+// reply_count for a reply, by delta as cmt becomes visible or stops being so
+func adjustCommentCounts(ctx context.Context, cmt models.Comment, delta int) error {
+	_, err := db.Collection("reviews").UpdateOne(ctx, bson.M{"_id": cmt.ReviewID}, bson.M{"$inc": bson.M{"comment_count": delta}})
+	if err != nil || cmt.ParentID == nil {
+		return err
+	}
+	_, err = db.Collection("comments").UpdateOne(ctx, bson.M{"_id": *cmt.ParentID}, bson.M{"$inc": bson.M{"reply_count": delta}})
+	return err
+}
+
+// pageQuery reads ?page and ?limit, aborting on values out of range
+func pageQuery(c *gin.Context) (page, limit int, ok bool) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		problem.Abort(c, http.StatusBadRequest, problem.CodeInvalidParameter, problem.Field("page", "min", "1"))
+		return 0, 0, false
+	}
+	limit, err = strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit < 1 || limit > maxPageSize {
+		problem.Abort(c, http.StatusBadRequest, problem.CodeInvalidParameter, problem.Field("limit", "range", "1-"+strconv.Itoa(maxPageSize)))
+		return 0, 0, false
+	}
+	return page, limit, true
+}
+
+// deleteReviewComments removes every comment on a review along with it
+func deleteReviewComments(ctx context.Context, reviewID primitive.ObjectID) error {
+	_, err := db.Collection("comments").DeleteMany(ctx, bson.M{"review_id": reviewID})
+	return err
+}
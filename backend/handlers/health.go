@@ -0,0 +1,229 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+
+	"gosmooth/migrations"
+)
+
+// Version is the build version, set with
+// -ldflags "-X gosmooth/handlers.Version=v1.2.3"
+var Version = "dev"
+
+// startedAt is used to report uptime
+var startedAt = time.Now()
+
+// checkTimeout bounds every dependency check so a hung dependency cannot hang the probe
+const checkTimeout = 2 * time.Second
+
+// CheckResult is the outcome of a single dependency check
+type CheckResult struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"` // ok, fail or disabled
+	Error     string  `json:"error,omitempty"`
+	LatencyMs float64 `json:"latencyMs"`
+}
+
+// errDisabled marks an optional dependency that is switched off
+var errDisabled = errors.New("disabled")
+
+type dependencyCheck struct {
+	name string
+	run  func(ctx context.Context) error
+}
+
+var dependencyChecks = []dependencyCheck{
+	{"mongodb", checkMongo},
+	{"migrations", checkMigrations},
+	{"uploads", checkUploadDir},
+	{"mailer", checkMailer},
+}
+
+// runChecks runs every dependency check concurrently
+func runChecks(ctx context.Context) ([]CheckResult, bool) {
+	results := make([]CheckResult, len(dependencyChecks))
+	var wg sync.WaitGroup
+	for i, check := range dependencyChecks {
+		wg.Add(1)
+		go func(i int, check dependencyCheck) {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, checkTimeout)
+			defer cancel()
+
+			start := time.Now()
+			err := check.run(checkCtx)
+			result := CheckResult{
+				Name:      check.name,
+				Status:    "ok",
+				LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+			}
+			switch {
+			case errors.Is(err, errDisabled):
+				result.Status = "disabled"
+			case err != nil:
+				result.Status = "fail"
+				result.Error = err.Error()
+			}
+			results[i] = result
+		}(i, check)
+	}
+	wg.Wait()
+
+	ready := true
+	for _, r := range results {
+		if r.Status == "fail" {
+			ready = false
+		}
+	}
+	return results, ready
+}
+
+func checkMongo(ctx context.Context) error {
+	return db.Client().Ping(ctx, readpref.Primary())
+}
+
+func checkMigrations(ctx context.Context) error {
+	pending, err := migrations.Pending(ctx, db)
+	if err != nil {
+		return err
+	}
+	if len(pending) > 0 {
+		versions := make([]string, len(pending))
+		for i, m := range pending {
+			versions[i] = fmt.Sprintf("%04d_%s", m.Version, m.Name)
+		}
+		return fmt.Errorf("pending migrations: %s", strings.Join(versions, ", "))
+	}
+	return nil
+}
+
+func checkUploadDir(ctx context.Context) error {
+	if err := os.MkdirAll(uploadDir, 0o755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(uploadDir, ".healthcheck-*")
+	if err != nil {
+		return err
+	}
+	name := f.Name()
+	_, werr := f.Write([]byte("ok"))
+	cerr := f.Close()
+	rerr := os.Remove(name)
+	for _, err := range []error{werr, cerr, rerr} {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkMailer only verifies the configuration; mail is optional outside
+// production so an unconfigured mailer there is reported as disabled.
+func checkMailer(ctx context.Context) error {
+	if appConfig == nil {
+		return fmt.Errorf("configuration not loaded")
+	}
+	if appConfig.MailerConfigured() {
+		return nil
+	}
+	if appConfig.IsProduction() {
+		return fmt.Errorf("SMTP_HOST and MAIL_FROM must be set in production")
+	}
+	return errDisabled
+}
+
+// Healthz handles GET /healthz. It only reports that the process is serving
+// requests and never touches dependencies, so a database outage does not get
+// the container restarted.
+func Healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// Readyz handles GET /readyz and returns 503 while any dependency is failing
+func Readyz(c *gin.Context) {
+	results, ready := runChecks(c.Request.Context())
+	// รายละเอียด error ดูได้ที่ /api/admin/status เท่านั้น ไม่เปิดให้คนทั่วไป
+	for i := range results {
+		results[i].Error = ""
+	}
+	status, code := "ok", http.StatusOK
+	if !ready {
+		status, code = "unavailable", http.StatusServiceUnavailable
+	}
+	c.JSON(code, gin.H{"status": status, "checks": results})
+}
+
+// GetSystemStatus handles GET /api/admin/status with build, runtime and
+// dependency details (admin only)
+func GetSystemStatus(c *gin.Context) {
+	results, ready := runChecks(c.Request.Context())
+
+	var mongoVersion string
+	var buildInfo bson.M
+	if err := db.RunCommand(c.Request.Context(), bson.D{{Key: "buildInfo", Value: 1}}).Decode(&buildInfo); err == nil {
+		mongoVersion, _ = buildInfo["version"].(string)
+	}
+
+	// เวอร์ชันที่ใช้กับฐานข้อมูลจริง อาจยังตามหลังเวอร์ชันล่าสุดใน binary
+	migrationStatus := gin.H{"latest": migrations.Latest()}
+	if current, err := migrations.Current(c.Request.Context(), db); err == nil {
+		migrationStatus["applied"] = current
+	}
+	if pending, err := migrations.Pending(c.Request.Context(), db); err == nil {
+		migrationStatus["pending"] = len(pending)
+	}
+
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	env := ""
+	if appConfig != nil {
+		env = appConfig.Env
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"ready": ready,
+		"build": gin.H{
+			"version":   Version,
+			"revision":  vcsSetting("vcs.revision"),
+			"commitAt":  vcsSetting("vcs.time"),
+			"goVersion": runtime.Version(),
+		},
+		"environment":   env,
+		"startedAt":     startedAt,
+		"uptimeSeconds": int64(time.Since(startedAt).Seconds()),
+		"runtime": gin.H{
+			"goroutines":     runtime.NumGoroutine(),
+			"heapAllocBytes": mem.HeapAlloc,
+			"numGC":          mem.NumGC,
+		},
+		"mongoVersion": mongoVersion,
+		"migrations":   migrationStatus,
+		"checks":       results,
+	})
+}
+
+func vcsSetting(key string) string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	for _, s := range info.Settings {
+		if s.Key == key {
+			return s.Value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"gosmooth/imaging"
+	"gosmooth/problem"
+)
+
+// storedImage is an upload written by saveUploadedImage. Paths are relative
+// to the API root, e.g. uploads/ReviewPhotos/<id>.jpg, as served by /uploads.
+type storedImage struct {
+	Path          string
+	ThumbnailPath string
+	Width, Height int
+}
+
+// saveUploadedImage runs the multipart file in field through imaging and
+// writes the image and its thumbnail under dir in the upload directory. It
+// aborts the request and returns false on failure.
+func saveUploadedImage(c *gin.Context, field, dir string) (storedImage, bool) {
+	file, err := c.FormFile(field)
+	if err != nil {
+		problem.Abort(c, http.StatusBadRequest, problem.CodeFileRequired)
+		return storedImage{}, false
+	}
+	if file.Size > maxUploadBytes {
+		problem.Abort(c, http.StatusRequestEntityTooLarge, problem.CodeFileTooLarge, problem.Args(maxUploadBytes))
+		return storedImage{}, false
+	}
+	f, err := file.Open()
+	if err != nil {
+		problem.Abort(c, http.StatusBadRequest, problem.CodeFileUnreadable)
+		return storedImage{}, false
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, maxUploadBytes+1))
+	if err != nil {
+		problem.Abort(c, http.StatusBadRequest, problem.CodeFileUnreadable)
+		return storedImage{}, false
+	}
+	if int64(len(data)) > maxUploadBytes {
+		problem.Abort(c, http.StatusRequestEntityTooLarge, problem.CodeFileTooLarge, problem.Args(maxUploadBytes))
+		return storedImage{}, false
+	}
+
+	img, err := imaging.Process(data, imageOptions)
+	switch {
+	case errors.Is(err, imaging.ErrUnsupported):
+		problem.Abort(c, http.StatusUnsupportedMediaType, problem.CodeUnsupportedImage)
+		return storedImage{}, false
+	case errors.Is(err, imaging.ErrTooLarge):
+		problem.Abort(c, http.StatusRequestEntityTooLarge, problem.CodeImageTooLarge)
+		return storedImage{}, false
+	case err != nil:
+		problem.Internal(c, err)
+		return storedImage{}, false
+	}
+
+	// ตั้งชื่อไฟล์ใหม่เองทั้งหมด ไม่ใช้ชื่อหรือนามสกุลที่ผู้ใช้ส่งมา
+	name := uuid.New().String()
+	stored := storedImage{
+		Path:          "uploads/" + dir + "/" + name + img.Ext,
+		ThumbnailPath: "uploads/" + dir + "/thumbs/" + name + ".jpg",
+		Width:         img.Width,
+		Height:        img.Height,
+	}
+	if err := os.MkdirAll(filepath.Join(uploadDir, dir, "thumbs"), 0o755); err != nil {
+		problem.Internal(c, err)
+		return storedImage{}, false
+	}
+	if err := os.WriteFile(imageFile(stored.Path), img.Data, 0o644); err != nil {
+		problem.Internal(c, err)
+		return storedImage{}, false
+	}
+	if err := os.WriteFile(imageFile(stored.ThumbnailPath), img.Thumbnail, 0o644); err != nil {
+		removeImages(c.Request.Context(), stored.Path)
+		problem.Internal(c, err)
+		return storedImage{}, false
+	}
+	return stored, true
+}
+
+// removeImages deletes stored images by path. Failures are only logged: the
+// database no longer refers to the files, so they are merely left over.
+func removeImages(ctx context.Context, paths ...string) {
+	for _, p := range paths {
+		name := imageFile(p)
+		if name == "" {
+			continue
+		}
+		if err := os.Remove(name); err != nil && !errors.Is(err, os.ErrNotExist) {
+			slog.WarnContext(ctx, "could not remove image", "path", p, "error", err)
+		}
+	}
+}
+
+// imageFile maps an uploads/... path to its file in the upload directory,
+// or "" if the path would leave it
+func imageFile(p string) string {
+	rel := filepath.FromSlash(strings.TrimPrefix(p, "uploads/"))
+	if !filepath.IsLocal(rel) {
+		return ""
+	}
+	return filepath.Join(uploadDir, rel)
+}
@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"gosmooth/models"
+	"gosmooth/problem"
+)
+
+// Likes and not-helpful marks are changed with a single conditional update:
+// the filter only matches when the vote actually changes state, so a count
+// and its voter list move together and repeating a request changes nothing.
+// A reader cannot both like a review and mark it not helpful; casting one
+// withdraws the other in the same update. Authors cannot vote on their own
+// reviews. Every endpoint responds with the
+// stored counts and the caller's resulting state.
+
+// LikeReview handles PUT /api/reviews/:id/like
+func LikeReview(c *gin.Context) {
+	setReviewVote(c, likeVote, true)
+}
+
+// UnlikeReview handles DELETE /api/reviews/:id/like
+func UnlikeReview(c *gin.Context) {
+	setReviewVote(c, likeVote, false)
+}
+
+// MarkNotHelpful handles PUT /api/reviews/:id/not-helpful
+func MarkNotHelpful(c *gin.Context) {
+	setReviewVote(c, notHelpfulVote, true)
+}
+
+// UnmarkNotHelpful handles DELETE /api/reviews/:id/not-helpful
+func UnmarkNotHelpful(c *gin.Context) {
+	setReviewVote(c, notHelpfulVote, false)
+}
+
+// LikeComment handles PUT /api/reviews/:id/comments/:commentId/like
+func LikeComment(c *gin.Context) {
+	setCommentLike(c, true)
+}
+
+// UnlikeComment handles DELETE /api/reviews/:id/comments/:commentId/like
+func UnlikeComment(c *gin.Context) {
+	setCommentLike(c, false)
+}
+
+// reviewVote names the count and voter list of one kind of review vote
+type reviewVote struct {
+	count, voters string
+}
+
+var (
+	likeVote       = reviewVote{"likes", "liked_by"}
+	notHelpfulVote = reviewVote{"not_helpful", "not_helpful_by"}
+)
+
+// opposite is the vote that casting v withdraws
+func (v reviewVote) opposite() reviewVote {
+	if v == likeVote {
+		return notHelpfulVote
+	}
+	return likeVote
+}
+
+func setReviewVote(c *gin.Context, vote reviewVote, cast bool) {
+	reviewID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		problem.Abort(c, http.StatusBadRequest, problem.CodeInvalidID)
+		return
+	}
+	userID := c.GetString("userID")
+	other := vote.opposite()
+
+	filter := bson.M{"_id": reviewID}
+	var update any
+	if cast {
+		// กดโหวตแบบหนึ่งจะถอนโหวตอีกแบบของผู้ใช้คนเดิมในคำสั่งเดียวกัน
+		voters := bson.M{"$ifNull": bson.A{"$" + other.voters, bson.A{}}}
+		filter[vote.voters] = bson.M{"$ne": userID}
+		filter["status"] = models.ReviewPublished
+		filter["user_id"] = bson.M{"$ne": userID}
+		update = mongo.Pipeline{{{Key: "$set", Value: bson.M{
+			vote.count:  bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$" + vote.count, 0}}, 1}},
+			vote.voters: bson.M{"$concatArrays": bson.A{bson.M{"$ifNull": bson.A{"$" + vote.voters, bson.A{}}}, bson.A{userID}}},
+			other.count: bson.M{"$cond": bson.A{
+				bson.M{"$in": bson.A{userID, voters}},
+				bson.M{"$subtract": bson.A{"$" + other.count, 1}},
+				bson.M{"$ifNull": bson.A{"$" + other.count, 0}},
+			}},
+			other.voters: bson.M{"$filter": bson.M{"input": voters, "cond": bson.M{"$ne": bson.A{"$$this", userID}}}},
+		}}}}
+	} else {
+		filter[vote.voters] = userID
+		update = bson.M{"$pull": bson.M{vote.voters: userID}, "$inc": bson.M{vote.count: -1}}
+	}
+
+	var before models.Review
+	opts := options.FindOneAndUpdate().
+		SetReturnDocument(options.Before).
+		SetProjection(bson.M{"user_id": 1, other.voters: 1})
+	err = db.Collection("reviews").FindOneAndUpdate(c.Request.Context(), filter, update, opts).Decode(&before)
+	switch {
+	case err == nil:
+		// only the opposite voter list was projected
+		withdrawn := slices.Contains(before.LikedBy, userID) || slices.Contains(before.NotHelpfulBy, userID)
+		delta := map[string]int{vote.count: -1}
+		if cast {
+			delta[vote.count] = 1
+			if withdrawn {
+				delta[other.count] = -1
+			}
+		}
+		if err := adjustAuthorVotes(c.Request.Context(), before.UserID, delta[likeVote.count], delta[notHelpfulVote.count]); err != nil {
+			slog.WarnContext(c.Request.Context(), "could not update author votes", "user_id", before.UserID, "error", err)
+		}
+		updateReviewRank(c.Request.Context(), reviewID)
+	case errors.Is(err, mongo.ErrNoDocuments):
+		// ไม่มีอะไรเปลี่ยน: กดซ้ำ หรือไม่มีรีวิวนี้
+	default:
+		problem.Internal(c, err)
+		return
+	}
+
+	var review models.Review
+	projection := bson.M{"user_id": 1, "status": 1, "likes": 1, "liked_by": 1, "not_helpful": 1, "not_helpful_by": 1}
+	err = db.Collection("reviews").FindOne(c.Request.Context(), bson.M{"_id": reviewID},
+		options.FindOne().SetProjection(projection)).Decode(&review)
+	// ถอนโหวตจากรีวิวที่ถูกซ่อนได้ แต่โหวตใหม่ไม่ได้ เหมือนความคิดเห็น
+	if errors.Is(err, mongo.ErrNoDocuments) || (err == nil && cast && review.Status != models.ReviewPublished) {
+		problem.Abort(c, http.StatusNotFound, problem.CodeReviewNotFound)
+		return
+	}
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	if cast && review.UserID == userID {
+		problem.Abort(c, http.StatusForbidden, problem.CodeOwnReview)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"liked":           slices.Contains(review.LikedBy, userID),
+		"likes":           review.Likes,
+		"notHelpful":      slices.Contains(review.NotHelpfulBy, userID),
+		"notHelpfulCount": review.NotHelpful,
+	})
+}
+
+func setCommentLike(c *gin.Context, like bool) {
+	reviewID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		problem.Abort(c, http.StatusBadRequest, problem.CodeInvalidID)
+		return
+	}
+	commentID, err := primitive.ObjectIDFromHex(c.Param("commentId"))
+	if err != nil {
+		problem.Abort(c, http.StatusBadRequest, problem.CodeInvalidID)
+		return
+	}
+	userID := c.GetString("userID")
+
+	filter := bson.M{"_id": commentID, "review_id": reviewID}
+	var update bson.M
+	if like {
+		filter["status"] = models.CommentVisible
+		filter["liked_by"] = bson.M{"$ne": userID}
+		update = bson.M{"$addToSet": bson.M{"liked_by": userID}, "$inc": bson.M{"likes": 1}}
+	} else {
+		filter["liked_by"] = userID
+		update = bson.M{"$pull": bson.M{"liked_by": userID}, "$inc": bson.M{"likes": -1}}
+	}
+
+	var comment models.Comment
+	projection := bson.M{"likes": 1, "status": 1}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After).SetProjection(projection)
+	err = db.Collection("comments").FindOneAndUpdate(c.Request.Context(), filter, update, opts).Decode(&comment)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		err = db.Collection("comments").FindOne(c.Request.Context(), bson.M{"_id": commentID, "review_id": reviewID},
+			options.FindOne().SetProjection(projection)).Decode(&comment)
+		if errors.Is(err, mongo.ErrNoDocuments) || (err == nil && like && comment.Status != models.CommentVisible) {
+			problem.Abort(c, http.StatusNotFound, problem.CodeCommentNotFound)
+			return
+		}
+	}
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"liked": like, "likes": comment.Likes})
+}
+
+// RepairLikeCounts recomputes likes from liked_by on every review and comment
+// where the two disagree, e.g. after the old read-then-write toggle raced,
+// and not-helpful counts on reviews likewise. It returns how many documents
+// were changed.
+func RepairLikeCounts(ctx context.Context) (int, error) {
+	repaired := 0
+	for _, target := range []struct {
+		collection string
+		vote       reviewVote
+	}{
+		{"reviews", likeVote},
+		{"reviews", notHelpfulVote},
+		{"comments", likeVote},
+	} {
+		n, err := repairVoteCounts(ctx, target.collection, target.vote)
+		repaired += n
+		if err != nil {
+			return repaired, err
+		}
+	}
+	return repaired, nil
+}
+
+func repairVoteCounts(ctx context.Context, collection string, vote reviewVote) (int, error) {
+	// รายชื่อผู้โหวตไม่ควรมีค่าซ้ำ แต่ถ้ามี ให้นับแค่ครั้งเดียว
+	voters := bson.M{"$setUnion": bson.A{bson.M{"$ifNull": bson.A{"$" + vote.voters, bson.A{}}}}}
+	filter := bson.M{"$expr": bson.M{"$or": bson.A{
+		bson.M{"$ne": bson.A{bson.M{"$type": "$" + vote.voters}, "array"}},
+		bson.M{"$ne": bson.A{"$" + vote.count, bson.M{"$size": voters}}},
+	}}}
+	update := mongo.Pipeline{{{Key: "$set", Value: bson.M{
+		vote.voters: voters,
+		vote.count:  bson.M{"$size": voters},
+	}}}}
+	result, err := db.Collection(collection).UpdateMany(ctx, filter, update)
+	if err != nil {
+		return 0, err
+	}
+	return int(result.ModifiedCount), nil
+}
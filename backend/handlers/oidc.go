@@ -0,0 +1,385 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"gosmooth/metrics"
+	"gosmooth/middleware"
+	"gosmooth/models"
+	"gosmooth/problem"
+	"gosmooth/sso"
+)
+
+// ssoProviders are the OpenID Connect providers enabled by Configure
+var ssoProviders *sso.Registry
+
+// frontendURL is where the browser is sent after an OpenID Connect callback
+var frontendURL = "http://localhost:5173"
+
+// oidcStateTTL bounds how long a sign-in may take at the provider. The TTL
+// index removes old states too, but only about once a minute.
+const oidcStateTTL = 10 * time.Minute
+
+// Errors the callback reports to the frontend as ?oidcError=<code>
+var (
+	errOIDCStateInvalid    = errors.New("state_invalid")
+	errOIDCFailed          = errors.New("oidc_failed")
+	errOIDCEmailUnverified = errors.New("email_unverified")
+	errOIDCIdentityInUse   = errors.New(string(problem.CodeIdentityInUse))
+	errOIDCAccountBanned   = errors.New(string(problem.CodeAccountBanned))
+)
+
+// errProviderUnavailable wraps discovery failures when starting a sign-in
+var errProviderUnavailable = errors.New("provider unavailable")
+
+// ListOIDCProviders handles GET /api/auth/oidc/providers
+func ListOIDCProviders(c *gin.Context) {
+	providers := []gin.H{}
+	for _, p := range ssoProviders.Providers() {
+		providers = append(providers, gin.H{
+			"name":        p.Name,
+			"displayName": p.DisplayName,
+			"loginUrl":    "/api/auth/oidc/" + p.Name + "/login",
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"providers": providers})
+}
+
+// OIDCLogin handles GET /api/auth/oidc/:provider/login and redirects the
+// browser to the provider's sign-in page.
+func OIDCLogin(c *gin.Context) {
+	provider, ok := ssoProviders.Lookup(c.Param("provider"))
+	if !ok {
+		problem.Abort(c, http.StatusNotFound, problem.CodeProviderNotFound)
+		return
+	}
+	authURL, err := startOIDC(c.Request.Context(), provider, "")
+	if err != nil {
+		abortOIDCStart(c, provider, err)
+		return
+	}
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// LinkIdentity handles POST /api/profile/identities/:provider. It returns the
+// URL the signed-in user must visit; the callback then links the identity.
+func LinkIdentity(c *gin.Context) {
+	provider, ok := ssoProviders.Lookup(c.Param("provider"))
+	if !ok {
+		problem.Abort(c, http.StatusNotFound, problem.CodeProviderNotFound)
+		return
+	}
+	userID := c.GetString("userID")
+	if _, err := primitive.ObjectIDFromHex(userID); err != nil {
+		problem.Abort(c, http.StatusBadRequest, problem.CodeInvalidID)
+		return
+	}
+	authURL, err := startOIDC(c.Request.Context(), provider, userID)
+	if err != nil {
+		abortOIDCStart(c, provider, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"authorizationUrl": authURL})
+}
+
+// startOIDC stores a pending sign-in and returns the provider URL for it.
+// linkUser is the id of the signed-in user when linking, otherwise "".
+func startOIDC(ctx context.Context, provider *sso.Provider, linkUser string) (string, error) {
+	state := models.OIDCState{
+		State:     sso.RandomToken(),
+		Provider:  provider.Name,
+		Verifier:  sso.NewVerifier(),
+		Nonce:     sso.RandomToken(),
+		LinkUser:  linkUser,
+		CreatedAt: time.Now(),
+	}
+	// ขอ URL ก่อนบันทึก state เพื่อไม่ให้เหลือ state ค้างเมื่อติดต่อ provider ไม่ได้
+	authURL, err := provider.AuthCodeURL(ctx, state.State, state.Nonce, state.Verifier)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", errProviderUnavailable, err)
+	}
+	if _, err := db.Collection("oidc_states").InsertOne(ctx, state); err != nil {
+		return "", err
+	}
+	return authURL, nil
+}
+
+func abortOIDCStart(c *gin.Context, provider *sso.Provider, err error) {
+	if !errors.Is(err, errProviderUnavailable) {
+		problem.Internal(c, err)
+		return
+	}
+	slog.WarnContext(c.Request.Context(), "oidc provider unavailable", "provider", provider.Name, "error", err)
+	problem.Abort(c, http.StatusBadGateway, problem.CodeProviderUnavailable)
+}
+
+// OIDCCallback handles GET /api/auth/oidc/:provider/callback. Errors are sent
+// back to the frontend rather than rendered, since the browser is mid-redirect.
+func OIDCCallback(c *gin.Context) {
+	ctx := c.Request.Context()
+	provider, ok := ssoProviders.Lookup(c.Param("provider"))
+	if !ok {
+		redirectToFrontend(c, "/login", url.Values{"oidcError": {string(problem.CodeProviderNotFound)}}, "")
+		return
+	}
+
+	var state models.OIDCState
+	err := db.Collection("oidc_states").FindOneAndDelete(ctx, bson.M{
+		"_id":      c.Query("state"),
+		"provider": provider.Name,
+	}).Decode(&state)
+	if errors.Is(err, mongo.ErrNoDocuments) || (err == nil && time.Since(state.CreatedAt) > oidcStateTTL) {
+		oidcFailed(c, "/login", errOIDCStateInvalid)
+		return
+	}
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+
+	errorPage := "/login"
+	if state.LinkUser != "" {
+		errorPage = "/profile"
+	}
+	// ผู้ใช้กดยกเลิกที่หน้า provider
+	if providerErr := c.Query("error"); providerErr != "" {
+		slog.InfoContext(ctx, "oidc sign-in declined", "provider", provider.Name, "error", providerErr)
+		oidcFailed(c, errorPage, errOIDCFailed)
+		return
+	}
+
+	claims, err := provider.Exchange(ctx, c.Query("code"), state.Verifier, state.Nonce)
+	if err != nil {
+		slog.WarnContext(ctx, "oidc exchange failed", "provider", provider.Name, "error", err)
+		oidcFailed(c, errorPage, errOIDCFailed)
+		return
+	}
+
+	if state.LinkUser != "" {
+		if err := linkIdentity(ctx, state.LinkUser, provider.Name, claims); err != nil {
+			oidcFailed(c, errorPage, err)
+			return
+		}
+		redirectToFrontend(c, "/profile", url.Values{"linked": {provider.Name}}, "")
+		return
+	}
+
+	user, err := findOrCreateOIDCUser(ctx, provider.Name, claims)
+	if err != nil {
+		oidcFailed(c, errorPage, err)
+		return
+	}
+	// เหมือน Login: บัญชีที่ต้องเปลี่ยนรหัสผ่านได้ token ที่ใช้ได้แค่เปลี่ยนรหัสผ่าน
+	if reason := passwordChangeReason(user); reason != "" {
+		token, err := middleware.GeneratePasswordChangeToken(user.ID.Hex())
+		if err != nil {
+			problem.Internal(c, err)
+			return
+		}
+		metrics.Logins.WithLabelValues("password_change").Inc()
+		redirectToFrontend(c, "/auth/callback", nil, url.Values{"token": {token}, "passwordChangeReason": {reason}}.Encode())
+		return
+	}
+
+	token, err := middleware.GenerateToken(user.ID.Hex(), false)
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	metrics.Logins.WithLabelValues("success").Inc()
+	// ส่ง token ทาง fragment เพื่อไม่ให้ไปอยู่ใน log ของ server หรือ Referer
+	redirectToFrontend(c, "/auth/callback", nil, url.Values{"token": {token}}.Encode())
+}
+
+// oidcFailed redirects to page with a known error code, or fails the request
+// for unexpected errors such as database outages.
+func oidcFailed(c *gin.Context, page string, err error) {
+	switch err {
+	case errOIDCStateInvalid, errOIDCFailed, errOIDCEmailUnverified, errOIDCIdentityInUse, errOIDCAccountBanned:
+		if page == "/login" {
+			metrics.Logins.WithLabelValues("failure").Inc()
+		}
+		redirectToFrontend(c, page, url.Values{"oidcError": {err.Error()}}, "")
+	default:
+		problem.Internal(c, err)
+	}
+}
+
+func redirectToFrontend(c *gin.Context, path string, query url.Values, fragment string) {
+	target := strings.TrimRight(frontendURL, "/") + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	if fragment != "" {
+		target += "#" + fragment
+	}
+	c.Redirect(http.StatusFound, target)
+}
+
+func identityFilter(provider, subject string) bson.M {
+	return bson.M{"identities": bson.M{"$elemMatch": bson.M{"provider": provider, "subject": subject}}}
+}
+
+// findOrCreateOIDCUser signs in the user linked to the identity. Otherwise
+// the identity is linked to the account with the same verified email, or a
+// new account without a password is created.
+func findOrCreateOIDCUser(ctx context.Context, provider string, claims sso.Claims) (models.User, error) {
+	users := db.Collection("users")
+	var user models.User
+	err := users.FindOne(ctx, identityFilter(provider, claims.Subject)).Decode(&user)
+	if err == nil {
+		return user, checkOIDCUserStatus(user)
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return user, err
+	}
+
+	// ห้ามผูกบัญชีด้วยอีเมลที่ provider ไม่ได้ยืนยัน ไม่เช่นนั้นใครก็ยึดบัญชีคนอื่นได้
+	if !claims.EmailVerified || claims.Email == "" {
+		return user, errOIDCEmailUnverified
+	}
+	identity := models.Identity{
+		Provider: provider,
+		Subject:  claims.Subject,
+		Email:    claims.Email,
+		LinkedAt: time.Now(),
+	}
+
+	emailFilter := bson.M{"email": primitive.Regex{Pattern: "^" + regexp.QuoteMeta(claims.Email) + "$", Options: "i"}}
+	err = users.FindOne(ctx, emailFilter).Decode(&user)
+	if err == nil {
+		if err := checkOIDCUserStatus(user); err != nil {
+			return user, err
+		}
+		_, err := users.UpdateOne(ctx, bson.M{"_id": user.ID}, bson.M{
+			"$push": bson.M{"identities": identity},
+			"$set":  bson.M{"updated_at": time.Now()},
+		})
+		if mongo.IsDuplicateKeyError(err) {
+			// อีก request ผูก identity นี้ไปพร้อมกัน
+			return findOrCreateOIDCUser(ctx, provider, claims)
+		}
+		return user, err
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return user, err
+	}
+
+	name := strings.TrimSpace(claims.Name)
+	if name == "" {
+		name = claims.Email[:strings.IndexByte(claims.Email+"@", '@')]
+	}
+	now := time.Now()
+	user = models.User{
+		ID:         primitive.NewObjectID(),
+		Email:      claims.Email,
+		Name:       name,
+		Role:       "user",
+		Status:     "active",
+		Identities: []models.Identity{identity},
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if _, err := users.InsertOne(ctx, user); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return findOrCreateOIDCUser(ctx, provider, claims)
+		}
+		return user, err
+	}
+	metrics.Registrations.Inc()
+	return user, nil
+}
+
+func checkOIDCUserStatus(user models.User) error {
+	if user.Status == "banned" {
+		return errOIDCAccountBanned
+	}
+	return nil
+}
+
+// linkIdentity adds the identity to a signed-in user's account
+func linkIdentity(ctx context.Context, userID, provider string, claims sso.Claims) error {
+	objectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return err
+	}
+	var owner models.User
+	err = db.Collection("users").FindOne(ctx, identityFilter(provider, claims.Subject)).Decode(&owner)
+	if err == nil {
+		if owner.ID == objectID {
+			return nil // ผูกไว้แล้ว
+		}
+		return errOIDCIdentityInUse
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return err
+	}
+
+	_, err = db.Collection("users").UpdateOne(ctx, bson.M{"_id": objectID}, bson.M{
+		"$push": bson.M{"identities": models.Identity{
+			Provider: provider,
+			Subject:  claims.Subject,
+			Email:    claims.Email,
+			LinkedAt: time.Now(),
+		}},
+		"$set": bson.M{"updated_at": time.Now()},
+	})
+	if mongo.IsDuplicateKeyError(err) {
+		return errOIDCIdentityInUse
+	}
+	return err
+}
+
+// UnlinkIdentity handles DELETE /api/profile/identities/:provider. Accounts
+// without a password must keep at least one linked identity to sign in with.
+func UnlinkIdentity(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.GetString("userID"))
+	if err != nil {
+		problem.Abort(c, http.StatusBadRequest, problem.CodeInvalidID)
+		return
+	}
+	provider := c.Param("provider")
+
+	var user models.User
+	if err := db.Collection("users").FindOne(c.Request.Context(), bson.M{"_id": objectID}).Decode(&user); err != nil {
+		problem.Abort(c, http.StatusNotFound, problem.CodeUserNotFound)
+		return
+	}
+	remaining := 0
+	for _, identity := range user.Identities {
+		if identity.Provider != provider {
+			remaining++
+		}
+	}
+	if remaining == len(user.Identities) {
+		problem.Abort(c, http.StatusNotFound, problem.CodeIdentityNotLinked)
+		return
+	}
+	if user.Password == "" && remaining == 0 {
+		problem.Abort(c, http.StatusConflict, problem.CodeLastLoginMethod)
+		return
+	}
+
+	_, err = db.Collection("users").UpdateOne(c.Request.Context(), bson.M{"_id": objectID}, bson.M{
+		"$pull": bson.M{"identities": bson.M{"provider": provider}},
+		"$set":  bson.M{"updated_at": time.Now()},
+	})
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Provider unlinked"})
+}
@@ -0,0 +1,352 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+
+	"gosmooth/middleware"
+	"gosmooth/models"
+	"gosmooth/sso"
+	"gosmooth/sso/ssotest"
+)
+
+// oidcTest drives the OpenID Connect handlers against an ssotest provider
+// and a mock database. Each database call takes the next queued response.
+type oidcTest struct {
+	mt     *mtest.T
+	idp    *ssotest.Provider
+	router *gin.Engine
+}
+
+func newOIDCTest(mt *mtest.T) *oidcTest {
+	srv, idp, err := ssotest.NewServer("client", "secret")
+	if err != nil {
+		mt.Fatal(err)
+	}
+	mt.Cleanup(srv.Close)
+
+	prevDB, prevProviders, prevFrontend := db, ssoProviders, frontendURL
+	mt.Cleanup(func() { db, ssoProviders, frontendURL = prevDB, prevProviders, prevFrontend })
+	db = mt.DB
+	ssoProviders = sso.NewRegistry("http://api.test", []sso.ProviderConfig{{
+		Name:         "mock",
+		Issuer:       srv.URL,
+		ClientID:     "client",
+		ClientSecret: "secret",
+	}})
+	frontendURL = "http://app.test"
+	middleware.SetAuthConfig("test-secret", time.Hour, time.Hour)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/api/auth/oidc/:provider/login", OIDCLogin)
+	router.GET("/api/auth/oidc/:provider/callback", OIDCCallback)
+	// ผู้ใช้ที่ล็อกอินแล้วมาจาก header แทน RequireAuth
+	signedIn := router.Group("/api/profile", func(c *gin.Context) {
+		c.Set("userID", c.GetHeader("X-Test-User"))
+	})
+	signedIn.POST("/identities/:provider", LinkIdentity)
+	signedIn.DELETE("/identities/:provider", UnlinkIdentity)
+	return &oidcTest{mt: mt, idp: idp, router: router}
+}
+
+func (o *oidcTest) serve(method, target, userID string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	req.Header.Set("X-Test-User", userID)
+	w := httptest.NewRecorder()
+	o.router.ServeHTTP(w, req)
+	return w
+}
+
+// start begins a sign-in, or links to userID when it is set, and returns the
+// provider URL with the state the handler stored
+func (o *oidcTest) start(userID string) (string, models.OIDCState) {
+	o.mt.Helper()
+	o.mt.ClearEvents()
+	o.mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}))
+
+	var authURL string
+	if userID == "" {
+		w := o.serve(http.MethodGet, "/api/auth/oidc/mock/login", "")
+		if w.Code != http.StatusFound {
+			o.mt.Fatalf("login status = %d, want 302: %s", w.Code, w.Body)
+		}
+		authURL = w.Header().Get("Location")
+	} else {
+		w := o.serve(http.MethodPost, "/api/profile/identities/mock", userID)
+		if w.Code != http.StatusOK {
+			o.mt.Fatalf("link status = %d, want 200: %s", w.Code, w.Body)
+		}
+		var body struct{ AuthorizationURL string }
+		json.Unmarshal(w.Body.Bytes(), &body)
+		authURL = body.AuthorizationURL
+	}
+
+	insert := o.mt.GetStartedEvent()
+	if insert == nil || insert.CommandName != "insert" {
+		o.mt.Fatalf("state was not stored: %+v", insert)
+	}
+	var state models.OIDCState
+	doc := insert.Command.Lookup("documents").Array().Index(0).Value().Document()
+	if err := bson.Unmarshal(doc, &state); err != nil {
+		o.mt.Fatal(err)
+	}
+	return authURL, state
+}
+
+// callback signs in at the provider and returns to OIDCCallback with the
+// code, the database handing back state. responses answer the queries the
+// callback makes after reading the state.
+func (o *oidcTest) callback(authURL string, state models.OIDCState, responses ...bson.D) *url.URL {
+	o.mt.Helper()
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.Get(authURL)
+	if err != nil {
+		o.mt.Fatal(err)
+	}
+	resp.Body.Close()
+	back, err := resp.Location()
+	if err != nil {
+		o.mt.Fatalf("provider did not redirect back: %v", err)
+	}
+
+	o.mt.ClearEvents()
+	o.mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "value", Value: state}))
+	o.mt.AddMockResponses(responses...)
+	w := o.serve(http.MethodGet, "/api/auth/oidc/mock/callback?"+back.RawQuery, "")
+	if w.Code != http.StatusFound {
+		o.mt.Fatalf("callback status = %d, want 302: %s", w.Code, w.Body)
+	}
+	target, err := url.Parse(w.Header().Get("Location"))
+	if err != nil {
+		o.mt.Fatal(err)
+	}
+	o.mt.GetStartedEvent() // findAndModify ของ state
+	return target
+}
+
+// findUsers answers a users query with docs
+func findUsers(docs ...models.User) bson.D {
+	batch := make([]bson.D, len(docs))
+	for i, u := range docs {
+		raw, _ := bson.Marshal(u)
+		bson.Unmarshal(raw, &batch[i])
+	}
+	return mtest.CreateCursorResponse(0, "test.users", mtest.FirstBatch, batch...)
+}
+
+var updated = mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1})
+
+// pushedIdentity returns the identity an update command pushes to a user
+func pushedIdentity(t *testing.T, evt bson.Raw) (primitive.ObjectID, models.Identity) {
+	t.Helper()
+	update := evt.Lookup("updates").Array().Index(0).Value().Document()
+	var u struct {
+		Q struct {
+			ID primitive.ObjectID `bson:"_id"`
+		} `bson:"q"`
+		U struct {
+			Push struct {
+				Identities models.Identity `bson:"identities"`
+			} `bson:"$push"`
+		} `bson:"u"`
+	}
+	if err := bson.Unmarshal(update, &u); err != nil {
+		t.Fatal(err)
+	}
+	return u.Q.ID, u.U.Push.Identities
+}
+
+func TestOIDCLoginPKCEAndNonce(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("round trip", func(mt *mtest.T) {
+		o := newOIDCTest(mt)
+		authURL, state := o.start("")
+
+		q, _ := url.Parse(authURL)
+		sum := sha256.Sum256([]byte(state.Verifier))
+		if got, want := q.Query().Get("code_challenge"), base64.RawURLEncoding.EncodeToString(sum[:]); got != want {
+			t.Errorf("code_challenge = %q, want S256 of the stored verifier %q", got, want)
+		}
+		if q.Query().Get("nonce") != state.Nonce || q.Query().Get("state") != state.State {
+			t.Errorf("nonce, state = %q, %q, want the stored %q, %q",
+				q.Query().Get("nonce"), q.Query().Get("state"), state.Nonce, state.State)
+		}
+		if state.Provider != "mock" || state.LinkUser != "" {
+			t.Errorf("stored state = %+v, want provider mock and no link user", state)
+		}
+
+		user := models.User{ID: primitive.NewObjectID(), Email: "mock.user@example.com", Status: "active",
+			Identities: []models.Identity{{Provider: "mock", Subject: "mock-user-1"}}}
+		target := o.callback(authURL, state, findUsers(user))
+		if target.Path != "/auth/callback" {
+			t.Fatalf("redirected to %s, want /auth/callback", target)
+		}
+		fragment, _ := url.ParseQuery(target.Fragment)
+		token, err := middleware.ValidateToken(fragment.Get("token"))
+		if err != nil || !token.Valid {
+			t.Fatalf("token %q is not valid: %v", fragment.Get("token"), err)
+		}
+		if claims, _ := token.Claims.(jwt.MapClaims); claims["user_id"] != user.ID.Hex() {
+			t.Errorf("token user_id = %v, want %s", claims["user_id"], user.ID.Hex())
+		}
+	})
+
+	tamper := map[string]func(*models.OIDCState){
+		"other verifier": func(s *models.OIDCState) { s.Verifier = sso.NewVerifier() },
+		"other nonce":    func(s *models.OIDCState) { s.Nonce = sso.RandomToken() },
+	}
+	for name, change := range tamper {
+		mt.Run(name, func(mt *mtest.T) {
+			o := newOIDCTest(mt)
+			authURL, state := o.start("")
+			change(&state)
+
+			target := o.callback(authURL, state)
+			if target.Path != "/login" || target.Query().Get("oidcError") != "oidc_failed" {
+				t.Errorf("redirected to %s, want /login?oidcError=oidc_failed", target)
+			}
+			if evt := mt.GetStartedEvent(); evt != nil {
+				t.Errorf("callback queried %s after a failed exchange", evt.CommandName)
+			}
+		})
+	}
+}
+
+func TestOIDCCallbackLinksVerifiedEmail(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("default", func(mt *mtest.T) {
+		o := newOIDCTest(mt)
+		o.idp.SetUser(ssotest.User{Subject: "sub-7", Email: "Ann@Example.com", EmailVerified: true, Name: "Ann"})
+		authURL, state := o.start("")
+
+		existing := models.User{ID: primitive.NewObjectID(), Email: "ann@example.com", Password: "hash", Status: "active"}
+		target := o.callback(authURL, state, findUsers(), findUsers(existing), updated)
+		if target.Path != "/auth/callback" {
+			t.Fatalf("redirected to %s, want /auth/callback", target)
+		}
+
+		mt.GetStartedEvent() // ค้นด้วย identity
+		byEmail := mt.GetStartedEvent()
+		if byEmail == nil || byEmail.CommandName != "find" {
+			t.Fatalf("second query = %+v, want find by email", byEmail)
+		}
+		pattern := byEmail.Command.Lookup("filter", "email")
+		if p, opts := pattern.Regex(); p != "^Ann@Example\\.com$" || opts != "i" {
+			t.Errorf("email filter = /%s/%s, want a case-insensitive exact match", p, opts)
+		}
+		update := mt.GetStartedEvent()
+		if update == nil || update.CommandName != "update" {
+			t.Fatalf("third query = %+v, want update", update)
+		}
+		id, identity := pushedIdentity(t, update.Command)
+		if id != existing.ID || identity.Provider != "mock" || identity.Subject != "sub-7" {
+			t.Errorf("pushed %+v to %s, want mock/sub-7 on %s", identity, id.Hex(), existing.ID.Hex())
+		}
+	})
+}
+
+func TestOIDCCallbackRejectsUnverifiedEmail(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("default", func(mt *mtest.T) {
+		o := newOIDCTest(mt)
+		o.idp.SetUser(ssotest.User{Subject: "sub-8", Email: "ann@example.com", Name: "Ann"})
+		authURL, state := o.start("")
+
+		target := o.callback(authURL, state, findUsers())
+		if target.Path != "/login" || target.Query().Get("oidcError") != "email_unverified" {
+			t.Errorf("redirected to %s, want /login?oidcError=email_unverified", target)
+		}
+		mt.GetStartedEvent() // ค้นด้วย identity
+		if evt := mt.GetStartedEvent(); evt != nil {
+			t.Errorf("unverified email led to %s on the database", evt.CommandName)
+		}
+	})
+}
+
+func TestLinkIdentity(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	userID := primitive.NewObjectID()
+
+	mt.Run("linked", func(mt *mtest.T) {
+		o := newOIDCTest(mt)
+		authURL, state := o.start(userID.Hex())
+		if state.LinkUser != userID.Hex() {
+			t.Errorf("stored link user = %q, want %s", state.LinkUser, userID.Hex())
+		}
+
+		target := o.callback(authURL, state, findUsers(), updated)
+		if target.Path != "/profile" || target.Query().Get("linked") != "mock" {
+			t.Fatalf("redirected to %s, want /profile?linked=mock", target)
+		}
+		mt.GetStartedEvent() // ค้นด้วย identity
+		update := mt.GetStartedEvent()
+		if update == nil || update.CommandName != "update" {
+			t.Fatalf("second query = %+v, want update", update)
+		}
+		id, identity := pushedIdentity(t, update.Command)
+		if id != userID || identity.Provider != "mock" || identity.Subject != "mock-user-1" {
+			t.Errorf("pushed %+v to %s, want mock/mock-user-1 on %s", identity, id.Hex(), userID.Hex())
+		}
+	})
+
+	mt.Run("identity of another account", func(mt *mtest.T) {
+		o := newOIDCTest(mt)
+		authURL, state := o.start(userID.Hex())
+
+		other := models.User{ID: primitive.NewObjectID(), Identities: []models.Identity{{Provider: "mock", Subject: "mock-user-1"}}}
+		target := o.callback(authURL, state, findUsers(other))
+		if target.Path != "/profile" || target.Query().Get("oidcError") != "identity_in_use" {
+			t.Errorf("redirected to %s, want /profile?oidcError=identity_in_use", target)
+		}
+	})
+}
+
+func TestUnlinkIdentity(t *testing.T) {
+	mock := models.Identity{Provider: "mock", Subject: "s1"}
+	google := models.Identity{Provider: "google", Subject: "s2"}
+	tests := []struct {
+		name     string
+		password string
+		linked   []models.Identity
+		status   int
+	}{
+		{"last credential", "", []models.Identity{mock}, http.StatusConflict},
+		{"password left", "hash", []models.Identity{mock}, http.StatusOK},
+		{"another identity left", "", []models.Identity{mock, google}, http.StatusOK},
+		{"not linked", "hash", []models.Identity{google}, http.StatusNotFound},
+	}
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	for _, tt := range tests {
+		mt.Run(tt.name, func(mt *mtest.T) {
+			o := newOIDCTest(mt)
+			user := models.User{ID: primitive.NewObjectID(), Password: tt.password, Identities: tt.linked}
+			mt.AddMockResponses(findUsers(user), updated)
+
+			w := o.serve(http.MethodDelete, "/api/profile/identities/mock", user.ID.Hex())
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+			mt.GetStartedEvent() // find ผู้ใช้
+			update := mt.GetStartedEvent()
+			if (update != nil) != (tt.status == http.StatusOK) {
+				t.Errorf("update sent = %v, want %v", update != nil, tt.status == http.StatusOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,366 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"gosmooth/models"
+	"gosmooth/problem"
+	"gosmooth/validation"
+)
+
+// GetOwnedPlaces handles GET /api/owner/places, the places the user is the
+// verified owner of
+func GetOwnedPlaces(c *gin.Context) {
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}})
+	cursor, err := db.Collection("places").Find(c.Request.Context(), bson.M{"owner_id": c.GetString("userID")}, opts)
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	places := []models.Place{}
+	if err := cursor.All(c.Request.Context(), &places); err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"places": places})
+}
+
+// UpdateOwnedPlace handles PATCH /api/owner/places/:id. Owners can keep
+// their description, phone, website and opening hours current; anything
+// that decides where or what the place is goes through an admin. Fields left
+// out are kept.
+func UpdateOwnedPlace(c *gin.Context) {
+	place, ok := ownedPlace(c)
+	if !ok {
+		return
+	}
+	var input models.OwnerPlaceInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		problem.Validation(c, err)
+		return
+	}
+	// ค่าว่างคือการลบ จึงตรวจรูปแบบเฉพาะเมื่อมีค่า
+	if input.Phone != nil && *input.Phone != "" && !validation.ValidPhone(*input.Phone) {
+		problem.Abort(c, http.StatusBadRequest, problem.CodeValidation, problem.Field("phone", "th_phone", ""))
+		return
+	}
+	if input.Website != nil && *input.Website != "" && validation.Validator().Var(*input.Website, "url") != nil {
+		problem.Abort(c, http.StatusBadRequest, problem.CodeValidation, problem.Field("website", "url", ""))
+		return
+	}
+
+	set := bson.M{"updated_at": time.Now()}
+	if input.Description != nil {
+		set["description"] = *input.Description
+	}
+	if input.Phone != nil {
+		set["phone"] = *input.Phone
+	}
+	if input.Website != nil {
+		set["website"] = *input.Website
+	}
+	if input.Hours != nil {
+		set["hours"] = *input.Hours
+	}
+	_, err := db.Collection("places").UpdateOne(c.Request.Context(),
+		bson.M{"_id": place.ObjectID, "owner_id": place.OwnerID},
+		bson.M{"$set": set},
+	)
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "place updated successfully"})
+}
+
+// GetPlaceAnalytics handles GET /api/owner/places/:id/analytics, review
+// figures for an owned place. from, to and interval work as for GetStats.
+func GetPlaceAnalytics(c *gin.Context) {
+	place, ok := ownedPlace(c)
+	if !ok {
+		return
+	}
+	from, to, err := parseStatsRange(c.Query("from"), c.Query("to"))
+	if err != nil {
+		var perr *statsParamError
+		if errors.As(err, &perr) {
+			problem.Abort(c, http.StatusBadRequest, problem.CodeInvalidParameter, problem.Field(perr.param, perr.rule, perr.want))
+			return
+		}
+		problem.Internal(c, err)
+		return
+	}
+	interval := c.DefaultQuery("interval", "day")
+	format, ok := intervalFormats[interval]
+	if !ok {
+		problem.Abort(c, http.StatusBadRequest, problem.CodeInvalidParameter, problem.Field("interval", "oneof", "day week month"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	totalReviews, averageRating, err := placeRating(ctx, place.ID)
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	newReviews, err := countByPeriod(ctx, "reviews", bson.M{"place_id": place.ID, "status": models.ReviewPublished}, from, to, format)
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	summary, err := placeReviewSummary(ctx, place.ID, from, to)
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"place":          gin.H{"id": place.ObjectID, "place_id": place.ID, "name": place.Name},
+		"total_reviews":  totalReviews,
+		"average_rating": averageRating,
+		"range":          gin.H{"from": from, "to": to, "interval": interval},
+		"summary":        summary,
+		"new_reviews":    newReviews,
+		"last_updated":   time.Now(),
+	})
+}
+
+// placeRating counts a place's published reviews and averages their ratings.
+// The rating stored on the place is only rebuilt by RebuildPlaceRatings and
+// lags behind new reviews.
+func placeRating(ctx context.Context, placeID string) (int, float64, error) {
+	cursor, err := db.Collection("reviews").Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"place_id": placeID, "status": models.ReviewPublished}}},
+		{{Key: "$group", Value: bson.M{"_id": nil, "count": bson.M{"$sum": 1}, "average": bson.M{"$avg": "$rating"}}}},
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	var totals []struct {
+		Count   int     `bson:"count"`
+		Average float64 `bson:"average"`
+	}
+	if err := cursor.All(ctx, &totals); err != nil || len(totals) == 0 {
+		return 0, 0, err
+	}
+	return totals[0].Count, totals[0].Average, nil
+}
+
+// PlaceReviewSummary describes a place's reviews within a date range
+type PlaceReviewSummary struct {
+	Reviews       int            `json:"reviews"`
+	Average       float64        `json:"average"`
+	Ratings       map[string]int `json:"ratings"` // "1" ถึง "5" -> จำนวนรีวิว
+	Likes         int            `json:"likes"`
+	Responded     int            `json:"responded"`
+	ResponseRate  float64        `json:"response_rate"`
+	ResponseHours float64        `json:"avg_response_hours"`
+}
+
+func placeReviewSummary(ctx context.Context, placeID string, from, to time.Time) (PlaceReviewSummary, error) {
+	summary := PlaceReviewSummary{Ratings: map[string]int{"1": 0, "2": 0, "3": 0, "4": 0, "5": 0}}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"place_id": placeID, "status": models.ReviewPublished, "created_at": bson.M{"$gte": from, "$lte": to}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":       "$rating",
+			"count":     bson.M{"$sum": 1},
+			"likes":     bson.M{"$sum": "$likes"},
+			"responded": bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$ifNull": bson.A{"$response", false}}, 1, 0}}},
+			// รีวิวที่ยังไม่ตอบ ผลต่างของเวลาเป็น null ซึ่ง $sum ข้ามไป
+			"response_ms": bson.M{"$sum": bson.M{"$subtract": bson.A{"$response.created_at", "$created_at"}}},
+		}}},
+	}
+	cursor, err := db.Collection("reviews").Aggregate(ctx, pipeline)
+	if err != nil {
+		return summary, err
+	}
+	var groups []struct {
+		Rating     int   `bson:"_id"`
+		Count      int   `bson:"count"`
+		Likes      int   `bson:"likes"`
+		Responded  int   `bson:"responded"`
+		ResponseMS int64 `bson:"response_ms"`
+	}
+	if err := cursor.All(ctx, &groups); err != nil {
+		return summary, err
+	}
+
+	var ratingTotal, responseMS int64
+	for _, g := range groups {
+		if g.Rating >= 1 && g.Rating <= 5 {
+			summary.Ratings[strconv.Itoa(g.Rating)] = g.Count
+		}
+		summary.Reviews += g.Count
+		summary.Likes += g.Likes
+		summary.Responded += g.Responded
+		ratingTotal += int64(g.Rating * g.Count)
+		responseMS += g.ResponseMS
+	}
+	if summary.Reviews > 0 {
+		summary.Average = float64(ratingTotal) / float64(summary.Reviews)
+		summary.ResponseRate = float64(summary.Responded) / float64(summary.Reviews)
+	}
+	if summary.Responded > 0 {
+		summary.ResponseHours = (time.Duration(responseMS/int64(summary.Responded)) * time.Millisecond).Hours()
+	}
+	return summary, nil
+}
+
+// RespondToReview handles PUT /api/reviews/:id/response, posting or editing
+// the place owner's public response. There is only ever one per review.
+func RespondToReview(c *gin.Context) {
+	review, ok := findReviewForResponse(c)
+	if !ok {
+		return
+	}
+	var input models.OwnerResponseInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		problem.Validation(c, err)
+		return
+	}
+	userID := c.GetString("userID")
+	place, err := findPlace(c.Request.Context(), review.PlaceID)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		problem.Internal(c, err)
+		return
+	}
+	if err != nil || place.OwnerID != userID {
+		problem.Abort(c, http.StatusForbidden, problem.CodeNotPlaceOwner)
+		return
+	}
+
+	now := time.Now()
+	response := models.OwnerResponse{Text: input.Text, UserID: userID, CreatedAt: now, UpdatedAt: now}
+	if review.Response == nil {
+		result, err := db.Collection("reviews").UpdateOne(c.Request.Context(),
+			bson.M{"_id": review.ID, "response": bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{"response": response}},
+		)
+		if err != nil {
+			problem.Internal(c, err)
+			return
+		}
+		if result.MatchedCount == 1 {
+			c.JSON(http.StatusCreated, gin.H{"response": response})
+			return
+		}
+		// มีคำตอบถูกเพิ่มเข้ามาพร้อมกัน จึงแก้ไขคำตอบนั้นแทน
+	}
+
+	var updated models.Review
+	err = db.Collection("reviews").FindOneAndUpdate(c.Request.Context(),
+		bson.M{"_id": review.ID, "response": bson.M{"$exists": true}},
+		bson.M{"$set": bson.M{"response.text": input.Text, "response.user_id": userID, "response.updated_at": now}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After).SetProjection(bson.M{"response": 1}),
+	).Decode(&updated)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		problem.Abort(c, http.StatusNotFound, problem.CodeReviewNotFound)
+		return
+	}
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"response": updated.Response})
+}
+
+// DeleteReviewResponse handles DELETE /api/reviews/:id/response by the
+// place's owner
+func DeleteReviewResponse(c *gin.Context) {
+	review, ok := findReviewForResponse(c)
+	if !ok {
+		return
+	}
+	place, err := findPlace(c.Request.Context(), review.PlaceID)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		problem.Internal(c, err)
+		return
+	}
+	if err != nil || place.OwnerID != c.GetString("userID") {
+		problem.Abort(c, http.StatusForbidden, problem.CodeNotPlaceOwner)
+		return
+	}
+	removeReviewResponse(c, review.ID)
+}
+
+// RemoveReviewResponse handles DELETE /api/admin/reviews/:id/response, so
+// moderators can take down an owner response
+func RemoveReviewResponse(c *gin.Context) {
+	review, ok := findReviewForResponse(c)
+	if !ok {
+		return
+	}
+	slog.InfoContext(c.Request.Context(), "owner response removed by moderator",
+		"review_id", review.ID.Hex(), "admin_id", c.GetString("userID"))
+	removeReviewResponse(c, review.ID)
+}
+
+func removeReviewResponse(c *gin.Context, reviewID primitive.ObjectID) {
+	result, err := db.Collection("reviews").UpdateOne(c.Request.Context(),
+		bson.M{"_id": reviewID, "response": bson.M{"$exists": true}},
+		bson.M{"$unset": bson.M{"response": ""}},
+	)
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	if result.ModifiedCount == 0 {
+		problem.Abort(c, http.StatusNotFound, problem.CodeResponseNotFound)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "response removed"})
+}
+
+// findReviewForResponse loads review :id with just what responding needs,
+// aborting with 404 if there is none
+func findReviewForResponse(c *gin.Context) (models.Review, bool) {
+	var review models.Review
+	reviewID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		problem.Abort(c, http.StatusBadRequest, problem.CodeInvalidID)
+		return review, false
+	}
+	opts := options.FindOne().SetProjection(bson.M{"place_id": 1, "response": 1})
+	err = db.Collection("reviews").FindOne(c.Request.Context(), bson.M{"_id": reviewID}, opts).Decode(&review)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		problem.Abort(c, http.StatusNotFound, problem.CodeReviewNotFound)
+		return review, false
+	}
+	if err != nil {
+		problem.Internal(c, err)
+		return review, false
+	}
+	return review, true
+}
+
+// ownedPlace loads place :id, aborting with 403 unless the user is its
+// verified owner
+func ownedPlace(c *gin.Context) (models.Place, bool) {
+	place, err := findPlace(c.Request.Context(), c.Param("id"))
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		problem.Abort(c, http.StatusNotFound, problem.CodePlaceNotFound)
+		return place, false
+	}
+	if err != nil {
+		problem.Internal(c, err)
+		return place, false
+	}
+	if place.OwnerID == "" || place.OwnerID != c.GetString("userID") {
+		problem.Abort(c, http.StatusForbidden, problem.CodeNotPlaceOwner)
+		return place, false
+	}
+	return place, true
+}
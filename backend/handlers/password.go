@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/crypto/bcrypt"
+
+	"gosmooth/models"
+	"gosmooth/problem"
+	"gosmooth/validation"
+)
+
+// AdminPasswordMaxAge forces admins whose password is older than this to
+// change it at their next login; zero disables expiry. Configure overrides it
+// from ADMIN_PASSWORD_MAX_AGE.
+var AdminPasswordMaxAge time.Duration
+
+// Reasons a login is limited to a password change
+const (
+	passwordChangeReset   = "reset_required"
+	passwordChangeExpired = "expired"
+)
+
+// passwordChangeReason returns why user must change their password before
+// doing anything else, or "" if they don't have to.
+func passwordChangeReason(user models.User) string {
+	if user.MustChangePassword {
+		return passwordChangeReset
+	}
+	if user.Role == "admin" && AdminPasswordMaxAge > 0 {
+		// บัญชีที่สร้างก่อนมี password_changed_at นับอายุจากวันที่สร้างบัญชี
+		changedAt := user.CreatedAt
+		if user.PasswordChangedAt != nil {
+			changedAt = *user.PasswordChangedAt
+		}
+		if time.Since(changedAt) > AdminPasswordMaxAge {
+			return passwordChangeExpired
+		}
+	}
+	return ""
+}
+
+// abortWeakPassword responds 400 weak_password listing the broken rules
+func abortWeakPassword(c *gin.Context, err error) {
+	var perr *validation.PasswordError
+	if !errors.As(err, &perr) {
+		problem.Internal(c, err)
+		return
+	}
+	problem.Abort(c, http.StatusBadRequest, problem.CodeWeakPassword, problem.With("reasons", perr.Reasons))
+}
+
+// passwordUpdate hashes password and returns the fields that store it.
+// mustChange marks it as temporary, e.g. when an admin sets it.
+func passwordUpdate(password string, mustChange bool) (bson.M, error) {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	return bson.M{
+		"password":             string(hashedPassword),
+		"password_changed_at":  now,
+		"must_change_password": mustChange,
+		"updated_at":           now,
+	}, nil
+}
+
+// RequirePasswordChange handles POST /api/admin/users/:id/require-password-change
+// and limits the user's next login to picking a new password.
+func RequirePasswordChange(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		problem.Abort(c, http.StatusBadRequest, problem.CodeInvalidID)
+		return
+	}
+	result, err := db.Collection("users").UpdateOne(c.Request.Context(), bson.M{"_id": objectID}, bson.M{
+		"$set": bson.M{"must_change_password": true, "updated_at": time.Now()},
+	})
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	if result.MatchedCount == 0 {
+		problem.Abort(c, http.StatusNotFound, problem.CodeUserNotFound)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "user must change password at next login"})
+}
+
+// RequirePasswordChangeByEmail is the CLI counterpart of RequirePasswordChange
+func RequirePasswordChangeByEmail(ctx context.Context, email string) error {
+	result, err := db.Collection("users").UpdateOne(ctx, bson.M{"email": email}, bson.M{
+		"$set": bson.M{"must_change_password": true, "updated_at": time.Now()},
+	})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("%w: no user with email %s", ErrNotFound, email)
+	}
+	return nil
+}
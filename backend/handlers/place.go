@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.opentelemetry.io/otel"
+
+	"gosmooth/models"
+	"gosmooth/problem"
+)
+
+// tracer creates spans for handler steps that are not Mongo commands
+var tracer = otel.Tracer("gosmooth/handlers")
+
+// ListPlaces handles GET /api/places (public). Signed-in callers see which
+// places they saved.
+func ListPlaces(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	cursor, err := db.Collection("places").Find(ctx, bson.M{})
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	defer cursor.Close(ctx)
+
+	var places []models.Place
+	if err := cursor.All(ctx, &places); err != nil {
+		problem.Internal(c, err)
+		return
+	}
+
+	// --- เพิ่ม logic คำนวณ rating จาก reviews ---
+	ratingCtx, ratingSpan := tracer.Start(ctx, "places.compute_ratings")
+	for i, place := range places {
+		reviewCursor, err := db.Collection("reviews").Find(ratingCtx, bson.M{"place_id": place.ID, "status": models.ReviewPublished})
+		if err == nil {
+			var reviews []struct {
+				Rating int `bson:"rating"`
+			}
+			_ = reviewCursor.All(ratingCtx, &reviews)
+			total := 0
+			for _, r := range reviews {
+				total += r.Rating
+			}
+			if len(reviews) > 0 {
+				places[i].Rating = float64(total) / float64(len(reviews))
+			} else {
+				places[i].Rating = 0.0
+			}
+		}
+	}
+	ratingSpan.End()
+
+	saved, err := savedPlaceIDs(ctx, c.GetString("userID"))
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	for i := range places {
+		places[i].Saved = saved[places[i].ID]
+	}
+
+	slog.DebugContext(c.Request.Context(), "places listed", "count", len(places))
+
+	_, encodeSpan := tracer.Start(ctx, "places.encode")
+	c.JSON(200, gin.H{"places": places})
+	encodeSpan.End()
+}
+
+// GetPlace handles GET /api/places/:id, looking the place up by place_id or _id
+func GetPlace(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	place, err := findPlace(ctx, c.Param("id"))
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		problem.Abort(c, http.StatusNotFound, problem.CodePlaceNotFound)
+		return
+	}
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	saved, err := savedPlaceIDs(ctx, c.GetString("userID"))
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	place.Saved = saved[place.ID]
+	c.JSON(200, gin.H{"place": place})
+}
+
+// findPlace loads a place by place_id, or by _id if id is an ObjectID and no
+// place_id matches. It returns mongo.ErrNoDocuments if neither does.
+func findPlace(ctx context.Context, id string) (models.Place, error) {
+	var place models.Place
+	err := db.Collection("places").FindOne(ctx, bson.M{"place_id": id}).Decode(&place)
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return place, err
+	}
+	objID, objErr := primitive.ObjectIDFromHex(id)
+	if objErr != nil {
+		return place, err
+	}
+	err = db.Collection("places").FindOne(ctx, bson.M{"_id": objID}).Decode(&place)
+	return place, err
+}
+
+// GetLocations handles GET /api/locations (public)
+func GetLocations(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+	cursor, err := db.Collection("locations").Find(ctx, bson.M{})
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	defer cursor.Close(ctx)
+
+	var locations []models.Location
+	if err := cursor.All(ctx, &locations); err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	c.JSON(200, gin.H{"locations": locations})
+}
+
+// RebuildPlaceRatings recomputes the stored rating of every place from its
+// published reviews and returns how many places were updated.
+func RebuildPlaceRatings(ctx context.Context) (int, error) {
+	cursor, err := db.Collection("reviews").Aggregate(ctx, []bson.M{
+		{"$match": bson.M{"status": models.ReviewPublished}},
+		{"$group": bson.M{"_id": "$place_id", "average": bson.M{"$avg": "$rating"}}},
+	})
+	if err != nil {
+		return 0, err
+	}
+	var averages []struct {
+		PlaceID string  `bson:"_id"`
+		Average float64 `bson:"average"`
+	}
+	if err := cursor.All(ctx, &averages); err != nil {
+		return 0, err
+	}
+
+	// รีเซ็ตเป็น 0 ก่อน สำหรับสถานที่ที่ไม่มีรีวิวแล้ว
+	if _, err := db.Collection("places").UpdateMany(ctx, bson.M{}, bson.M{"$set": bson.M{"rating": 0.0}}); err != nil {
+		return 0, err
+	}
+	updated := 0
+	for _, a := range averages {
+		result, err := db.Collection("places").UpdateOne(ctx,
+			bson.M{"place_id": a.PlaceID},
+			bson.M{"$set": bson.M{"rating": a.Average, "updated_at": time.Now()}})
+		if err != nil {
+			return updated, err
+		}
+		updated += int(result.ModifiedCount)
+	}
+	return updated, nil
+}
@@ -0,0 +1,569 @@
+package handlers
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"gosmooth/models"
+	"gosmooth/problem"
+	"gosmooth/validation"
+)
+
+// placeCSVHeader is the column order used for CSV export; import matches
+// columns by header name so spreadsheets may reorder or omit optional ones.
+var placeCSVHeader = []string{
+	"place_id", "name", "location_id", "category", "description", "address",
+	"phone", "website", "hours", "lat", "lng", "cover_image", "highlight_images",
+}
+
+// highlightSeparator joins highlight image paths inside a single CSV cell
+const highlightSeparator = "|"
+
+// formulaPrefixes start a formula when a spreadsheet opens a CSV cell
+const formulaPrefixes = "=+-@\t\r"
+
+// csvText escapes a text cell for export: cells a spreadsheet would run as a
+// formula get a leading ' so they show as text
+func csvText(s string) string {
+	if s != "" && strings.ContainsRune(formulaPrefixes, rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// csvUnescape undoes csvText, so an exported file imports unchanged
+func csvUnescape(s string) string {
+	if len(s) > 1 && s[0] == '\'' && strings.ContainsRune(formulaPrefixes, rune(s[1])) {
+		return s[1:]
+	}
+	return s
+}
+
+// PlaceRecord is one parsed row (CSV) or feature (GeoJSON) of an import.
+// Fields are the place fields the file has, by their bson name; an update
+// leaves the others as they are.
+type PlaceRecord struct {
+	Row    int
+	Place  models.Place
+	Fields map[string]bool
+	Errors []string
+}
+
+// importFields maps CSV columns and GeoJSON properties to place fields.
+// lat and lng come from the geometry in GeoJSON.
+var importFields = map[string]string{
+	"name":             "name",
+	"location_id":      "location_id",
+	"category":         "category",
+	"description":      "description",
+	"address":          "address",
+	"phone":            "phone",
+	"website":          "website",
+	"hours":            "hours",
+	"cover_image":      "cover_image",
+	"highlight_images": "highlight_images",
+	"lat":              "coordinates.lat",
+	"lng":              "coordinates.lng",
+}
+
+// importDefaults are what a new place gets for fields the file leaves out
+var importDefaults = bson.M{
+	"description":      "",
+	"address":          "",
+	"phone":            "",
+	"website":          "",
+	"hours":            "",
+	"cover_image":      "",
+	"highlight_images": []string{},
+	"coordinates.lat":  0.0,
+	"coordinates.lng":  0.0,
+}
+
+// inputColumns names UpdatePlaceInput fields by their import column
+var inputColumns = map[string]string{
+	"coverImage":      "cover_image",
+	"highlights":      "highlight_images",
+	"coordinates.lat": "lat",
+	"coordinates.lng": "lng",
+}
+
+// PlaceImportRow is the per-row outcome reported back to the caller
+type PlaceImportRow struct {
+	Row     int      `json:"row"`
+	PlaceID string   `json:"placeId,omitempty"`
+	Name    string   `json:"name,omitempty"`
+	Action  string   `json:"action"` // create, update or invalid
+	Errors  []string `json:"errors,omitempty"`
+}
+
+// PlaceImportReport summarizes an import or dry run
+type PlaceImportReport struct {
+	DryRun  bool             `json:"dryRun"`
+	Applied bool             `json:"applied"`
+	Total   int              `json:"total"`
+	Created int              `json:"created"`
+	Updated int              `json:"updated"`
+	Invalid int              `json:"invalid"`
+	Rows    []PlaceImportRow `json:"rows"`
+}
+
+// ParsePlacesCSV reads places from CSV with a header row
+func ParsePlacesCSV(r io.Reader) ([]PlaceRecord, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	columns := map[string]int{}
+	for i, h := range header {
+		// Excel มักใส่ BOM ไว้หน้าคอลัมน์แรก
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))] = i
+	}
+	for _, required := range []string{"name", "location_id", "category"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("CSV header is missing column %q", required)
+		}
+	}
+	present := map[string]bool{}
+	for col := range columns {
+		if field, ok := importFields[col]; ok {
+			present[field] = true
+		}
+	}
+
+	var records []PlaceRecord
+	for row := 2; ; row++ {
+		fields, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		rec := PlaceRecord{Row: row, Fields: present}
+		if err != nil {
+			rec.Errors = append(rec.Errors, err.Error())
+			records = append(records, rec)
+			continue
+		}
+		get := func(col string) string {
+			if i, ok := columns[col]; ok && i < len(fields) {
+				return strings.TrimSpace(csvUnescape(fields[i]))
+			}
+			return ""
+		}
+
+		p := &rec.Place
+		p.ID = get("place_id")
+		p.Name = get("name")
+		p.LocationID = get("location_id")
+		p.Category = get("category")
+		p.Description = get("description")
+		p.Address = get("address")
+		p.Phone = get("phone")
+		p.Website = get("website")
+		p.Hours = get("hours")
+		p.CoverImage = get("cover_image")
+		p.HighlightImages = []string{}
+		if h := get("highlight_images"); h != "" {
+			for _, img := range strings.Split(h, highlightSeparator) {
+				if img = strings.TrimSpace(img); img != "" {
+					p.HighlightImages = append(p.HighlightImages, img)
+				}
+			}
+		}
+		if v := get("lat"); v != "" {
+			if p.Coordinates.Lat, err = strconv.ParseFloat(v, 64); err != nil {
+				rec.Errors = append(rec.Errors, fmt.Sprintf("lat %q is not a number", v))
+			}
+		}
+		if v := get("lng"); v != "" {
+			if p.Coordinates.Lng, err = strconv.ParseFloat(v, 64); err != nil {
+				rec.Errors = append(rec.Errors, fmt.Sprintf("lng %q is not a number", v))
+			}
+		}
+		records = append(records, rec)
+	}
+	return records, nil
+}
+
+type geoJSONFeatureCollection struct {
+	Type     string           `json:"type"`
+	Features []geoJSONFeature `json:"features"`
+}
+
+type geoJSONFeature struct {
+	Type     string `json:"type"`
+	Geometry *struct {
+		Type        string    `json:"type"`
+		Coordinates []float64 `json:"coordinates"`
+	} `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// ParsePlacesGeoJSON reads places from a GeoJSON FeatureCollection of Points
+func ParsePlacesGeoJSON(r io.Reader) ([]PlaceRecord, error) {
+	var fc geoJSONFeatureCollection
+	if err := json.NewDecoder(r).Decode(&fc); err != nil {
+		return nil, fmt.Errorf("invalid GeoJSON: %w", err)
+	}
+	if fc.Type != "FeatureCollection" {
+		return nil, errors.New("GeoJSON must be a FeatureCollection")
+	}
+
+	records := make([]PlaceRecord, 0, len(fc.Features))
+	for i, f := range fc.Features {
+		rec := PlaceRecord{Row: i + 1, Fields: map[string]bool{"coordinates.lat": true, "coordinates.lng": true}}
+		for key := range f.Properties {
+			if field, ok := importFields[key]; ok && key != "lat" && key != "lng" {
+				rec.Fields[field] = true
+			}
+		}
+		str := func(key string) string {
+			switch v := f.Properties[key].(type) {
+			case string:
+				return strings.TrimSpace(v)
+			case float64:
+				return strconv.FormatFloat(v, 'f', -1, 64)
+			}
+			return ""
+		}
+
+		p := &rec.Place
+		p.ID = str("place_id")
+		p.Name = str("name")
+		p.LocationID = str("location_id")
+		p.Category = str("category")
+		p.Description = str("description")
+		p.Address = str("address")
+		p.Phone = str("phone")
+		p.Website = str("website")
+		p.Hours = str("hours")
+		p.CoverImage = str("cover_image")
+		p.HighlightImages = []string{}
+		switch v := f.Properties["highlight_images"].(type) {
+		case []interface{}:
+			for _, img := range v {
+				if s, ok := img.(string); ok && s != "" {
+					p.HighlightImages = append(p.HighlightImages, s)
+				}
+			}
+		case string:
+			for _, img := range strings.Split(v, highlightSeparator) {
+				if img = strings.TrimSpace(img); img != "" {
+					p.HighlightImages = append(p.HighlightImages, img)
+				}
+			}
+		}
+
+		if f.Geometry == nil || f.Geometry.Type != "Point" || len(f.Geometry.Coordinates) < 2 {
+			rec.Errors = append(rec.Errors, "geometry must be a Point with [lng, lat] coordinates")
+		} else {
+			// GeoJSON เก็บพิกัดเป็น [lng, lat]
+			p.Coordinates.Lng = f.Geometry.Coordinates[0]
+			p.Coordinates.Lat = f.Geometry.Coordinates[1]
+		}
+		records = append(records, rec)
+	}
+	return records, nil
+}
+
+// ImportPlaces validates records and, unless dryRun is set or any record is
+// invalid, upserts them by place_id. Records without a place_id get a new one.
+// Existing places keep the fields the file leaves out.
+func ImportPlaces(ctx context.Context, records []PlaceRecord, dryRun bool) (PlaceImportReport, error) {
+	report := PlaceImportReport{DryRun: dryRun, Total: len(records), Rows: []PlaceImportRow{}}
+
+	cursor, err := db.Collection("locations").Find(ctx, bson.M{})
+	if err != nil {
+		return report, err
+	}
+	var locations []models.Location
+	if err := cursor.All(ctx, &locations); err != nil {
+		return report, err
+	}
+	validLocations := map[string]bool{}
+	for _, l := range locations {
+		validLocations[l.ID] = true
+	}
+
+	var ids []string
+	for _, rec := range records {
+		if rec.Place.ID != "" {
+			ids = append(ids, rec.Place.ID)
+		}
+	}
+	existing := map[string]bool{}
+	if len(ids) > 0 {
+		cursor, err := db.Collection("places").Find(ctx, bson.M{"place_id": bson.M{"$in": ids}},
+			options.Find().SetProjection(bson.M{"place_id": 1}))
+		if err != nil {
+			return report, err
+		}
+		var found []struct {
+			ID string `bson:"place_id"`
+		}
+		if err := cursor.All(ctx, &found); err != nil {
+			return report, err
+		}
+		for _, f := range found {
+			existing[f.ID] = true
+		}
+	}
+
+	seen := map[string]int{}
+	for i := range records {
+		rec := &records[i]
+		p := &rec.Place
+		// ตรวจด้วยกฎเดียวกับ CreatePlace/UpdatePlace ไฟล์นำเข้าจึงเก็บค่าที่ API ไม่รับไม่ได้
+		for _, fm := range problem.FieldMessages(validation.Struct(placeImportInput(p))) {
+			field := fm.Field
+			if col, ok := inputColumns[field]; ok {
+				field = col
+			}
+			rec.Errors = append(rec.Errors, field+" "+fm.Message)
+		}
+		if p.LocationID != "" && !validLocations[p.LocationID] {
+			rec.Errors = append(rec.Errors, fmt.Sprintf("location_id %q does not exist", p.LocationID))
+		}
+		if !models.IsValidPlaceCategory(p.Category) {
+			rec.Errors = append(rec.Errors, fmt.Sprintf("category %q must be one of: %s", p.Category, strings.Join(models.PlaceCategories, ", ")))
+		}
+		if p.ID != "" {
+			if prev, dup := seen[p.ID]; dup {
+				rec.Errors = append(rec.Errors, fmt.Sprintf("place_id %q is duplicated in row %d", p.ID, prev))
+			}
+			seen[p.ID] = rec.Row
+		}
+
+		row := PlaceImportRow{Row: rec.Row, PlaceID: p.ID, Name: p.Name, Errors: rec.Errors}
+		switch {
+		case len(rec.Errors) > 0:
+			row.Action = "invalid"
+			report.Invalid++
+		case p.ID != "" && existing[p.ID]:
+			row.Action = "update"
+			report.Updated++
+		default:
+			row.Action = "create"
+			report.Created++
+		}
+		report.Rows = append(report.Rows, row)
+	}
+
+	// ไม่นำเข้าบางส่วน: ถ้ามีแถวผิดแม้แถวเดียวให้แก้ไฟล์แล้วส่งใหม่ทั้งชุด
+	if dryRun || report.Invalid > 0 {
+		return report, nil
+	}
+
+	now := time.Now()
+	for i := range records {
+		p := &records[i].Place
+		if p.ID == "" {
+			p.ID = primitive.NewObjectID().Hex()
+			report.Rows[i].PlaceID = p.ID
+		}
+		values := bson.M{
+			"name":             p.Name,
+			"location_id":      p.LocationID,
+			"category":         p.Category,
+			"description":      p.Description,
+			"address":          p.Address,
+			"phone":            p.Phone,
+			"website":          p.Website,
+			"hours":            p.Hours,
+			"cover_image":      p.CoverImage,
+			"highlight_images": p.HighlightImages,
+			"coordinates.lat":  p.Coordinates.Lat,
+			"coordinates.lng":  p.Coordinates.Lng,
+		}
+		// คอลัมน์ที่ไฟล์ไม่มีไม่ทับค่าเดิม สถานที่ใหม่ได้ค่าเริ่มต้นแทน
+		set := bson.M{"updated_at": now}
+		setOnInsert := bson.M{"place_id": p.ID, "rating": 0.0, "created_at": now}
+		for field, value := range values {
+			if records[i].Fields[field] {
+				set[field] = value
+			} else if def, ok := importDefaults[field]; ok {
+				setOnInsert[field] = def
+			}
+		}
+		update := bson.M{"$set": set, "$setOnInsert": setOnInsert}
+		if _, err := db.Collection("places").UpdateOne(ctx, bson.M{"place_id": p.ID}, update, options.Update().SetUpsert(true)); err != nil {
+			return report, fmt.Errorf("row %d: %w", records[i].Row, err)
+		}
+	}
+	report.Applied = true
+	return report, nil
+}
+
+// placeImportInput is p as the API's place input. Category is left out: the
+// input lets it be empty but an import requires it, which ImportPlaces
+// checks itself.
+func placeImportInput(p *models.Place) models.UpdatePlaceInput {
+	input := models.UpdatePlaceInput{
+		Name:        p.Name,
+		Description: p.Description,
+		LocationID:  p.LocationID,
+		Address:     p.Address,
+		Phone:       p.Phone,
+		Website:     p.Website,
+		Hours:       p.Hours,
+		CoverImage:  p.CoverImage,
+		Highlights:  p.HighlightImages,
+	}
+	input.Coordinates.Lat = p.Coordinates.Lat
+	input.Coordinates.Lng = p.Coordinates.Lng
+	return input
+}
+
+// placeImportFormat picks csv or geojson from ?format= or the file extension
+func placeImportFormat(format, filename string) (string, error) {
+	if format == "" {
+		switch strings.ToLower(filepath.Ext(filename)) {
+		case ".csv":
+			format = "csv"
+		case ".geojson", ".json":
+			format = "geojson"
+		}
+	}
+	switch format {
+	case "csv", "geojson":
+		return format, nil
+	}
+	return "", errors.New("format must be csv or geojson")
+}
+
+// ImportPlacesHandler handles POST /api/admin/places/import (admin only).
+// The file is read from the multipart "file" field or the raw request body.
+// ?dry_run=true validates and reports per-row errors without writing.
+func ImportPlacesHandler(c *gin.Context) {
+	var body io.Reader = c.Request.Body
+	filename := ""
+	if fh, err := c.FormFile("file"); err == nil {
+		f, err := fh.Open()
+		if err != nil {
+			problem.Abort(c, http.StatusBadRequest, problem.CodeFileUnreadable)
+			return
+		}
+		defer f.Close()
+		body = f
+		filename = fh.Filename
+	}
+
+	format, err := placeImportFormat(c.Query("format"), filename)
+	if err != nil {
+		problem.Abort(c, http.StatusBadRequest, problem.CodeInvalidParameter, problem.Field("format", "oneof", "csv geojson"))
+		return
+	}
+	dryRun, _ := strconv.ParseBool(c.DefaultQuery("dry_run", "false"))
+
+	var records []PlaceRecord
+	if format == "csv" {
+		records, err = ParsePlacesCSV(body)
+	} else {
+		records, err = ParsePlacesGeoJSON(body)
+	}
+	if err != nil {
+		problem.Abort(c, http.StatusBadRequest, problem.CodeFileUnreadable, problem.With("reason", err.Error()))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Minute)
+	defer cancel()
+	report, err := ImportPlaces(ctx, records, dryRun)
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+
+	if !dryRun && report.Invalid > 0 {
+		problem.Abort(c, http.StatusUnprocessableEntity, problem.CodeImportInvalid, problem.With("report", report))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"report": report})
+}
+
+// ExportPlaces handles GET /api/admin/places/export?format=csv|geojson (admin only)
+func ExportPlaces(c *gin.Context) {
+	format := c.DefaultQuery("format", "csv")
+	if format != "csv" && format != "geojson" {
+		problem.Abort(c, http.StatusBadRequest, problem.CodeInvalidParameter, problem.Field("format", "oneof", "csv geojson"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), time.Minute)
+	defer cancel()
+	cursor, err := db.Collection("places").Find(ctx, bson.M{}, options.Find().SetSort(bson.M{"place_id": 1}))
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	var places []models.Place
+	if err := cursor.All(ctx, &places); err != nil {
+		problem.Internal(c, err)
+		return
+	}
+
+	stamp := time.Now().Format("20060102")
+	if format == "geojson" {
+		features := make([]gin.H, 0, len(places))
+		for _, p := range places {
+			features = append(features, gin.H{
+				"type": "Feature",
+				"geometry": gin.H{
+					"type":        "Point",
+					"coordinates": []float64{p.Coordinates.Lng, p.Coordinates.Lat},
+				},
+				"properties": gin.H{
+					"place_id":         p.ID,
+					"name":             p.Name,
+					"location_id":      p.LocationID,
+					"category":         p.Category,
+					"description":      p.Description,
+					"address":          p.Address,
+					"phone":            p.Phone,
+					"website":          p.Website,
+					"hours":            p.Hours,
+					"cover_image":      p.CoverImage,
+					"highlight_images": nonNil(p.HighlightImages),
+				},
+			})
+		}
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "places-"+stamp+".geojson"))
+		c.Header("Content-Type", "application/geo+json")
+		c.JSON(http.StatusOK, gin.H{"type": "FeatureCollection", "features": features})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "places-"+stamp+".csv"))
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Status(http.StatusOK)
+	// BOM ช่วยให้ Excel อ่านภาษาไทยเป็น UTF-8
+	_, _ = c.Writer.WriteString("\ufeff")
+	w := csv.NewWriter(c.Writer)
+	_ = w.Write(placeCSVHeader)
+	for _, p := range places {
+		_ = w.Write([]string{
+			csvText(p.ID), csvText(p.Name), csvText(p.LocationID), csvText(p.Category),
+			csvText(p.Description), csvText(p.Address), csvText(p.Phone), csvText(p.Website), csvText(p.Hours),
+			strconv.FormatFloat(p.Coordinates.Lat, 'f', -1, 64),
+			strconv.FormatFloat(p.Coordinates.Lng, 'f', -1, 64),
+			csvText(p.CoverImage), csvText(strings.Join(p.HighlightImages, highlightSeparator)),
+		})
+	}
+	w.Flush()
+}
@@ -0,0 +1,459 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"golang.org/x/crypto/bcrypt"
+
+	"gosmooth/models"
+	"gosmooth/problem"
+)
+
+// AccountDeletionGracePeriod is how long a deletion request can still be
+// cancelled before the account's personal fields are anonymized. Configure
+// overrides it from ACCOUNT_DELETION_GRACE_PERIOD.
+var AccountDeletionGracePeriod = 30 * 24 * time.Hour
+
+// deletedUserName replaces the display name of anonymized accounts everywhere
+// it has been denormalized (reviews, comments, reports).
+const deletedUserName = "Deleted user"
+
+// ExportProfile handles GET /api/profile/export and returns a ZIP archive with
+// every piece of personal data we hold about the caller (PDPA data portability).
+func ExportProfile(c *gin.Context) {
+	userID := c.GetString("userID")
+	objectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		problem.Abort(c, http.StatusBadRequest, problem.CodeInvalidID)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	var user models.User
+	if err := db.Collection("users").FindOne(ctx, bson.M{"_id": objectID}).Decode(&user); err != nil {
+		problem.Abort(c, http.StatusNotFound, problem.CodeUserNotFound)
+		return
+	}
+
+	files, err := collectUserData(ctx, user)
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+
+	// สร้าง zip ใน memory ก่อน เพื่อให้ยังตอบ error ได้ถ้าเขียนไม่สำเร็จ
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range files {
+		w, err := zw.Create(f.name)
+		if err != nil {
+			problem.Internal(c, err)
+			return
+		}
+		data, err := json.MarshalIndent(f.data, "", "  ")
+		if err != nil {
+			problem.Internal(c, err)
+			return
+		}
+		if _, err := w.Write(data); err != nil {
+			problem.Internal(c, err)
+			return
+		}
+	}
+	if err := zw.Close(); err != nil {
+		problem.Internal(c, err)
+		return
+	}
+
+	filename := fmt.Sprintf("gosmooth-export-%s-%s.zip", userID, time.Now().Format("20060102"))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Data(http.StatusOK, "application/zip", buf.Bytes())
+}
+
+type exportFile struct {
+	name string
+	data interface{}
+}
+
+// collectUserData gathers the JSON documents that make up a data export.
+func collectUserData(ctx context.Context, user models.User) ([]exportFile, error) {
+	userID := user.ID.Hex()
+
+	var reviews []models.Review
+	cursor, err := db.Collection("reviews").Find(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return nil, err
+	}
+	if err := cursor.All(ctx, &reviews); err != nil {
+		return nil, err
+	}
+	// รายชื่อผู้โหวตเป็น ID ของผู้ใช้คนอื่น เก็บไว้แค่โหวตของเจ้าของข้อมูลเอง
+	for i := range reviews {
+		reviews[i].LikedBy = ownVote(reviews[i].LikedBy, userID)
+		reviews[i].NotHelpfulBy = ownVote(reviews[i].NotHelpfulBy, userID)
+	}
+
+	comments := []models.Comment{}
+	cursor, err = db.Collection("comments").Find(ctx, bson.M{"user_id": userID}, options.Find().SetProjection(bson.M{"flags": 0}))
+	if err != nil {
+		return nil, err
+	}
+	if err := cursor.All(ctx, &comments); err != nil {
+		return nil, err
+	}
+
+	var likedComments []struct {
+		ID       primitive.ObjectID `bson:"_id" json:"comment_id"`
+		ReviewID primitive.ObjectID `bson:"review_id" json:"review_id"`
+	}
+	cursor, err = db.Collection("comments").Find(ctx, bson.M{"liked_by": userID})
+	if err != nil {
+		return nil, err
+	}
+	if err := cursor.All(ctx, &likedComments); err != nil {
+		return nil, err
+	}
+
+	type votedReview struct {
+		ID        primitive.ObjectID `bson:"_id" json:"review_id"`
+		PlaceID   string             `bson:"place_id" json:"place_id"`
+		PlaceName string             `bson:"place_name" json:"place_name"`
+	}
+	votedFields := options.Find().SetProjection(bson.M{"place_id": 1, "place_name": 1})
+	var likedReviews []votedReview
+	cursor, err = db.Collection("reviews").Find(ctx, bson.M{"liked_by": userID}, votedFields)
+	if err != nil {
+		return nil, err
+	}
+	if err := cursor.All(ctx, &likedReviews); err != nil {
+		return nil, err
+	}
+
+	var notHelpfulReviews []votedReview
+	cursor, err = db.Collection("reviews").Find(ctx, bson.M{"not_helpful_by": userID}, votedFields)
+	if err != nil {
+		return nil, err
+	}
+	if err := cursor.All(ctx, &notHelpfulReviews); err != nil {
+		return nil, err
+	}
+
+	var reports []models.ReviewReport
+	cursor, err = db.Collection("review_reports").Find(ctx, bson.M{"reporter_id": userID})
+	if err != nil {
+		return nil, err
+	}
+	if err := cursor.All(ctx, &reports); err != nil {
+		return nil, err
+	}
+
+	var suggestions []models.RouteSuggestion
+	cursor, err = db.Collection("route_suggestions").Find(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return nil, err
+	}
+	if err := cursor.All(ctx, &suggestions); err != nil {
+		return nil, err
+	}
+
+	var apiKeys []models.APIKey
+	cursor, err = db.Collection("api_keys").Find(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return nil, err
+	}
+	if err := cursor.All(ctx, &apiKeys); err != nil {
+		return nil, err
+	}
+
+	var claims []models.PlaceClaim
+	cursor, err = db.Collection("place_claims").Find(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return nil, err
+	}
+	if err := cursor.All(ctx, &claims); err != nil {
+		return nil, err
+	}
+
+	var checkIns []models.CheckIn
+	cursor, err = db.Collection("check_ins").Find(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return nil, err
+	}
+	if err := cursor.All(ctx, &checkIns); err != nil {
+		return nil, err
+	}
+
+	var collections []models.PlaceCollection
+	cursor, err = db.Collection("collections").Find(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return nil, err
+	}
+	if err := cursor.All(ctx, &collections); err != nil {
+		return nil, err
+	}
+
+	var trips []models.Trip
+	cursor, err = db.Collection("trips").Find(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return nil, err
+	}
+	if err := cursor.All(ctx, &trips); err != nil {
+		return nil, err
+	}
+
+	profile := gin.H{
+		"id":         user.ID,
+		"email":      user.Email,
+		"name":       user.Name,
+		"role":       user.Role,
+		"status":     user.Status,
+		"banReason":  user.BanReason,
+		"identities": exportIdentities(user.Identities),
+		"created_at": user.CreatedAt,
+		"updated_at": user.UpdatedAt,
+	}
+
+	return []exportFile{
+		{"profile.json", profile},
+		{"address.json", user.Address},
+		{"reviews.json", nonNil(reviews)},
+		{"comments.json", comments},
+		{"likes.json", gin.H{
+			"reviews":             nonNil(likedReviews),
+			"comments":            nonNil(likedComments),
+			"not_helpful_reviews": nonNil(notHelpfulReviews),
+		}},
+		{"reports.json", nonNil(reports)},
+		{"route_suggestions.json", nonNil(suggestions)},
+		{"api_keys.json", nonNil(apiKeys)},
+		{"place_claims.json", nonNil(claims)},
+		{"check_ins.json", nonNil(checkIns)},
+		{"collections.json", nonNil(collections)},
+		{"trips.json", nonNil(trips)},
+	}, nil
+}
+
+// exportIdentities includes the provider subjects, which the API otherwise hides
+func exportIdentities(identities []models.Identity) []gin.H {
+	out := []gin.H{}
+	for _, id := range identities {
+		out = append(out, gin.H{
+			"provider": id.Provider,
+			"subject":  id.Subject,
+			"email":    id.Email,
+			"linkedAt": id.LinkedAt,
+		})
+	}
+	return out
+}
+
+// ownVote keeps userID from a list of voters, leaving out everyone else
+func ownVote(voters []string, userID string) []string {
+	if slices.Contains(voters, userID) {
+		return []string{userID}
+	}
+	return []string{}
+}
+
+// nonNil makes empty exports serialize as [] instead of null.
+func nonNil[T any](s []T) []T {
+	if s == nil {
+		return []T{}
+	}
+	return s
+}
+
+// DeleteAccount handles DELETE /api/profile. The password must be confirmed,
+// or the email address typed for accounts without one; the account is then
+// scheduled for anonymization after the grace period.
+func DeleteAccount(c *gin.Context) {
+	userID := c.GetString("userID")
+	objectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		problem.Abort(c, http.StatusBadRequest, problem.CodeInvalidID)
+		return
+	}
+
+	var input models.DeleteAccountInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		problem.Validation(c, err)
+		return
+	}
+
+	var user models.User
+	if err := db.Collection("users").FindOne(c.Request.Context(), bson.M{"_id": objectID}).Decode(&user); err != nil {
+		problem.Abort(c, http.StatusNotFound, problem.CodeUserNotFound)
+		return
+	}
+	if user.Password == "" {
+		// บัญชีที่เข้าสู่ระบบผ่าน provider อย่างเดียวไม่มีรหัสผ่าน ให้พิมพ์อีเมลแทน
+		if confirm := strings.TrimSpace(input.Confirm); confirm == "" || !strings.EqualFold(confirm, user.Email) {
+			problem.Abort(c, http.StatusBadRequest, problem.CodeConfirmationMismatch)
+			return
+		}
+	} else if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(input.Password)); err != nil {
+		problem.Abort(c, http.StatusUnauthorized, problem.CodePasswordIncorrect)
+		return
+	}
+	if user.Status == "pending_deletion" {
+		problem.Abort(c, http.StatusConflict, problem.CodeDeletionAlreadyRequested,
+			problem.With("deletionScheduledAt", user.DeletionScheduledAt))
+		return
+	}
+
+	now := time.Now()
+	scheduledAt := now.Add(AccountDeletionGracePeriod)
+	_, err = db.Collection("users").UpdateOne(c.Request.Context(), bson.M{"_id": objectID}, bson.M{
+		"$set": bson.M{
+			"status":                 "pending_deletion",
+			"status_before_deletion": user.Status,
+			"deletion_requested_at":  now,
+			"deletion_scheduled_at":  scheduledAt,
+			"updated_at":             now,
+		},
+	})
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":             "account scheduled for deletion",
+		"deletionScheduledAt": scheduledAt,
+	})
+}
+
+// CancelAccountDeletion handles POST /api/profile/restore and aborts a pending
+// deletion during the grace period.
+func CancelAccountDeletion(c *gin.Context) {
+	userID := c.GetString("userID")
+	objectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		problem.Abort(c, http.StatusBadRequest, problem.CodeInvalidID)
+		return
+	}
+
+	// คืนสถานะเดิม (เช่น banned) แทนการตั้งเป็น active เสมอ
+	result, err := db.Collection("users").UpdateOne(c.Request.Context(),
+		bson.M{"_id": objectID, "status": "pending_deletion"},
+		mongo.Pipeline{
+			{{Key: "$set", Value: bson.M{
+				"status":     bson.M{"$ifNull": bson.A{"$status_before_deletion", "active"}},
+				"updated_at": time.Now(),
+			}}},
+			{{Key: "$unset", Value: bson.A{"status_before_deletion", "deletion_requested_at", "deletion_scheduled_at"}}},
+		})
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	if result.MatchedCount == 0 {
+		problem.Abort(c, http.StatusConflict, problem.CodeNoPendingDeletion)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "account deletion cancelled"})
+}
+
+// PurgeDeletedAccounts anonymizes every account whose grace period has
+// expired and returns how many were processed. Reviews and comments are kept
+// so place ratings stay intact, but they no longer carry the author's name.
+func PurgeDeletedAccounts(ctx context.Context) (int, error) {
+	cursor, err := db.Collection("users").Find(ctx, bson.M{
+		"status":                "pending_deletion",
+		"deletion_scheduled_at": bson.M{"$lte": time.Now()},
+	})
+	if err != nil {
+		return 0, err
+	}
+	var users []models.User
+	if err := cursor.All(ctx, &users); err != nil {
+		return 0, err
+	}
+
+	for i, user := range users {
+		if err := anonymizeUser(ctx, user.ID); err != nil {
+			return i, err
+		}
+	}
+	return len(users), nil
+}
+
+func anonymizeUser(ctx context.Context, objectID primitive.ObjectID) error {
+	userID := objectID.Hex()
+	now := time.Now()
+
+	// อีเมลต้อง unique จึงแทนด้วยค่าที่ผูกกับ id แทนการลบทิ้ง
+	_, err := db.Collection("users").UpdateOne(ctx, bson.M{"_id": objectID}, bson.M{
+		"$set": bson.M{
+			"email":      fmt.Sprintf("deleted-%s@deleted.invalid", userID),
+			"password":   "",
+			"name":       deletedUserName,
+			"status":     "deleted",
+			"updated_at": now,
+		},
+		"$unset": bson.M{
+			"address":                "",
+			"ban_reason":             "",
+			"identities":             "",
+			"status_before_deletion": "",
+			"deletion_requested_at":  "",
+			"deletion_scheduled_at":  "",
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := deleteUserAPIKeys(ctx, userID); err != nil {
+		return err
+	}
+	if err := deleteUserPlaceClaims(ctx, userID); err != nil {
+		return err
+	}
+	// รีวิวยังคงป้ายยืนยันการไปจริงไว้ แต่ประวัติเช็กอินเป็นข้อมูลส่วนตัว
+	if _, err := db.Collection("check_ins").DeleteMany(ctx, bson.M{"user_id": userID}); err != nil {
+		return err
+	}
+	if _, err := db.Collection("collections").DeleteMany(ctx, bson.M{"user_id": userID}); err != nil {
+		return err
+	}
+	if _, err := db.Collection("trips").DeleteMany(ctx, bson.M{"user_id": userID}); err != nil {
+		return err
+	}
+
+	if _, err := db.Collection("reviews").UpdateMany(ctx,
+		bson.M{"user_id": userID},
+		bson.M{"$set": bson.M{"username": deletedUserName}},
+	); err != nil {
+		return err
+	}
+
+	if _, err := db.Collection("comments").UpdateMany(ctx,
+		bson.M{"user_id": userID},
+		bson.M{"$set": bson.M{"username": deletedUserName}},
+	); err != nil {
+		return err
+	}
+
+	_, err = db.Collection("review_reports").UpdateMany(ctx,
+		bson.M{"reporter_id": userID},
+		bson.M{"$set": bson.M{"reporter": deletedUserName}},
+	)
+	return err
+}
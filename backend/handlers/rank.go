@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"unicode/utf8"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"gosmooth/models"
+	"gosmooth/ranking"
+)
+
+// Rank scores are stored on each review and recomputed whenever one of
+// their inputs changes, so the "relevant" sort reads an index instead of
+// scoring every review per request. The author reputation is read at that
+// moment; it catches up on a review's next change or "reviews rerank".
+
+// rankProjection is what reviewRank needs from a review
+var rankProjection = bson.M{"user_id": 1, "comment": 1, "likes": 1, "not_helpful": 1, "photos": 1, "created_at": 1}
+
+// reviewRank computes the rank score of r from the author's current votes
+func reviewRank(ctx context.Context, r models.Review) (float64, error) {
+	var author models.User
+	if userObjID, err := primitive.ObjectIDFromHex(r.UserID); err == nil {
+		opts := options.FindOne().SetProjection(bson.M{"review_likes": 1, "review_not_helpful": 1})
+		err := db.Collection("users").FindOne(ctx, bson.M{"_id": userObjID}, opts).Decode(&author)
+		if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+			return 0, err
+		}
+	}
+	return ranking.Score(ranking.Review{
+		Likes:            r.Likes,
+		NotHelpful:       r.NotHelpful,
+		TextLength:       utf8.RuneCountInString(r.Comment),
+		HasPhotos:        len(r.Photos) > 0,
+		AuthorLikes:      author.ReviewLikes,
+		AuthorNotHelpful: author.ReviewNotHelpful,
+		CreatedAt:        r.CreatedAt,
+	}, rankHalfLife), nil
+}
+
+// refreshReviewRank recomputes and stores the rank score of a review. The
+// write only applies if the votes are still those the score was computed
+// from; a few retries cover concurrent voting.
+func refreshReviewRank(ctx context.Context, reviewID primitive.ObjectID) error {
+	for attempt := 0; attempt < 3; attempt++ {
+		var review models.Review
+		err := db.Collection("reviews").FindOne(ctx, bson.M{"_id": reviewID},
+			options.FindOne().SetProjection(rankProjection)).Decode(&review)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		score, err := reviewRank(ctx, review)
+		if err != nil {
+			return err
+		}
+		result, err := db.Collection("reviews").UpdateOne(ctx,
+			bson.M{"_id": reviewID, "likes": review.Likes, "not_helpful": review.NotHelpful},
+			bson.M{"$set": bson.M{"rank_score": score}},
+		)
+		if err != nil || result.MatchedCount > 0 {
+			return err
+		}
+	}
+	// โหวตยังเปลี่ยนอยู่ตลอด ครั้งถัดไปที่มีการเปลี่ยนแปลงจะคำนวณใหม่เอง
+	return nil
+}
+
+// updateReviewRank is refreshReviewRank for handlers, where the change that
+// prompted it has already been saved: a failure is only logged
+func updateReviewRank(ctx context.Context, reviewID primitive.ObjectID) {
+	if err := refreshReviewRank(ctx, reviewID); err != nil {
+		slog.WarnContext(ctx, "could not update review rank", "review_id", reviewID.Hex(), "error", err)
+	}
+}
+
+// adjustAuthorVotes moves the vote totals of a review's author
+func adjustAuthorVotes(ctx context.Context, authorID string, likes, notHelpful int) error {
+	userObjID, err := primitive.ObjectIDFromHex(authorID)
+	if err != nil || (likes == 0 && notHelpful == 0) {
+		return nil
+	}
+	_, err = db.Collection("users").UpdateOne(ctx, bson.M{"_id": userObjID},
+		bson.M{"$inc": bson.M{"review_likes": likes, "review_not_helpful": notHelpful}})
+	return err
+}
+
+// RerankReviews recomputes every author's vote totals from their reviews
+// and then every review's rank score, e.g. after REVIEW_RANK_HALF_LIFE
+// changed or votes were repaired. It returns the number of reviews ranked.
+func RerankReviews(ctx context.Context) (int, error) {
+	cursor, err := db.Collection("reviews").Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$group", Value: bson.M{
+			"_id":         "$user_id",
+			"likes":       bson.M{"$sum": "$likes"},
+			"not_helpful": bson.M{"$sum": "$not_helpful"},
+		}}},
+	})
+	if err != nil {
+		return 0, err
+	}
+	var authors []struct {
+		UserID     string `bson:"_id"`
+		Likes      int    `bson:"likes"`
+		NotHelpful int    `bson:"not_helpful"`
+	}
+	if err := cursor.All(ctx, &authors); err != nil {
+		return 0, err
+	}
+	if _, err := db.Collection("users").UpdateMany(ctx, bson.M{},
+		bson.M{"$unset": bson.M{"review_likes": "", "review_not_helpful": ""}}); err != nil {
+		return 0, err
+	}
+	for _, a := range authors {
+		userObjID, err := primitive.ObjectIDFromHex(a.UserID)
+		if err != nil {
+			continue
+		}
+		if _, err := db.Collection("users").UpdateOne(ctx, bson.M{"_id": userObjID},
+			bson.M{"$set": bson.M{"review_likes": a.Likes, "review_not_helpful": a.NotHelpful}}); err != nil {
+			return 0, err
+		}
+	}
+
+	cursor, err = db.Collection("reviews").Find(ctx, bson.M{}, options.Find().SetProjection(rankProjection))
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+	ranked := 0
+	for cursor.Next(ctx) {
+		var review models.Review
+		if err := cursor.Decode(&review); err != nil {
+			return ranked, err
+		}
+		score, err := reviewRank(ctx, review)
+		if err != nil {
+			return ranked, err
+		}
+		if _, err := db.Collection("reviews").UpdateOne(ctx, bson.M{"_id": review.ID},
+			bson.M{"$set": bson.M{"rank_score": score}}); err != nil {
+			return ranked, err
+		}
+		ranked++
+	}
+	return ranked, cursor.Err()
+}
@@ -2,7 +2,8 @@ package handlers
 
 import (
 	"context"
-	"fmt"
+	"errors"
+	"log/slog"
 	"net/http"
 	"strconv"
 	"time"
@@ -10,248 +11,139 @@ import (
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 
+	"gosmooth/metrics"
 	"gosmooth/models"
+	"gosmooth/problem"
 )
 
 // CreateReview handles creating a new review
 func CreateReview(c *gin.Context) {
-	var input models.Review
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	var body models.CreateReviewInput
+	if err := c.ShouldBindJSON(&body); err != nil {
+		problem.Validation(c, err)
 		return
 	}
 
 	userID := c.GetString("userID")
 	objectID, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user ID"})
+		problem.Abort(c, http.StatusUnauthorized, problem.CodeInvalidToken)
 		return
 	}
 	var user models.User
-	if err := db.Collection("users").FindOne(context.Background(), bson.M{"_id": objectID}).Decode(&user); err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
-		return
-	}
-	input.UserID = userID
-	input.Username = user.Name
-	input.Likes = 0
-	input.LikedBy = []string{}
-	input.Comments = []models.Comment{}
-	input.CreatedAt = time.Now()
-	input.UpdatedAt = time.Now()
-
-	// ถ้าไม่ได้ส่ง place_name มา ให้ map จาก DB
-	if input.PlaceName == "" {
-		var place models.Place
-		placeObjID, _ := primitive.ObjectIDFromHex(input.PlaceID)
-		err = db.Collection("places").FindOne(context.Background(), bson.M{"$or": []bson.M{{"_id": placeObjID}, {"place_id": input.PlaceID}}}).Decode(&place)
-		if err == nil {
-			input.PlaceName = place.Name
-		}
-	}
-
-	// DEBUG LOG
-	fmt.Println("DEBUG: input.PlaceID =", input.PlaceID)
-	fmt.Println("DEBUG: input.PlaceName =", input.PlaceName)
-
-	result, err := db.Collection("reviews").InsertOne(context.Background(), input)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create review"})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"message":   "review created successfully",
-		"id":        result.InsertedID,
-		"placeName": input.PlaceName,
-	})
-}
-
-// LikeReview handles liking/unliking a review
-func LikeReview(c *gin.Context) {
-	reviewID := c.Param("id")
-	objectID, err := primitive.ObjectIDFromHex(reviewID)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid review ID"})
+	if err := db.Collection("users").FindOne(c.Request.Context(), bson.M{"_id": objectID}).Decode(&user); err != nil {
+		problem.Abort(c, http.StatusUnauthorized, problem.CodeInvalidToken)
 		return
 	}
-
-	userID := c.GetString("userID")
-	if userID == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "missing user ID"})
+	place, err := findPlace(c.Request.Context(), body.PlaceID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		problem.Abort(c, http.StatusNotFound, problem.CodePlaceNotFound)
 		return
 	}
-
-	var review models.Review
-	err = db.Collection("reviews").FindOne(context.Background(), bson.M{"_id": objectID}).Decode(&review)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "review not found"})
+		problem.Internal(c, err)
 		return
 	}
 
-	liked := false
-	for _, uid := range review.LikedBy {
-		if uid == userID {
-			liked = true
-			break
-		}
-	}
-
-	var update bson.M
-	if liked {
-		// Unlike
-		update = bson.M{
-			"$pull": bson.M{"liked_by": userID},
-			"$inc":  bson.M{"likes": -1},
-		}
-	} else {
-		// Like
-		update = bson.M{
-			"$addToSet": bson.M{"liked_by": userID},
-			"$inc":      bson.M{"likes": 1},
-		}
-	}
-
-	_, err = db.Collection("reviews").UpdateOne(context.Background(), bson.M{"_id": objectID}, update)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update like status"})
+	// รีวิวได้คนละหนึ่งรายการต่อสถานที่ โพสต์ซ้ำถือเป็นการแก้ไขรีวิวเดิม
+	edit := models.UpdateReviewInput{Rating: body.Rating, Comment: body.Comment}
+	existing := bson.M{"user_id": userID, "place_id": place.ID}
+	if review, err := editReview(c.Request.Context(), existing, user, edit); !errors.Is(err, mongo.ErrNoDocuments) {
+		respondReviewEdited(c, review, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "like toggled", "liked": !liked})
-}
-
-// AddComment handles adding a comment to a review
-func AddComment(c *gin.Context) {
-	reviewID := c.Param("id")
-	reviewObjID, err := primitive.ObjectIDFromHex(reviewID)
+	verified, err := visitedPlace(c.Request.Context(), userID, place.ID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid review ID"})
-		return
-	}
-
-	var input struct {
-		Text string `json:"text" binding:"required"`
-	}
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	userID := c.GetString("userID")
-	userObjID, err := primitive.ObjectIDFromHex(userID)
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user ID"})
-		return
-	}
-
-	var user models.User
-	if err := db.Collection("users").FindOne(context.Background(), bson.M{"_id": userObjID}).Decode(&user); err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
-		return
-	}
-
-	comment := models.Comment{
-		ID:        primitive.NewObjectID(),
-		UserID:    userID,
-		Username:  user.Name,
-		Text:      input.Text,
-		Likes:     0,
-		LikedBy:   []string{},
-		CreatedAt: time.Now(),
-	}
-
-	update := bson.M{"$push": bson.M{"comments": comment}}
-	_, err = db.Collection("reviews").UpdateOne(context.Background(), bson.M{"_id": reviewObjID}, update)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to add comment"})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{"message": "comment added", "comment": comment})
-}
-
-// LikeComment handles liking/unliking a comment on a review
-func LikeComment(c *gin.Context) {
-	reviewID := c.Param("id")
-	commentID := c.Param("commentId")
-	objectID, err := primitive.ObjectIDFromHex(reviewID)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid review ID"})
-		return
-	}
-	commentObjID, err := primitive.ObjectIDFromHex(commentID)
+		problem.Internal(c, err)
+		return
+	}
+	input := models.Review{
+		ID:            primitive.NewObjectID(),
+		UserID:        userID,
+		Username:      user.Name,
+		PlaceID:       place.ID,
+		PlaceName:     place.Name,
+		Rating:        body.Rating,
+		Comment:       body.Comment,
+		LikedBy:       []string{},
+		NotHelpfulBy:  []string{},
+		Status:        models.ReviewPublished,
+		VerifiedVisit: verified,
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
+	}
+
+	flags, sig, err := screenPost(c.Request.Context(), post{
+		collection: "reviews",
+		author:     user,
+		text:       input.Comment,
+		placeID:    input.PlaceID,
+		rating:     input.Rating,
+	})
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid comment ID"})
+		problem.Internal(c, err)
 		return
 	}
-	userID := c.GetString("userID")
-	if userID == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "missing user ID"})
-		return
+	if len(flags) > 0 {
+		input.Status = models.ReviewHeld
+		input.Screening = flags
 	}
+	input.Fingerprint, input.FingerprintBands = sig, sig.Bands()
 
-	// ดึง review
-	var review models.Review
-	err = db.Collection("reviews").FindOne(context.Background(), bson.M{"_id": objectID}).Decode(&review)
+	input.RankScore, err = reviewRank(c.Request.Context(), input)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "review not found"})
+		problem.Internal(c, err)
 		return
 	}
-
-	// หา comment ที่ต้องการ
-	var liked bool
-	for _, cmt := range review.Comments {
-		if cmt.ID == commentObjID {
-			for _, uid := range cmt.LikedBy {
-				if uid == userID {
-					liked = true
-					break
-				}
-			}
-			break
+	if _, err := db.Collection("reviews").InsertOne(c.Request.Context(), input); err != nil {
+		// unique index user_id_place_id: another request created it first
+		if mongo.IsDuplicateKeyError(err) {
+			review, err := editReview(c.Request.Context(), existing, user, edit)
+			respondReviewEdited(c, review, err)
+			return
 		}
+		problem.Internal(c, err)
+		return
 	}
-
-	var update bson.M
-	if liked {
-		// Unlike
-		update = bson.M{
-			"$pull": bson.M{"comments.$[elem].liked_by": userID},
-			"$inc":  bson.M{"comments.$[elem].likes": -1},
-		}
-	} else {
-		// Like
-		update = bson.M{
-			"$addToSet": bson.M{"comments.$[elem].liked_by": userID},
-			"$inc":      bson.M{"comments.$[elem].likes": 1},
-		}
+	if input.Status == models.ReviewHeld {
+		recordHeld(c.Request.Context(), "review", input.ID, flags)
 	}
 
-	arrayFilters := options.Update().SetArrayFilters(options.ArrayFilters{
-		Filters: []interface{}{bson.M{"elem._id": commentObjID}},
+	metrics.ReviewsCreated.Inc()
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "review created successfully",
+		"id":            input.ID,
+		"placeName":     input.PlaceName,
+		"status":        input.Status,
+		"verifiedVisit": input.VerifiedVisit,
+		"updated":       false,
 	})
+}
 
-	_, err = db.Collection("reviews").UpdateOne(
-		context.Background(),
-		bson.M{"_id": objectID},
-		update,
-		arrayFilters,
-	)
+// respondReviewEdited answers a repeat CreateReview with the review it edited
+func respondReviewEdited(c *gin.Context, review models.Review, err error) {
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update like status"})
+		problem.Internal(c, err)
 		return
 	}
-
-	c.JSON(http.StatusOK, gin.H{"message": "like toggled", "liked": !liked})
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "review updated successfully",
+		"id":            review.ID,
+		"placeName":     review.PlaceName,
+		"status":        review.Status,
+		"verifiedVisit": review.VerifiedVisit,
+		"updated":       true,
+	})
 }
 
 // GetReviews handles getting all reviews (with user, place, comments, username)
 func GetReviews(c *gin.Context) {
 	placeId := c.Query("placeId")
-	filter := bson.M{}
+	filter := bson.M{"status": models.ReviewPublished}
 	if placeId != "" {
 		filter["place_id"] = placeId
 	}
@@ -282,40 +174,38 @@ func GetReviews(c *gin.Context) {
 		sort = bson.D{{Key: "rating", Value: -1}, {Key: "created_at", Value: -1}}
 	case "lowest":
 		sort = bson.D{{Key: "rating", Value: 1}, {Key: "created_at", Value: -1}}
+	case "relevant":
+		// rank_score คำนวณไว้ล่วงหน้าทุกครั้งที่โหวต แก้ไข หรือเพิ่มรูป ดู handlers/rank.go
+		sort = bson.D{{Key: "rank_score", Value: -1}, {Key: "created_at", Value: -1}}
 	default: // newest
 		sort = bson.D{{Key: "created_at", Value: -1}}
 	}
 
-	fmt.Println("[DEBUG] GetReviews placeId:", placeId, "sort:", sortParam, "filter:", filter)
-
-	findOpts := options.Find().SetSort(sort)
+	findOpts := options.Find().SetSort(sort).SetProjection(hiddenFields)
 	var reviews []models.Review
-	cursor, err := db.Collection("reviews").Find(context.Background(), filter, findOpts)
+	cursor, err := db.Collection("reviews").Find(c.Request.Context(), filter, findOpts)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get reviews"})
+		problem.Internal(c, err)
 		return
 	}
-	defer cursor.Close(context.Background())
+	defer cursor.Close(c.Request.Context())
 
-	if err = cursor.All(context.Background(), &reviews); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to decode reviews"})
+	if err = cursor.All(c.Request.Context(), &reviews); err != nil {
+		problem.Internal(c, err)
 		return
 	}
 
-	fmt.Printf("[DEBUG] GetReviews found %d reviews\n", len(reviews))
-	if len(reviews) > 0 {
-		fmt.Printf("[DEBUG] First review: %+v\n", reviews[0])
-	}
+	slog.DebugContext(c.Request.Context(), "reviews listed", "place_id", placeId, "sort", sortParam, "count", len(reviews))
 
 	// ดึง place ทั้งหมดมา map id -> name
 	placeMap := map[string]string{}
-	placeCursor, err := db.Collection("places").Find(context.Background(), bson.M{})
+	placeCursor, err := db.Collection("places").Find(c.Request.Context(), bson.M{})
 	if err == nil {
 		var places []struct {
 			ID   string `bson:"place_id" json:"place_id"`
 			Name string `bson:"name" json:"name"`
 		}
-		_ = placeCursor.All(context.Background(), &places)
+		_ = placeCursor.All(c.Request.Context(), &places)
 		for _, p := range places {
 			placeMap[p.ID] = p.Name
 		}
@@ -346,14 +236,19 @@ func GetReview(c *gin.Context) {
 	id := c.Param("id")
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid review ID"})
+		problem.Abort(c, http.StatusBadRequest, problem.CodeInvalidID)
 		return
 	}
 
+	// รีวิวที่ยังไม่เผยแพร่ให้เห็นได้เฉพาะผู้เขียน
+	filter := bson.M{
+		"_id": objectID,
+		"$or": []bson.M{{"status": models.ReviewPublished}, {"user_id": c.GetString("userID")}},
+	}
 	var review models.Review
-	err = db.Collection("reviews").FindOne(context.Background(), bson.M{"_id": objectID}).Decode(&review)
+	err = db.Collection("reviews").FindOne(c.Request.Context(), filter, options.FindOne().SetProjection(hiddenFields)).Decode(&review)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "review not found"})
+		problem.Abort(c, http.StatusNotFound, problem.CodeReviewNotFound)
 		return
 	}
 
@@ -365,31 +260,118 @@ func UpdateReview(c *gin.Context) {
 	id := c.Param("id")
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid review ID"})
+		problem.Abort(c, http.StatusBadRequest, problem.CodeInvalidID)
 		return
 	}
 
-	var input models.Review
+	var input models.UpdateReviewInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		problem.Validation(c, err)
 		return
 	}
-
-	update := bson.M{
-		"$set": bson.M{
-			"rating":     input.Rating,
-			"comment":    input.Comment,
-			"updated_at": time.Now(),
-		},
+	userObjID, err := primitive.ObjectIDFromHex(c.GetString("userID"))
+	if err != nil {
+		problem.Abort(c, http.StatusUnauthorized, problem.CodeInvalidToken)
+		return
+	}
+	var user models.User
+	if err := db.Collection("users").FindOne(c.Request.Context(), bson.M{"_id": userObjID}).Decode(&user); err != nil {
+		problem.Abort(c, http.StatusUnauthorized, problem.CodeInvalidToken)
+		return
 	}
 
-	_, err = db.Collection("reviews").UpdateOne(context.Background(), bson.M{"_id": objectID}, update)
+	review, err := editReview(c.Request.Context(), bson.M{"_id": objectID}, user, input)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		// ไม่ตรงกับรีวิวของผู้ใช้ แยกว่ารีวิวไม่มีอยู่หรือเป็นของคนอื่น
+		n, err := db.Collection("reviews").CountDocuments(c.Request.Context(), bson.M{"_id": objectID})
+		if err != nil {
+			problem.Internal(c, err)
+			return
+		}
+		if n > 0 {
+			problem.Abort(c, http.StatusForbidden, problem.CodeNotOwner)
+			return
+		}
+		problem.Abort(c, http.StatusNotFound, problem.CodeReviewNotFound)
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update review"})
+		problem.Internal(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "review updated successfully"})
+	c.JSON(http.StatusOK, gin.H{"message": "review updated successfully", "status": review.Status})
+}
+
+// editReview saves a new rating and text on author's review matching filter
+// and returns the review's id, place name, status and badge. The edit is
+// screened like a new post, rating included, and the verified-visit badge is
+// worked out again. It returns mongo.ErrNoDocuments if no review of theirs
+// matches.
+func editReview(ctx context.Context, filter bson.M, author models.User, input models.UpdateReviewInput) (models.Review, error) {
+	var review models.Review
+	userID := author.ID.Hex()
+	own := bson.M{"user_id": userID}
+	for k, v := range filter {
+		own[k] = v
+	}
+	var current models.Review
+	err := db.Collection("reviews").FindOne(ctx, own, options.FindOne().SetProjection(bson.M{"place_id": 1})).Decode(&current)
+	if err != nil {
+		return review, err
+	}
+
+	// ตรวจเหมือนโพสต์ใหม่ ไม่อย่างนั้นโพสต์รีวิวเบา ๆ ก่อนแล้วค่อยแก้เป็น 1 ดาวจะผ่านไปได้
+	flags, sig, err := screenPost(ctx, post{
+		collection: "reviews",
+		author:     author,
+		text:       input.Comment,
+		placeID:    current.PlaceID,
+		rating:     input.Rating,
+	})
+	if err != nil {
+		return review, err
+	}
+	verified, err := visitedPlace(ctx, userID, current.PlaceID)
+	if err != nil {
+		return review, err
+	}
+	set := bson.M{
+		"rating":         input.Rating,
+		"comment":        input.Comment,
+		"verified_visit": verified,
+		"updated_at":     time.Now(),
+	}
+	update := bson.M{"$set": set}
+	if sig != nil {
+		set["fingerprint"], set["fingerprint_bands"] = sig, sig.Bands()
+	} else {
+		update["$unset"] = bson.M{"fingerprint": "", "fingerprint_bands": ""}
+	}
+
+	opts := options.FindOneAndUpdate().
+		SetReturnDocument(options.After).
+		SetProjection(bson.M{"status": 1, "place_name": 1, "verified_visit": 1})
+	err = db.Collection("reviews").FindOneAndUpdate(ctx, bson.M{"_id": current.ID, "user_id": userID}, update, opts).Decode(&review)
+	if err != nil {
+		return review, err
+	}
+	if len(flags) > 0 && review.Status == models.ReviewPublished {
+		// รีวิวที่ผู้ดูแลซ่อนไว้แล้วคงสถานะเดิม
+		result, err := db.Collection("reviews").UpdateOne(ctx,
+			bson.M{"_id": review.ID, "status": models.ReviewPublished},
+			bson.M{"$set": bson.M{"status": models.ReviewHeld, "screening": flags}},
+		)
+		if err != nil {
+			return review, err
+		}
+		if result.ModifiedCount > 0 {
+			review.Status = models.ReviewHeld
+			recordHeld(ctx, "review", review.ID, flags)
+		}
+	}
+	updateReviewRank(ctx, review.ID)
+	return review, nil
 }
 
 // DeleteReview handles deleting a review
@@ -397,29 +379,39 @@ func DeleteReview(c *gin.Context) {
 	id := c.Param("id")
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid review ID"})
+		problem.Abort(c, http.StatusBadRequest, problem.CodeInvalidID)
 		return
 	}
 	userID := c.GetString("userID")
 	if userID == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "missing user ID"})
+		problem.Abort(c, http.StatusUnauthorized, problem.CodeInvalidToken)
 		return
 	}
 	var review models.Review
-	err = db.Collection("reviews").FindOne(context.Background(), bson.M{"_id": objectID}).Decode(&review)
+	err = db.Collection("reviews").FindOne(c.Request.Context(), bson.M{"_id": objectID}).Decode(&review)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "review not found"})
+		problem.Abort(c, http.StatusNotFound, problem.CodeReviewNotFound)
 		return
 	}
 	if review.UserID != userID {
-		c.JSON(http.StatusForbidden, gin.H{"error": "you can only delete your own review"})
+		problem.Abort(c, http.StatusForbidden, problem.CodeNotOwner)
 		return
 	}
-	_, err = db.Collection("reviews").DeleteOne(context.Background(), bson.M{"_id": objectID})
+	_, err = db.Collection("reviews").DeleteOne(c.Request.Context(), bson.M{"_id": objectID})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete review"})
+		problem.Internal(c, err)
+		return
+	}
+	if err := deleteReviewComments(c.Request.Context(), objectID); err != nil {
+		problem.Internal(c, err)
 		return
 	}
+	if err := adjustAuthorVotes(c.Request.Context(), review.UserID, -review.Likes, -review.NotHelpful); err != nil {
+		slog.WarnContext(c.Request.Context(), "could not update author votes", "user_id", review.UserID, "error", err)
+	}
+	for _, photo := range review.Photos {
+		removeImages(c.Request.Context(), photo.URL, photo.ThumbnailURL)
+	}
 	c.JSON(http.StatusOK, gin.H{"message": "review deleted successfully"})
 }
 
@@ -428,25 +420,22 @@ func ReportReview(c *gin.Context) {
 	reviewID := c.Param("id")
 	userID := c.GetString("userID")
 	if userID == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "missing user ID"})
+		problem.Abort(c, http.StatusUnauthorized, problem.CodeInvalidToken)
 		return
 	}
 	var user models.User
 	userObjID, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user ID"})
+		problem.Abort(c, http.StatusUnauthorized, problem.CodeInvalidToken)
 		return
 	}
-	if err := db.Collection("users").FindOne(context.Background(), bson.M{"_id": userObjID}).Decode(&user); err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+	if err := db.Collection("users").FindOne(c.Request.Context(), bson.M{"_id": userObjID}).Decode(&user); err != nil {
+		problem.Abort(c, http.StatusUnauthorized, problem.CodeInvalidToken)
 		return
 	}
-	var input struct {
-		Type   string `json:"type" binding:"required"`
-		Detail string `json:"detail"`
-	}
-	if err := c.ShouldBindJSON(&input); err != nil || input.Type == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "report type is required"})
+	var input models.ReportReviewInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		problem.Validation(c, err)
 		return
 	}
 	report := models.ReviewReport{
@@ -458,10 +447,11 @@ func ReportReview(c *gin.Context) {
 		Status:     "pending",
 		CreatedAt:  time.Now(),
 	}
-	_, err = db.Collection("review_reports").InsertOne(context.Background(), report)
+	_, err = db.Collection("review_reports").InsertOne(c.Request.Context(), report)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create report"})
+		problem.Internal(c, err)
 		return
 	}
+	metrics.ReportsFiled.Inc()
 	c.JSON(http.StatusOK, gin.H{"message": "report submitted"})
 }
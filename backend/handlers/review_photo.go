@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"gosmooth/metrics"
+	"gosmooth/models"
+	"gosmooth/problem"
+)
+
+// AddReviewPhoto handles POST /api/reviews/:id/photos, a multipart form with
+// the image in "photo" and optional "alt" text. Only the author may attach
+// photos, up to REVIEW_PHOTO_MAX per review.
+func AddReviewPhoto(c *gin.Context) {
+	reviewID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		problem.Abort(c, http.StatusBadRequest, problem.CodeInvalidID)
+		return
+	}
+	var input models.ReviewPhotoInput
+	if err := c.ShouldBind(&input); err != nil {
+		problem.Validation(c, err)
+		return
+	}
+	userID := c.GetString("userID")
+
+	var review models.Review
+	opts := options.FindOne().SetProjection(bson.M{"user_id": 1, "photos": 1})
+	err = db.Collection("reviews").FindOne(c.Request.Context(), bson.M{"_id": reviewID}, opts).Decode(&review)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		problem.Abort(c, http.StatusNotFound, problem.CodeReviewNotFound)
+		return
+	}
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	if review.UserID != userID {
+		problem.Abort(c, http.StatusForbidden, problem.CodeNotOwner)
+		return
+	}
+	if len(review.Photos) >= reviewPhotoMax {
+		problem.Abort(c, http.StatusConflict, problem.CodeTooManyPhotos, problem.Args(reviewPhotoMax))
+		return
+	}
+
+	stored, ok := saveUploadedImage(c, "photo", "ReviewPhotos")
+	if !ok {
+		return
+	}
+	photo := models.ReviewPhoto{
+		ID:           primitive.NewObjectID(),
+		URL:          stored.Path,
+		ThumbnailURL: stored.ThumbnailPath,
+		Alt:          input.Alt,
+		Width:        stored.Width,
+		Height:       stored.Height,
+		CreatedAt:    time.Now(),
+	}
+	// เงื่อนไข photos.N-1 ไม่มี คือยังแนบได้อีก กันการอัปโหลดพร้อมกันจนเกินจำนวน
+	result, err := db.Collection("reviews").UpdateOne(c.Request.Context(),
+		bson.M{
+			"_id":     reviewID,
+			"user_id": userID,
+			"photos." + strconv.Itoa(reviewPhotoMax-1): bson.M{"$exists": false},
+		},
+		bson.M{"$push": bson.M{"photos": photo}},
+	)
+	if err != nil || result.MatchedCount == 0 {
+		removeImages(c.Request.Context(), stored.Path, stored.ThumbnailPath)
+		if err != nil {
+			problem.Internal(c, err)
+			return
+		}
+		problem.Abort(c, http.StatusConflict, problem.CodeTooManyPhotos, problem.Args(reviewPhotoMax))
+		return
+	}
+
+	updateReviewRank(c.Request.Context(), reviewID)
+	metrics.Uploads.WithLabelValues("review").Inc()
+	c.JSON(http.StatusCreated, gin.H{"photo": photo})
+}
+
+// UpdateReviewPhoto handles PATCH /api/reviews/:id/photos/:photoId to change
+// a photo's alt text
+func UpdateReviewPhoto(c *gin.Context) {
+	review, photo, ok := findReviewPhoto(c)
+	if !ok {
+		return
+	}
+	var input models.ReviewPhotoInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		problem.Validation(c, err)
+		return
+	}
+	if review.UserID != c.GetString("userID") {
+		problem.Abort(c, http.StatusForbidden, problem.CodeNotOwner)
+		return
+	}
+	result, err := db.Collection("reviews").UpdateOne(c.Request.Context(),
+		bson.M{"_id": review.ID, "photos._id": photo.ID},
+		bson.M{"$set": bson.M{"photos.$.alt": input.Alt}},
+	)
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	if result.MatchedCount == 0 {
+		problem.Abort(c, http.StatusNotFound, problem.CodePhotoNotFound)
+		return
+	}
+	photo.Alt = input.Alt
+	c.JSON(http.StatusOK, gin.H{"photo": photo})
+}
+
+// DeleteReviewPhoto handles DELETE /api/reviews/:id/photos/:photoId by the
+// review's author
+func DeleteReviewPhoto(c *gin.Context) {
+	review, photo, ok := findReviewPhoto(c)
+	if !ok {
+		return
+	}
+	if review.UserID != c.GetString("userID") {
+		problem.Abort(c, http.StatusForbidden, problem.CodeNotOwner)
+		return
+	}
+	removeReviewPhoto(c, review, photo)
+}
+
+// RemoveReviewPhoto handles DELETE /api/admin/reviews/:id/photos/:photoId, so
+// moderators can take down a single photo from a reported review
+func RemoveReviewPhoto(c *gin.Context) {
+	review, photo, ok := findReviewPhoto(c)
+	if !ok {
+		return
+	}
+	slog.InfoContext(c.Request.Context(), "review photo removed by moderator",
+		"review_id", review.ID.Hex(), "photo_id", photo.ID.Hex(), "admin_id", c.GetString("userID"))
+	removeReviewPhoto(c, review, photo)
+}
+
+func removeReviewPhoto(c *gin.Context, review models.Review, photo models.ReviewPhoto) {
+	result, err := db.Collection("reviews").UpdateOne(c.Request.Context(),
+		bson.M{"_id": review.ID},
+		bson.M{"$pull": bson.M{"photos": bson.M{"_id": photo.ID}}},
+	)
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	if result.ModifiedCount == 0 {
+		problem.Abort(c, http.StatusNotFound, problem.CodePhotoNotFound)
+		return
+	}
+	removeImages(c.Request.Context(), photo.URL, photo.ThumbnailURL)
+	updateReviewRank(c.Request.Context(), review.ID)
+	c.JSON(http.StatusOK, gin.H{"message": "photo removed"})
+}
+
+// findReviewPhoto loads review :id and its photo :photoId, aborting with 404
+// if either is missing
+func findReviewPhoto(c *gin.Context) (models.Review, models.ReviewPhoto, bool) {
+	var review models.Review
+	reviewID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		problem.Abort(c, http.StatusBadRequest, problem.CodeInvalidID)
+		return review, models.ReviewPhoto{}, false
+	}
+	photoID, err := primitive.ObjectIDFromHex(c.Param("photoId"))
+	if err != nil {
+		problem.Abort(c, http.StatusBadRequest, problem.CodeInvalidID)
+		return review, models.ReviewPhoto{}, false
+	}
+	opts := options.FindOne().SetProjection(bson.M{"user_id": 1, "photos": 1})
+	err = db.Collection("reviews").FindOne(c.Request.Context(), bson.M{"_id": reviewID}, opts).Decode(&review)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		problem.Abort(c, http.StatusNotFound, problem.CodeReviewNotFound)
+		return review, models.ReviewPhoto{}, false
+	}
+	if err != nil {
+		problem.Internal(c, err)
+		return review, models.ReviewPhoto{}, false
+	}
+	for _, photo := range review.Photos {
+		if photo.ID == photoID {
+			return review, photo, true
+		}
+	}
+	problem.Abort(c, http.StatusNotFound, problem.CodePhotoNotFound)
+	return review, models.ReviewPhoto{}, false
+}
@@ -1,7 +1,6 @@
 package handlers
 
 import (
-	"context"
 	"net/http"
 	"time"
 
@@ -10,24 +9,29 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 
 	"gosmooth/models"
+	"gosmooth/problem"
 )
 
 // SuggestRoute handles route suggestions
 func SuggestRoute(c *gin.Context) {
-	var input models.RouteSuggestion
+	var input models.RouteSuggestionInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		problem.Validation(c, err)
 		return
 	}
 
-	// Add user ID to suggestion
-	userID := c.GetString("userID")
-	input.UserID = userID
-	input.CreatedAt = time.Now()
+	suggestion := models.RouteSuggestion{
+		UserID:        c.GetString("userID"),
+		StartLocation: input.StartLocation,
+		EndLocation:   input.EndLocation,
+		Description:   input.Description,
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
+	}
 
-	result, err := db.Collection("route_suggestions").InsertOne(context.Background(), input)
+	result, err := db.Collection("route_suggestions").InsertOne(c.Request.Context(), suggestion)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save route suggestion"})
+		problem.Internal(c, err)
 		return
 	}
 
@@ -40,15 +44,15 @@ func SuggestRoute(c *gin.Context) {
 // GetRouteSuggestions handles getting route suggestions
 func GetRouteSuggestions(c *gin.Context) {
 	var suggestions []models.RouteSuggestion
-	cursor, err := db.Collection("route_suggestions").Find(context.Background(), bson.M{})
+	cursor, err := db.Collection("route_suggestions").Find(c.Request.Context(), bson.M{})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get route suggestions"})
+		problem.Internal(c, err)
 		return
 	}
-	defer cursor.Close(context.Background())
+	defer cursor.Close(c.Request.Context())
 
-	if err = cursor.All(context.Background(), &suggestions); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to decode route suggestions"})
+	if err = cursor.All(c.Request.Context(), &suggestions); err != nil {
+		problem.Internal(c, err)
 		return
 	}
 
@@ -60,14 +64,14 @@ func GetRouteSuggestion(c *gin.Context) {
 	id := c.Param("id")
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid route suggestion ID"})
+		problem.Abort(c, http.StatusBadRequest, problem.CodeInvalidID)
 		return
 	}
 
 	var suggestion models.RouteSuggestion
-	err = db.Collection("route_suggestions").FindOne(context.Background(), bson.M{"_id": objectID}).Decode(&suggestion)
+	err = db.Collection("route_suggestions").FindOne(c.Request.Context(), bson.M{"_id": objectID}).Decode(&suggestion)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "route suggestion not found"})
+		problem.Abort(c, http.StatusNotFound, problem.CodeRouteSuggestionNotFound)
 		return
 	}
 
@@ -79,13 +83,13 @@ func UpdateRouteSuggestion(c *gin.Context) {
 	id := c.Param("id")
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid route suggestion ID"})
+		problem.Abort(c, http.StatusBadRequest, problem.CodeInvalidID)
 		return
 	}
 
-	var input models.RouteSuggestion
+	var input models.RouteSuggestionInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		problem.Validation(c, err)
 		return
 	}
 
@@ -98,9 +102,9 @@ func UpdateRouteSuggestion(c *gin.Context) {
 		},
 	}
 
-	_, err = db.Collection("route_suggestions").UpdateOne(context.Background(), bson.M{"_id": objectID}, update)
+	_, err = db.Collection("route_suggestions").UpdateOne(c.Request.Context(), bson.M{"_id": objectID}, update)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update route suggestion"})
+		problem.Internal(c, err)
 		return
 	}
 
@@ -112,13 +116,13 @@ func DeleteRouteSuggestion(c *gin.Context) {
 	id := c.Param("id")
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid route suggestion ID"})
+		problem.Abort(c, http.StatusBadRequest, problem.CodeInvalidID)
 		return
 	}
 
-	_, err = db.Collection("route_suggestions").DeleteOne(context.Background(), bson.M{"_id": objectID})
+	_, err = db.Collection("route_suggestions").DeleteOne(c.Request.Context(), bson.M{"_id": objectID})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete route suggestion"})
+		problem.Internal(c, err)
 		return
 	}
 
@@ -129,7 +133,7 @@ func DeleteRouteSuggestion(c *gin.Context) {
 func EstimateCost(c *gin.Context) {
 	var input models.RouteInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		problem.Validation(c, err)
 		return
 	}
 
@@ -0,0 +1,235 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"gosmooth/metrics"
+	"gosmooth/models"
+	"gosmooth/problem"
+	"gosmooth/screening"
+)
+
+// Reviews and comments are screened before they are published. The text
+// rules (listed words, links, phone numbers and near-copies of what other
+// accounts posted) apply to new posts and edits; posting bursts and outlying
+// ratings from new accounts only to new posts. A flagged post is saved as
+// held with the rules that fired and waits for a moderator in
+// GET /api/admin/reviews/held or GET /api/admin/comments/flagged.
+
+var (
+	screener         = screening.Default()
+	screeningEnabled = true
+	burstLimit       = 5
+	burstWindow      = 10 * time.Minute
+	newAccountAge    = 72 * time.Hour
+)
+
+const (
+	// duplicateWindow is how far back near-copies are looked for
+	duplicateWindow = 90 * 24 * time.Hour
+	// maxDuplicateCandidates caps how many sharing a band are compared
+	maxDuplicateCandidates = 50
+)
+
+// hiddenFields are left out wherever reviews and comments are shown to users
+var hiddenFields = bson.M{"screening": 0, "fingerprint": 0, "fingerprint_bands": 0}
+
+// SetScreener replaces the word list reviews and comments are checked against
+func SetScreener(s *screening.Screener) {
+	screener = s
+}
+
+// post is a new review or comment about to be screened
+type post struct {
+	collection string
+	author     models.User
+	text       string
+	// placeID and rating are set for reviews
+	placeID string
+	rating  int
+}
+
+// screenPost applies every rule to a new post or an edited review. The
+// fingerprint is returned even when screening is off, so posts made meanwhile
+// can be compared later.
+func screenPost(ctx context.Context, p post) ([]models.ScreeningFlag, screening.Signature, error) {
+	flags, sig, err := screenText(ctx, p.collection, p.author.ID.Hex(), p.text)
+	if err != nil || !screeningEnabled {
+		return flags, sig, err
+	}
+
+	since := time.Now().Add(-burstWindow)
+	posted := int64(0)
+	for _, collection := range []string{"reviews", "comments"} {
+		n, err := db.Collection(collection).CountDocuments(ctx, bson.M{"user_id": p.author.ID.Hex(), "created_at": bson.M{"$gte": since}})
+		if err != nil {
+			return nil, nil, err
+		}
+		posted += n
+	}
+	if posted >= int64(burstLimit) {
+		flags = append(flags, models.ScreeningFlag{
+			Rule:   screening.RuleBurst,
+			Detail: fmt.Sprintf("%d posts within %s", posted+1, burstWindow),
+		})
+	}
+
+	if p.placeID != "" && time.Since(p.author.CreatedAt) < newAccountAge {
+		cursor, err := db.Collection("reviews").Aggregate(ctx, mongo.Pipeline{
+			// รีวิวเดิมของผู้เขียนเองไม่นับเป็นค่าเฉลี่ยที่ใช้เทียบ
+			{{Key: "$match", Value: bson.M{"place_id": p.placeID, "status": models.ReviewPublished, "user_id": bson.M{"$ne": p.author.ID.Hex()}}}},
+			{{Key: "$group", Value: bson.M{"_id": nil, "average": bson.M{"$avg": "$rating"}, "count": bson.M{"$sum": 1}}}},
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		var place []struct {
+			Average float64 `bson:"average"`
+			Count   int     `bson:"count"`
+		}
+		if err := cursor.All(ctx, &place); err != nil {
+			return nil, nil, err
+		}
+		if len(place) > 0 && screening.RatingOutlier(p.rating, place[0].Average, place[0].Count) {
+			flags = append(flags, models.ScreeningFlag{
+				Rule:   screening.RuleRatingOutlier,
+				Detail: fmt.Sprintf("%d stars from a new account against an average of %.1f", p.rating, place[0].Average),
+			})
+		}
+	}
+	return flags, sig, nil
+}
+
+// screenText applies the text rules to text that userID is about to save in
+// collection and returns the flags with the text's fingerprint
+func screenText(ctx context.Context, collection, userID, text string) ([]models.ScreeningFlag, screening.Signature, error) {
+	sig := screening.Fingerprint(text)
+	if !screeningEnabled {
+		return nil, sig, nil
+	}
+	var flags []models.ScreeningFlag
+	for _, f := range screener.Check(text) {
+		flags = append(flags, models.ScreeningFlag{Rule: f.Rule, Detail: f.Detail})
+	}
+	if sig == nil {
+		return flags, sig, nil
+	}
+
+	opts := options.Find().
+		SetProjection(bson.M{"fingerprint": 1}).
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetLimit(maxDuplicateCandidates)
+	cursor, err := db.Collection(collection).Find(ctx, bson.M{
+		"fingerprint_bands": bson.M{"$in": sig.Bands()},
+		"user_id":           bson.M{"$ne": userID},
+		"created_at":        bson.M{"$gte": time.Now().Add(-duplicateWindow)},
+	}, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	var candidates []struct {
+		ID          primitive.ObjectID `bson:"_id"`
+		Fingerprint []uint32           `bson:"fingerprint"`
+	}
+	if err := cursor.All(ctx, &candidates); err != nil {
+		return nil, nil, err
+	}
+	for _, other := range candidates {
+		if similarity := screening.Similarity(sig, other.Fingerprint); similarity >= screening.DuplicateSimilarity {
+			flags = append(flags, models.ScreeningFlag{
+				Rule:   screening.RuleDuplicate,
+				Detail: fmt.Sprintf("%.0f%% like %s", similarity*100, other.ID.Hex()),
+			})
+			break
+		}
+	}
+	return flags, sig, nil
+}
+
+// recordHeld logs and counts a post that screening held
+func recordHeld(ctx context.Context, kind string, id primitive.ObjectID, flags []models.ScreeningFlag) {
+	rules := make([]string, len(flags))
+	for i, f := range flags {
+		rules[i] = f.Rule
+		metrics.PostsHeld.WithLabelValues(kind, f.Rule).Inc()
+	}
+	slog.InfoContext(ctx, "post held for moderation", "kind", kind, "id", id.Hex(), "rules", rules)
+}
+
+// GetHeldReviews handles GET /api/admin/reviews/held, the reviews screening
+// held, oldest first with the rules that held them
+func GetHeldReviews(c *gin.Context) {
+	page, limit, ok := pageQuery(c)
+	if !ok {
+		return
+	}
+	filter := bson.M{"status": models.ReviewHeld}
+	total, err := db.Collection("reviews").CountDocuments(c.Request.Context(), filter)
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: 1}}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+	cursor, err := db.Collection("reviews").Find(c.Request.Context(), filter, opts)
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	reviews := []models.Review{}
+	if err := cursor.All(c.Request.Context(), &reviews); err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"reviews": reviews, "total": total, "page": page, "limit": limit})
+}
+
+// ModerateReview handles PATCH /api/admin/reviews/:id/status, publishing a
+// held review or hiding one. The screening flags are kept as a record.
+func ModerateReview(c *gin.Context) {
+	reviewID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		problem.Abort(c, http.StatusBadRequest, problem.CodeInvalidID)
+		return
+	}
+	var input models.ModerateReviewInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		problem.Validation(c, err)
+		return
+	}
+
+	var before models.Review
+	err = db.Collection("reviews").FindOneAndUpdate(c.Request.Context(),
+		bson.M{"_id": reviewID},
+		bson.M{"$set": bson.M{
+			"status":       input.Status,
+			"moderated_at": time.Now(),
+			"moderated_by": c.GetString("userID"),
+		}},
+		options.FindOneAndUpdate().SetProjection(bson.M{"status": 1}),
+	).Decode(&before)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		problem.Abort(c, http.StatusNotFound, problem.CodeReviewNotFound)
+		return
+	}
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	slog.InfoContext(c.Request.Context(), "review moderated",
+		"review_id", reviewID.Hex(), "from", before.Status, "to", input.Status, "admin_id", c.GetString("userID"))
+	c.JSON(http.StatusOK, gin.H{"message": "review status updated", "status": input.Status})
+}
@@ -0,0 +1,501 @@
+package handlers
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"gosmooth/models"
+	"gosmooth/problem"
+)
+
+// statsTimezone is used to bucket time series so "a day" matches the Thai calendar day
+const statsTimezone = "Asia/Bangkok"
+
+// publishedStatus matches reviews that count towards the stats: those
+// published, and older rows from before screening that have no status.
+// Reviews held by screening or hidden by a moderator are left out.
+var publishedStatus = bson.M{"$in": bson.A{models.ReviewPublished, nil}}
+
+// intervalFormats maps the ?interval= parameter to a $dateToString format
+var intervalFormats = map[string]string{
+	"day":   "%Y-%m-%d",
+	"week":  "%G-W%V",
+	"month": "%Y-%m",
+}
+
+// TimeBucket is one point of a time series
+type TimeBucket struct {
+	Period string `bson:"_id" json:"period"`
+	Count  int64  `bson:"count" json:"count"`
+}
+
+// RatingDistribution counts reviews per star rating for one category or location
+type RatingDistribution struct {
+	Key     string           `json:"key"`
+	Name    string           `json:"name,omitempty"`
+	Ratings map[string]int64 `json:"ratings"`
+	Total   int64            `json:"total"`
+	Average float64          `json:"average"`
+}
+
+// PlaceStat summarizes reviews for a single place
+type PlaceStat struct {
+	PlaceID   string  `bson:"_id" json:"placeId"`
+	PlaceName string  `bson:"place_name" json:"placeName"`
+	Reviews   int64   `bson:"reviews" json:"reviews"`
+	Average   float64 `bson:"average" json:"average"`
+}
+
+// ReportBacklog describes the age of reports still waiting for an admin
+type ReportBacklog struct {
+	Pending        int64            `json:"pending"`
+	OldestAt       *time.Time       `json:"oldestAt,omitempty"`
+	AverageAgeHour float64          `json:"averageAgeHours"`
+	Buckets        map[string]int64 `json:"buckets"`
+}
+
+// GetStats handles getting system statistics. Review figures count only
+// published reviews.
+//
+// Query parameters:
+//   - from, to: date range (YYYY-MM-DD or RFC3339), defaults to the last 30 days
+//   - interval: day, week or month bucket size for the time series
+//   - limit: number of entries in the top places lists (default 10)
+//   - min_reviews: minimum reviews for a place to rank by rating (default 3)
+//   - format=csv: download the same data as CSV
+func GetStats(c *gin.Context) {
+	from, to, err := parseStatsRange(c.Query("from"), c.Query("to"))
+	if err != nil {
+		var perr *statsParamError
+		if errors.As(err, &perr) {
+			problem.Abort(c, http.StatusBadRequest, problem.CodeInvalidParameter, problem.Field(perr.param, perr.rule, perr.want))
+			return
+		}
+		problem.Internal(c, err)
+		return
+	}
+	interval := c.DefaultQuery("interval", "day")
+	format, ok := intervalFormats[interval]
+	if !ok {
+		problem.Abort(c, http.StatusBadRequest, problem.CodeInvalidParameter, problem.Field("interval", "oneof", "day week month"))
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 || limit > 100 {
+		problem.Abort(c, http.StatusBadRequest, problem.CodeInvalidParameter, problem.Field("limit", "range", "1-100"))
+		return
+	}
+	minReviews, err := strconv.Atoi(c.DefaultQuery("min_reviews", "3"))
+	if err != nil || minReviews < 1 {
+		problem.Abort(c, http.StatusBadRequest, problem.CodeInvalidParameter, problem.Field("min_reviews", "min", "1"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	// Get totals
+	usersCount, err := db.Collection("users").CountDocuments(ctx, bson.M{})
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	reviewsCount, err := db.Collection("reviews").CountDocuments(ctx, bson.M{"status": publishedStatus})
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	routesCount, err := db.Collection("route_suggestions").CountDocuments(ctx, bson.M{})
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+
+	// Time series
+	newUsers, err := countByPeriod(ctx, "users", nil, from, to, format)
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	newReviews, err := countByPeriod(ctx, "reviews", bson.M{"status": publishedStatus}, from, to, format)
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	newReports, err := countByPeriod(ctx, "review_reports", nil, from, to, format)
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+
+	// Breakdowns
+	byCategory, err := ratingDistribution(ctx, "category", from, to)
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	byLocation, err := ratingDistribution(ctx, "location_id", from, to)
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	if err := attachLocationNames(ctx, byLocation); err != nil {
+		problem.Internal(c, err)
+		return
+	}
+
+	topByVolume, err := topPlaces(ctx, from, to, 1, bson.D{{Key: "reviews", Value: -1}, {Key: "average", Value: -1}}, limit)
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	topByRating, err := topPlaces(ctx, from, to, minReviews, bson.D{{Key: "average", Value: -1}, {Key: "reviews", Value: -1}}, limit)
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+
+	backlog, err := reportBacklog(ctx, time.Now())
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+
+	if c.Query("format") == "csv" {
+		writeStatsCSV(c, interval, newUsers, newReviews, newReports, byCategory, byLocation, topByVolume, topByRating, backlog)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"total_users":   usersCount,
+		"total_reviews": reviewsCount,
+		"total_routes":  routesCount,
+		"range":         gin.H{"from": from, "to": to, "interval": interval},
+		"time_series": gin.H{
+			"new_users":   newUsers,
+			"new_reviews": newReviews,
+			"new_reports": newReports,
+		},
+		"rating_distribution": gin.H{
+			"by_category": byCategory,
+			"by_location": byLocation,
+		},
+		"top_places": gin.H{
+			"by_volume": topByVolume,
+			"by_rating": topByRating,
+		},
+		"report_backlog": backlog,
+		"last_updated":   time.Now(),
+	})
+}
+
+// statsParamError names the query parameter that could not be used
+type statsParamError struct {
+	param, rule, want string
+}
+
+func (e *statsParamError) Error() string {
+	return fmt.Sprintf("invalid %s: %s %s", e.param, e.rule, e.want)
+}
+
+// parseStatsRange accepts dates as YYYY-MM-DD (whole days in Thai time) or RFC3339
+func parseStatsRange(fromStr, toStr string) (time.Time, time.Time, error) {
+	loc, err := time.LoadLocation(statsTimezone)
+	if err != nil {
+		loc = time.FixedZone("+07:00", 7*60*60)
+	}
+	parse := func(param, s string, endOfDay bool) (time.Time, error) {
+		if t, err := time.Parse(time.RFC3339, s); err == nil {
+			return t, nil
+		}
+		t, err := time.ParseInLocation("2006-01-02", s, loc)
+		if err != nil {
+			return time.Time{}, &statsParamError{param, "datetime", "YYYY-MM-DD"}
+		}
+		if endOfDay {
+			t = t.AddDate(0, 0, 1).Add(-time.Nanosecond)
+		}
+		return t, nil
+	}
+
+	to := time.Now()
+	if toStr != "" {
+		if to, err = parse("to", toStr, true); err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+	}
+	from := to.AddDate(0, 0, -30)
+	if fromStr != "" {
+		if from, err = parse("from", fromStr, false); err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+	}
+	if from.After(to) {
+		return time.Time{}, time.Time{}, &statsParamError{"from", "ltefield", "to"}
+	}
+	return from, to, nil
+}
+
+// countByPeriod counts documents created in the range per period; match
+// narrows the documents further and may be nil
+func countByPeriod(ctx context.Context, collection string, match bson.M, from, to time.Time, format string) ([]TimeBucket, error) {
+	filter := bson.M{"created_at": bson.M{"$gte": from, "$lte": to}}
+	for k, v := range match {
+		filter[k] = v
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$group", Value: bson.M{
+			"_id": bson.M{"$dateToString": bson.M{
+				"format":   format,
+				"date":     "$created_at",
+				"timezone": statsTimezone,
+			}},
+			"count": bson.M{"$sum": 1},
+		}}},
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+	}
+	cursor, err := db.Collection(collection).Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	buckets := []TimeBucket{}
+	if err := cursor.All(ctx, &buckets); err != nil {
+		return nil, err
+	}
+	return buckets, nil
+}
+
+// ratingDistribution groups published reviews in the range by a field of the
+// reviewed place
+func ratingDistribution(ctx context.Context, placeField string, from, to time.Time) ([]RatingDistribution, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"created_at": bson.M{"$gte": from, "$lte": to}, "status": publishedStatus}}},
+		{{Key: "$lookup", Value: bson.M{
+			"from":         "places",
+			"localField":   "place_id",
+			"foreignField": "place_id",
+			"as":           "place",
+		}}},
+		{{Key: "$unwind", Value: bson.M{"path": "$place", "preserveNullAndEmptyArrays": true}}},
+		{{Key: "$group", Value: bson.M{
+			"_id": bson.M{
+				"key":    bson.M{"$ifNull": bson.A{"$place." + placeField, "unknown"}},
+				"rating": "$rating",
+			},
+			"count": bson.M{"$sum": 1},
+		}}},
+	}
+	cursor, err := db.Collection("reviews").Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	var rows []struct {
+		ID struct {
+			Key    string `bson:"key"`
+			Rating int    `bson:"rating"`
+		} `bson:"_id"`
+		Count int64 `bson:"count"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+
+	byKey := map[string]*RatingDistribution{}
+	sums := map[string]int64{}
+	for _, row := range rows {
+		d, ok := byKey[row.ID.Key]
+		if !ok {
+			d = &RatingDistribution{Key: row.ID.Key, Ratings: map[string]int64{"1": 0, "2": 0, "3": 0, "4": 0, "5": 0}}
+			byKey[row.ID.Key] = d
+		}
+		d.Ratings[strconv.Itoa(row.ID.Rating)] += row.Count
+		d.Total += row.Count
+		sums[row.ID.Key] += int64(row.ID.Rating) * row.Count
+	}
+
+	result := make([]RatingDistribution, 0, len(byKey))
+	for key, d := range byKey {
+		if d.Total > 0 {
+			d.Average = float64(sums[key]) / float64(d.Total)
+		}
+		result = append(result, *d)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Total > result[j].Total })
+	return result, nil
+}
+
+func attachLocationNames(ctx context.Context, dist []RatingDistribution) error {
+	cursor, err := db.Collection("locations").Find(ctx, bson.M{})
+	if err != nil {
+		return err
+	}
+	var locations []struct {
+		ID   string `bson:"location_id"`
+		Name string `bson:"name"`
+	}
+	if err := cursor.All(ctx, &locations); err != nil {
+		return err
+	}
+	names := map[string]string{}
+	for _, l := range locations {
+		names[l.ID] = l.Name
+	}
+	for i := range dist {
+		dist[i].Name = names[dist[i].Key]
+	}
+	return nil
+}
+
+func topPlaces(ctx context.Context, from, to time.Time, minReviews int, order bson.D, limit int) ([]PlaceStat, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"created_at": bson.M{"$gte": from, "$lte": to}, "status": publishedStatus}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":        "$place_id",
+			"place_name": bson.M{"$last": "$place_name"},
+			"reviews":    bson.M{"$sum": 1},
+			"average":    bson.M{"$avg": "$rating"},
+		}}},
+		{{Key: "$match", Value: bson.M{"reviews": bson.M{"$gte": minReviews}}}},
+		{{Key: "$sort", Value: order}},
+		{{Key: "$limit", Value: limit}},
+	}
+	cursor, err := db.Collection("reviews").Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	places := []PlaceStat{}
+	if err := cursor.All(ctx, &places); err != nil {
+		return nil, err
+	}
+	return places, nil
+}
+
+func reportBacklog(ctx context.Context, now time.Time) (ReportBacklog, error) {
+	backlog := ReportBacklog{Buckets: map[string]int64{"<1d": 0, "1-3d": 0, "3-7d": 0, ">7d": 0}}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"status": "pending"}}},
+		{{Key: "$project", Value: bson.M{
+			"created_at": 1,
+			"age_hours":  bson.M{"$divide": bson.A{bson.M{"$subtract": bson.A{now, "$created_at"}}, 3600000}},
+		}}},
+		{{Key: "$facet", Value: bson.M{
+			"summary": bson.A{bson.M{"$group": bson.M{
+				"_id":     nil,
+				"pending": bson.M{"$sum": 1},
+				"oldest":  bson.M{"$min": "$created_at"},
+				"avg_age": bson.M{"$avg": "$age_hours"},
+			}}},
+			"buckets": bson.A{bson.M{"$bucket": bson.M{
+				"groupBy":    "$age_hours",
+				"boundaries": bson.A{0, 24, 72, 168},
+				"default":    ">7d",
+				"output":     bson.M{"count": bson.M{"$sum": 1}},
+			}}},
+		}}},
+	}
+	cursor, err := db.Collection("review_reports").Aggregate(ctx, pipeline)
+	if err != nil {
+		return backlog, err
+	}
+	var out []struct {
+		Summary []struct {
+			Pending int64     `bson:"pending"`
+			Oldest  time.Time `bson:"oldest"`
+			AvgAge  float64   `bson:"avg_age"`
+		} `bson:"summary"`
+		Buckets []struct {
+			ID    interface{} `bson:"_id"`
+			Count int64       `bson:"count"`
+		} `bson:"buckets"`
+	}
+	if err := cursor.All(ctx, &out); err != nil {
+		return backlog, err
+	}
+	if len(out) == 0 || len(out[0].Summary) == 0 {
+		return backlog, nil
+	}
+
+	s := out[0].Summary[0]
+	backlog.Pending = s.Pending
+	backlog.OldestAt = &s.Oldest
+	backlog.AverageAgeHour = s.AvgAge
+	labels := map[string]string{"0": "<1d", "24": "1-3d", "72": "3-7d", ">7d": ">7d"}
+	for _, b := range out[0].Buckets {
+		backlog.Buckets[labels[fmt.Sprint(b.ID)]] += b.Count
+	}
+	return backlog, nil
+}
+
+// writeStatsCSV flattens the stats into section,group,key,value rows
+func writeStatsCSV(c *gin.Context, interval string, newUsers, newReviews, newReports []TimeBucket,
+	byCategory, byLocation []RatingDistribution, topByVolume, topByRating []PlaceStat, backlog ReportBacklog) {
+	filename := fmt.Sprintf("gosmooth-stats-%s.csv", time.Now().Format("20060102"))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Status(http.StatusOK)
+
+	w := csv.NewWriter(c.Writer)
+	_ = w.Write([]string{"section", "group", "key", "value"})
+
+	series := []struct {
+		name    string
+		buckets []TimeBucket
+	}{{"new_users", newUsers}, {"new_reviews", newReviews}, {"new_reports", newReports}}
+	for _, s := range series {
+		for _, b := range s.buckets {
+			_ = w.Write([]string{s.name, interval, b.Period, strconv.FormatInt(b.Count, 10)})
+		}
+	}
+
+	dists := []struct {
+		name string
+		rows []RatingDistribution
+	}{{"ratings_by_category", byCategory}, {"ratings_by_location", byLocation}}
+	for _, d := range dists {
+		for _, row := range d.rows {
+			group := row.Key
+			if row.Name != "" {
+				group = row.Name
+			}
+			for star := 1; star <= 5; star++ {
+				key := strconv.Itoa(star)
+				_ = w.Write([]string{d.name, group, key, strconv.FormatInt(row.Ratings[key], 10)})
+			}
+			_ = w.Write([]string{d.name, group, "average", strconv.FormatFloat(row.Average, 'f', 2, 64)})
+		}
+	}
+
+	tops := []struct {
+		name   string
+		places []PlaceStat
+	}{{"top_places_by_volume", topByVolume}, {"top_places_by_rating", topByRating}}
+	for _, t := range tops {
+		for _, p := range t.places {
+			group := p.PlaceID
+			if p.PlaceName != "" {
+				group = p.PlaceName
+			}
+			_ = w.Write([]string{t.name, group, "reviews", strconv.FormatInt(p.Reviews, 10)})
+			_ = w.Write([]string{t.name, group, "average", strconv.FormatFloat(p.Average, 'f', 2, 64)})
+		}
+	}
+
+	_ = w.Write([]string{"report_backlog", "", "pending", strconv.FormatInt(backlog.Pending, 10)})
+	_ = w.Write([]string{"report_backlog", "", "average_age_hours", strconv.FormatFloat(backlog.AverageAgeHour, 'f', 1, 64)})
+	for _, label := range []string{"<1d", "1-3d", "3-7d", ">7d"} {
+		_ = w.Write([]string{"report_backlog", "age", label, strconv.FormatInt(backlog.Buckets[label], 10)})
+	}
+	w.Flush()
+}
@@ -0,0 +1,556 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"gosmooth/models"
+	"gosmooth/planner"
+	"gosmooth/problem"
+)
+
+// maxTrips is how many trips a user can keep
+const maxTrips = 100
+
+// defaultDayStart is when a trip day starts if no time is given
+const defaultDayStart = "09:00"
+
+// tripZone is the time zone trip dates are in
+var tripZone = time.FixedZone("+07:00", 7*60*60)
+
+// ListMyTrips handles GET /api/profile/trips, most recently changed first.
+// Plans are only worked out for a single trip.
+func ListMyTrips(c *gin.Context) {
+	opts := options.Find().SetSort(bson.D{{Key: "updated_at", Value: -1}})
+	cursor, err := db.Collection("trips").Find(c.Request.Context(), bson.M{"user_id": c.GetString("userID")}, opts)
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	trips := []models.Trip{}
+	if err := cursor.All(c.Request.Context(), &trips); err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	for i := range trips {
+		setTripShareURL(&trips[i])
+	}
+	c.JSON(http.StatusOK, gin.H{"trips": trips})
+}
+
+// CreateTrip handles POST /api/profile/trips. New trips are private unless
+// asked otherwise.
+func CreateTrip(c *gin.Context) {
+	var input models.TripInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		problem.Validation(c, err)
+		return
+	}
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		problem.Abort(c, http.StatusBadRequest, problem.CodeValidation, problem.Field("name", "required", ""))
+		return
+	}
+	userID := c.GetString("userID")
+	count, err := db.Collection("trips").CountDocuments(c.Request.Context(), bson.M{"user_id": userID})
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	if count >= maxTrips {
+		problem.Abort(c, http.StatusConflict, problem.CodeTooManyTrips, problem.Args(maxTrips))
+		return
+	}
+	days, ok := tripDays(c, input.Days)
+	if !ok {
+		return
+	}
+
+	trip := models.Trip{
+		ID:          primitive.NewObjectID(),
+		UserID:      userID,
+		Name:        name,
+		Description: input.Description,
+		StartDate:   input.StartDate,
+		Visibility:  input.Visibility,
+		Days:        days,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+	if trip.Visibility == "" {
+		trip.Visibility = models.TripPrivate
+	}
+	if _, err := db.Collection("trips").InsertOne(c.Request.Context(), trip); err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	syncTripVisits(c.Request.Context(), userID, tripPlaceIDs(trip))
+	respondTrip(c, http.StatusCreated, trip)
+}
+
+// GetMyTrip handles GET /api/profile/trips/:id, the trip with its plan
+func GetMyTrip(c *gin.Context) {
+	trip, ok := findMyTrip(c)
+	if !ok {
+		return
+	}
+	respondTrip(c, http.StatusOK, trip)
+}
+
+// UpdateTrip handles PUT /api/profile/trips/:id, replacing the whole trip.
+// This is also how stops are reordered or moved between days.
+func UpdateTrip(c *gin.Context) {
+	tripID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		problem.Abort(c, http.StatusBadRequest, problem.CodeInvalidID)
+		return
+	}
+	var input models.TripInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		problem.Validation(c, err)
+		return
+	}
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		problem.Abort(c, http.StatusBadRequest, problem.CodeValidation, problem.Field("name", "required", ""))
+		return
+	}
+	days, ok := tripDays(c, input.Days)
+	if !ok {
+		return
+	}
+
+	set := bson.M{
+		"name":        name,
+		"description": input.Description,
+		"days":        days,
+		"updated_at":  time.Now(),
+	}
+	unset := bson.M{}
+	if input.StartDate != "" {
+		set["start_date"] = input.StartDate
+	} else {
+		unset["start_date"] = ""
+	}
+	if input.Visibility != "" {
+		set["visibility"] = input.Visibility
+	}
+	update := bson.M{"$set": set}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+	userID := c.GetString("userID")
+	filter := bson.M{"_id": tripID, "user_id": userID}
+	if input.UpdatedAt != nil {
+		filter["updated_at"] = *input.UpdatedAt
+	}
+
+	// ป้ายไปมาจริงของสถานที่ที่ถูกเอาออกจากทริปต้องตรวจใหม่ด้วย
+	var before models.Trip
+	err = db.Collection("trips").FindOneAndUpdate(c.Request.Context(), filter, update,
+		options.FindOneAndUpdate().SetProjection(bson.M{"days.stops.place_id": 1}),
+	).Decode(&before)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		tripMissing(c, tripID, input.UpdatedAt != nil)
+		return
+	}
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	var trip models.Trip
+	if err := db.Collection("trips").FindOne(c.Request.Context(), bson.M{"_id": tripID}).Decode(&trip); err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	syncTripVisits(c.Request.Context(), userID, append(tripPlaceIDs(before), tripPlaceIDs(trip)...))
+	respondTrip(c, http.StatusOK, trip)
+}
+
+// DeleteTrip handles DELETE /api/profile/trips/:id
+func DeleteTrip(c *gin.Context) {
+	tripID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		problem.Abort(c, http.StatusBadRequest, problem.CodeInvalidID)
+		return
+	}
+	userID := c.GetString("userID")
+	var trip models.Trip
+	err = db.Collection("trips").FindOneAndDelete(c.Request.Context(),
+		bson.M{"_id": tripID, "user_id": userID},
+		options.FindOneAndDelete().SetProjection(bson.M{"days.stops.place_id": 1}),
+	).Decode(&trip)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		problem.Abort(c, http.StatusNotFound, problem.CodeTripNotFound)
+		return
+	}
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	syncTripVisits(c.Request.Context(), userID, tripPlaceIDs(trip))
+	c.JSON(http.StatusOK, gin.H{"message": "trip deleted successfully"})
+}
+
+// DuplicateTrip handles POST /api/profile/trips/:id/duplicate, making a
+// private copy of one of the caller's trips or of a shared trip. A copy of
+// someone else's trip leaves out their dates.
+func DuplicateTrip(c *gin.Context) {
+	tripID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		problem.Abort(c, http.StatusBadRequest, problem.CodeInvalidID)
+		return
+	}
+	userID := c.GetString("userID")
+	var trip models.Trip
+	err = db.Collection("trips").FindOne(c.Request.Context(), bson.M{
+		"_id": tripID,
+		"$or": bson.A{bson.M{"user_id": userID}, bson.M{"visibility": models.TripPublic}},
+	}).Decode(&trip)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		problem.Abort(c, http.StatusNotFound, problem.CodeTripNotFound)
+		return
+	}
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	count, err := db.Collection("trips").CountDocuments(c.Request.Context(), bson.M{"user_id": userID})
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	if count >= maxTrips {
+		problem.Abort(c, http.StatusConflict, problem.CodeTooManyTrips, problem.Args(maxTrips))
+		return
+	}
+
+	if trip.UserID != userID {
+		trip.StartDate = ""
+	}
+	trip.ID = primitive.NewObjectID()
+	trip.UserID = userID
+	trip.Name = copyName(trip.Name)
+	trip.Visibility = models.TripPrivate
+	trip.CreatedAt = time.Now()
+	trip.UpdatedAt = time.Now()
+	if _, err := db.Collection("trips").InsertOne(c.Request.Context(), trip); err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	syncTripVisits(c.Request.Context(), userID, tripPlaceIDs(trip))
+	respondTrip(c, http.StatusCreated, trip)
+}
+
+// GetSharedTrip handles GET /api/trips/:id, the public view of a shared trip
+// with its plan. Private trips are not found.
+func GetSharedTrip(c *gin.Context) {
+	tripID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		problem.Abort(c, http.StatusBadRequest, problem.CodeInvalidID)
+		return
+	}
+	var trip models.Trip
+	err = db.Collection("trips").FindOne(c.Request.Context(),
+		bson.M{"_id": tripID, "visibility": models.TripPublic}).Decode(&trip)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		problem.Abort(c, http.StatusNotFound, problem.CodeTripNotFound)
+		return
+	}
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	plan, err := planTrip(c.Request.Context(), trip)
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	setTripShareURL(&trip)
+	c.JSON(http.StatusOK, gin.H{"trip": trip, "plan": plan, "owner": ownerName(c.Request.Context(), trip.UserID)})
+}
+
+// respondTrip sends a trip with its plan
+func respondTrip(c *gin.Context, status int, trip models.Trip) {
+	plan, err := planTrip(c.Request.Context(), trip)
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	setTripShareURL(&trip)
+	c.JSON(status, gin.H{"trip": trip, "plan": plan})
+}
+
+// tripDays checks that every stop is a place and names it as the place is
+// named now. It answers the request itself when a place is missing.
+func tripDays(c *gin.Context, input []models.TripDayInput) ([]models.TripDay, bool) {
+	var refs []string
+	var objectIDs []primitive.ObjectID
+	for _, d := range input {
+		for _, s := range d.Stops {
+			refs = append(refs, s.PlaceID)
+			if objID, err := primitive.ObjectIDFromHex(s.PlaceID); err == nil {
+				objectIDs = append(objectIDs, objID)
+			}
+		}
+	}
+	cursor, err := db.Collection("places").Find(c.Request.Context(), bson.M{"$or": bson.A{
+		bson.M{"place_id": bson.M{"$in": refs}},
+		bson.M{"_id": bson.M{"$in": objectIDs}},
+	}}, options.Find().SetProjection(bson.M{"place_id": 1, "name": 1}))
+	if err != nil {
+		problem.Internal(c, err)
+		return nil, false
+	}
+	var found []models.Place
+	if err := cursor.All(c.Request.Context(), &found); err != nil {
+		problem.Internal(c, err)
+		return nil, false
+	}
+	byRef := make(map[string]models.Place, 2*len(found))
+	for _, p := range found {
+		byRef[p.ObjectID.Hex()] = p
+		if p.ID != "" {
+			byRef[p.ID] = p
+		}
+	}
+
+	days := make([]models.TripDay, len(input))
+	for i, d := range input {
+		day := models.TripDay{StartTime: d.StartTime, Stops: make([]models.TripStop, len(d.Stops))}
+		if day.StartTime == "" {
+			day.StartTime = defaultDayStart
+		}
+		for j, s := range d.Stops {
+			place, ok := byRef[s.PlaceID]
+			if !ok {
+				problem.Abort(c, http.StatusNotFound, problem.CodePlaceNotFound)
+				return nil, false
+			}
+			day.Stops[j] = models.TripStop{
+				PlaceID:   place.ID,
+				PlaceName: place.Name,
+				Duration:  s.Duration,
+				Note:      s.Note,
+			}
+		}
+		days[i] = day
+	}
+	return days, true
+}
+
+// planTrip works out a trip's legs, times and costs from the routes and
+// places as they are now
+func planTrip(ctx context.Context, trip models.Trip) (planner.Itinerary, error) {
+	cursor, err := db.Collection("routes").Find(ctx, bson.M{})
+	if err != nil {
+		return planner.Itinerary{}, err
+	}
+	var routes []models.Route
+	if err := cursor.All(ctx, &routes); err != nil {
+		return planner.Itinerary{}, err
+	}
+	edges := make([]planner.Edge, len(routes))
+	for i, r := range routes {
+		edges[i] = planner.Edge{
+			From:     r.StartLocID,
+			To:       r.EndLocID,
+			Distance: r.Distance,
+			Duration: r.Duration,
+			Cost:     r.Cost,
+			Mode:     r.TransportMode,
+		}
+	}
+
+	var placeIDs []string
+	for _, d := range trip.Days {
+		for _, s := range d.Stops {
+			placeIDs = append(placeIDs, s.PlaceID)
+		}
+	}
+	cursor, err = db.Collection("places").Find(ctx, bson.M{"place_id": bson.M{"$in": placeIDs}},
+		options.Find().SetProjection(bson.M{"place_id": 1, "location_id": 1, "coordinates": 1, "hours": 1}))
+	if err != nil {
+		return planner.Itinerary{}, err
+	}
+	var found []models.Place
+	if err := cursor.All(ctx, &found); err != nil {
+		return planner.Itinerary{}, err
+	}
+	byID := make(map[string]models.Place, len(found))
+	for _, p := range found {
+		byID[p.ID] = p
+	}
+
+	start, err := time.Parse("2006-01-02", trip.StartDate)
+	dated := err == nil
+	days := make([]planner.Day, len(trip.Days))
+	for i, d := range trip.Days {
+		day := planner.Day{Start: minuteOfDay(d.StartTime), Stops: make([]planner.Stop, len(d.Stops))}
+		if dated {
+			day.Dated = true
+			day.Weekday = start.AddDate(0, 0, i).Weekday()
+		}
+		for j, s := range d.Stops {
+			// สถานที่ที่ถูกลบไปแล้วไม่มีพิกัด แผนจะเตือนแทน
+			p := byID[s.PlaceID]
+			day.Stops[j] = planner.Stop{
+				LocationID: p.LocationID,
+				Lat:        p.Coordinates.Lat,
+				Lng:        p.Coordinates.Lng,
+				Stay:       s.Duration,
+				Hours:      p.Hours,
+			}
+		}
+		days[i] = day
+	}
+	return planner.Plan(planner.NewGraph(edges), days), nil
+}
+
+// minuteOfDay reads a "15:04" time as minutes from midnight
+func minuteOfDay(clock string) int {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		t, _ = time.Parse("15:04", defaultDayStart)
+	}
+	return t.Hour()*60 + t.Minute()
+}
+
+// tripVisitedPlaces returns the place_ids on days of trip that are over.
+// Only days after the day the trip was made count, so a trip dated in the
+// past or saved on the day itself is not a visit; today is not over yet and
+// an undated trip has not come.
+func tripVisitedPlaces(trip models.Trip, today string) []string {
+	start, err := time.Parse("2006-01-02", trip.StartDate)
+	if err != nil {
+		return nil
+	}
+	planned := trip.CreatedAt.In(tripZone).Format("2006-01-02")
+	var placeIDs []string
+	for i, d := range trip.Days {
+		day := start.AddDate(0, 0, i).Format("2006-01-02")
+		if day >= today {
+			break
+		}
+		if day <= planned {
+			continue
+		}
+		for _, s := range d.Stops {
+			placeIDs = append(placeIDs, s.PlaceID)
+		}
+	}
+	return placeIDs
+}
+
+// tripPlaceIDs returns every place_id on trip
+func tripPlaceIDs(trip models.Trip) []string {
+	var placeIDs []string
+	for _, d := range trip.Days {
+		for _, s := range d.Stops {
+			placeIDs = append(placeIDs, s.PlaceID)
+		}
+	}
+	return placeIDs
+}
+
+// syncTripVisits sets or clears the verified visit badge on userID's
+// reviews of placeIDs after a trip that has them was saved or deleted
+func syncTripVisits(ctx context.Context, userID string, placeIDs []string) {
+	if len(placeIDs) == 0 {
+		return
+	}
+	cursor, err := db.Collection("reviews").Find(ctx,
+		bson.M{"user_id": userID, "place_id": bson.M{"$in": placeIDs}},
+		options.Find().SetProjection(bson.M{"place_id": 1, "verified_visit": 1}))
+	if err != nil {
+		slog.WarnContext(ctx, "could not update verified visits", "user_id", userID, "error", err)
+		return
+	}
+	var reviews []models.Review
+	if err := cursor.All(ctx, &reviews); err != nil {
+		slog.WarnContext(ctx, "could not update verified visits", "user_id", userID, "error", err)
+		return
+	}
+	for _, review := range reviews {
+		visited, err := visitedPlace(ctx, userID, review.PlaceID)
+		if err != nil {
+			slog.WarnContext(ctx, "could not update verified visit", "review_id", review.ID.Hex(), "error", err)
+			continue
+		}
+		if visited == review.VerifiedVisit {
+			continue
+		}
+		if _, err := db.Collection("reviews").UpdateOne(ctx,
+			bson.M{"_id": review.ID},
+			bson.M{"$set": bson.M{"verified_visit": visited}},
+		); err != nil {
+			slog.WarnContext(ctx, "could not update verified visit", "review_id", review.ID.Hex(), "error", err)
+		}
+	}
+}
+
+func findMyTrip(c *gin.Context) (models.Trip, bool) {
+	var trip models.Trip
+	tripID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		problem.Abort(c, http.StatusBadRequest, problem.CodeInvalidID)
+		return trip, false
+	}
+	err = db.Collection("trips").FindOne(c.Request.Context(),
+		bson.M{"_id": tripID, "user_id": c.GetString("userID")}).Decode(&trip)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		problem.Abort(c, http.StatusNotFound, problem.CodeTripNotFound)
+		return trip, false
+	}
+	if err != nil {
+		problem.Internal(c, err)
+		return trip, false
+	}
+	return trip, true
+}
+
+// tripMissing answers an update that matched nothing: the trip changed
+// since the caller read it, or it is not the caller's
+func tripMissing(c *gin.Context, tripID primitive.ObjectID, checkedVersion bool) {
+	if checkedVersion {
+		n, err := db.Collection("trips").CountDocuments(c.Request.Context(),
+			bson.M{"_id": tripID, "user_id": c.GetString("userID")})
+		if err != nil {
+			problem.Internal(c, err)
+			return
+		}
+		if n > 0 {
+			problem.Abort(c, http.StatusConflict, problem.CodeConflict)
+			return
+		}
+	}
+	problem.Abort(c, http.StatusNotFound, problem.CodeTripNotFound)
+}
+
+// copyName names a duplicated trip, within the 100 characters a name may have
+func copyName(name string) string {
+	const suffix = " (copy)"
+	for utf8.RuneCountInString(name)+len(suffix) > 100 {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	return name + suffix
+}
+
+// setTripShareURL fills in the link to a public trip's page on the frontend
+func setTripShareURL(trip *models.Trip) {
+	if trip.Visibility == models.TripPublic {
+		trip.ShareURL = strings.TrimRight(frontendURL, "/") + "/trips/" + trip.ID.Hex()
+	}
+}
@@ -2,15 +2,20 @@ package handlers
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/crypto/bcrypt"
 
 	"gosmooth/middleware"
 	"gosmooth/models"
+	"gosmooth/problem"
+	"gosmooth/validation"
 )
 
 // GetProfile handles getting user profile
@@ -18,17 +23,17 @@ func GetProfile(c *gin.Context) {
 	userID := c.GetString("userID")
 	objectID, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		problem.Abort(c, http.StatusBadRequest, problem.CodeInvalidID)
 		return
 	}
 
 	var user models.User
-	err = db.Collection("users").FindOne(context.Background(), bson.M{"_id": objectID}).Decode(&user)
+	err = db.Collection("users").FindOne(c.Request.Context(), bson.M{"_id": objectID}).Decode(&user)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		problem.Abort(c, http.StatusNotFound, problem.CodeUserNotFound)
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"user": user})
+	c.JSON(http.StatusOK, gin.H{"user": user, "hasPassword": user.Password != ""})
 }
 
 // UpdateProfile handles updating user profile (name + address)
@@ -36,22 +41,19 @@ func UpdateProfile(c *gin.Context) {
 	userID := c.GetString("userID")
 	objectID, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		problem.Abort(c, http.StatusBadRequest, problem.CodeInvalidID)
 		return
 	}
 
-	var input struct {
-		Name    string         `json:"name" binding:"required"`
-		Address models.Address `json:"address" binding:"required"`
-	}
+	var input models.UpdateProfileInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		problem.Validation(c, err)
 		return
 	}
 
 	// Validate address fields (optional: เพิ่ม validate เพิ่มเติม)
 	if input.Address.Lat == 0 || input.Address.Lng == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid address coordinates"})
+		problem.Abort(c, http.StatusBadRequest, problem.CodeInvalidCoordinates)
 		return
 	}
 
@@ -63,17 +65,17 @@ func UpdateProfile(c *gin.Context) {
 		},
 	}
 
-	_, err = db.Collection("users").UpdateOne(context.Background(), bson.M{"_id": objectID}, update)
+	_, err = db.Collection("users").UpdateOne(c.Request.Context(), bson.M{"_id": objectID}, update)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update profile"})
+		problem.Internal(c, err)
 		return
 	}
 
 	// ส่ง user กลับ (อัปเดตล่าสุด)
 	var user models.User
-	err = db.Collection("users").FindOne(context.Background(), bson.M{"_id": objectID}).Decode(&user)
+	err = db.Collection("users").FindOne(c.Request.Context(), bson.M{"_id": objectID}).Decode(&user)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch updated user"})
+		problem.Internal(c, err)
 		return
 	}
 
@@ -85,7 +87,7 @@ func RefreshToken(c *gin.Context) {
 	userID := c.GetString("userID")
 	token, err := middleware.GenerateToken(userID, true)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
+		problem.Internal(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"token": token})
@@ -97,3 +99,79 @@ func Logout(c *gin.Context) {
 	// by removing the token. The server doesn't need to do anything.
 	c.JSON(http.StatusOK, gin.H{"message": "logged out successfully"})
 }
+
+// CreateUserAccount creates an active account; it is used by the admin CLI.
+// A temporary password must be changed at the first login.
+func CreateUserAccount(ctx context.Context, email, name, password, role string, temporary bool) (models.User, error) {
+	if email == "" || name == "" {
+		return models.User{}, fmt.Errorf("%w: email and name are required", ErrBadRequest)
+	}
+	if role != "user" && role != "admin" {
+		return models.User{}, fmt.Errorf("%w: role must be user or admin", ErrBadRequest)
+	}
+	if err := validation.CheckPassword(password, email, name); err != nil {
+		return models.User{}, fmt.Errorf("%w: %v", ErrBadRequest, err)
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	now := time.Now()
+	user := models.User{
+		ID:                 primitive.NewObjectID(),
+		Email:              email,
+		Password:           string(hashedPassword),
+		Name:               name,
+		Role:               role,
+		Status:             "active",
+		PasswordChangedAt:  &now,
+		MustChangePassword: temporary,
+		CreatedAt:          now,
+		UpdatedAt:          now,
+	}
+	if _, err := db.Collection("users").InsertOne(ctx, user); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return models.User{}, fmt.Errorf("%w: email %s already exists", ErrDuplicateKey, email)
+		}
+		return models.User{}, err
+	}
+	return user, nil
+}
+
+// SetUserRole changes the role of the account with the given email
+func SetUserRole(ctx context.Context, email, role string) error {
+	if role != "user" && role != "admin" {
+		return fmt.Errorf("%w: role must be user or admin", ErrBadRequest)
+	}
+	result, err := db.Collection("users").UpdateOne(ctx, bson.M{"email": email}, bson.M{
+		"$set": bson.M{"role": role, "updated_at": time.Now()},
+	})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("%w: no user with email %s", ErrNotFound, email)
+	}
+	return nil
+}
+
+// ResetUserPassword replaces the password of the account with the given email.
+// A temporary password must be changed at the next login.
+func ResetUserPassword(ctx context.Context, email, password string, temporary bool) error {
+	if err := validation.CheckPassword(password, email); err != nil {
+		return fmt.Errorf("%w: %v", ErrBadRequest, err)
+	}
+	set, err := passwordUpdate(password, temporary)
+	if err != nil {
+		return err
+	}
+	result, err := db.Collection("users").UpdateOne(ctx, bson.M{"email": email}, bson.M{"$set": set})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("%w: no user with email %s", ErrNotFound, email)
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+// Package imaging prepares uploaded photos for storage. Process checks that
+// an upload is a JPEG or PNG of sensible dimensions, removes location
+// metadata unless asked to keep it, and renders a JPEG thumbnail. The stored
+// image keeps its original encoding; only metadata is edited, so there is no
+// loss of quality. A file whose metadata cannot be parsed is encoded afresh
+// instead, so its location never reaches storage.
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+
+	"golang.org/x/image/draw"
+)
+
+var (
+	// ErrUnsupported is returned for anything but a JPEG or PNG image
+	ErrUnsupported = errors.New("imaging: unsupported image format")
+	// ErrTooLarge is returned when the image has more pixels than allowed
+	ErrTooLarge = errors.New("imaging: image dimensions too large")
+)
+
+// Options control Process
+type Options struct {
+	// ThumbnailSize is the longest edge of the thumbnail in pixels
+	ThumbnailSize int
+	// MaxPixels limits width × height so a small file cannot decode into a
+	// huge bitmap
+	MaxPixels int
+	// KeepLocation keeps GPS coordinates and XMP metadata in the image
+	KeepLocation bool
+}
+
+// Image is a processed upload
+type Image struct {
+	Data []byte
+	// Ext is the file extension for Data, ".jpg" or ".png"
+	Ext string
+	// Width and Height are as displayed, after EXIF orientation
+	Width, Height int
+	// Thumbnail is always a JPEG
+	Thumbnail []byte
+}
+
+// Process validates data and returns the image to store with its thumbnail
+func Process(data []byte, opts Options) (*Image, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || (format != "jpeg" && format != "png") {
+		return nil, ErrUnsupported
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 {
+		return nil, ErrUnsupported
+	}
+	if opts.MaxPixels > 0 && cfg.Width*cfg.Height > opts.MaxPixels {
+		return nil, ErrTooLarge
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupported
+	}
+
+	img := &Image{Data: data, Ext: ".png", Width: cfg.Width, Height: cfg.Height}
+	orientation := 1
+	if format == "jpeg" {
+		img.Ext = ".jpg"
+		orientation = jpegOrientation(data)
+	}
+	if orientation >= 5 {
+		// ค่า 5-8 หมุนภาพ 90 องศา กว้างกับสูงจึงสลับกัน
+		img.Width, img.Height = img.Height, img.Width
+	}
+	if !opts.KeepLocation {
+		var stripped []byte
+		var ok bool
+		if format == "jpeg" {
+			stripped, ok = stripJPEGLocation(data)
+		} else {
+			stripped, ok = stripPNGLocation(data)
+		}
+		if !ok {
+			// แยกส่วน metadata ไม่ได้ จึงเข้ารหัสภาพใหม่แทนการเก็บไฟล์เดิม
+			if stripped, err = reencode(src, format, orientation); err != nil {
+				return nil, err
+			}
+		}
+		img.Data = stripped
+	}
+
+	thumb := orient(scaleDown(src, opts.ThumbnailSize), orientation)
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, thumb, &jpeg.Options{Quality: 80}); err != nil {
+		return nil, err
+	}
+	img.Thumbnail = buf.Bytes()
+	return img, nil
+}
+
+// reencode writes src afresh without any of the original metadata. The EXIF
+// orientation goes with the metadata, so JPEG pixels are turned upright.
+func reencode(src image.Image, format string, orientation int) ([]byte, error) {
+	var buf bytes.Buffer
+	if format == "png" {
+		err := png.Encode(&buf, src)
+		return buf.Bytes(), err
+	}
+	b := src.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), src, b.Min, draw.Src)
+	err := jpeg.Encode(&buf, orient(rgba, orientation), &jpeg.Options{Quality: 92})
+	return buf.Bytes(), err
+}
+
+// scaleDown fits src within a size × size box on a white background, which
+// also flattens any transparency for JPEG. Smaller images are not enlarged.
+func scaleDown(src image.Image, size int) *image.RGBA {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if size > 0 && (w > size || h > size) {
+		if w >= h {
+			w, h = size, max(1, h*size/w)
+		} else {
+			w, h = max(1, w*size/h), size
+		}
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, b, draw.Over, nil)
+	return dst
+}
+
+// orient turns src upright according to an EXIF orientation value
+func orient(src *image.RGBA, orientation int) *image.RGBA {
+	if orientation < 2 || orientation > 8 {
+		return src
+	}
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2: // mirrored
+				dx, dy = w-1-x, y
+			case 3: // rotated 180°
+				dx, dy = w-1-x, h-1-y
+			case 4: // mirrored vertically
+				dx, dy = x, h-1-y
+			case 5: // transposed
+				dx, dy = y, x
+			case 6: // rotated 90° clockwise to display
+				dx, dy = h-1-y, x
+			case 7: // transversed
+				dx, dy = h-1-y, w-1-x
+			case 8: // rotated 90° counter-clockwise to display
+				dx, dy = y, w-1-x
+			}
+			dst.SetRGBA(dx, dy, src.RGBAAt(x, y))
+		}
+	}
+	return dst
+}
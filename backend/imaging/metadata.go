@@ -0,0 +1,209 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// Metadata that can reveal where a photo was taken: the EXIF GPS directory
+// and XMP packets, which cameras and phones also fill with coordinates.
+var (
+	exifHeader    = []byte("Exif\x00\x00")
+	xmpHeader     = []byte("http://ns.adobe.com/xap/1.0/\x00")
+	xmpExtHeader  = []byte("http://ns.adobe.com/xmp/extension/\x00")
+	pngSignature  = []byte("\x89PNG\r\n\x1a\n")
+	pngXMPKeyword = []byte("XML:com.adobe.xmp\x00")
+)
+
+const (
+	tagOrientation = 0x0112
+	tagGPSInfo     = 0x8825
+)
+
+// jpegSegments calls fn with the marker and byte range of each JPEG segment
+// before the image data. It stops early when fn returns false. The returned
+// offset is where the image data starts, or -1 if the file is malformed.
+func jpegSegments(data []byte, fn func(marker byte, start, end int) bool) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return -1
+	}
+	i := 2
+	for i+4 <= len(data) {
+		if data[i] != 0xFF {
+			return -1
+		}
+		marker := data[i+1]
+		if marker == 0xFF { // fill byte
+			i++
+			continue
+		}
+		if marker == 0xDA { // start of scan: image data follows
+			return i
+		}
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		if length < 2 || i+2+length > len(data) {
+			return -1
+		}
+		if !fn(marker, i, i+2+length) {
+			return i
+		}
+		i += 2 + length
+	}
+	return -1
+}
+
+// jpegOrientation returns the EXIF orientation, 1 (upright) if there is none
+func jpegOrientation(data []byte) int {
+	orientation := 1
+	jpegSegments(data, func(marker byte, start, end int) bool {
+		payload := data[start+4 : end]
+		if marker != 0xE1 || !bytes.HasPrefix(payload, exifHeader) {
+			return true
+		}
+		t, ifd0, ok := parseTIFF(payload[len(exifHeader):])
+		if !ok {
+			return false
+		}
+		t.eachEntry(ifd0, func(entry int) {
+			if t.order.Uint16(t.b[entry:]) == tagOrientation {
+				if v := int(t.order.Uint16(t.b[entry+8:])); v >= 1 && v <= 8 {
+					orientation = v
+				}
+			}
+		})
+		return false
+	})
+	return orientation
+}
+
+// stripJPEGLocation returns data without XMP segments and with the EXIF GPS
+// directory erased. It reports false for a file it cannot parse: the decoder
+// skips stray bytes between segments, so such a file can still be a valid
+// image with its location in it.
+func stripJPEGLocation(data []byte) ([]byte, bool) {
+	out := make([]byte, 0, len(data))
+	out = append(out, data[:2]...)
+	sos := jpegSegments(data, func(marker byte, start, end int) bool {
+		payload := data[start+4 : end]
+		if marker == 0xE1 {
+			switch {
+			case bytes.HasPrefix(payload, xmpHeader), bytes.HasPrefix(payload, xmpExtHeader):
+				return true
+			case bytes.HasPrefix(payload, exifHeader):
+				segment := append([]byte(nil), data[start:end]...)
+				if t, ifd0, ok := parseTIFF(segment[4+len(exifHeader):]); ok {
+					t.eraseGPS(ifd0)
+				}
+				out = append(out, segment...)
+				return true
+			}
+		}
+		out = append(out, data[start:end]...)
+		return true
+	})
+	if sos < 0 {
+		return nil, false
+	}
+	return append(out, data[sos:]...), true
+}
+
+// stripPNGLocation drops eXIf chunks and XMP text chunks. It reports false
+// for a file it cannot parse up to IEND.
+func stripPNGLocation(data []byte) ([]byte, bool) {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil, false
+	}
+	out := append([]byte(nil), pngSignature...)
+	for i := len(pngSignature); i+12 <= len(data); {
+		length := int(binary.BigEndian.Uint32(data[i:]))
+		end := i + 12 + length
+		if length < 0 || end > len(data) {
+			return nil, false
+		}
+		kind := string(data[i+4 : i+8])
+		body := data[i+8 : i+8+length]
+		drop := kind == "eXIf" || (kind == "iTXt" && bytes.HasPrefix(body, pngXMPKeyword))
+		if !drop {
+			out = append(out, data[i:end]...)
+		}
+		i = end
+		if kind == "IEND" {
+			return out, true
+		}
+	}
+	return nil, false
+}
+
+// tiff is the TIFF structure inside an EXIF segment
+type tiff struct {
+	b     []byte
+	order binary.ByteOrder
+}
+
+// parseTIFF reads the TIFF header and returns the offset of IFD0
+func parseTIFF(b []byte) (*tiff, int, bool) {
+	if len(b) < 8 {
+		return nil, 0, false
+	}
+	t := &tiff{b: b}
+	switch string(b[:2]) {
+	case "II":
+		t.order = binary.LittleEndian
+	case "MM":
+		t.order = binary.BigEndian
+	default:
+		return nil, 0, false
+	}
+	if t.order.Uint16(b[2:]) != 42 {
+		return nil, 0, false
+	}
+	return t, int(t.order.Uint32(b[4:])), true
+}
+
+// eachEntry calls fn with the offset of each 12 byte entry of the IFD at off
+func (t *tiff) eachEntry(off int, fn func(entry int)) {
+	if off < 8 || off+2 > len(t.b) {
+		return
+	}
+	n := int(t.order.Uint16(t.b[off:]))
+	for i := 0; i < n; i++ {
+		entry := off + 2 + 12*i
+		if entry+12 > len(t.b) {
+			return
+		}
+		fn(entry)
+	}
+}
+
+// typeSizes are the byte sizes of TIFF field types 1-12
+var typeSizes = [...]int{0, 1, 1, 2, 4, 8, 1, 1, 2, 4, 8, 4, 8}
+
+// eraseGPS zeroes every entry of the GPS directory and the values they point
+// to, leaving an empty directory so offsets elsewhere stay valid
+func (t *tiff) eraseGPS(ifd0 int) {
+	gps := -1
+	t.eachEntry(ifd0, func(entry int) {
+		if t.order.Uint16(t.b[entry:]) == tagGPSInfo {
+			gps = int(t.order.Uint32(t.b[entry+8:]))
+		}
+	})
+	if gps < 0 {
+		return
+	}
+	t.eachEntry(gps, func(entry int) {
+		kind := int(t.order.Uint16(t.b[entry+2:]))
+		count := int(t.order.Uint32(t.b[entry+4:]))
+		if kind > 0 && kind < len(typeSizes) {
+			if size := typeSizes[kind] * count; size > 4 && count > 0 {
+				off := int(t.order.Uint32(t.b[entry+8:]))
+				if off >= 8 && off+size <= len(t.b) {
+					clear(t.b[off : off+size])
+				}
+			}
+		}
+		clear(t.b[entry : entry+12])
+	})
+	if gps+2 <= len(t.b) {
+		t.order.PutUint16(t.b[gps:], 0)
+	}
+}
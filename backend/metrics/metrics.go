@@ -0,0 +1,150 @@
+// Package metrics defines the Prometheus collectors exposed on /metrics:
+// HTTP request latency, MongoDB command latency and errors, and counters for
+// business events such as registrations and reviews.
+package metrics
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "gosmooth"
+
+// Registry holds every collector; a private registry keeps the output free of
+// anything other packages might register globally.
+var Registry = prometheus.NewRegistry()
+
+var (
+	// HTTPRequestDuration is labelled by route template, not the raw path,
+	// so ids in URLs do not explode the number of series.
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method, route template and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	HTTPRequestsInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "http_requests_in_flight",
+		Help:      "HTTP requests currently being served.",
+	})
+
+	MongoCommandDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "mongodb_command_duration_seconds",
+		Help:      "MongoDB command latency by collection and command.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"collection", "command"})
+
+	MongoCommandErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "mongodb_command_errors_total",
+		Help:      "Failed MongoDB commands by collection and command.",
+	}, []string{"collection", "command"})
+)
+
+// Business event counters
+var (
+	Registrations = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "registrations_total",
+		Help:      "Accounts registered.",
+	})
+
+	// Logins is labelled with result="success", "failure" or "password_change"
+	Logins = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "logins_total",
+		Help:      "Login attempts by result.",
+	}, []string{"result"})
+
+	ReviewsCreated = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reviews_created_total",
+		Help:      "Reviews created.",
+	})
+
+	// PostsHeld is labelled with the kind of post (review or comment) and
+	// each screening rule that held it
+	PostsHeld = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "posts_held_total",
+		Help:      "Reviews and comments held for moderation by screening rule.",
+	}, []string{"kind", "rule"})
+
+	ReportsFiled = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "review_reports_filed_total",
+		Help:      "Review reports filed by users.",
+	})
+
+	Bans = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "user_bans_total",
+		Help:      "Users banned by an admin.",
+	})
+
+	// Uploads is labelled with the image type (cover, highlight or review)
+	Uploads = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "uploads_total",
+		Help:      "Images uploaded by type.",
+	}, []string{"type"})
+
+	// RateLimited is labelled with the rate limit policy that refused the request
+	RateLimited = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_limited_requests_total",
+		Help:      "Requests refused by a rate limit policy.",
+	}, []string{"policy"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequestDuration,
+		HTTPRequestsInFlight,
+		MongoCommandDuration,
+		MongoCommandErrors,
+		Registrations,
+		Logins,
+		ReviewsCreated,
+		PostsHeld,
+		ReportsFiled,
+		Bans,
+		Uploads,
+		RateLimited,
+	)
+	// ให้ label ที่รู้ค่าล่วงหน้าโผล่เป็น 0 ตั้งแต่เริ่ม แทนที่จะหายไปจนกว่าจะเกิด event แรก
+	Logins.WithLabelValues("success")
+	Logins.WithLabelValues("failure")
+	Logins.WithLabelValues("password_change")
+	Uploads.WithLabelValues("cover")
+	Uploads.WithLabelValues("highlight")
+	Uploads.WithLabelValues("review")
+}
+
+// Handler serves the registry in the Prometheus text format. When token is
+// set, scrapers must send it as "Authorization: Bearer <token>".
+func Handler(token string) http.Handler {
+	h := promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+	if token == "" {
+		return h
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="metrics"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
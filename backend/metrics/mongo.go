@@ -0,0 +1,51 @@
+package metrics
+
+import (
+	"context"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+// MongoMonitor returns a command monitor that records latency and errors per
+// collection. Pass it to options.Client().SetMonitor.
+func MongoMonitor() *event.CommandMonitor {
+	var started sync.Map // request id -> collection name
+
+	finish := func(requestID int64, command string, seconds float64, failed bool) {
+		collection := "unknown"
+		if v, ok := started.LoadAndDelete(requestID); ok {
+			collection = v.(string)
+		}
+		MongoCommandDuration.WithLabelValues(collection, command).Observe(seconds)
+		if failed {
+			MongoCommandErrors.WithLabelValues(collection, command).Inc()
+		}
+	}
+
+	return &event.CommandMonitor{
+		Started: func(_ context.Context, e *event.CommandStartedEvent) {
+			started.Store(e.RequestID, commandCollection(e.CommandName, e.Command))
+		},
+		Succeeded: func(_ context.Context, e *event.CommandSucceededEvent) {
+			finish(e.RequestID, e.CommandName, e.Duration.Seconds(), false)
+		},
+		Failed: func(_ context.Context, e *event.CommandFailedEvent) {
+			finish(e.RequestID, e.CommandName, e.Duration.Seconds(), true)
+		},
+	}
+}
+
+// commandCollection extracts the target collection from a command document,
+// e.g. {find: "places"} or {getMore: <cursor>, collection: "places"}.
+func commandCollection(name string, cmd bson.Raw) string {
+	if coll, ok := cmd.Lookup(name).StringValueOK(); ok {
+		return coll
+	}
+	if coll, ok := cmd.Lookup("collection").StringValueOK(); ok {
+		return coll
+	}
+	// คำสั่งระดับ database เช่น ping, hello, endSessions
+	return "admin"
+}
@@ -3,7 +3,6 @@ package middleware
 import (
 	"errors"
 	"net/http"
-	"os"
 	"strings"
 	"time"
 
@@ -14,6 +13,7 @@ import (
 	"go.mongodb.org/mongo-driver/mongo"
 
 	"gosmooth/models"
+	"gosmooth/problem"
 )
 
 var db *mongo.Database
@@ -23,10 +23,31 @@ func SetDB(database *mongo.Database) {
 	db = database
 }
 
+// Token signing settings, set from the loaded config via SetAuthConfig
+var (
+	jwtSecret     []byte
+	tokenTTL      = 24 * time.Hour
+	rememberMeTTL = 7 * 24 * time.Hour
+)
+
+// ErrNoSigningKey is returned when tokens are issued before a secret is configured
+var ErrNoSigningKey = errors.New("JWT secret is not configured")
+
+// SetAuthConfig sets the JWT signing secret and token lifetimes
+func SetAuthConfig(secret string, ttl, rememberMe time.Duration) {
+	jwtSecret = []byte(secret)
+	tokenTTL = ttl
+	rememberMeTTL = rememberMe
+}
+
 func GenerateToken(userID string, rememberMe bool) (string, error) {
-	expirationTime := time.Hour * 24 // 24 hours
+	// ห้ามออก token ที่เซ็นด้วย key ว่าง เพราะใครก็ปลอมได้
+	if len(jwtSecret) == 0 {
+		return "", ErrNoSigningKey
+	}
+	expirationTime := tokenTTL
 	if rememberMe {
-		expirationTime = time.Hour * 24 * 7 // 7 days
+		expirationTime = rememberMeTTL
 	}
 
 	claims := jwt.MapClaims{
@@ -35,7 +56,29 @@ func GenerateToken(userID string, rememberMe bool) (string, error) {
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(os.Getenv("JWT_SECRET")))
+	return token.SignedString(jwtSecret)
+}
+
+// passwordChangeTTL is the lifetime of a token that may only change the password
+const passwordChangeTTL = 15 * time.Minute
+
+// scopePasswordChange marks a token issued to an account that must change its
+// password before it can use the rest of the API
+const scopePasswordChange = "password_change"
+
+// GeneratePasswordChangeToken issues a short-lived token that RequireAuth only
+// accepts on routes mounted with AllowPasswordChange.
+func GeneratePasswordChangeToken(userID string) (string, error) {
+	if len(jwtSecret) == 0 {
+		return "", ErrNoSigningKey
+	}
+	claims := jwt.MapClaims{
+		"user_id": userID,
+		"scope":   scopePasswordChange,
+		"exp":     time.Now().Add(passwordChangeTTL).Unix(),
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(jwtSecret)
 }
 
 func ValidateToken(tokenString string) (*jwt.Token, error) {
@@ -43,11 +86,35 @@ func ValidateToken(tokenString string) (*jwt.Token, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, errors.New("unexpected signing method")
 		}
-		return []byte(os.Getenv("JWT_SECRET")), nil
+		if len(jwtSecret) == 0 {
+			return nil, ErrNoSigningKey
+		}
+		return jwtSecret, nil
 	})
 }
 
-func RequireAuth() gin.HandlerFunc {
+// AuthOption adjusts what RequireAuth accepts
+type AuthOption func(*authOptions)
+
+type authOptions struct {
+	allowPasswordChange bool
+	// scopes are the API key scopes accepted; none means API keys are refused
+	scopes []string
+}
+
+// AllowPasswordChange lets password-change tokens through, for the few
+// routes an account needs while it is forced to pick a new password.
+func AllowPasswordChange(o *authOptions) {
+	o.allowPasswordChange = true
+}
+
+// RequireAuth accepts a user's JWT from the Authorization header or the token
+// cookie, or an API key when the route is mounted with Scope.
+func RequireAuth(opts ...AuthOption) gin.HandlerFunc {
+	var o authOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		var tokenString string
@@ -57,6 +124,10 @@ func RequireAuth() gin.HandlerFunc {
 				tokenString = parts[1]
 			}
 		}
+		if rawKey := apiKeyFromRequest(c, tokenString); rawKey != "" {
+			authenticateAPIKey(c, rawKey, o)
+			return
+		}
 		if tokenString == "" {
 			// ลองอ่านจาก cookie
 			cookie, err := c.Cookie("token")
@@ -65,52 +136,80 @@ func RequireAuth() gin.HandlerFunc {
 			}
 		}
 		if tokenString == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "authorization token required"})
-			c.Abort()
+			problem.Abort(c, http.StatusUnauthorized, problem.CodeTokenRequired)
 			return
 		}
 
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.ErrSignatureInvalid
-			}
-			return []byte(os.Getenv("JWT_SECRET")), nil
-		})
+		token, err := ValidateToken(tokenString)
 
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
-			c.Abort()
+			problem.Abort(c, http.StatusUnauthorized, problem.CodeInvalidToken)
 			return
 		}
 
 		if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-			userID := claims["user_id"].(string)
+			userID, _ := claims["user_id"].(string)
+			if scope, _ := claims["scope"].(string); scope == scopePasswordChange {
+				if !o.allowPasswordChange {
+					problem.Abort(c, http.StatusForbidden, problem.CodePasswordChangeRequired)
+					return
+				}
+				c.Set("passwordChangeOnly", true)
+			}
 			c.Set("userID", userID)
 			c.Next()
 		} else {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token claims"})
-			c.Abort()
+			problem.Abort(c, http.StatusUnauthorized, problem.CodeInvalidToken)
 			return
 		}
 	}
 }
 
-func RequireAdmin() gin.HandlerFunc {
+// OptionalAuth sets userID like RequireAuth when the request carries a valid
+// user token and otherwise lets it through anonymously, for public routes
+// that show signed-in callers a little more. API keys are not read.
+func OptionalAuth() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		userID := c.GetString("userID")
-		var user models.User
-		objectID, err := primitive.ObjectIDFromHex(userID)
-		if err != nil {
-			c.JSON(http.StatusForbidden, gin.H{"error": "admin access required"})
-			c.Abort()
-			return
+		tokenString, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok {
+			tokenString, _ = c.Cookie("token")
 		}
-		err = db.Collection("users").FindOne(c, bson.M{"_id": objectID}).Decode(&user)
-		if err != nil || user.Role != "admin" {
-			c.JSON(http.StatusForbidden, gin.H{"error": "admin access required"})
-			c.Abort()
+		if tokenString != "" {
+			token, err := ValidateToken(tokenString)
+			if err == nil && token.Valid {
+				claims, _ := token.Claims.(jwt.MapClaims)
+				if scope, _ := claims["scope"].(string); scope != scopePasswordChange {
+					userID, _ := claims["user_id"].(string)
+					c.Set("userID", userID)
+				}
+			}
+		}
+		c.Next()
+	}
+}
+
+func RequireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !IsAdmin(c) {
+			problem.Abort(c, http.StatusForbidden, problem.CodeAdminRequired)
 			return
 		}
 		c.Next()
 	}
 }
+
+// IsAdmin reports whether the request's user, set by RequireAuth, is an
+// admin. The answer is cached on the request.
+func IsAdmin(c *gin.Context) bool {
+	if v, ok := c.Get("isAdmin"); ok {
+		return v.(bool)
+	}
+	isAdmin := false
+	if objectID, err := primitive.ObjectIDFromHex(c.GetString("userID")); err == nil {
+		var user models.User
+		err := db.Collection("users").FindOne(c, bson.M{"_id": objectID}).Decode(&user)
+		isAdmin = err == nil && user.Role == "admin"
+	}
+	c.Set("isAdmin", isAdmin)
+	return isAdmin
+}
@@ -0,0 +1,78 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func init() {
+	register(Migration{
+		Version: 1,
+		Name:    "initial_indexes",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			collections := map[string][]mongo.IndexModel{
+				"users": {
+					{
+						Keys:    bson.D{{Key: "email", Value: 1}},
+						Options: options.Index().SetName("email_1").SetUnique(true),
+					},
+				},
+				"reviews": {
+					{
+						Keys:    bson.D{{Key: "user_id", Value: 1}},
+						Options: options.Index().SetName("user_id_1"),
+					},
+				},
+				"route_suggestions": {
+					{
+						Keys:    bson.D{{Key: "user_id", Value: 1}},
+						Options: options.Index().SetName("user_id_1"),
+					},
+				},
+				"places": {
+					{
+						Keys:    bson.D{{Key: "name", Value: 1}},
+						Options: options.Index().SetName("name_1"),
+					},
+				},
+			}
+
+			// CreateMany is a no-op for indexes that already exist with the same spec
+			for collection, indexes := range collections {
+				if _, err := db.Collection(collection).Indexes().CreateMany(ctx, indexes); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			drops := map[string]string{
+				"users":             "email_1",
+				"reviews":           "user_id_1",
+				"route_suggestions": "user_id_1",
+				"places":            "name_1",
+			}
+			for collection, index := range drops {
+				if err := dropIndexIfExists(ctx, db.Collection(collection), index); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+}
+
+// dropIndexIfExists keeps Down steps idempotent
+func dropIndexIfExists(ctx context.Context, coll *mongo.Collection, name string) error {
+	_, err := coll.Indexes().DropOne(ctx, name)
+	var cmdErr mongo.CommandError
+	if err != nil && errors.As(err, &cmdErr) && (cmdErr.Code == 27 || cmdErr.Code == 26) {
+		// 27 IndexNotFound, 26 NamespaceNotFound
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,62 @@
+package migrations
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Early versions stored places with a string "id" and no ObjectID _id. This
+// step re-inserts each of them with a real ObjectID and keeps the old id in
+// place_id. It is safe to re-run after a crash between insert and delete.
+func init() {
+	register(Migration{
+		Version: 2,
+		Name:    "places_object_ids",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			cursor, err := db.Collection("places").Find(ctx, bson.M{})
+			if err != nil {
+				return err
+			}
+			defer cursor.Close(ctx)
+			for cursor.Next(ctx) {
+				var place bson.M
+				if err := cursor.Decode(&place); err != nil {
+					return err
+				}
+				// ถ้ายังไม่มี _id เป็น ObjectID
+				if _, ok := place["_id"].(primitive.ObjectID); ok {
+					continue
+				}
+				idStr, _ := place["id"].(string)
+				if idStr == "" {
+					continue
+				}
+				// _id แก้ไขไม่ได้ จึงต้องสร้าง document ใหม่แล้วลบของเดิม
+				oldID := place["_id"]
+				count, err := db.Collection("places").CountDocuments(ctx, bson.M{
+					"place_id": idStr,
+					"_id":      bson.M{"$type": "objectId"},
+				})
+				if err != nil {
+					return err
+				}
+				if count == 0 {
+					place["_id"] = primitive.NewObjectID()
+					place["place_id"] = idStr
+					if _, err := db.Collection("places").InsertOne(ctx, place); err != nil {
+						return err
+					}
+				}
+				if _, err := db.Collection("places").DeleteOne(ctx, bson.M{"_id": oldID}); err != nil {
+					return err
+				}
+			}
+			return cursor.Err()
+		},
+		// The original ids are kept in place_id, so there is nothing to undo.
+		Down: func(ctx context.Context, db *mongo.Database) error { return nil },
+	})
+}
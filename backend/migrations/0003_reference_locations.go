@@ -0,0 +1,64 @@
+package migrations
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"gosmooth/models"
+)
+
+// referenceLocations are the provinces places are grouped under. Unlike the
+// demo data in package seed they are required in every environment.
+var referenceLocations = []models.Location{
+	{ID: "1", Name: "กรุงเทพมหานคร", Description: "กรุงเทพมหานคร เมืองหลวงและศูนย์กลางทางเศรษฐกิจ การเมือง และวัฒนธรรมของประเทศไทย เต็มไปด้วยวัดและพระบรมมหาราชวังสำคัญอย่างวัดพระแก้ว และแหล่งช็อปปิงทันสมัยเช่น สยามพารากอน พร้อมด้วยระบบขนส่งมวลชนทั้ง BTS, MRT และเรือคลอง"},
+	{ID: "2", Name: "เชียงใหม่", Description: "เชียงใหม่ เมืองใหญ่ในภาคเหนือของไทย มีประวัติศาสตร์ยาวนานกับโบราณสถานอย่างวัดพระสิงห์ และวัดเจดีย์หลวง อยู่ท่ามกลางภูเขาและธรรมชาติ สามารถเที่ยวชมสวนดอกไม้ริมดอยอินทนนท์ และสัมผัสวิถีชีวิตชาวเขาเผ่าต่างๆ"},
+	{ID: "3", Name: "เชียงราย", Description: "เชียงราย จังหวัดชายแดนภาคเหนือ ที่มีสถาปัตยกรรมร่วมสมัยของวัดร่องขุ่น และวัดร่องเสือเต้น อีกทั้งยังเป็นประตูสู่สามเหลี่ยมทองคำ สามารถล่องเรือชมแม่น้ำแม่โขง และขึ้นภูชี้ฟ้าเพื่อชมทะเลหมอก"},
+	{ID: "4", Name: "บุรีรัมย์", Description: "บุรีรัมย์ จังหวัดในภาคอีสานที่มีปราสาทหินพนมรุ้งเป็นแหล่งมรดกทางวัฒนธรรม และสนามฟุตบอลบุรีรัมย์ยูไนเต็ด เป็นศูนย์รวมกีฬาสำคัญ นอกจากนี้ยังมีพิพิธภัณฑ์สถานแสดงเรื่องราวประวัติศาสตร์สุวรรณภูมิ"},
+	{ID: "5", Name: "อุบลราชธานี", Description: "อุบลราชธานี จังหวัดริมแม่น้ำโขง มีประเพณีแห่เทียนพรรษาที่ยิ่งใหญ่ วัดทุ่งศรีเมือง ซีเมตตาธรรมสถาน และเป็นทางผ่านสู่แก่งหินผาฮีในฤดูน้ำหลาก"},
+	{ID: "6", Name: "ระยอง", Description: "ระยอง จังหวัดชายฝั่งทะเลตะวันออก มีชายหาดยอดนิยมอย่างหาดแหลมแม่พิมพ์ เกาะเสม็ด และพิพิธภัณฑ์สัตว์น้ำ สถานตากอากาศที่ผสมผสานเกษตรกรรมกับการท่องเที่ยวทางทะเล"},
+	{ID: "7", Name: "ชลบุรี", Description: "ชลบุรี จังหวัดชายฝั่งทะเลตะวันออก มีเมืองท่องเที่ยวชื่อดังอย่างพัทยา สวนน้ำ และเกาะล้าน นอกจากนี้ยังเป็นศูนย์กลางอุตสาหกรรมและท่าเรือสำคัญของประเทศไทย"},
+	{ID: "8", Name: "กาญจนบุรี", Description: "กาญจนบุรี จังหวัดที่มีประวัติศาสตร์สงครามโลกครั้งที่ 2 กับสะพานข้ามแม่น้ำแคว อุทยานแห่งชาติน้ำตกเอราวัณ และเส้นทางเที่ยวน้ำตกไทรโยคน้อย"},
+	{ID: "9", Name: "กระบี่", Description: "กระบี่ จังหวัดในภาคใต้ที่มีชายหาดขาว เกาะแก่งหินปูนสูงชัน เช่น อ่าวนาง, อ่าวมาหยา รวมถึงอุทยานแห่งชาติหาดนพรัตน์ธารา-หมู่เกาะพีพี"},
+	{ID: "10", Name: "พังงา", Description: "พังงา จังหวัดชายฝั่งอันดามัน มีอ่าวพังงาเกาะเจมส์บอนด์ (เกาะตาปู) ถ้ำลอดเขาพิงกัน และอุทยานแห่งชาติหมู่เกาะสิมิลัน"},
+	{ID: "11", Name: "สุราษฎร์ธานี", Description: "สุราษฎร์ธานี จังหวัดภาคใต้ที่เป็นทางผ่านไปยังเกาะสมุย เกาะพะงัน และเขื่อนเชี่ยวหลาน มีตลาดน้ำและวิถีชีวิตริมแม่น้ำตาปี"},
+}
+
+func init() {
+	register(Migration{
+		Version: 3,
+		Name:    "reference_locations",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("locations").Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys:    bson.D{{Key: "location_id", Value: 1}},
+				Options: options.Index().SetName("location_id_1").SetUnique(true),
+			})
+			if err != nil {
+				return err
+			}
+			// ใส่เฉพาะจังหวัดที่ยังไม่มี เพื่อไม่ทับข้อมูลที่แอดมินแก้ไขไปแล้ว
+			for _, loc := range referenceLocations {
+				_, err := db.Collection("locations").UpdateOne(ctx,
+					bson.M{"location_id": loc.ID},
+					bson.M{"$setOnInsert": loc},
+					options.Update().SetUpsert(true))
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			ids := make([]string, 0, len(referenceLocations))
+			for _, loc := range referenceLocations {
+				ids = append(ids, loc.ID)
+			}
+			if _, err := db.Collection("locations").DeleteMany(ctx, bson.M{"location_id": bson.M{"$in": ids}}); err != nil {
+				return err
+			}
+			return dropIndexIfExists(ctx, db.Collection("locations"), "location_id_1")
+		},
+	})
+}
@@ -0,0 +1,31 @@
+package migrations
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// place_id is the key used by reviews, routes and bulk import upserts, so it
+// must be unique. Documents without a place_id are left out of the index.
+func init() {
+	register(Migration{
+		Version: 4,
+		Name:    "unique_place_ids",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("places").Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys: bson.D{{Key: "place_id", Value: 1}},
+				Options: options.Index().
+					SetName("place_id_1").
+					SetUnique(true).
+					SetPartialFilterExpression(bson.M{"place_id": bson.M{"$type": "string"}}),
+			})
+			return err
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			return dropIndexIfExists(ctx, db.Collection("places"), "place_id_1")
+		},
+	})
+}
@@ -0,0 +1,47 @@
+package migrations
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// demoAdminEmail and demoAdminPassword are what older seeds created
+const (
+	demoAdminEmail    = "Admin001@go-smooth.co.th"
+	demoAdminPassword = "goadmin7"
+)
+
+// Databases seeded before the password policy still have the demo admin with
+// its published password. Force a change at next login if it was never
+// changed; accounts with any other password are left alone.
+func init() {
+	register(Migration{
+		Version: 5,
+		Name:    "expire_demo_admin_password",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			var admin struct {
+				Password string `bson:"password"`
+			}
+			err := db.Collection("users").FindOne(ctx, bson.M{"email": demoAdminEmail}).Decode(&admin)
+			if err == mongo.ErrNoDocuments {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if bcrypt.CompareHashAndPassword([]byte(admin.Password), []byte(demoAdminPassword)) != nil {
+				return nil
+			}
+			_, err = db.Collection("users").UpdateOne(ctx, bson.M{"email": demoAdminEmail},
+				bson.M{"$set": bson.M{"must_change_password": true}})
+			return err
+		},
+		// ไม่ย้อนกลับ: การปลดบังคับเปลี่ยนรหัสผ่าน demo จะทำให้ระบบไม่ปลอดภัยอีกครั้ง
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			return nil
+		},
+	})
+}
@@ -0,0 +1,41 @@
+package migrations
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// An OIDC identity may be linked to one account only, and pending sign-ins
+// in oidc_states are removed by MongoDB once they are ten minutes old.
+func init() {
+	register(Migration{
+		Version: 6,
+		Name:    "oidc_identities",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("users").Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys: bson.D{{Key: "identities.provider", Value: 1}, {Key: "identities.subject", Value: 1}},
+				Options: options.Index().
+					SetName("identities_provider_subject").
+					SetUnique(true).
+					SetPartialFilterExpression(bson.M{"identities.subject": bson.M{"$exists": true}}),
+			})
+			if err != nil {
+				return err
+			}
+			_, err = db.Collection("oidc_states").Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys:    bson.D{{Key: "created_at", Value: 1}},
+				Options: options.Index().SetName("created_at_ttl").SetExpireAfterSeconds(600),
+			})
+			return err
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			if err := dropIndexIfExists(ctx, db.Collection("users"), "identities_provider_subject"); err != nil {
+				return err
+			}
+			return db.Collection("oidc_states").Drop(ctx)
+		},
+	})
+}
@@ -0,0 +1,34 @@
+package migrations
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// API keys are looked up by hash on every request that uses one, and listed
+// per owner on the profile page.
+func init() {
+	register(Migration{
+		Version: 7,
+		Name:    "api_keys",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("api_keys").Indexes().CreateMany(ctx, []mongo.IndexModel{
+				{
+					Keys:    bson.D{{Key: "hash", Value: 1}},
+					Options: options.Index().SetName("hash_unique").SetUnique(true),
+				},
+				{
+					Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}},
+					Options: options.Index().SetName("user_id_created_at"),
+				},
+			})
+			return err
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			return db.Collection("api_keys").Drop(ctx)
+		},
+	})
+}
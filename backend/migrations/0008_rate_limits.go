@@ -0,0 +1,28 @@
+package migrations
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Rate limit buckets kept in MongoDB are removed once they have refilled;
+// a full bucket is the same as a missing one.
+func init() {
+	register(Migration{
+		Version: 8,
+		Name:    "rate_limits_ttl",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("rate_limits").Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys:    bson.D{{Key: "expires_at", Value: 1}},
+				Options: options.Index().SetName("expires_at_ttl").SetExpireAfterSeconds(0),
+			})
+			return err
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			return db.Collection("rate_limits").Drop(ctx)
+		},
+	})
+}
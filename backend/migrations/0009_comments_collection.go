@@ -0,0 +1,143 @@
+package migrations
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Comments used to be embedded in their review, which grew without bound and
+// shipped with every review listing. This step moves them to the comments
+// collection, keeping their ids, and stores the count on the review instead.
+// Comments are upserted by id before the array is removed, so an interrupted
+// run can simply be repeated.
+func init() {
+	register(Migration{
+		Version: 9,
+		Name:    "comments_collection",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("comments").Indexes().CreateMany(ctx, []mongo.IndexModel{
+				{
+					Keys:    bson.D{{Key: "review_id", Value: 1}, {Key: "parent_id", Value: 1}, {Key: "created_at", Value: 1}},
+					Options: options.Index().SetName("review_id_parent_id_created_at"),
+				},
+				{
+					Keys:    bson.D{{Key: "user_id", Value: 1}},
+					Options: options.Index().SetName("user_id"),
+				},
+				{
+					Keys: bson.D{{Key: "flag_count", Value: -1}, {Key: "created_at", Value: 1}},
+					Options: options.Index().
+						SetName("flagged").
+						SetPartialFilterExpression(bson.M{"flag_count": bson.M{"$gt": 0}}),
+				},
+			})
+			if err != nil {
+				return err
+			}
+
+			cursor, err := db.Collection("reviews").Find(ctx, bson.M{"comments": bson.M{"$exists": true}})
+			if err != nil {
+				return err
+			}
+			defer cursor.Close(ctx)
+			for cursor.Next(ctx) {
+				var review struct {
+					ID       primitive.ObjectID `bson:"_id"`
+					Comments []bson.M           `bson:"comments"`
+				}
+				if err := cursor.Decode(&review); err != nil {
+					return err
+				}
+				var writes []mongo.WriteModel
+				for _, cmt := range review.Comments {
+					id, ok := cmt["_id"].(primitive.ObjectID)
+					if !ok {
+						id = primitive.NewObjectID()
+						cmt["_id"] = id
+					}
+					if cmt["liked_by"] == nil {
+						cmt["liked_by"] = bson.A{}
+					}
+					cmt["review_id"] = review.ID
+					cmt["status"] = "visible"
+					cmt["reply_count"] = 0
+					cmt["flag_count"] = 0
+					writes = append(writes, mongo.NewReplaceOneModel().SetFilter(bson.M{"_id": id}).SetReplacement(cmt).SetUpsert(true))
+				}
+				if len(writes) > 0 {
+					if _, err := db.Collection("comments").BulkWrite(ctx, writes); err != nil {
+						return err
+					}
+				}
+				_, err := db.Collection("reviews").UpdateOne(ctx, bson.M{"_id": review.ID}, bson.M{
+					"$set":   bson.M{"comment_count": len(review.Comments)},
+					"$unset": bson.M{"comments": ""},
+				})
+				if err != nil {
+					return err
+				}
+			}
+			if err := cursor.Err(); err != nil {
+				return err
+			}
+			_, err = db.Collection("reviews").UpdateMany(ctx,
+				bson.M{"comment_count": bson.M{"$exists": false}},
+				bson.M{"$set": bson.M{"comment_count": 0}})
+			return err
+		},
+		// Down embeds the comments again. Threads are flattened and deleted
+		// comments are dropped, since the embedded array had neither.
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			opts := options.Find().SetSort(bson.D{{Key: "review_id", Value: 1}, {Key: "created_at", Value: 1}})
+			cursor, err := db.Collection("comments").Find(ctx, bson.M{"status": bson.M{"$ne": "deleted"}}, opts)
+			if err != nil {
+				return err
+			}
+			var comments []struct {
+				ID        primitive.ObjectID `bson:"_id"`
+				ReviewID  primitive.ObjectID `bson:"review_id"`
+				UserID    string             `bson:"user_id"`
+				Username  string             `bson:"username"`
+				Text      string             `bson:"text"`
+				Likes     int                `bson:"likes"`
+				LikedBy   []string           `bson:"liked_by"`
+				CreatedAt primitive.DateTime `bson:"created_at"`
+			}
+			if err := cursor.All(ctx, &comments); err != nil {
+				return err
+			}
+			byReview := map[primitive.ObjectID]bson.A{}
+			for _, cmt := range comments {
+				byReview[cmt.ReviewID] = append(byReview[cmt.ReviewID], bson.M{
+					"_id":        cmt.ID,
+					"user_id":    cmt.UserID,
+					"username":   cmt.Username,
+					"text":       cmt.Text,
+					"likes":      cmt.Likes,
+					"liked_by":   cmt.LikedBy,
+					"created_at": cmt.CreatedAt,
+				})
+			}
+			for reviewID, embedded := range byReview {
+				if _, err := db.Collection("reviews").UpdateOne(ctx, bson.M{"_id": reviewID},
+					bson.M{"$set": bson.M{"comments": embedded}}); err != nil {
+					return err
+				}
+			}
+			if _, err := db.Collection("reviews").UpdateMany(ctx,
+				bson.M{"comments": bson.M{"$exists": false}},
+				bson.M{"$set": bson.M{"comments": bson.A{}}}); err != nil {
+				return err
+			}
+			if _, err := db.Collection("reviews").UpdateMany(ctx, bson.M{},
+				bson.M{"$unset": bson.M{"comment_count": ""}}); err != nil {
+				return err
+			}
+			return db.Collection("comments").Drop(ctx)
+		},
+	})
+}
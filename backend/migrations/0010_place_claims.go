@@ -0,0 +1,55 @@
+package migrations
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Place claims: a user may have only one pending claim per place, admins
+// work the queue by status, and owners look up the places they own.
+func init() {
+	register(Migration{
+		Version: 10,
+		Name:    "place_claims",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("place_claims").Indexes().CreateMany(ctx, []mongo.IndexModel{
+				{
+					Keys: bson.D{{Key: "place_id", Value: 1}, {Key: "user_id", Value: 1}},
+					Options: options.Index().SetName("pending_claim_unique").SetUnique(true).
+						SetPartialFilterExpression(bson.M{"status": "pending"}),
+				},
+				{
+					Keys:    bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: 1}},
+					Options: options.Index().SetName("status_created_at"),
+				},
+				{
+					Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}},
+					Options: options.Index().SetName("user_id_created_at"),
+				},
+			})
+			if err != nil {
+				return err
+			}
+			_, err = db.Collection("places").Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys:    bson.D{{Key: "owner_id", Value: 1}},
+				Options: options.Index().SetName("owner_id").SetSparse(true),
+			})
+			return err
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			if err := dropIndexIfExists(ctx, db.Collection("places"), "owner_id"); err != nil {
+				return err
+			}
+			if _, err := db.Collection("places").UpdateMany(ctx, bson.M{}, bson.M{"$unset": bson.M{"owner_id": ""}}); err != nil {
+				return err
+			}
+			if _, err := db.Collection("reviews").UpdateMany(ctx, bson.M{}, bson.M{"$unset": bson.M{"response": ""}}); err != nil {
+				return err
+			}
+			return db.Collection("place_claims").Drop(ctx)
+		},
+	})
+}
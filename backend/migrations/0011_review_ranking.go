@@ -0,0 +1,123 @@
+package migrations
+
+import (
+	"context"
+	"time"
+	"unicode/utf8"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"gosmooth/ranking"
+)
+
+// Review ranking: reviews get not-helpful votes and a stored rank score for
+// the "relevant" sort, and users get the vote totals of their reviews as
+// author reputation. Scores are backfilled with the default half-life; run
+// "gosmooth reviews rerank" afterwards if REVIEW_RANK_HALF_LIFE differs.
+func init() {
+	register(Migration{
+		Version: 11,
+		Name:    "review_ranking",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("reviews").Indexes().CreateMany(ctx, []mongo.IndexModel{
+				{
+					Keys:    bson.D{{Key: "rank_score", Value: -1}, {Key: "created_at", Value: -1}},
+					Options: options.Index().SetName("rank_score_created_at"),
+				},
+				{
+					Keys:    bson.D{{Key: "place_id", Value: 1}, {Key: "rank_score", Value: -1}, {Key: "created_at", Value: -1}},
+					Options: options.Index().SetName("place_id_rank_score_created_at"),
+				},
+			})
+			if err != nil {
+				return err
+			}
+			_, err = db.Collection("reviews").UpdateMany(ctx,
+				bson.M{"not_helpful_by": bson.M{"$exists": false}},
+				bson.M{"$set": bson.M{"not_helpful": 0, "not_helpful_by": bson.A{}}},
+			)
+			if err != nil {
+				return err
+			}
+
+			cursor, err := db.Collection("reviews").Aggregate(ctx, mongo.Pipeline{
+				{{Key: "$group", Value: bson.M{"_id": "$user_id", "likes": bson.M{"$sum": "$likes"}}}},
+			})
+			if err != nil {
+				return err
+			}
+			var authors []struct {
+				UserID string `bson:"_id"`
+				Likes  int    `bson:"likes"`
+			}
+			if err := cursor.All(ctx, &authors); err != nil {
+				return err
+			}
+			authorLikes := map[string]int{}
+			for _, a := range authors {
+				authorLikes[a.UserID] = a.Likes
+				userObjID, err := primitive.ObjectIDFromHex(a.UserID)
+				if err != nil {
+					continue
+				}
+				_, err = db.Collection("users").UpdateOne(ctx, bson.M{"_id": userObjID},
+					bson.M{"$set": bson.M{"review_likes": a.Likes, "review_not_helpful": 0}})
+				if err != nil {
+					return err
+				}
+			}
+
+			// ยังไม่มีใครกดไม่เป็นประโยชน์ คะแนนจึงคำนวณจากยอดไลก์อย่างเดียว
+			cursor, err = db.Collection("reviews").Find(ctx, bson.M{},
+				options.Find().SetProjection(bson.M{"user_id": 1, "comment": 1, "likes": 1, "photos": 1, "created_at": 1}))
+			if err != nil {
+				return err
+			}
+			defer cursor.Close(ctx)
+			for cursor.Next(ctx) {
+				var review struct {
+					ID        primitive.ObjectID `bson:"_id"`
+					UserID    string             `bson:"user_id"`
+					Comment   string             `bson:"comment"`
+					Likes     int                `bson:"likes"`
+					Photos    bson.A             `bson:"photos"`
+					CreatedAt time.Time          `bson:"created_at"`
+				}
+				if err := cursor.Decode(&review); err != nil {
+					return err
+				}
+				score := ranking.Score(ranking.Review{
+					Likes:       review.Likes,
+					TextLength:  utf8.RuneCountInString(review.Comment),
+					HasPhotos:   len(review.Photos) > 0,
+					AuthorLikes: authorLikes[review.UserID],
+					CreatedAt:   review.CreatedAt,
+				}, ranking.DefaultHalfLife)
+				_, err := db.Collection("reviews").UpdateOne(ctx, bson.M{"_id": review.ID},
+					bson.M{"$set": bson.M{"rank_score": score}})
+				if err != nil {
+					return err
+				}
+			}
+			return cursor.Err()
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			for _, name := range []string{"rank_score_created_at", "place_id_rank_score_created_at"} {
+				if err := dropIndexIfExists(ctx, db.Collection("reviews"), name); err != nil {
+					return err
+				}
+			}
+			_, err := db.Collection("reviews").UpdateMany(ctx, bson.M{},
+				bson.M{"$unset": bson.M{"not_helpful": "", "not_helpful_by": "", "rank_score": ""}})
+			if err != nil {
+				return err
+			}
+			_, err = db.Collection("users").UpdateMany(ctx, bson.M{},
+				bson.M{"$unset": bson.M{"review_likes": "", "review_not_helpful": ""}})
+			return err
+		},
+	})
+}
@@ -0,0 +1,123 @@
+package migrations
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"gosmooth/screening"
+)
+
+// Content screening: reviews get a status so held ones can be kept out of
+// listings, existing reviews and comments are fingerprinted so new posts can
+// be compared with them, and the moderation queues get their indexes.
+func init() {
+	register(Migration{
+		Version: 12,
+		Name:    "content_screening",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("reviews").UpdateMany(ctx,
+				bson.M{"status": bson.M{"$exists": false}},
+				bson.M{"$set": bson.M{"status": "published"}},
+			)
+			if err != nil {
+				return err
+			}
+			_, err = db.Collection("reviews").Indexes().CreateMany(ctx, []mongo.IndexModel{
+				{
+					Keys:    bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: 1}},
+					Options: options.Index().SetName("status_created_at"),
+				},
+				{
+					Keys:    bson.D{{Key: "fingerprint_bands", Value: 1}, {Key: "created_at", Value: -1}},
+					Options: options.Index().SetName("fingerprint_bands_created_at"),
+				},
+			})
+			if err != nil {
+				return err
+			}
+			_, err = db.Collection("comments").Indexes().CreateMany(ctx, []mongo.IndexModel{
+				{
+					Keys: bson.D{{Key: "created_at", Value: 1}},
+					Options: options.Index().
+						SetName("held").
+						SetPartialFilterExpression(bson.M{"status": "held"}),
+				},
+				{
+					Keys:    bson.D{{Key: "fingerprint_bands", Value: 1}, {Key: "created_at", Value: -1}},
+					Options: options.Index().SetName("fingerprint_bands_created_at"),
+				},
+			})
+			if err != nil {
+				return err
+			}
+			if err := fingerprintAll(ctx, db.Collection("reviews"), "comment"); err != nil {
+				return err
+			}
+			return fingerprintAll(ctx, db.Collection("comments"), "text")
+		},
+		// Down lets held reviews back into listings, since the old code has
+		// no notion of them; work the queue before reverting. Held comments
+		// become hidden.
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			for _, name := range []string{"status_created_at", "fingerprint_bands_created_at"} {
+				if err := dropIndexIfExists(ctx, db.Collection("reviews"), name); err != nil {
+					return err
+				}
+			}
+			for _, name := range []string{"held", "fingerprint_bands_created_at"} {
+				if err := dropIndexIfExists(ctx, db.Collection("comments"), name); err != nil {
+					return err
+				}
+			}
+			_, err := db.Collection("reviews").UpdateMany(ctx, bson.M{}, bson.M{"$unset": bson.M{
+				"status": "", "screening": "", "fingerprint": "", "fingerprint_bands": "", "moderated_at": "", "moderated_by": "",
+			}})
+			if err != nil {
+				return err
+			}
+			if _, err := db.Collection("comments").UpdateMany(ctx,
+				bson.M{"status": "held"},
+				bson.M{"$set": bson.M{"status": "hidden"}},
+			); err != nil {
+				return err
+			}
+			_, err = db.Collection("comments").UpdateMany(ctx, bson.M{},
+				bson.M{"$unset": bson.M{"screening": "", "fingerprint": "", "fingerprint_bands": ""}})
+			return err
+		},
+	})
+}
+
+// fingerprintAll stores the screening fingerprint of the text in field on
+// every document of coll that has none yet
+func fingerprintAll(ctx context.Context, coll *mongo.Collection, field string) error {
+	cursor, err := coll.Find(ctx,
+		bson.M{"fingerprint": bson.M{"$exists": false}},
+		options.Find().SetProjection(bson.M{field: 1}))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+	for cursor.Next(ctx) {
+		var doc bson.M
+		if err := cursor.Decode(&doc); err != nil {
+			return err
+		}
+		text, _ := doc[field].(string)
+		sig := screening.Fingerprint(text)
+		if sig == nil {
+			continue
+		}
+		id, _ := doc["_id"].(primitive.ObjectID)
+		_, err := coll.UpdateOne(ctx, bson.M{"_id": id},
+			bson.M{"$set": bson.M{"fingerprint": sig, "fingerprint_bands": sig.Bands()}})
+		if err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
@@ -0,0 +1,181 @@
+package migrations
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// One review per user per place: reviews that name their place by ObjectID
+// are moved to its place_id, and where a user has reviewed a place more than
+// once the most recently updated review stays and the others are moved to
+// reviews_superseded, with their votes taken off the author. Their comments
+// stay behind, unreachable, so Down can bring them back. Run
+// "gosmooth ratings rebuild" afterwards. Check-ins get their indexes.
+func init() {
+	register(Migration{
+		Version: 13,
+		Name:    "one_review_per_place",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			reviews := db.Collection("reviews")
+			cursor, err := db.Collection("places").Find(ctx,
+				bson.M{"place_id": bson.M{"$type": "string"}},
+				options.Find().SetProjection(bson.M{"place_id": 1}))
+			if err != nil {
+				return err
+			}
+			var places []struct {
+				ObjectID primitive.ObjectID `bson:"_id"`
+				PlaceID  string             `bson:"place_id"`
+			}
+			if err := cursor.All(ctx, &places); err != nil {
+				return err
+			}
+			for _, p := range places {
+				if _, err := reviews.UpdateMany(ctx,
+					bson.M{"place_id": p.ObjectID.Hex()},
+					bson.M{"$set": bson.M{"place_id": p.PlaceID}},
+				); err != nil {
+					return err
+				}
+			}
+
+			cursor, err = reviews.Aggregate(ctx, mongo.Pipeline{
+				{{Key: "$sort", Value: bson.D{{Key: "updated_at", Value: -1}, {Key: "_id", Value: -1}}}},
+				{{Key: "$group", Value: bson.M{
+					"_id":   bson.M{"user_id": "$user_id", "place_id": "$place_id"},
+					"ids":   bson.M{"$push": "$_id"},
+					"count": bson.M{"$sum": 1},
+				}}},
+				{{Key: "$match", Value: bson.M{"count": bson.M{"$gt": 1}}}},
+			})
+			if err != nil {
+				return err
+			}
+			var duplicates []struct {
+				IDs []primitive.ObjectID `bson:"ids"`
+			}
+			if err := cursor.All(ctx, &duplicates); err != nil {
+				return err
+			}
+			now := time.Now()
+			for _, d := range duplicates {
+				kept := d.IDs[0]
+				for _, id := range d.IDs[1:] {
+					var review bson.M
+					if err := reviews.FindOne(ctx, bson.M{"_id": id}).Decode(&review); err != nil {
+						return err
+					}
+					review["superseded_by"] = kept
+					review["superseded_at"] = now
+					if _, err := db.Collection("reviews_superseded").InsertOne(ctx, review); err != nil && !mongo.IsDuplicateKeyError(err) {
+						return err
+					}
+					if _, err := reviews.DeleteOne(ctx, bson.M{"_id": id}); err != nil {
+						return err
+					}
+					if err := moveAuthorVotes(ctx, db, review, -1); err != nil {
+						return err
+					}
+				}
+			}
+
+			_, err = reviews.Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "place_id", Value: 1}},
+				Options: options.Index().SetName("user_id_place_id").SetUnique(true),
+			})
+			if err != nil {
+				return err
+			}
+			_, err = db.Collection("check_ins").Indexes().CreateMany(ctx, []mongo.IndexModel{
+				{
+					Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "place_id", Value: 1}},
+					Options: options.Index().SetName("user_id_place_id"),
+				},
+				{
+					Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}},
+					Options: options.Index().SetName("user_id_created_at"),
+				},
+			})
+			return err
+		},
+		// Down brings superseded reviews back; place_ids are left as they are
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			if err := dropIndexIfExists(ctx, db.Collection("reviews"), "user_id_place_id"); err != nil {
+				return err
+			}
+			for _, name := range []string{"user_id_place_id", "user_id_created_at"} {
+				if err := dropIndexIfExists(ctx, db.Collection("check_ins"), name); err != nil {
+					return err
+				}
+			}
+
+			superseded := db.Collection("reviews_superseded")
+			cursor, err := superseded.Find(ctx, bson.M{})
+			if err != nil {
+				return err
+			}
+			defer cursor.Close(ctx)
+			for cursor.Next(ctx) {
+				var review bson.M
+				if err := cursor.Decode(&review); err != nil {
+					return err
+				}
+				delete(review, "superseded_by")
+				delete(review, "superseded_at")
+				if _, err := db.Collection("reviews").InsertOne(ctx, review); err != nil {
+					if mongo.IsDuplicateKeyError(err) {
+						continue
+					}
+					return err
+				}
+				if err := moveAuthorVotes(ctx, db, review, 1); err != nil {
+					return err
+				}
+			}
+			if err := cursor.Err(); err != nil {
+				return err
+			}
+			if err := superseded.Drop(ctx); err != nil {
+				return err
+			}
+			_, err = db.Collection("reviews").UpdateMany(ctx, bson.M{},
+				bson.M{"$unset": bson.M{"verified_visit": ""}})
+			return err
+		},
+	})
+}
+
+// moveAuthorVotes adds the votes of review to its author's totals, or takes
+// them off when sign is -1
+func moveAuthorVotes(ctx context.Context, db *mongo.Database, review bson.M, sign int) error {
+	authorID, _ := review["user_id"].(string)
+	userObjID, err := primitive.ObjectIDFromHex(authorID)
+	if err != nil {
+		return nil
+	}
+	likes, notHelpful := voteCount(review["likes"]), voteCount(review["not_helpful"])
+	if likes == 0 && notHelpful == 0 {
+		return nil
+	}
+	_, err = db.Collection("users").UpdateOne(ctx, bson.M{"_id": userObjID},
+		bson.M{"$inc": bson.M{"review_likes": sign * likes, "review_not_helpful": sign * notHelpful}})
+	return err
+}
+
+// voteCount reads a counter decoded into a bson.M, whichever width it was stored as
+func voteCount(v any) int {
+	switch n := v.(type) {
+	case int32:
+		return int(n)
+	case int64:
+		return int(n)
+	case float64:
+		return int(n)
+	}
+	return 0
+}
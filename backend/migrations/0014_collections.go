@@ -0,0 +1,48 @@
+package migrations
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Collections of saved places: one Favorites per user, enforced by a unique
+// index so concurrent first requests cannot make two, and an index to take
+// a deleted place out of every collection.
+func init() {
+	register(Migration{
+		Version: 14,
+		Name:    "collections",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("collections").Indexes().CreateMany(ctx, []mongo.IndexModel{
+				{
+					Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: 1}},
+					Options: options.Index().SetName("user_id_created_at"),
+				},
+				{
+					Keys: bson.D{{Key: "user_id", Value: 1}},
+					Options: options.Index().
+						SetName("default_per_user").
+						SetUnique(true).
+						SetPartialFilterExpression(bson.M{"is_default": true}),
+				},
+				{
+					Keys:    bson.D{{Key: "places.place_id", Value: 1}},
+					Options: options.Index().SetName("places_place_id"),
+				},
+			})
+			return err
+		},
+		// Down leaves the collections in place
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			for _, name := range []string{"user_id_created_at", "default_per_user", "places_place_id"} {
+				if err := dropIndexIfExists(ctx, db.Collection("collections"), name); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+}
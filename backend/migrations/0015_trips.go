@@ -0,0 +1,40 @@
+package migrations
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Trip plans: a user's trips most recently changed first, and the places on
+// them, both to check a verified visit and to take a deleted place out.
+func init() {
+	register(Migration{
+		Version: 15,
+		Name:    "trips",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("trips").Indexes().CreateMany(ctx, []mongo.IndexModel{
+				{
+					Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "updated_at", Value: -1}},
+					Options: options.Index().SetName("user_id_updated_at"),
+				},
+				{
+					Keys:    bson.D{{Key: "days.stops.place_id", Value: 1}, {Key: "user_id", Value: 1}},
+					Options: options.Index().SetName("stops_place_id_user_id"),
+				},
+			})
+			return err
+		},
+		// Down leaves the trips in place
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			for _, name := range []string{"user_id_updated_at", "stops_place_id_user_id"} {
+				if err := dropIndexIfExists(ctx, db.Collection("trips"), name); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+}
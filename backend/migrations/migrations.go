@@ -0,0 +1,315 @@
+// Package migrations applies numbered, idempotent schema and reference-data
+// changes to MongoDB. Applied versions are recorded in the schema_migrations
+// collection and a lock document keeps concurrent instances from racing.
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	migrationsCollection = "schema_migrations"
+	lockCollection       = "schema_migrations_lock"
+	lockID               = "migrations"
+)
+
+var (
+	// LockTTL is how long a lock is honoured without being refreshed. A crashed
+	// instance therefore blocks others for at most this long.
+	LockTTL = 2 * time.Minute
+	// LockPollInterval is how often a waiting instance retries the lock.
+	LockPollInterval = 500 * time.Millisecond
+)
+
+// ErrLocked is returned when the lock could not be acquired before ctx expired
+var ErrLocked = errors.New("migrations are locked by another instance")
+
+// ErrLockLost is returned when the lock expired or was taken over while
+// migrations were running
+var ErrLockLost = errors.New("migrations lock was lost")
+
+// Migration is a single numbered step. Up and Down must be idempotent so that a
+// step interrupted half-way can simply be run again.
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(ctx context.Context, db *mongo.Database) error
+	Down    func(ctx context.Context, db *mongo.Database) error
+}
+
+// Record is a row of the schema_migrations collection
+type Record struct {
+	Version   int       `bson:"_id" json:"version"`
+	Name      string    `bson:"name" json:"name"`
+	AppliedAt time.Time `bson:"applied_at" json:"applied_at"`
+}
+
+// StatusEntry reports whether a known migration has been applied
+type StatusEntry struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+}
+
+var registry []Migration
+
+// register adds a migration; each migration file calls it from init
+func register(m Migration) {
+	for _, existing := range registry {
+		if existing.Version == m.Version {
+			panic(fmt.Sprintf("migrations: duplicate version %d (%s, %s)", m.Version, existing.Name, m.Name))
+		}
+	}
+	registry = append(registry, m)
+	sort.Slice(registry, func(i, j int) bool { return registry[i].Version < registry[j].Version })
+}
+
+// All returns every known migration in version order
+func All() []Migration {
+	return append([]Migration(nil), registry...)
+}
+
+// Latest returns the highest known migration version
+func Latest() int {
+	if len(registry) == 0 {
+		return 0
+	}
+	return registry[len(registry)-1].Version
+}
+
+// Current returns the highest migration version applied to db, or 0 if none
+// has been. It can differ from Latest when this binary is newer or older than
+// the database.
+func Current(ctx context.Context, db *mongo.Database) (int, error) {
+	var record Record
+	opts := options.FindOne().SetSort(bson.M{"_id": -1})
+	err := db.Collection(migrationsCollection).FindOne(ctx, bson.M{}, opts).Decode(&record)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return 0, nil
+	}
+	return record.Version, err
+}
+
+func applied(ctx context.Context, db *mongo.Database) (map[int]Record, error) {
+	cursor, err := db.Collection(migrationsCollection).Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	var records []Record
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, err
+	}
+	result := make(map[int]Record, len(records))
+	for _, r := range records {
+		result[r.Version] = r
+	}
+	return result, nil
+}
+
+// Status lists every known migration and whether it has been applied
+func Status(ctx context.Context, db *mongo.Database) ([]StatusEntry, error) {
+	done, err := applied(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]StatusEntry, 0, len(registry))
+	for _, m := range registry {
+		entry := StatusEntry{Version: m.Version, Name: m.Name}
+		if r, ok := done[m.Version]; ok {
+			entry.Applied = true
+			appliedAt := r.AppliedAt
+			entry.AppliedAt = &appliedAt
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// Pending returns the migrations that have not been applied yet
+func Pending(ctx context.Context, db *mongo.Database) ([]Migration, error) {
+	done, err := applied(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	var pending []Migration
+	for _, m := range registry {
+		if _, ok := done[m.Version]; !ok {
+			pending = append(pending, m)
+		}
+	}
+	return pending, nil
+}
+
+// Up applies every pending migration in order and returns those it ran
+func Up(ctx context.Context, db *mongo.Database) ([]Migration, error) {
+	var ran []Migration
+	err := withLock(ctx, db, func(ctx context.Context, l *lock) error {
+		pending, err := Pending(ctx, db)
+		if err != nil {
+			return err
+		}
+		for _, m := range pending {
+			if err := l.refresh(ctx); err != nil {
+				return err
+			}
+			slog.Info("applying migration", "version", m.Version, "name", m.Name)
+			if err := m.Up(ctx, db); err != nil {
+				return fmt.Errorf("migration %04d_%s: %w", m.Version, m.Name, err)
+			}
+			record := Record{Version: m.Version, Name: m.Name, AppliedAt: time.Now()}
+			if _, err := db.Collection(migrationsCollection).ReplaceOne(ctx,
+				bson.M{"_id": m.Version}, record, options.Replace().SetUpsert(true)); err != nil {
+				return err
+			}
+			ran = append(ran, m)
+		}
+		return nil
+	})
+	return ran, err
+}
+
+// Down reverts the most recently applied migrations, at most steps of them
+func Down(ctx context.Context, db *mongo.Database, steps int) ([]Migration, error) {
+	var reverted []Migration
+	err := withLock(ctx, db, func(ctx context.Context, l *lock) error {
+		done, err := applied(ctx, db)
+		if err != nil {
+			return err
+		}
+		for i := len(registry) - 1; i >= 0 && len(reverted) < steps; i-- {
+			m := registry[i]
+			if _, ok := done[m.Version]; !ok {
+				continue
+			}
+			if err := l.refresh(ctx); err != nil {
+				return err
+			}
+			slog.Info("reverting migration", "version", m.Version, "name", m.Name)
+			if m.Down != nil {
+				if err := m.Down(ctx, db); err != nil {
+					return fmt.Errorf("revert %04d_%s: %w", m.Version, m.Name, err)
+				}
+			}
+			if _, err := db.Collection(migrationsCollection).DeleteOne(ctx, bson.M{"_id": m.Version}); err != nil {
+				return err
+			}
+			reverted = append(reverted, m)
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+type lock struct {
+	db    *mongo.Database
+	owner string
+}
+
+// withLock runs fn while holding the migrations lock, waiting for another
+// instance to finish (or for its lock to expire) until ctx is done. The lock
+// is refreshed in the background while fn runs, and the context passed to fn
+// is cancelled if the lock is lost, so a step longer than LockTTL is never
+// run by two instances at once.
+func withLock(ctx context.Context, db *mongo.Database, fn func(context.Context, *lock) error) error {
+	hostname, _ := os.Hostname()
+	l := &lock{db: db, owner: fmt.Sprintf("%s/%d/%s", hostname, os.Getpid(), primitive.NewObjectID().Hex())}
+	coll := db.Collection(lockCollection)
+
+	for {
+		now := time.Now()
+		_, err := coll.InsertOne(ctx, bson.M{
+			"_id":         lockID,
+			"owner":       l.owner,
+			"acquired_at": now,
+			"expires_at":  now.Add(LockTTL),
+		})
+		if err == nil {
+			break
+		}
+		if !mongo.IsDuplicateKeyError(err) {
+			return err
+		}
+		// ล็อกค้างจาก instance ที่ตายไปแล้ว: ลบทิ้งเมื่อหมดอายุแล้วลองใหม่
+		if _, err := coll.DeleteOne(ctx, bson.M{"_id": lockID, "expires_at": bson.M{"$lt": now}}); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ErrLocked
+		case <-time.After(LockPollInterval):
+		}
+	}
+
+	defer func() {
+		// ใช้ context ใหม่เพื่อปลดล็อกได้แม้ ctx เดิมถูกยกเลิกไปแล้ว
+		releaseCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if _, err := coll.DeleteOne(releaseCtx, bson.M{"_id": lockID, "owner": l.owner}); err != nil {
+			slog.Error("release migrations lock failed", "error", err)
+		}
+	}()
+
+	runCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		l.heartbeat(runCtx, cancel, stop)
+	}()
+	err := fn(runCtx, l)
+	close(stop)
+	<-stopped
+	// ล็อกหลุดระหว่างทาง: รายงานสาเหตุแทน context canceled ที่ขั้นตอนได้รับ
+	if cause := context.Cause(runCtx); cause != nil && ctx.Err() == nil {
+		return cause
+	}
+	return err
+}
+
+// heartbeat refreshes the lock every LockTTL/3 until stop is closed. When a
+// refresh fails it cancels the running migrations with the reason.
+func (l *lock) heartbeat(ctx context.Context, cancel context.CancelCauseFunc, stop <-chan struct{}) {
+	ticker := time.NewTicker(LockTTL / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := l.refresh(ctx); err != nil {
+				slog.Error("refresh migrations lock failed", "error", err)
+				cancel(err)
+				return
+			}
+		}
+	}
+}
+
+// refresh extends the lock before a long-running step
+func (l *lock) refresh(ctx context.Context) error {
+	result, err := l.db.Collection(lockCollection).UpdateOne(ctx,
+		bson.M{"_id": lockID, "owner": l.owner},
+		bson.M{"$set": bson.M{"expires_at": time.Now().Add(LockTTL)}})
+	if err != nil {
+		return fmt.Errorf("refresh migrations lock: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrLockLost
+	}
+	return nil
+}
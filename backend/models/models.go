@@ -14,18 +14,106 @@ type User struct {
 	Name      string             `bson:"name" json:"name" validate:"required"`
 	Role      string             `bson:"role" json:"role"`
 	Address   Address            `bson:"address,omitempty" json:"address,omitempty"`
-	Status    string             `bson:"status" json:"status"`                            // "active" or "banned"
+	Status    string             `bson:"status" json:"status"`                            // "active", "banned", "pending_deletion" or "deleted"
 	BanReason string             `bson:"ban_reason,omitempty" json:"banReason,omitempty"` // เหตุผลที่แบน
-	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
-	UpdatedAt time.Time          `bson:"updated_at" json:"updated_at"`
+	// PasswordChangedAt is when the password was last set; nil for accounts
+	// created before it was tracked. MustChangePassword limits the next login
+	// to a password change, e.g. after an admin reset.
+	PasswordChangedAt  *time.Time `bson:"password_changed_at,omitempty" json:"passwordChangedAt,omitempty"`
+	MustChangePassword bool       `bson:"must_change_password,omitempty" json:"mustChangePassword,omitempty"`
+	// Identities are the OpenID Connect accounts linked for social login
+	Identities []Identity `bson:"identities,omitempty" json:"identities,omitempty"`
+	// DeletionRequestedAt/DeletionScheduledAt are set while the account is in
+	// its deletion grace period; personal fields are anonymized after
+	// DeletionScheduledAt passes.
+	DeletionRequestedAt *time.Time `bson:"deletion_requested_at,omitempty" json:"deletionRequestedAt,omitempty"`
+	DeletionScheduledAt *time.Time `bson:"deletion_scheduled_at,omitempty" json:"deletionScheduledAt,omitempty"`
+	// ReviewLikes and ReviewNotHelpful total the votes on the user's reviews,
+	// the author reputation used when ranking them
+	ReviewLikes      int       `bson:"review_likes,omitempty" json:"-"`
+	ReviewNotHelpful int       `bson:"review_not_helpful,omitempty" json:"-"`
+	CreatedAt        time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt        time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// Identity links a user to an account at an OpenID Connect provider
+type Identity struct {
+	Provider string    `bson:"provider" json:"provider"`
+	Subject  string    `bson:"subject" json:"-"`
+	Email    string    `bson:"email,omitempty" json:"email,omitempty"`
+	LinkedAt time.Time `bson:"linked_at" json:"linkedAt"`
+}
+
+// OIDCState is a pending OpenID Connect sign-in, kept until the provider
+// redirects back. It expires after a few minutes.
+type OIDCState struct {
+	State     string    `bson:"_id"`
+	Provider  string    `bson:"provider"`
+	Verifier  string    `bson:"verifier"`
+	Nonce     string    `bson:"nonce"`
+	LinkUser  string    `bson:"link_user,omitempty"` // set when linking to a signed-in account
+	CreatedAt time.Time `bson:"created_at"`
+}
+
+// API key scopes. A key can only call routes that accept one of its scopes,
+// and still only as far as its owner's role allows.
+const (
+	ScopePlacesRead  = "places:read"
+	ScopePlacesWrite = "places:write"
+	ScopeReviewsRead = "reviews:read"
+	ScopeProfileRead = "profile:read"
+)
+
+// APIKeyScopes lists every scope a key may be given
+var APIKeyScopes = []string{ScopePlacesRead, ScopePlacesWrite, ScopeReviewsRead, ScopeProfileRead}
+
+// IsValidAPIKeyScope reports whether scope is one of APIKeyScopes
+func IsValidAPIKeyScope(scope string) bool {
+	for _, s := range APIKeyScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// APIKey lets an integration call the API on behalf of its owner. Only a
+// hash of the key is stored; the key itself is shown once when issued.
+type APIKey struct {
+	ID     primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID string             `bson:"user_id" json:"userId"`
+	Name   string             `bson:"name" json:"name"`
+	// Prefix is the start of the key, enough to tell keys apart in lists
+	Prefix string   `bson:"prefix" json:"prefix"`
+	Hash   string   `bson:"hash" json:"-"`
+	Scopes []string `bson:"scopes" json:"scopes"`
+	// RateLimit is requests per minute; zero uses API_KEY_RATE_LIMIT
+	RateLimit  int        `bson:"rate_limit,omitempty" json:"rateLimit,omitempty"`
+	ExpiresAt  time.Time  `bson:"expires_at" json:"expiresAt"`
+	LastUsedAt *time.Time `bson:"last_used_at,omitempty" json:"lastUsedAt,omitempty"`
+	LastUsedIP string     `bson:"last_used_ip,omitempty" json:"lastUsedIp,omitempty"`
+	RotatedAt  *time.Time `bson:"rotated_at,omitempty" json:"rotatedAt,omitempty"`
+	RevokedAt  *time.Time `bson:"revoked_at,omitempty" json:"revokedAt,omitempty"`
+	CreatedAt  time.Time  `bson:"created_at" json:"created_at"`
+	UpdatedAt  time.Time  `bson:"updated_at" json:"updated_at"`
+}
+
+// CreateAPIKeyInput represents the input for issuing an API key
+type CreateAPIKeyInput struct {
+	Name   string   `json:"name" validate:"required,max=100"`
+	Scopes []string `json:"scopes" validate:"required,min=1,unique,dive,api_scope"`
+	// ExpiresInDays defaults to API_KEY_DEFAULT_TTL and is capped by API_KEY_MAX_TTL
+	ExpiresInDays int `json:"expiresInDays" validate:"omitempty,min=1"`
+	// RateLimit is only taken from admins; other keys get API_KEY_RATE_LIMIT
+	RateLimit int `json:"rateLimit" validate:"omitempty,min=1,max=10000"`
 }
 
 // RegisterInput represents the input for user registration
 type RegisterInput struct {
-	Email    string  `json:"email" validate:"required,email"`
-	Password string  `json:"password" validate:"required,min=6"`
-	Name     string  `json:"name" validate:"required"`
-	Address  Address `json:"address" validate:"required"`
+	Email    string  `json:"email" validate:"required,email,max=254"`
+	Password string  `json:"password" validate:"required,max=72"`
+	Name     string  `json:"name" validate:"required,min=2,max=100"`
+	Address  Address `json:"address"`
 }
 
 // LoginCredentials represents the input for user login
@@ -37,24 +125,148 @@ type LoginCredentials struct {
 
 // UpdateProfileInput represents the input for updating user profile
 type UpdateProfileInput struct {
-	Name string `json:"name" validate:"required"`
+	Name    string  `json:"name" validate:"required,min=2,max=100"`
+	Address Address `json:"address"`
+}
+
+// ChangePasswordInput represents the input for changing the caller's password
+type ChangePasswordInput struct {
+	// CurrentPassword may be empty for accounts created through social login
+	// that have never had a password
+	CurrentPassword string `json:"currentPassword" validate:"max=72"`
+	NewPassword     string `json:"newPassword" validate:"required,max=72"`
+}
+
+// DeleteAccountInput represents the input for self-service account deletion.
+// Accounts with a password confirm with it; accounts that only sign in
+// through a provider have none and type their email address instead.
+type DeleteAccountInput struct {
+	Password string `json:"password" validate:"omitempty,max=72"`
+	Confirm  string `json:"confirm" validate:"omitempty,max=254"`
 }
 
 // UpdateUserInput represents the input for updating user (admin only)
 type UpdateUserInput struct {
-	Name string `json:"name" validate:"required"`
+	Name string `json:"name" validate:"required,min=2,max=100"`
 	Role string `json:"role" validate:"required,oneof=user admin"`
 }
 
-// Comment represents a comment on a review
+// BanUserInput represents the input for banning a user (admin only)
+type BanUserInput struct {
+	Reason string `json:"reason" validate:"max=500"`
+}
+
+// CreateReviewInput represents the input for creating a review. PlaceID is
+// either a place's ObjectID or its place_id. PlaceName is ignored; the name
+// is taken from the place.
+type CreateReviewInput struct {
+	PlaceID   string `json:"placeId" validate:"required,max=64"`
+	PlaceName string `json:"placeName" validate:"max=200"`
+	Rating    int    `json:"rating" validate:"required,min=1,max=5"`
+	Comment   string `json:"comment" validate:"required,max=5000"`
+}
+
+// UpdateReviewInput represents the input for editing a review
+type UpdateReviewInput struct {
+	Rating  int    `json:"rating" validate:"required,min=1,max=5"`
+	Comment string `json:"comment" validate:"required,max=5000"`
+}
+
+// CommentInput represents the input for commenting on a review. ParentID
+// makes the comment a reply to another comment on the same review.
+type CommentInput struct {
+	Text     string `json:"text" validate:"required,max=2000"`
+	ParentID string `json:"parentId" validate:"omitempty,objectid"`
+}
+
+// UpdateCommentInput represents the input for editing a comment
+type UpdateCommentInput struct {
+	Text string `json:"text" validate:"required,max=2000"`
+}
+
+// FlagCommentInput represents the input for flagging a comment for moderation
+type FlagCommentInput struct {
+	Reason string `json:"reason" validate:"required,oneof=inappropriate spam harassment other"`
+	Detail string `json:"detail" validate:"max=500"`
+}
+
+// ModerateCommentInput represents the input for an admin decision on a comment
+type ModerateCommentInput struct {
+	Status string `json:"status" validate:"required,oneof=visible hidden"`
+}
+
+// ModerateReviewInput represents the input for an admin decision on a review
+type ModerateReviewInput struct {
+	Status string `json:"status" validate:"required,oneof=published hidden"`
+}
+
+// ReportReviewInput represents the input for reporting a review
+type ReportReviewInput struct {
+	Type   string `json:"type" validate:"required,oneof=inappropriate spam fake other"`
+	Detail string `json:"detail" validate:"max=2000"`
+}
+
+// UpdateReportStatusInput represents the input for resolving a review report (admin only)
+type UpdateReportStatusInput struct {
+	Status string `json:"status" validate:"required,oneof=pending resolved rejected"`
+}
+
+// Comment statuses. Only visible comments count toward a review's
+// comment_count and a parent's reply_count.
+const (
+	CommentVisible = "visible"
+	CommentHidden  = "hidden"  // hidden by a moderator
+	CommentDeleted = "deleted" // deleted by its author
+	CommentHeld    = "held"    // held by screening until a moderator decides
+)
+
+// Review statuses. Only published reviews are listed and count toward a
+// place's rating.
+const (
+	ReviewPublished = "published"
+	ReviewHeld      = "held"   // held by screening until a moderator decides
+	ReviewHidden    = "hidden" // hidden by a moderator
+)
+
+// ScreeningFlag records a screening rule that held a review or comment
+type ScreeningFlag struct {
+	Rule   string `bson:"rule" json:"rule"`
+	Detail string `bson:"detail,omitempty" json:"detail,omitempty"`
+}
+
+// Comment represents a comment on a review, stored in its own collection.
+// Replies point at the comment they answer with ParentID.
 type Comment struct {
-	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	UserID    string             `bson:"user_id" json:"user_id"`
-	Username  string             `bson:"username" json:"username"`
-	Text      string             `bson:"text" json:"text"`
-	Likes     int                `bson:"likes" json:"likes"`
-	LikedBy   []string           `bson:"liked_by" json:"liked_by"`
-	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+	ID         primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	ReviewID   primitive.ObjectID  `bson:"review_id" json:"reviewId"`
+	ParentID   *primitive.ObjectID `bson:"parent_id,omitempty" json:"parentId,omitempty"`
+	UserID     string              `bson:"user_id" json:"user_id"`
+	Username   string              `bson:"username" json:"username"`
+	Text       string              `bson:"text" json:"text"`
+	Status     string              `bson:"status" json:"status"`
+	Likes      int                 `bson:"likes" json:"likes"`
+	LikedBy    []string            `bson:"liked_by" json:"liked_by"`
+	ReplyCount int                 `bson:"reply_count" json:"replyCount"`
+	Flags      []CommentFlag       `bson:"flags,omitempty" json:"flags,omitempty"`
+	FlagCount  int                 `bson:"flag_count" json:"flagCount,omitempty"`
+	CreatedAt  time.Time           `bson:"created_at" json:"created_at"`
+	EditedAt   *time.Time          `bson:"edited_at,omitempty" json:"editedAt,omitempty"`
+	// ModeratedAt and ModeratedBy record the last admin decision
+	ModeratedAt *time.Time `bson:"moderated_at,omitempty" json:"moderatedAt,omitempty"`
+	ModeratedBy string     `bson:"moderated_by,omitempty" json:"moderatedBy,omitempty"`
+	// Screening lists the rules that held the comment, if any
+	Screening []ScreeningFlag `bson:"screening,omitempty" json:"screening,omitempty"`
+	// Fingerprint and FingerprintBands find near-duplicates; see package screening
+	Fingerprint      []uint32 `bson:"fingerprint,omitempty" json:"-"`
+	FingerprintBands []int64  `bson:"fingerprint_bands,omitempty" json:"-"`
+}
+
+// CommentFlag is one user's request to have a comment moderated
+type CommentFlag struct {
+	UserID    string    `bson:"user_id" json:"userId"`
+	Reason    string    `bson:"reason" json:"reason"`
+	Detail    string    `bson:"detail,omitempty" json:"detail,omitempty"`
+	CreatedAt time.Time `bson:"created_at" json:"createdAt"`
 }
 
 // Review represents a user review
@@ -64,32 +276,90 @@ type Review struct {
 	Username  string             `bson:"username" json:"username"`
 	PlaceID   string             `bson:"place_id" json:"placeId"`
 	PlaceName string             `bson:"place_name" json:"placeName"`
-	Rating    int                `bson:"rating" json:"rating" validate:"required,min=1,max=5"`
-	Comment   string             `bson:"comment" json:"comment" validate:"required"`
+	Rating    int                `bson:"rating" json:"rating"`
+	Comment   string             `bson:"comment" json:"comment"`
 	Likes     int                `bson:"likes" json:"likes"`
 	LikedBy   []string           `bson:"liked_by" json:"liked_by"`
-	Comments  []Comment          `bson:"comments" json:"comments"`
-	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
-	UpdatedAt time.Time          `bson:"updated_at" json:"updated_at"`
+	// NotHelpful counts readers who marked the review not helpful; a reader
+	// either likes a review or marks it not helpful, never both
+	NotHelpful   int           `bson:"not_helpful" json:"notHelpful"`
+	NotHelpfulBy []string      `bson:"not_helpful_by" json:"not_helpful_by"`
+	Photos       []ReviewPhoto `bson:"photos,omitempty" json:"photos"`
+	// RankScore orders the "relevant" sort; see package ranking
+	RankScore float64 `bson:"rank_score" json:"-"`
+	// CommentCount is the number of visible comments, kept in step with the comments collection
+	CommentCount int `bson:"comment_count" json:"commentCount"`
+	// Response is the public reply from the place's verified owner
+	Response *OwnerResponse `bson:"response,omitempty" json:"response,omitempty"`
+	Status   string         `bson:"status" json:"status"`
+	// Screening lists the rules that held the review, if any
+	Screening []ScreeningFlag `bson:"screening,omitempty" json:"screening,omitempty"`
+	// Fingerprint and FingerprintBands find near-duplicates; see package screening
+	Fingerprint      []uint32 `bson:"fingerprint,omitempty" json:"-"`
+	FingerprintBands []int64  `bson:"fingerprint_bands,omitempty" json:"-"`
+	// ModeratedAt and ModeratedBy record the last admin decision
+	ModeratedAt *time.Time `bson:"moderated_at,omitempty" json:"moderatedAt,omitempty"`
+	ModeratedBy string     `bson:"moderated_by,omitempty" json:"moderatedBy,omitempty"`
+	CreatedAt   time.Time  `bson:"created_at" json:"created_at"`
+	UpdatedAt   time.Time  `bson:"updated_at" json:"updated_at"`
+	// VerifiedVisit is set once the author has checked in at the place
+	VerifiedVisit bool `bson:"verified_visit" json:"verifiedVisit"`
+}
+
+// OwnerResponse is a place owner's reply to a review. A review has at most one.
+type OwnerResponse struct {
+	Text      string    `bson:"text" json:"text"`
+	UserID    string    `bson:"user_id" json:"userId"`
+	CreatedAt time.Time `bson:"created_at" json:"createdAt"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updatedAt"`
+}
+
+// OwnerResponseInput represents the input for posting or editing an owner response
+type OwnerResponseInput struct {
+	Text string `json:"text" validate:"required,max=2000"`
+}
+
+// ReviewPhoto is a photo attached to a review. URL and ThumbnailURL are
+// paths under the upload directory, like place images.
+type ReviewPhoto struct {
+	ID           primitive.ObjectID `bson:"_id" json:"id"`
+	URL          string             `bson:"url" json:"url"`
+	ThumbnailURL string             `bson:"thumbnail_url" json:"thumbnailUrl"`
+	Alt          string             `bson:"alt" json:"alt"`
+	Width        int                `bson:"width" json:"width"`
+	Height       int                `bson:"height" json:"height"`
+	CreatedAt    time.Time          `bson:"created_at" json:"createdAt"`
+}
+
+// ReviewPhotoInput represents the form fields sent with a review photo
+type ReviewPhotoInput struct {
+	Alt string `form:"alt" json:"alt" validate:"max=300"`
 }
 
 // RouteSuggestion represents a route suggestion
 type RouteSuggestion struct {
 	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
 	UserID        string             `bson:"user_id" json:"user_id"`
-	StartLocation string             `bson:"start_location" json:"start_location" validate:"required"`
-	EndLocation   string             `bson:"end_location" json:"end_location" validate:"required"`
+	StartLocation string             `bson:"start_location" json:"start_location"`
+	EndLocation   string             `bson:"end_location" json:"end_location"`
 	Description   string             `bson:"description" json:"description"`
 	CreatedAt     time.Time          `bson:"created_at" json:"created_at"`
 	UpdatedAt     time.Time          `bson:"updated_at" json:"updated_at"`
 }
 
+// RouteSuggestionInput represents the input for creating or editing a route suggestion
+type RouteSuggestionInput struct {
+	StartLocation string `json:"start_location" validate:"required,max=200"`
+	EndLocation   string `json:"end_location" validate:"required,max=200"`
+	Description   string `json:"description" validate:"max=2000"`
+}
+
 // RouteInput represents the input for route cost estimation
 type RouteInput struct {
-	StartLocation string  `json:"start_location" validate:"required"`
-	EndLocation   string  `json:"end_location" validate:"required"`
-	Distance      float64 `json:"distance" validate:"required"`
-	Duration      int     `json:"duration" validate:"required"` // in minutes
+	StartLocation string  `json:"start_location" validate:"required,max=200"`
+	EndLocation   string  `json:"end_location" validate:"required,max=200"`
+	Distance      float64 `json:"distance" validate:"required,gt=0"`
+	Duration      int     `json:"duration" validate:"required,gt=0"` // in minutes
 }
 
 // Location represents a geographical location with details
@@ -132,37 +402,262 @@ type Place struct {
 	Phone     string    `bson:"phone" json:"Phone"`
 	Website   string    `bson:"website" json:"Website"`
 	Hours     string    `bson:"hours" json:"Hours"`
+	OwnerID   string    `bson:"owner_id,omitempty" json:"OwnerID,omitempty"` // verified through an approved PlaceClaim
 	CreatedAt time.Time `bson:"created_at" json:"CreatedAt"`
 	UpdatedAt time.Time `bson:"updated_at" json:"UpdatedAt"`
+	// Saved is set in responses when the signed-in caller has the place in
+	// one of their collections
+	Saved bool `bson:"-" json:"Saved,omitempty"`
+}
+
+// PlaceCategories lists the categories a place may belong to
+var PlaceCategories = []string{
+	"Food & Drink",
+	"Activity",
+	"Attraction",
+	"Nature",
+	"Culture",
+	"Shopping",
+	"Hotel & Resort",
+}
+
+// IsValidPlaceCategory reports whether category is one of PlaceCategories
+func IsValidPlaceCategory(category string) bool {
+	for _, c := range PlaceCategories {
+		if c == category {
+			return true
+		}
+	}
+	return false
 }
 
 // UpdatePlaceInput represents the input for updating a place
 type UpdatePlaceInput struct {
-	Name        string   `json:"name" validate:"required"`
-	Description string   `json:"description"`
+	Name        string   `json:"name" validate:"required,max=200"`
+	Description string   `json:"description" validate:"max=5000"`
 	LocationID  string   `json:"location_id" validate:"required"`
-	Category    string   `json:"category"`
-	Address     string   `json:"address"`
-	Phone       string   `json:"phone"`
-	Website     string   `json:"website"`
-	Hours       string   `json:"hours"`
+	Category    string   `json:"category" validate:"omitempty,place_category"`
+	Address     string   `json:"address" validate:"max=500"`
+	Phone       string   `json:"phone" validate:"omitempty,th_phone"`
+	Website     string   `json:"website" validate:"omitempty,url"`
+	Hours       string   `json:"hours" validate:"max=200"`
 	CoverImage  string   `json:"coverImage"`
 	Highlights  []string `json:"highlights"`
 	Coordinates struct {
-		Lat float64 `json:"lat"`
-		Lng float64 `json:"lng"`
+		Lat float64 `json:"lat" validate:"latitude"`
+		Lng float64 `json:"lng" validate:"longitude"`
 	} `json:"coordinates"`
 }
 
+// OwnerPlaceInput represents the fields a verified owner may change on their
+// place; fields left out are kept. Name, category, location, address and
+// coordinates stay with admins. Phone and Website may be sent empty to clear
+// them, so UpdateOwnedPlace checks their format.
+type OwnerPlaceInput struct {
+	Description *string `json:"description" validate:"omitempty,max=5000"`
+	Phone       *string `json:"phone" validate:"omitempty,max=20"`
+	Website     *string `json:"website" validate:"omitempty,max=500"`
+	Hours       *string `json:"hours" validate:"omitempty,max=200"`
+}
+
+// Place claim statuses
+const (
+	ClaimPending  = "pending"
+	ClaimApproved = "approved"
+	ClaimRejected = "rejected"
+)
+
+// PlaceClaim is a business's request to be verified as the owner of a place.
+// PlaceID is the place's place_id, as on reviews.
+type PlaceClaim struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	PlaceID      string             `bson:"place_id" json:"placeId"`
+	PlaceName    string             `bson:"place_name" json:"placeName"`
+	UserID       string             `bson:"user_id" json:"userId"`
+	Username     string             `bson:"username" json:"username"`
+	BusinessName string             `bson:"business_name" json:"businessName"`
+	Position     string             `bson:"position" json:"position"` // ตำแหน่งของผู้ขอในกิจการ เช่น เจ้าของ ผู้จัดการ
+	ContactPhone string             `bson:"contact_phone" json:"contactPhone"`
+	Evidence     string             `bson:"evidence" json:"evidence"`
+	EvidenceURLs []string           `bson:"evidence_urls,omitempty" json:"evidenceUrls,omitempty"`
+	Status       string             `bson:"status" json:"status"`
+	ReviewNote   string             `bson:"review_note,omitempty" json:"reviewNote,omitempty"`
+	ReviewedBy   string             `bson:"reviewed_by,omitempty" json:"reviewedBy,omitempty"`
+	ReviewedAt   *time.Time         `bson:"reviewed_at,omitempty" json:"reviewedAt,omitempty"`
+	CreatedAt    time.Time          `bson:"created_at" json:"createdAt"`
+}
+
+// PlaceClaimInput represents the input for claiming a place
+type PlaceClaimInput struct {
+	BusinessName string   `json:"businessName" validate:"required,max=200"`
+	Position     string   `json:"position" validate:"required,max=100"`
+	ContactPhone string   `json:"contactPhone" validate:"required,th_phone"`
+	Evidence     string   `json:"evidence" validate:"required,min=20,max=2000"`
+	EvidenceURLs []string `json:"evidenceUrls" validate:"max=5,dive,url"`
+}
+
+// RejectClaimInput represents the reason an admin gives for rejecting a claim,
+// shown to the claimant
+type RejectClaimInput struct {
+	Note string `json:"note" validate:"required,max=500"`
+}
+
+// Collection visibilities
+const (
+	CollectionPrivate = "private"
+	CollectionPublic  = "public"
+)
+
+// FavoritesName is the name of the default collection every user has
+const FavoritesName = "Favorites"
+
+// PlaceCollection is a user's list of places, such as places they want to
+// visit. Each user has one default collection, Favorites, which is created
+// on first use and cannot be renamed or deleted. Places keep the order the
+// user gives them.
+type PlaceCollection struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID      string             `bson:"user_id" json:"userId"`
+	Name        string             `bson:"name" json:"name"`
+	Description string             `bson:"description" json:"description"`
+	Visibility  string             `bson:"visibility" json:"visibility"`
+	IsDefault   bool               `bson:"is_default" json:"isDefault"`
+	Places      []CollectionPlace  `bson:"places" json:"places"`
+	CreatedAt   time.Time          `bson:"created_at" json:"createdAt"`
+	UpdatedAt   time.Time          `bson:"updated_at" json:"updatedAt"`
+	// ShareURL is set in responses for public collections
+	ShareURL string `bson:"-" json:"shareUrl,omitempty"`
+}
+
+// CollectionPlace is a place saved in a collection. PlaceID is the place's
+// place_id, as on reviews.
+type CollectionPlace struct {
+	PlaceID   string    `bson:"place_id" json:"placeId"`
+	PlaceName string    `bson:"place_name" json:"placeName"`
+	Note      string    `bson:"note,omitempty" json:"note,omitempty"`
+	AddedAt   time.Time `bson:"added_at" json:"addedAt"`
+}
+
+// CollectionInput represents the input for creating a collection
+type CollectionInput struct {
+	Name        string `json:"name" validate:"required,max=100"`
+	Description string `json:"description" validate:"max=500"`
+	Visibility  string `json:"visibility" validate:"omitempty,oneof=private public"`
+}
+
+// UpdateCollectionInput represents the fields of a collection to change;
+// fields left out are kept
+type UpdateCollectionInput struct {
+	Name        *string `json:"name" validate:"omitempty,min=1,max=100"`
+	Description *string `json:"description" validate:"omitempty,max=500"`
+	Visibility  *string `json:"visibility" validate:"omitempty,oneof=private public"`
+}
+
+// CollectionPlaceInput represents the note kept with a saved place
+type CollectionPlaceInput struct {
+	Note string `json:"note" validate:"max=500"`
+}
+
+// ReorderCollectionInput lists every place of a collection in its new order
+type ReorderCollectionInput struct {
+	PlaceIDs []string `json:"placeIds" validate:"required,unique"`
+}
+
+// Trip visibilities
+const (
+	TripPrivate = "private"
+	TripPublic  = "public"
+)
+
+// Trip is a user's plan of places to visit over one or more days. Legs,
+// times and costs are worked out when the trip is read, from the routes and
+// places as they are then.
+type Trip struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID      string             `bson:"user_id" json:"userId"`
+	Name        string             `bson:"name" json:"name"`
+	Description string             `bson:"description" json:"description"`
+	StartDate   string             `bson:"start_date,omitempty" json:"startDate,omitempty"` // 2006-01-02; ไม่ระบุก็ได้
+	Visibility  string             `bson:"visibility" json:"visibility"`
+	Days        []TripDay          `bson:"days" json:"days"`
+	CreatedAt   time.Time          `bson:"created_at" json:"createdAt"`
+	UpdatedAt   time.Time          `bson:"updated_at" json:"updatedAt"`
+	// ShareURL is set in responses for public trips
+	ShareURL string `bson:"-" json:"shareUrl,omitempty"`
+}
+
+// TripDay is a day of a trip, its stops in the order they are visited
+type TripDay struct {
+	StartTime string     `bson:"start_time" json:"startTime"` // 15:04
+	Stops     []TripStop `bson:"stops" json:"stops"`
+}
+
+// TripStop is a place on a trip day. PlaceID is the place's place_id, as on
+// reviews.
+type TripStop struct {
+	PlaceID   string `bson:"place_id" json:"placeId"`
+	PlaceName string `bson:"place_name" json:"placeName"`
+	Duration  int    `bson:"duration" json:"duration"` // นาทีที่อยู่ที่สถานที่
+	Note      string `bson:"note,omitempty" json:"note,omitempty"`
+}
+
+// TripInput represents a whole trip, for creating one or replacing one.
+// Stops are reordered or moved between days by sending the trip again;
+// UpdatedAt, when sent, must match the stored trip so edits made meanwhile
+// are not overwritten.
+type TripInput struct {
+	Name        string         `json:"name" validate:"required,max=100"`
+	Description string         `json:"description" validate:"max=1000"`
+	StartDate   string         `json:"startDate" validate:"omitempty,datetime=2006-01-02"`
+	Visibility  string         `json:"visibility" validate:"omitempty,oneof=private public"`
+	Days        []TripDayInput `json:"days" validate:"required,min=1,max=30,dive"`
+	UpdatedAt   *time.Time     `json:"updatedAt"`
+}
+
+// TripDayInput represents a day of a trip; it starts at 09:00 if no time is given
+type TripDayInput struct {
+	StartTime string          `json:"startTime" validate:"omitempty,datetime=15:04"`
+	Stops     []TripStopInput `json:"stops" validate:"max=20,dive"`
+}
+
+// TripStopInput represents a place on a trip day; PlaceID may also be the
+// place's ObjectID
+type TripStopInput struct {
+	PlaceID  string `json:"placeId" validate:"required,max=100"`
+	Duration int    `json:"duration" validate:"required,min=5,max=720"`
+	Note     string `json:"note" validate:"max=500"`
+}
+
+// CheckIn records that a user was at a place, within CHECK_IN_RADIUS of its
+// coordinates. PlaceID is the place's place_id, as on reviews. Accuracy is
+// what the device reported, kept so moderators can judge doubtful check-ins.
+type CheckIn struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID    string             `bson:"user_id" json:"userId"`
+	PlaceID   string             `bson:"place_id" json:"placeId"`
+	PlaceName string             `bson:"place_name" json:"placeName"`
+	Distance  float64            `bson:"distance" json:"distance"`                     // เมตร
+	Accuracy  float64            `bson:"accuracy,omitempty" json:"accuracy,omitempty"` // เมตร, 0 ถ้าไม่ได้ส่งมา
+	CreatedAt time.Time          `bson:"created_at" json:"createdAt"`
+}
+
+// CheckInInput represents where the user is when checking in. Accuracy is
+// the radius in meters the device gives for the position, if known.
+type CheckInInput struct {
+	Lat      float64 `json:"lat" validate:"required,latitude"`
+	Lng      float64 `json:"lng" validate:"required,longitude"`
+	Accuracy float64 `json:"accuracy" validate:"omitempty,gte=0,lte=100000"`
+}
+
 // Address represents the address of a user
 type Address struct {
 	AddressLine string  `bson:"addressLine,omitempty" json:"addressLine,omitempty"`
 	City        string  `bson:"city,omitempty" json:"city,omitempty"`
 	Province    string  `bson:"province,omitempty" json:"province,omitempty"`
-	Zipcode     string  `bson:"zipcode,omitempty" json:"zipcode,omitempty"`
+	Zipcode     string  `bson:"zipcode,omitempty" json:"zipcode,omitempty" validate:"omitempty,th_postcode"`
 	Country     string  `bson:"country,omitempty" json:"country,omitempty"`
-	Lat         float64 `bson:"lat,omitempty" json:"lat,omitempty"`
-	Lng         float64 `bson:"lng,omitempty" json:"lng,omitempty"`
+	Lat         float64 `bson:"lat,omitempty" json:"lat,omitempty" validate:"latitude"`
+	Lng         float64 `bson:"lng,omitempty" json:"lng,omitempty" validate:"longitude"`
 }
 
 // ReviewReport represents a report on a review
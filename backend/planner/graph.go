@@ -0,0 +1,69 @@
+package planner
+
+import "math"
+
+// Edge is a route between two locations, as stored in the routes collection
+type Edge struct {
+	From, To string
+	Distance float64 // km
+	Duration int     // minutes
+	Cost     float64 // baht
+	Mode     string
+}
+
+// Graph finds the quickest way between locations over the routes. Routes
+// are taken to run both ways.
+type Graph struct {
+	edges map[string][]Edge
+}
+
+// NewGraph returns a graph of edges
+func NewGraph(edges []Edge) *Graph {
+	g := &Graph{edges: map[string][]Edge{}}
+	for _, e := range edges {
+		g.edges[e.From] = append(g.edges[e.From], e)
+		back := e
+		back.From, back.To = e.To, e.From
+		g.edges[e.To] = append(g.edges[e.To], back)
+	}
+	return g
+}
+
+// Path returns the edges of the quickest way from one location to another,
+// and false if there is none. The graph is small, so this is plain Dijkstra
+// without a heap.
+func (g *Graph) Path(from, to string) ([]Edge, bool) {
+	if from == to {
+		return nil, true
+	}
+	dist := map[string]int{from: 0}
+	via := map[string]Edge{}
+	done := map[string]bool{}
+	for {
+		current, best := "", math.MaxInt
+		for node, d := range dist {
+			if !done[node] && (d < best || (d == best && node < current)) {
+				current, best = node, d
+			}
+		}
+		if current == "" {
+			return nil, false
+		}
+		if current == to {
+			break
+		}
+		done[current] = true
+		for _, e := range g.edges[current] {
+			if d, ok := dist[e.To]; !ok || best+e.Duration < d {
+				dist[e.To] = best + e.Duration
+				via[e.To] = e
+			}
+		}
+	}
+
+	var path []Edge
+	for node := to; node != from; node = via[node].From {
+		path = append([]Edge{via[node]}, path...)
+	}
+	return path, true
+}
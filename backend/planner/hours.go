@@ -0,0 +1,231 @@
+package planner
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Interval is a span of the day in minutes from midnight. Close may pass
+// 1440 for places open past midnight.
+type Interval struct {
+	Open  int
+	Close int
+}
+
+// Hours are a place's opening times by weekday
+type Hours struct {
+	days [7][]Interval
+}
+
+// allDay is an interval for places open around the clock
+var allDay = Interval{Open: 0, Close: 24 * 60}
+
+var (
+	// clockRange matches "08:00น. - 17:00น.", "8.30-16.30" and "09:00–18:00"
+	clockRange = regexp.MustCompile(`(\d{1,2})[:.](\d{2})\s*(?:น\.?)?\s*(?:-|–|ถึง|to)\s*(\d{1,2})[:.](\d{2})\s*(?:น\.?)?`)
+	// aroundTheClock matches "24 ชั่วโมง", "24 hours" and "24/7"
+	aroundTheClock = regexp.MustCompile(`(?i)24\s*(?:ชั่วโมง|ชม\.?|hours?|hrs?)|24\s*/\s*7`)
+)
+
+// weekdayNames are the Thai and English names a place's hours may use.
+// Longer names come first so "พฤหัสบดี" is not also read as "พฤหัส".
+var weekdayNames = []struct {
+	name string
+	day  time.Weekday
+}{
+	{"พฤหัสบดี", time.Thursday}, {"อาทิตย์", time.Sunday}, {"จันทร์", time.Monday},
+	{"อังคาร", time.Tuesday}, {"พฤหัส", time.Thursday}, {"ศุกร์", time.Friday},
+	{"เสาร์", time.Saturday}, {"พุธ", time.Wednesday},
+	{"sunday", time.Sunday}, {"monday", time.Monday}, {"tuesday", time.Tuesday},
+	{"wednesday", time.Wednesday}, {"thursday", time.Thursday}, {"friday", time.Friday},
+	{"saturday", time.Saturday},
+	{"sun", time.Sunday}, {"mon", time.Monday}, {"tue", time.Tuesday}, {"wed", time.Wednesday},
+	{"thu", time.Thursday}, {"fri", time.Friday}, {"sat", time.Saturday},
+}
+
+// ParseHours reads the free-text opening hours places are entered with,
+// such as "08:00น. - 17:00น. ปิดวันจันทร์" or "จันทร์–ศุกร์ 09:00น. -
+// 18:00น., เสาร์–อาทิตย์ 08:30น. - 19:00น.". Days named as closed, in any
+// segment, are closed whatever the other segments say. It reports false for
+// text it cannot read, like a hotel's check-in times, and the plan then
+// skips the opening-hours check for that place.
+func ParseHours(text string) (Hours, bool) {
+	var h Hours
+	var closed [7]bool
+	found := false
+	for _, segment := range strings.FieldsFunc(text, func(r rune) bool { return r == ',' || r == ';' || r == '\n' }) {
+		var interval Interval
+		rest := segment
+		timed := true
+		if m := clockRange.FindStringSubmatchIndex(segment); m != nil {
+			interval = Interval{
+				Open:  clock(segment[m[2]:m[3]], segment[m[4]:m[5]]),
+				Close: clock(segment[m[6]:m[7]], segment[m[8]:m[9]]),
+			}
+			if interval.Close <= interval.Open {
+				interval.Close += 24 * 60
+			}
+			rest = segment[:m[0]] + " " + segment[m[1]:]
+		} else if aroundTheClock.MatchString(segment) {
+			interval = allDay
+		} else {
+			// ส่วนที่ไม่มีเวลา เช่น "ปิดวันจันทร์" บอกได้แค่วันที่ปิด
+			timed = false
+		}
+		open, shut := segmentDays(rest)
+		for day := range closed {
+			closed[day] = closed[day] || shut[day]
+		}
+		if !timed {
+			continue
+		}
+		for day := range open {
+			if open[day] {
+				h.days[day] = append(h.days[day], interval)
+			}
+		}
+		found = true
+	}
+	for day := range closed {
+		if closed[day] {
+			h.days[day] = nil
+		}
+	}
+	return h, found
+}
+
+// closedWords mark the days after them as closed, openWords as open again
+var (
+	closedWords = []string{"ปิด", "ยกเว้น", "closed", "except"}
+	openWords   = []string{"เปิด", "open"}
+)
+
+// segmentDays reads which weekdays the rest of a segment is open and closed
+// on. Days named after "ปิด" (closed) are closed; other named days, ranges
+// like "เสาร์–อาทิตย์" included, are open. A segment that names no open day
+// is open every day, so "ปิดวันหยุดนักขัตฤกษ์" alone leaves the hours as
+// they are.
+func segmentDays(text string) (open, closed [7]bool) {
+	lower := strings.ToLower(text)
+	type mention struct {
+		start, end int
+		day        time.Weekday
+	}
+	var mentions []mention
+	taken := make([]bool, len(lower))
+	for _, w := range weekdayNames {
+		for from := 0; ; {
+			i := strings.Index(lower[from:], w.name)
+			if i < 0 {
+				break
+			}
+			start, end := from+i, from+i+len(w.name)
+			from = end
+			if taken[start] || (start > 0 && isLatinLetter(lower[start-1])) || (end < len(lower) && isLatinLetter(lower[end])) {
+				continue
+			}
+			for k := start; k < end; k++ {
+				taken[k] = true
+			}
+			mentions = append(mentions, mention{start, end, w.day})
+		}
+	}
+	sort.Slice(mentions, func(i, j int) bool { return mentions[i].start < mentions[j].start })
+
+	namedOpen := false
+	for i := 0; i < len(mentions); i++ {
+		m := mentions[i]
+		days := &open
+		if closedBefore(lower, m.start) {
+			days = &closed
+		} else {
+			namedOpen = true
+		}
+		if i+1 < len(mentions) {
+			between := strings.TrimSpace(lower[m.end:mentions[i+1].start])
+			if between == "-" || between == "–" || between == "ถึง" || between == "to" {
+				for d := m.day; ; d = (d + 1) % 7 {
+					days[d] = true
+					if d == mentions[i+1].day {
+						break
+					}
+				}
+				i++
+				continue
+			}
+		}
+		days[m.day] = true
+	}
+	if !namedOpen {
+		for d := range open {
+			open[d] = true
+		}
+	}
+	for d := range open {
+		open[d] = open[d] && !closed[d]
+	}
+	return open, closed
+}
+
+// closedBefore reports whether the last open or closed word before end in
+// text says closed
+func closedBefore(text string, end int) bool {
+	last, closed := -1, false
+	for _, w := range closedWords {
+		i := strings.LastIndex(text[:end], w)
+		// "ปิด" ที่อยู่ใน "เปิด" ไม่นับ
+		for i > 0 && strings.HasSuffix(text[:i], "เ") {
+			i = strings.LastIndex(text[:i], w)
+		}
+		if i > last {
+			last, closed = i, true
+		}
+	}
+	for _, w := range openWords {
+		if i := strings.LastIndex(text[:end], w); i > last {
+			last, closed = i, false
+		}
+	}
+	return closed
+}
+
+func isLatinLetter(b byte) bool {
+	return b >= 'a' && b <= 'z'
+}
+
+func clock(hour, minute string) int {
+	h, _ := strconv.Atoi(hour)
+	m, _ := strconv.Atoi(minute)
+	return h*60 + m
+}
+
+// On returns the intervals the place is open on day
+func (h Hours) On(day time.Weekday) []Interval {
+	return h.days[day]
+}
+
+// Daily returns the intervals when they are the same every day, and false
+// when they differ by weekday
+func (h Hours) Daily() ([]Interval, bool) {
+	for d := 1; d < 7; d++ {
+		if !sameIntervals(h.days[d], h.days[0]) {
+			return nil, false
+		}
+	}
+	return h.days[0], true
+}
+
+func sameIntervals(a, b []Interval) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,77 @@
+package planner
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// week writes the hours of each day, Sunday first, as "08:00-17:00" with
+// intervals joined by "+" and "" for closed days
+func week(h Hours) [7]string {
+	var w [7]string
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		var spans []string
+		for _, iv := range h.On(d) {
+			spans = append(spans, fmt.Sprintf("%02d:%02d-%02d:%02d", iv.Open/60, iv.Open%60, iv.Close/60, iv.Close%60))
+		}
+		w[d] = strings.Join(spans, "+")
+	}
+	return w
+}
+
+func every(span string) [7]string {
+	return [7]string{span, span, span, span, span, span, span}
+}
+
+func TestParseHours(t *testing.T) {
+	tests := []struct {
+		text string
+		ok   bool
+		want [7]string // Sunday first
+	}{
+		// ค่าจาก seed
+		{"08:00น. - 17:00น. เปิดทุกวัน", true, every("08:00-17:00")},
+		{"10:00น. - 22:00น. ทุกวัน", true, every("10:00-22:00")},
+		{"เปิดทุกวัน 24 ชั่วโมง", true, every("00:00-24:00")},
+		{"09:00น. - 16:00น. ปิดวันจันทร์", true, [7]string{"09:00-16:00", "", "09:00-16:00", "09:00-16:00", "09:00-16:00", "09:00-16:00", "09:00-16:00"}},
+		{"08:00น. - 17:00น. เฉพาะวันเสาร์–อาทิตย์", true, [7]string{"08:00-17:00", "", "", "", "", "", "08:00-17:00"}},
+		{"06:00น. - 18:00น. ศุกร์–อาทิตย์", true, [7]string{"06:00-18:00", "", "", "", "", "06:00-18:00", "06:00-18:00"}},
+		{"จันทร์–ศุกร์ 09:00น. - 18:00น., เสาร์–อาทิตย์ 08:30น. - 19:00น.", true,
+			[7]string{"08:30-19:00", "09:00-18:00", "09:00-18:00", "09:00-18:00", "09:00-18:00", "09:00-18:00", "08:30-19:00"}},
+		{"Check-in 14:00, Check-out 12:00", false, [7]string{}},
+
+		// วันปิดที่แยกไว้หลังเครื่องหมายจุลภาค
+		{"08:00-17:00, ปิดวันจันทร์", true, [7]string{"08:00-17:00", "", "08:00-17:00", "08:00-17:00", "08:00-17:00", "08:00-17:00", "08:00-17:00"}},
+		{"ปิดวันอังคาร; 10:00-20:00", true, [7]string{"10:00-20:00", "10:00-20:00", "", "10:00-20:00", "10:00-20:00", "10:00-20:00", "10:00-20:00"}},
+		// ปิดวันหยุดที่ไม่ใช่วันในสัปดาห์ไม่ได้ทำให้วันที่ระบุกลับด้าน
+		{"08:00-17:00 ปิดวันหยุดนักขัตฤกษ์", true, every("08:00-17:00")},
+		{"จันทร์–ศุกร์ 09:00-18:00 ปิดวันหยุดนักขัตฤกษ์", true, [7]string{"", "09:00-18:00", "09:00-18:00", "09:00-18:00", "09:00-18:00", "09:00-18:00", ""}},
+		{"เปิดจันทร์–ศุกร์ 09:00-18:00", true, [7]string{"", "09:00-18:00", "09:00-18:00", "09:00-18:00", "09:00-18:00", "09:00-18:00", ""}},
+		{"daily 10:00-19:00 closed tue", true, [7]string{"10:00-19:00", "10:00-19:00", "", "10:00-19:00", "10:00-19:00", "10:00-19:00", "10:00-19:00"}},
+		{"18:00-02:00", true, every("18:00-26:00")},
+		{"ปิดวันจันทร์", false, [7]string{}},
+	}
+	for _, tt := range tests {
+		h, ok := ParseHours(tt.text)
+		if ok != tt.ok {
+			t.Errorf("ParseHours(%q) ok = %v, want %v", tt.text, ok, tt.ok)
+			continue
+		}
+		if got := week(h); got != tt.want {
+			t.Errorf("ParseHours(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestHoursDaily(t *testing.T) {
+	h, _ := ParseHours("08:00น. - 17:00น. เปิดทุกวัน")
+	if open, ok := h.Daily(); !ok || len(open) != 1 || open[0] != (Interval{8 * 60, 17 * 60}) {
+		t.Errorf("Daily() = %v, %v, want [{480 1020}], true", open, ok)
+	}
+	h, _ = ParseHours("09:00น. - 16:00น. ปิดวันจันทร์")
+	if _, ok := h.Daily(); ok {
+		t.Error("Daily() ok for hours closed on Monday")
+	}
+}
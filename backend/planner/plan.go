@@ -0,0 +1,254 @@
+// Package planner lays out trip days: the legs between consecutive stops
+// over the routes graph, when each stop is reached and left, how that fits
+// the place's opening hours, and the time and cost of the whole trip.
+package planner
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Stop is a place on a day of a trip
+type Stop struct {
+	LocationID string
+	Lat, Lng   float64
+	Stay       int    // minutes
+	Hours      string // as entered on the place
+}
+
+// Day is a day of a trip. Weekday is only known when the trip has dates.
+type Day struct {
+	Weekday time.Weekday
+	Dated   bool
+	Start   int // minutes from midnight
+	Stops   []Stop
+}
+
+// Clock is minutes from midnight, written as "HH:MM" in JSON. Times past
+// midnight carry on counting, as "25:30".
+type Clock int
+
+// MarshalJSON writes c as "HH:MM"
+func (c Clock) MarshalJSON() ([]byte, error) {
+	return []byte(fmt.Sprintf(`"%02d:%02d"`, int(c)/60, int(c)%60)), nil
+}
+
+// Warning codes
+const (
+	WarnClosed        = "closed"         // ปิดทั้งวัน หรือปิดไปแล้วตอนที่ไปถึง
+	WarnOpensLater    = "opens_later"    // ไปถึงก่อนเปิด ต้องรอ
+	WarnClosesEarly   = "closes_early"   // ปิดก่อนที่จะออก
+	WarnHoursVary     = "hours_vary"     // ทริปไม่มีวันที่ แต่เวลาเปิดต่างกันแต่ละวัน
+	WarnNoRoute       = "no_route"       // ไม่มีเส้นทางระหว่างพื้นที่ ประมาณจากระยะทางตรง
+	WarnNoCoordinates = "no_coordinates" // ไม่มีพิกัด คำนวณการเดินทางไม่ได้
+	WarnPastMidnight  = "past_midnight"  // วันนั้นเลยเที่ยงคืน
+)
+
+// Warning is something about a stop or a day the traveller should know.
+// At is the opening or closing time the warning is about, when there is one.
+type Warning struct {
+	Code string `json:"code"`
+	At   *Clock `json:"at,omitempty"`
+}
+
+// Leg is the way from one stop to the next. Estimated legs are worked out
+// from the straight-line distance rather than taken from the routes.
+type Leg struct {
+	Modes     []string  `json:"modes"`
+	Distance  float64   `json:"distance"` // km
+	Duration  int       `json:"duration"` // minutes
+	Cost      float64   `json:"cost"`     // baht
+	Estimated bool      `json:"estimated"`
+	Warnings  []Warning `json:"warnings"`
+}
+
+// Visit is when a stop is reached and left
+type Visit struct {
+	Arrive   Clock     `json:"arrive"`
+	Depart   Clock     `json:"depart"`
+	Wait     int       `json:"wait"` // minutes waiting for the place to open
+	Warnings []Warning `json:"warnings"`
+}
+
+// Totals add up a day or a whole trip. Duration runs from the start of the
+// day to leaving the last stop.
+type Totals struct {
+	Duration   int     `json:"duration"`
+	TravelTime int     `json:"travelTime"`
+	VisitTime  int     `json:"visitTime"`
+	WaitTime   int     `json:"waitTime"`
+	Distance   float64 `json:"distance"`
+	Cost       float64 `json:"cost"`
+}
+
+// DayPlan is a planned day. Legs[i] goes from stop i to stop i+1.
+type DayPlan struct {
+	Start    Clock     `json:"start"`
+	End      Clock     `json:"end"`
+	Visits   []Visit   `json:"visits"`
+	Legs     []Leg     `json:"legs"`
+	Totals   Totals    `json:"totals"`
+	Warnings []Warning `json:"warnings"`
+}
+
+// Itinerary is a planned trip
+type Itinerary struct {
+	Days   []DayPlan `json:"days"`
+	Totals Totals    `json:"totals"`
+}
+
+// Estimates for legs that are not on the routes
+const (
+	detourFactor = 1.3  // ระยะทางจริงเทียบกับระยะทางตรง
+	walkingLimit = 1.0  // km; ไกลกว่านี้นั่งแท็กซี่
+	walkingSpeed = 4.5  // km/h
+	taxiSpeed    = 20.0 // km/h ในเมือง
+	taxiFlagFall = 35.0 // baht, first km
+	taxiPerKm    = 7.0  // baht
+)
+
+// Plan lays out every day of a trip
+func Plan(g *Graph, days []Day) Itinerary {
+	it := Itinerary{Days: make([]DayPlan, len(days))}
+	for i, day := range days {
+		p := PlanDay(g, day)
+		it.Days[i] = p
+		it.Totals.Duration += p.Totals.Duration
+		it.Totals.TravelTime += p.Totals.TravelTime
+		it.Totals.VisitTime += p.Totals.VisitTime
+		it.Totals.WaitTime += p.Totals.WaitTime
+		it.Totals.Distance += p.Totals.Distance
+		it.Totals.Cost += p.Totals.Cost
+	}
+	it.Totals.Distance = round(it.Totals.Distance, 1)
+	return it
+}
+
+// PlanDay lays out a day, waiting at a stop for it to open when the day
+// gets there early
+func PlanDay(g *Graph, day Day) DayPlan {
+	p := DayPlan{
+		Start:    Clock(day.Start),
+		End:      Clock(day.Start),
+		Visits:   make([]Visit, len(day.Stops)),
+		Legs:     make([]Leg, 0, len(day.Stops)),
+		Warnings: []Warning{},
+	}
+	now := day.Start
+	for i, stop := range day.Stops {
+		if i > 0 {
+			leg := legBetween(g, day.Stops[i-1], stop)
+			p.Legs = append(p.Legs, leg)
+			now += leg.Duration
+			p.Totals.TravelTime += leg.Duration
+			p.Totals.Distance += leg.Distance
+			p.Totals.Cost += leg.Cost
+		}
+		v := visit(day, stop, now)
+		p.Visits[i] = v
+		now = int(v.Depart)
+		p.Totals.VisitTime += stop.Stay
+		p.Totals.WaitTime += v.Wait
+	}
+	p.End = Clock(now)
+	p.Totals.Duration = now - day.Start
+	p.Totals.Distance = round(p.Totals.Distance, 1)
+	if now > 24*60 {
+		p.Warnings = append(p.Warnings, Warning{Code: WarnPastMidnight})
+	}
+	return p
+}
+
+// visit places a stop reached at arrive against its opening hours
+func visit(day Day, stop Stop, arrive int) Visit {
+	v := Visit{Arrive: Clock(arrive), Depart: Clock(arrive + stop.Stay), Warnings: []Warning{}}
+	hours, ok := ParseHours(stop.Hours)
+	if !ok {
+		return v
+	}
+	var open []Interval
+	if day.Dated {
+		open = hours.On(day.Weekday)
+	} else if open, ok = hours.Daily(); !ok {
+		v.Warnings = append(v.Warnings, Warning{Code: WarnHoursVary})
+		return v
+	}
+
+	// เวลาที่เลยเที่ยงคืนยังเทียบกับเวลาเปิดของวันเดียวกัน
+	at := arrive
+	for _, iv := range open {
+		if at >= iv.Close {
+			continue
+		}
+		if at < iv.Open {
+			opening := Clock(iv.Open)
+			v.Wait = iv.Open - at
+			v.Warnings = append(v.Warnings, Warning{Code: WarnOpensLater, At: &opening})
+			at = iv.Open
+		}
+		v.Depart = Clock(at + stop.Stay)
+		if at+stop.Stay > iv.Close {
+			closing := Clock(iv.Close)
+			v.Warnings = append(v.Warnings, Warning{Code: WarnClosesEarly, At: &closing})
+		}
+		return v
+	}
+	v.Warnings = append(v.Warnings, Warning{Code: WarnClosed})
+	return v
+}
+
+// legBetween finds the way between two stops: along the routes when they
+// are in different locations and a route joins them, otherwise estimated
+// from how far apart they are
+func legBetween(g *Graph, from, to Stop) Leg {
+	leg := Leg{Modes: []string{}, Warnings: []Warning{}}
+	if from.LocationID != "" && to.LocationID != "" && from.LocationID != to.LocationID {
+		if path, ok := g.Path(from.LocationID, to.LocationID); ok {
+			for _, e := range path {
+				leg.Distance += e.Distance
+				leg.Duration += e.Duration
+				leg.Cost += e.Cost
+				if len(leg.Modes) == 0 || leg.Modes[len(leg.Modes)-1] != e.Mode {
+					leg.Modes = append(leg.Modes, e.Mode)
+				}
+			}
+			leg.Distance = round(leg.Distance, 1)
+			return leg
+		}
+		leg.Warnings = append(leg.Warnings, Warning{Code: WarnNoRoute})
+	}
+
+	leg.Estimated = true
+	if (from.Lat == 0 && from.Lng == 0) || (to.Lat == 0 && to.Lng == 0) {
+		leg.Warnings = append(leg.Warnings, Warning{Code: WarnNoCoordinates})
+		return leg
+	}
+	km := distanceKm(from.Lat, from.Lng, to.Lat, to.Lng) * detourFactor
+	leg.Distance = round(km, 1)
+	if km <= walkingLimit {
+		leg.Modes = append(leg.Modes, "walking")
+		leg.Duration = int(math.Ceil(km / walkingSpeed * 60))
+		return leg
+	}
+	leg.Modes = append(leg.Modes, "taxi")
+	leg.Duration = int(math.Ceil(km / taxiSpeed * 60))
+	leg.Cost = math.Round(taxiFlagFall + taxiPerKm*(km-1))
+	return leg
+}
+
+// distanceKm returns the great-circle distance between two points
+func distanceKm(lat1, lng1, lat2, lng2 float64) float64 {
+	const earthRadius = 6371.0
+	const rad = math.Pi / 180
+	dLat := (lat2 - lat1) * rad
+	dLng := (lng2 - lng1) * rad
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*rad)*math.Cos(lat2*rad)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(a))
+}
+
+func round(x float64, places int) float64 {
+	p := math.Pow(10, float64(places))
+	return math.Round(x*p) / p
+}
@@ -0,0 +1,275 @@
+package problem
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/text/language"
+)
+
+// Code is a stable, machine-readable error identifier. Clients should branch
+// on it rather than on the localized text.
+type Code string
+
+// General codes
+const (
+	CodeBadRequest       Code = "bad_request"
+	CodeInvalidBody      Code = "invalid_body"
+	CodeValidation       Code = "validation_failed"
+	CodeInvalidID        Code = "invalid_id"
+	CodeInvalidParameter Code = "invalid_parameter"
+	CodeUnauthorized     Code = "unauthorized"
+	CodeForbidden        Code = "forbidden"
+	CodeNotFound         Code = "not_found"
+	CodeConflict         Code = "conflict"
+	CodeInternal         Code = "internal_error"
+	CodeRouteNotFound    Code = "route_not_found"
+	CodeMethodNotAllowed Code = "method_not_allowed"
+)
+
+// Authentication and account codes
+const (
+	CodeTokenRequired            Code = "token_required"
+	CodeInvalidToken             Code = "invalid_token"
+	CodeInvalidCredentials       Code = "invalid_credentials"
+	CodePasswordIncorrect        Code = "password_incorrect"
+	CodeConfirmationMismatch     Code = "confirmation_mismatch"
+	CodeAccountBanned            Code = "account_banned"
+	CodeAdminRequired            Code = "admin_required"
+	CodeEmailTaken               Code = "email_taken"
+	CodeWeakPassword             Code = "weak_password"
+	CodePasswordUnchanged        Code = "password_unchanged"
+	CodePasswordChangeRequired   Code = "password_change_required"
+	CodeProviderNotFound         Code = "provider_not_found"
+	CodeProviderUnavailable      Code = "provider_unavailable"
+	CodeIdentityNotLinked        Code = "identity_not_linked"
+	CodeIdentityInUse            Code = "identity_in_use"
+	CodeLastLoginMethod          Code = "last_login_method"
+	CodeInvalidAPIKey            Code = "invalid_api_key"
+	CodeAPIKeyExpired            Code = "api_key_expired"
+	CodeAPIKeyNotAllowed         Code = "api_key_not_allowed"
+	CodeInsufficientScope        Code = "insufficient_scope"
+	CodeRateLimited              Code = "rate_limited"
+	CodeDeletionAlreadyRequested Code = "deletion_already_requested"
+	CodeNoPendingDeletion        Code = "no_pending_deletion"
+)
+
+// Resource codes
+const (
+	CodeUserNotFound            Code = "user_not_found"
+	CodePlaceNotFound           Code = "place_not_found"
+	CodeReviewNotFound          Code = "review_not_found"
+	CodeCommentNotFound         Code = "comment_not_found"
+	CodeInvalidParentComment    Code = "invalid_parent_comment"
+	CodeReportNotFound          Code = "report_not_found"
+	CodeClaimNotFound           Code = "claim_not_found"
+	CodeClaimPending            Code = "claim_pending"
+	CodeClaimNotPending         Code = "claim_not_pending"
+	CodePlaceAlreadyClaimed     Code = "place_already_claimed"
+	CodeNotPlaceOwner           Code = "not_place_owner"
+	CodeResponseNotFound        Code = "response_not_found"
+	CodeRouteSuggestionNotFound Code = "route_suggestion_not_found"
+	CodeAPIKeyNotFound          Code = "api_key_not_found"
+	CodeAPIKeyRevoked           Code = "api_key_revoked"
+	CodeNotOwner                Code = "not_owner"
+	CodeOwnReview               Code = "own_review"
+	CodeFileRequired            Code = "file_required"
+	CodeFileTooLarge            Code = "file_too_large"
+	CodeFileUnreadable          Code = "file_unreadable"
+	CodeUnsupportedImage        Code = "unsupported_image"
+	CodeImageTooLarge           Code = "image_too_large"
+	CodePhotoNotFound           Code = "photo_not_found"
+	CodeTooManyPhotos           Code = "too_many_photos"
+	CodeImportInvalid           Code = "import_invalid"
+	CodeInvalidCoordinates      Code = "invalid_coordinates"
+	CodeTooFarFromPlace         Code = "too_far_from_place"
+	CodeCheckedInRecently       Code = "checked_in_recently"
+	CodeLocationImplausible     Code = "location_implausible"
+	CodeCollectionNotFound      Code = "collection_not_found"
+	CodeTooManyCollections      Code = "too_many_collections"
+	CodeCollectionFull          Code = "collection_full"
+	CodeDefaultCollection       Code = "default_collection"
+	CodeCollectionOrder         Code = "collection_order_mismatch"
+	CodeTripNotFound            Code = "trip_not_found"
+	CodeTooManyTrips            Code = "too_many_trips"
+)
+
+// text holds a message in each supported language
+type text struct {
+	en, th string
+}
+
+var messages = map[Code]text{
+	CodeBadRequest:       {"The request could not be processed.", "ไม่สามารถประมวลผลคำขอได้"},
+	CodeInvalidBody:      {"The request body is not valid JSON.", "ข้อมูลที่ส่งมาไม่ใช่ JSON ที่ถูกต้อง"},
+	CodeValidation:       {"One or more fields are invalid.", "ข้อมูลบางช่องไม่ถูกต้อง"},
+	CodeInvalidID:        {"The ID in the request is not valid.", "รหัสที่ระบุไม่ถูกต้อง"},
+	CodeInvalidParameter: {"One or more query parameters are invalid.", "พารามิเตอร์บางตัวไม่ถูกต้อง"},
+	CodeUnauthorized:     {"Authentication is required.", "กรุณาเข้าสู่ระบบ"},
+	CodeForbidden:        {"You are not allowed to do this.", "คุณไม่มีสิทธิ์ทำรายการนี้"},
+	CodeNotFound:         {"The requested resource was not found.", "ไม่พบข้อมูลที่ต้องการ"},
+	CodeConflict:         {"The resource already exists.", "ข้อมูลนี้มีอยู่แล้ว"},
+	CodeInternal:         {"An unexpected error occurred. Please try again later.", "เกิดข้อผิดพลาดในระบบ กรุณาลองใหม่ภายหลัง"},
+	CodeRouteNotFound:    {"No endpoint matches this path.", "ไม่พบ endpoint นี้"},
+	CodeMethodNotAllowed: {"This method is not allowed on this endpoint.", "endpoint นี้ไม่รองรับ method ที่ใช้"},
+
+	CodeTokenRequired:            {"An authorization token is required.", "ต้องใช้ token สำหรับยืนยันตัวตน"},
+	CodeInvalidToken:             {"The authorization token is invalid or has expired.", "token ไม่ถูกต้องหรือหมดอายุแล้ว"},
+	CodeInvalidCredentials:       {"The email or password is incorrect.", "อีเมลหรือรหัสผ่านไม่ถูกต้อง"},
+	CodePasswordIncorrect:        {"The password is incorrect.", "รหัสผ่านไม่ถูกต้อง"},
+	CodeConfirmationMismatch:     {"Type your account's email address to confirm.", "กรุณาพิมพ์อีเมลของบัญชีเพื่อยืนยัน"},
+	CodeAccountBanned:            {"Your account has been banned.", "บัญชีของคุณถูกระงับการใช้งาน"},
+	CodeAdminRequired:            {"Admin access is required.", "ต้องเป็นผู้ดูแลระบบเท่านั้น"},
+	CodeEmailTaken:               {"An account with this email already exists.", "อีเมลนี้ถูกใช้งานแล้ว"},
+	CodeWeakPassword:             {"The password does not meet the password policy.", "รหัสผ่านไม่เป็นไปตามนโยบายรหัสผ่าน"},
+	CodePasswordUnchanged:        {"The new password must be different from the current one.", "รหัสผ่านใหม่ต้องไม่ซ้ำกับรหัสผ่านเดิม"},
+	CodePasswordChangeRequired:   {"You must change your password before continuing.", "กรุณาเปลี่ยนรหัสผ่านก่อนใช้งานต่อ"},
+	CodeProviderNotFound:         {"This sign-in provider is not enabled.", "ไม่ได้เปิดใช้การเข้าสู่ระบบด้วยผู้ให้บริการนี้"},
+	CodeProviderUnavailable:      {"The sign-in provider could not be reached. Please try again later.", "ไม่สามารถติดต่อผู้ให้บริการเข้าสู่ระบบได้ กรุณาลองใหม่ภายหลัง"},
+	CodeIdentityNotLinked:        {"This provider is not linked to your account.", "บัญชีของคุณยังไม่ได้เชื่อมกับผู้ให้บริการนี้"},
+	CodeIdentityInUse:            {"This provider account is already linked to another user.", "บัญชีของผู้ให้บริการนี้ถูกเชื่อมกับผู้ใช้อื่นแล้ว"},
+	CodeLastLoginMethod:          {"Set a password or link another provider before unlinking your only sign-in method.", "กรุณาตั้งรหัสผ่านหรือเชื่อมผู้ให้บริการอื่นก่อนยกเลิกวิธีเข้าสู่ระบบสุดท้าย"},
+	CodeInvalidAPIKey:            {"The API key is not valid or has been revoked.", "API key ไม่ถูกต้องหรือถูกเพิกถอนแล้ว"},
+	CodeAPIKeyExpired:            {"The API key has expired.", "API key หมดอายุแล้ว"},
+	CodeAPIKeyNotAllowed:         {"This endpoint cannot be used with an API key.", "ไม่สามารถใช้ API key กับ endpoint นี้ได้"},
+	CodeInsufficientScope:        {"The API key does not have the scope this endpoint requires.", "API key ไม่มี scope ที่ endpoint นี้ต้องการ"},
+	CodeRateLimited:              {"Too many requests. Please retry later.", "ส่งคำขอถี่เกินไป กรุณาลองใหม่ภายหลัง"},
+	CodeDeletionAlreadyRequested: {"Account deletion has already been requested.", "มีการขอลบบัญชีไว้แล้ว"},
+	CodeNoPendingDeletion:        {"There is no pending account deletion.", "ไม่มีคำขอลบบัญชีที่รอดำเนินการ"},
+
+	CodeUserNotFound:            {"User not found.", "ไม่พบผู้ใช้"},
+	CodePlaceNotFound:           {"Place not found.", "ไม่พบสถานที่"},
+	CodeReviewNotFound:          {"Review not found.", "ไม่พบรีวิว"},
+	CodeCommentNotFound:         {"Comment not found.", "ไม่พบความคิดเห็น"},
+	CodeInvalidParentComment:    {"The comment being replied to is not on this review or can no longer be replied to.", "ไม่สามารถตอบกลับความคิดเห็นนี้ได้ หรือความคิดเห็นไม่ได้อยู่ในรีวิวนี้"},
+	CodeReportNotFound:          {"Report not found.", "ไม่พบรายงาน"},
+	CodeClaimNotFound:           {"Place claim not found.", "ไม่พบคำขอยืนยันความเป็นเจ้าของ"},
+	CodeClaimPending:            {"You already have a pending claim for this place.", "คุณมีคำขอยืนยันความเป็นเจ้าของสถานที่นี้ที่รอตรวจสอบอยู่แล้ว"},
+	CodeClaimNotPending:         {"This claim has already been decided.", "คำขอนี้ได้รับการพิจารณาไปแล้ว"},
+	CodePlaceAlreadyClaimed:     {"This place already has a verified owner.", "สถานที่นี้มีเจ้าของที่ยืนยันแล้ว"},
+	CodeNotPlaceOwner:           {"Only the verified owner of this place can do this.", "เฉพาะเจ้าของสถานที่ที่ยืนยันแล้วเท่านั้นที่ทำรายการนี้ได้"},
+	CodeResponseNotFound:        {"This review has no owner response.", "รีวิวนี้ยังไม่มีการตอบกลับจากเจ้าของ"},
+	CodeRouteSuggestionNotFound: {"Route suggestion not found.", "ไม่พบเส้นทางที่แนะนำ"},
+	CodeAPIKeyNotFound:          {"The API key could not be found.", "ไม่พบ API key"},
+	CodeAPIKeyRevoked:           {"The API key has been revoked.", "API key ถูกเพิกถอนแล้ว"},
+	CodeNotOwner:                {"You can only change your own content.", "คุณแก้ไขหรือลบได้เฉพาะข้อมูลของตัวเอง"},
+	CodeOwnReview:               {"You cannot vote on your own review.", "ไม่สามารถโหวตรีวิวของตัวเองได้"},
+	CodeFileRequired:            {"No file was received.", "ไม่ได้รับไฟล์"},
+	CodeFileTooLarge:            {"The file is larger than %d bytes.", "ไฟล์มีขนาดเกิน %d ไบต์"},
+	CodeFileUnreadable:          {"The uploaded file could not be read.", "ไม่สามารถอ่านไฟล์ที่อัปโหลดได้"},
+	CodeUnsupportedImage:        {"Only JPEG and PNG images are accepted.", "รองรับเฉพาะไฟล์ภาพ JPEG และ PNG"},
+	CodeImageTooLarge:           {"The image has too many pixels.", "ภาพมีความละเอียดสูงเกินไป"},
+	CodePhotoNotFound:           {"Photo not found.", "ไม่พบรูปภาพ"},
+	CodeTooManyPhotos:           {"A review can have at most %d photos.", "รีวิวหนึ่งรายการแนบรูปได้ไม่เกิน %d รูป"},
+	CodeImportInvalid:           {"Some rows are invalid; nothing was imported.", "มีบางแถวไม่ถูกต้อง จึงยังไม่ได้นำเข้าข้อมูล"},
+	CodeInvalidCoordinates:      {"The address coordinates are invalid.", "พิกัดของที่อยู่ไม่ถูกต้อง"},
+	CodeTooFarFromPlace:         {"You must be within %d meters of the place to check in.", "ต้องอยู่ห่างจากสถานที่ไม่เกิน %d เมตรจึงจะเช็กอินได้"},
+	CodeCheckedInRecently:       {"You checked in here recently. Please try again later.", "คุณเพิ่งเช็กอินที่นี่ไป กรุณาลองใหม่ภายหลัง"},
+	CodeLocationImplausible:     {"Your location could not be confirmed.", "ไม่สามารถยืนยันตำแหน่งของคุณได้"},
+	CodeCollectionNotFound:      {"Collection not found.", "ไม่พบคอลเลกชัน"},
+	CodeTooManyCollections:      {"You can have at most %d collections.", "สร้างคอลเลกชันได้ไม่เกิน %d รายการ"},
+	CodeCollectionFull:          {"A collection can hold at most %d places.", "คอลเลกชันหนึ่งเก็บสถานที่ได้ไม่เกิน %d แห่ง"},
+	CodeDefaultCollection:       {"Favorites cannot be renamed or deleted.", "ไม่สามารถเปลี่ยนชื่อหรือลบรายการโปรดได้"},
+	CodeCollectionOrder:         {"The new order must list each place in the collection exactly once.", "ลำดับใหม่ต้องมีสถานที่ในคอลเลกชันครบทุกแห่งและไม่ซ้ำกัน"},
+	CodeTripNotFound:            {"Trip not found.", "ไม่พบแผนการเดินทาง"},
+	CodeTooManyTrips:            {"You can have at most %d trips.", "สร้างแผนการเดินทางได้ไม่เกิน %d รายการ"},
+}
+
+// ruleMessages explain a failed validation rule; %s is the rule parameter
+var ruleMessages = map[string]text{
+	"required":       {"is required", "จำเป็นต้องกรอก"},
+	"email":          {"must be a valid email address", "ต้องเป็นอีเมลที่ถูกต้อง"},
+	"url":            {"must be a valid URL", "ต้องเป็น URL ที่ถูกต้อง"},
+	"min":            {"must be at least %s", "ต้องมีค่าหรือความยาวอย่างน้อย %s"},
+	"max":            {"must be at most %s", "ต้องมีค่าหรือความยาวไม่เกิน %s"},
+	"len":            {"must have length %s", "ต้องมีความยาว %s"},
+	"gte":            {"must be at least %s", "ต้องมีค่าอย่างน้อย %s"},
+	"lte":            {"must be at most %s", "ต้องมีค่าไม่เกิน %s"},
+	"gt":             {"must be greater than %s", "ต้องมากกว่า %s"},
+	"lt":             {"must be less than %s", "ต้องน้อยกว่า %s"},
+	"range":          {"must be between %s", "ต้องอยู่ระหว่าง %s"},
+	"oneof":          {"must be one of: %s", "ต้องเป็นค่าใดค่าหนึ่งต่อไปนี้: %s"},
+	"numeric":        {"must be numeric", "ต้องเป็นตัวเลข"},
+	"number":         {"must be a number", "ต้องเป็นตัวเลข"},
+	"eqfield":        {"must match %s", "ต้องตรงกับ %s"},
+	"ltefield":       {"must not be after %s", "ต้องไม่อยู่หลัง %s"},
+	"datetime":       {"must be a date in the format %s", "ต้องเป็นวันที่ในรูปแบบ %s"},
+	"latitude":       {"must be a latitude between -90 and 90", "ต้องเป็นละติจูดระหว่าง -90 ถึง 90"},
+	"longitude":      {"must be a longitude between -180 and 180", "ต้องเป็นลองจิจูดระหว่าง -180 ถึง 180"},
+	"type":           {"must be of type %s", "ต้องเป็นชนิด %s"},
+	"hexadecimal":    {"must be hexadecimal", "ต้องเป็นเลขฐานสิบหก"},
+	"objectid":       {"must be a valid ID", "ต้องเป็นรหัสที่ถูกต้อง"},
+	"th_postcode":    {"must be a valid Thai postal code", "ต้องเป็นรหัสไปรษณีย์ไทยที่ถูกต้อง"},
+	"th_phone":       {"must be a valid Thai phone number", "ต้องเป็นหมายเลขโทรศัพท์ไทยที่ถูกต้อง"},
+	"place_category": {"must be one of the place categories", "ต้องเป็นหมวดหมู่สถานที่ที่กำหนด"},
+	"api_scope":      {"must be a known API key scope", "ต้องเป็น scope ของ API key ที่รองรับ"},
+	"unique":         {"must not contain duplicates", "ต้องไม่มีค่าซ้ำกัน"},
+}
+
+var statusTitles = map[int]text{
+	http.StatusBadRequest:            {"Bad Request", "คำขอไม่ถูกต้อง"},
+	http.StatusUnauthorized:          {"Unauthorized", "ยังไม่ได้ยืนยันตัวตน"},
+	http.StatusForbidden:             {"Forbidden", "ไม่มีสิทธิ์เข้าถึง"},
+	http.StatusNotFound:              {"Not Found", "ไม่พบข้อมูล"},
+	http.StatusMethodNotAllowed:      {"Method Not Allowed", "ไม่รองรับ method นี้"},
+	http.StatusConflict:              {"Conflict", "ข้อมูลขัดแย้ง"},
+	http.StatusRequestEntityTooLarge: {"Payload Too Large", "ข้อมูลมีขนาดใหญ่เกินไป"},
+	http.StatusUnprocessableEntity:   {"Unprocessable Entity", "ไม่สามารถประมวลผลข้อมูลได้"},
+	http.StatusTooManyRequests:       {"Too Many Requests", "ส่งคำขอถี่เกินไป"},
+	http.StatusInternalServerError:   {"Internal Server Error", "เกิดข้อผิดพลาดในระบบ"},
+	http.StatusBadGateway:            {"Bad Gateway", "ติดต่อระบบภายนอกไม่สำเร็จ"},
+	http.StatusServiceUnavailable:    {"Service Unavailable", "ระบบไม่พร้อมให้บริการชั่วคราว"},
+}
+
+var supported = []language.Tag{language.English, language.Thai}
+
+var matcher = language.NewMatcher(supported)
+
+// Language picks "en" or "th" from the request's Accept-Language header
+func Language(c *gin.Context) string {
+	tags, _, err := language.ParseAcceptLanguage(c.GetHeader("Accept-Language"))
+	if err != nil || len(tags) == 0 {
+		return "en"
+	}
+	_, index, _ := matcher.Match(tags...)
+	if supported[index] == language.Thai {
+		return "th"
+	}
+	return "en"
+}
+
+func (t text) in(lang string) string {
+	if lang == "th" && t.th != "" {
+		return t.th
+	}
+	return t.en
+}
+
+func message(lang string, code Code) string {
+	if t, ok := messages[code]; ok {
+		return t.in(lang)
+	}
+	return ""
+}
+
+func statusTitle(lang string, status int) string {
+	if t, ok := statusTitles[status]; ok {
+		return t.in(lang)
+	}
+	return http.StatusText(status)
+}
+
+func ruleMessage(lang, rule, param string) string {
+	t, ok := ruleMessages[rule]
+	if !ok {
+		t = text{"failed the %s rule", "ไม่ผ่านเงื่อนไข %s"}
+		param = rule
+	}
+	if param == "" {
+		// rules used without a parameter: drop the placeholder
+		return strings.TrimSpace(strings.Replace(t.in(lang), "%s", "", 1))
+	}
+	return fmt.Sprintf(t.in(lang), param)
+}
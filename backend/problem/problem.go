@@ -0,0 +1,170 @@
+// Package problem writes RFC 7807 application/problem+json error responses.
+// Every error carries a stable machine-readable code; the human-readable
+// title and detail are localized (English or Thai) from Accept-Language.
+package problem
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"gosmooth/logger"
+)
+
+// ContentType is the media type of problem responses
+const ContentType = "application/problem+json"
+
+// Sentinel errors for store functions; Error maps them to a response.
+var (
+	ErrBadRequest   = errors.New("bad request")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+)
+
+// Problem is the response body. Extension members are flattened into the
+// top-level object as RFC 7807 allows.
+type Problem struct {
+	Type       string         `json:"type"`
+	Title      string         `json:"title"`
+	Status     int            `json:"status"`
+	Detail     string         `json:"detail,omitempty"`
+	Instance   string         `json:"instance,omitempty"`
+	Code       Code           `json:"code"`
+	RequestID  string         `json:"requestId,omitempty"`
+	Errors     []FieldError   `json:"errors,omitempty"`
+	Extensions map[string]any `json:"-"`
+}
+
+// FieldError describes one invalid field of the request
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// MarshalJSON flattens Extensions next to the standard members
+func (p Problem) MarshalJSON() ([]byte, error) {
+	type plain Problem
+	data, err := json.Marshal(plain(p))
+	if err != nil || len(p.Extensions) == 0 {
+		return data, err
+	}
+	merged := map[string]any{}
+	if err := json.Unmarshal(data, &merged); err != nil {
+		return nil, err
+	}
+	for k, v := range p.Extensions {
+		if _, taken := merged[k]; !taken {
+			merged[k] = v
+		}
+	}
+	return json.Marshal(merged)
+}
+
+// Option customizes a problem before it is written
+type Option func(*Problem, string)
+
+// Args fills the placeholders of the code's message
+func Args(args ...any) Option {
+	return func(p *Problem, _ string) {
+		p.Detail = fmt.Sprintf(p.Detail, args...)
+	}
+}
+
+// With adds an extension member such as banReason
+func With(key string, value any) Option {
+	return func(p *Problem, _ string) {
+		if p.Extensions == nil {
+			p.Extensions = map[string]any{}
+		}
+		p.Extensions[key] = value
+	}
+}
+
+// Field adds a field error for a rule in the validation catalog, used for
+// checks done by hand such as query parameters.
+func Field(field, rule, param string) Option {
+	return func(p *Problem, lang string) {
+		p.Errors = append(p.Errors, FieldError{
+			Field:   field,
+			Rule:    rule,
+			Param:   param,
+			Message: ruleMessage(lang, rule, param),
+		})
+	}
+}
+
+// New builds a localized problem for the request
+func New(c *gin.Context, status int, code Code, opts ...Option) Problem {
+	lang := Language(c)
+	p := Problem{
+		Type:      "/problems/" + string(code),
+		Title:     statusTitle(lang, status),
+		Status:    status,
+		Detail:    message(lang, code),
+		Instance:  c.Request.URL.Path,
+		Code:      code,
+		RequestID: logger.RequestID(c.Request.Context()),
+	}
+	for _, opt := range opts {
+		opt(&p, lang)
+	}
+	// ให้ client ที่แสดงแค่ detail ยังเห็นว่าช่องไหนผิด
+	if code == CodeValidation && len(p.Errors) == 1 {
+		p.Detail = p.Errors[0].Field + " " + p.Errors[0].Message
+	}
+	return p
+}
+
+// Abort writes a problem response and stops the handler chain
+func Abort(c *gin.Context, status int, code Code, opts ...Option) {
+	p := New(c, status, code, opts...)
+	c.Header("Content-Type", ContentType)
+	c.AbortWithStatusJSON(status, p)
+}
+
+// Internal logs err and responds with a generic 500 that leaks no details
+func Internal(c *gin.Context, err error) {
+	slog.ErrorContext(c.Request.Context(), "internal error",
+		"route", c.FullPath(), "method", c.Request.Method, "error", err)
+	Abort(c, http.StatusInternalServerError, CodeInternal)
+}
+
+// Error maps err to a response: binding errors become validation problems,
+// sentinel and driver errors their matching status, anything else a 500.
+func Error(c *gin.Context, err error) {
+	switch {
+	case isBindingError(err):
+		Validation(c, err)
+	case errors.Is(err, ErrBadRequest):
+		Abort(c, http.StatusBadRequest, CodeBadRequest)
+	case errors.Is(err, ErrUnauthorized):
+		Abort(c, http.StatusUnauthorized, CodeUnauthorized)
+	case errors.Is(err, ErrForbidden):
+		Abort(c, http.StatusForbidden, CodeForbidden)
+	case errors.Is(err, ErrNotFound), errors.Is(err, mongo.ErrNoDocuments):
+		Abort(c, http.StatusNotFound, CodeNotFound)
+	case errors.Is(err, ErrConflict), mongo.IsDuplicateKeyError(err):
+		Abort(c, http.StatusConflict, CodeConflict)
+	default:
+		Internal(c, err)
+	}
+}
+
+// NoRoute responds to paths that match no route
+func NoRoute(c *gin.Context) {
+	Abort(c, http.StatusNotFound, CodeRouteNotFound)
+}
+
+// NoMethod responds to a known path called with the wrong method
+func NoMethod(c *gin.Context) {
+	Abort(c, http.StatusMethodNotAllowed, CodeMethodNotAllowed)
+}
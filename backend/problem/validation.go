@@ -0,0 +1,71 @@
+package problem
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// Validation responds 400 with one field error per failed rule. Malformed
+// JSON is reported as invalid_body instead.
+func Validation(c *gin.Context, err error) {
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) && typeErr.Field != "" {
+			Abort(c, http.StatusBadRequest, CodeValidation, Field(typeErr.Field, "type", typeErr.Type.String()))
+			return
+		}
+		Abort(c, http.StatusBadRequest, CodeInvalidBody)
+		return
+	}
+
+	opts := make([]Option, 0, len(verrs))
+	for _, fe := range verrs {
+		opts = append(opts, Field(fieldPath(fe), fe.Tag(), fe.Param()))
+	}
+	Abort(c, http.StatusBadRequest, CodeValidation, opts...)
+}
+
+// FieldMessage is a failed rule described in English
+type FieldMessage struct {
+	Field   string
+	Message string
+}
+
+// FieldMessages describes each failed rule of a validation error, such as
+// "must be a valid Thai phone number" for phone, for reports that are not a
+// problem response. Other errors give nil.
+func FieldMessages(err error) []FieldMessage {
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return nil
+	}
+	out := make([]FieldMessage, 0, len(verrs))
+	for _, fe := range verrs {
+		out = append(out, FieldMessage{Field: fieldPath(fe), Message: ruleMessage("en", fe.Tag(), fe.Param())})
+	}
+	return out
+}
+
+// fieldPath turns "RegisterInput.address.lat" into "address.lat"
+func fieldPath(fe validator.FieldError) string {
+	ns := fe.Namespace()
+	if i := strings.IndexByte(ns, '.'); i >= 0 {
+		return ns[i+1:]
+	}
+	return fe.Field()
+}
+
+func isBindingError(err error) bool {
+	var verrs validator.ValidationErrors
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	return errors.As(err, &verrs) || errors.As(err, &syntaxErr) || errors.As(err, &typeErr) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
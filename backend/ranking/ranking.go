@@ -0,0 +1,77 @@
+// Package ranking scores reviews for the "relevant" sort. A score combines
+// how helpful readers found a review, how much it says, whether it has
+// photos and how well its author's other reviews were received, and decays
+// with the review's age.
+//
+// Decay is folded in as an offset on a log scale: the score is
+// ln(quality) + (created - epoch) / tau, which orders reviews exactly as
+// quality × e^(-age/tau) does at any moment. A stored score therefore never
+// has to be recomputed as time passes, only when its inputs change, and the
+// sort can use an index on the stored value.
+package ranking
+
+import (
+	"math"
+	"time"
+)
+
+// DefaultHalfLife is how long it takes for a review's weight to halve
+const DefaultHalfLife = 30 * 24 * time.Hour
+
+// epoch keeps the time offset small; scores from different epochs must not be mixed
+var epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Weights of the quality signals. A review with no votes, no text and no
+// photos by a new author has quality 1 (score 0 at the epoch).
+const (
+	helpfulWeight    = 4.0
+	lengthWeight     = 1.0
+	photoWeight      = 0.5
+	reputationWeight = 1.0
+
+	// fullLength is the text length, in characters, that earns the whole
+	// length weight; longer reviews are not favoured further
+	fullLength = 600
+)
+
+// z is the normal quantile for a 95% confidence interval
+const z = 1.96
+
+// Review holds the inputs to a review's score
+type Review struct {
+	Likes      int
+	NotHelpful int
+	// TextLength is the review text's length in characters
+	TextLength int
+	HasPhotos  bool
+	// AuthorLikes and AuthorNotHelpful count the votes on all of the
+	// author's reviews
+	AuthorLikes      int
+	AuthorNotHelpful int
+	CreatedAt        time.Time
+}
+
+// Score returns the rank of r; higher ranks first. halfLife must be positive.
+func Score(r Review, halfLife time.Duration) float64 {
+	quality := 1 +
+		helpfulWeight*Wilson(r.Likes, r.NotHelpful) +
+		lengthWeight*math.Min(float64(r.TextLength)/fullLength, 1) +
+		reputationWeight*Wilson(r.AuthorLikes, r.AuthorNotHelpful)
+	if r.HasPhotos {
+		quality += photoWeight
+	}
+	tau := halfLife.Hours() / math.Ln2
+	return math.Log(quality) + r.CreatedAt.Sub(epoch).Hours()/tau
+}
+
+// Wilson returns the lower bound of the Wilson score interval for the share
+// of positive votes, so a few votes count for less than many with the same
+// ratio. It is 0 without votes.
+func Wilson(positive, negative int) float64 {
+	n := float64(positive + negative)
+	if n <= 0 || positive <= 0 {
+		return 0
+	}
+	p := float64(positive) / n
+	return (p + z*z/(2*n) - z*math.Sqrt((p*(1-p)+z*z/(4*n))/n)) / (1 + z*z/n)
+}
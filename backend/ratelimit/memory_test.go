@@ -0,0 +1,105 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// fakeClock is a MemoryStore clock moved by hand
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time      { return c.t }
+func (c *fakeClock) add(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTestStore() (*MemoryStore, *fakeClock) {
+	clock := &fakeClock{t: time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)}
+	s := NewMemoryStore()
+	s.now = clock.now
+	return s, clock
+}
+
+func take(t *testing.T, s *MemoryStore, key string, p Policy) Result {
+	t.Helper()
+	r, err := s.Take(context.Background(), key, p)
+	if err != nil {
+		t.Fatalf("Take(%q) error: %v", key, err)
+	}
+	return r
+}
+
+func TestMemoryStoreTakeBurstThenRefill(t *testing.T) {
+	s, clock := newTestStore()
+	p := Policy{Name: "test", Limit: 6, Period: time.Minute, Burst: 3} // a token every 10s
+
+	for i, want := range []int{2, 1, 0} {
+		r := take(t, s, "a", p)
+		if !r.Allowed || r.Remaining != want || r.Limit != 3 {
+			t.Fatalf("take %d = %+v, want allowed with %d remaining of 3", i+1, r, want)
+		}
+	}
+	r := take(t, s, "a", p)
+	if r.Allowed || r.Remaining != 0 {
+		t.Fatalf("take on empty bucket = %+v, want refused", r)
+	}
+	if r.RetryAfter != 10*time.Second || r.Reset != 30*time.Second {
+		t.Errorf("RetryAfter, Reset = %v, %v, want 10s, 30s", r.RetryAfter, r.Reset)
+	}
+
+	clock.add(5 * time.Second)
+	if r := take(t, s, "a", p); r.Allowed || r.RetryAfter != 5*time.Second {
+		t.Errorf("take after 5s = %+v, want refused with 5s to wait", r)
+	}
+	clock.add(5 * time.Second)
+	if r := take(t, s, "a", p); !r.Allowed || r.Remaining != 0 {
+		t.Errorf("take after 10s = %+v, want allowed with 0 remaining", r)
+	}
+
+	// ไม่เติมเกินขนาดถัง
+	clock.add(time.Hour)
+	if r := take(t, s, "a", p); !r.Allowed || r.Remaining != 2 {
+		t.Errorf("take after an hour = %+v, want allowed with 2 remaining", r)
+	}
+}
+
+func TestMemoryStoreTakeKeysAreSeparate(t *testing.T) {
+	s, _ := newTestStore()
+	p := Policy{Name: "test", Limit: 1, Period: time.Minute}
+
+	if r := take(t, s, "a", p); !r.Allowed {
+		t.Fatalf("first take for a refused: %+v", r)
+	}
+	if r := take(t, s, "a", p); r.Allowed {
+		t.Fatalf("second take for a allowed: %+v", r)
+	}
+	if r := take(t, s, "b", p); !r.Allowed {
+		t.Errorf("first take for b refused: %+v", r)
+	}
+}
+
+func TestMemoryStoreTakeClockGoesBack(t *testing.T) {
+	s, clock := newTestStore()
+	p := Policy{Name: "test", Limit: 2, Period: time.Minute}
+
+	take(t, s, "a", p)
+	take(t, s, "a", p)
+	clock.add(-time.Minute)
+	if r := take(t, s, "a", p); r.Allowed {
+		t.Errorf("take after the clock went back = %+v, want refused", r)
+	}
+}
+
+func TestMemoryStoreSweep(t *testing.T) {
+	s, clock := newTestStore()
+	p := Policy{Name: "test", Limit: 10, Period: time.Minute}
+
+	take(t, s, "idle", p)
+	clock.add(2 * time.Minute)
+	take(t, s, "busy", p)
+	if _, ok := s.buckets["idle"]; ok {
+		t.Error("idle bucket was not swept once full again")
+	}
+	if _, ok := s.buckets["busy"]; !ok {
+		t.Error("busy bucket was swept")
+	}
+}
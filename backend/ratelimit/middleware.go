@@ -0,0 +1,99 @@
+package ratelimit
+
+import (
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"gosmooth/metrics"
+	"gosmooth/problem"
+)
+
+// Limiter builds per-route middleware sharing one store
+type Limiter struct {
+	store Store
+	// exempt skips limiting for a request, e.g. one made by an admin
+	exempt    func(*gin.Context) bool
+	overrides map[string]Policy
+}
+
+// NewLimiter returns a limiter using store; a nil store disables limiting.
+// overrides replace policies by name, and exempt may be nil.
+func NewLimiter(store Store, overrides map[string]Policy, exempt func(*gin.Context) bool) *Limiter {
+	return &Limiter{store: store, exempt: exempt, overrides: overrides}
+}
+
+// Limit throttles each caller to policy p. Route it after RequireAuth to key
+// by user or API key; before it, callers are told apart by IP address.
+func (l *Limiter) Limit(p Policy) gin.HandlerFunc {
+	if l == nil || l.store == nil {
+		return func(c *gin.Context) { c.Next() }
+	}
+	if o, ok := l.overrides[p.Name]; ok {
+		p = o
+	}
+	return func(c *gin.Context) {
+		if l.exempt != nil && l.exempt(c) {
+			c.Next()
+			return
+		}
+		if !Allow(c, l.store, p, p.Name+":"+Key(c)) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// Allow takes a token for key and writes the rate limit headers. When the
+// bucket is empty it aborts with 429 and returns false. Store errors are
+// logged and let the request through rather than failing it.
+func Allow(c *gin.Context, store Store, p Policy, key string) bool {
+	result, err := store.Take(c.Request.Context(), key, p)
+	if err != nil {
+		slog.WarnContext(c.Request.Context(), "rate limit store unavailable", "policy", p.Name, "error", err)
+		return true
+	}
+	writeHeaders(c, p, result)
+	if !result.Allowed {
+		metrics.RateLimited.WithLabelValues(p.Name).Inc()
+		problem.Abort(c, http.StatusTooManyRequests, problem.CodeRateLimited)
+		return false
+	}
+	return true
+}
+
+// Key identifies the caller: the API key or user set by RequireAuth, or
+// else the client IP
+func Key(c *gin.Context) string {
+	if id := c.GetString("apiKeyID"); id != "" {
+		return "key:" + id
+	}
+	if id := c.GetString("userID"); id != "" {
+		return "user:" + id
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// writeHeaders sets the RateLimit-* headers from the IETF draft. When several
+// policies apply to one route, the one closest to running out is reported.
+func writeHeaders(c *gin.Context, p Policy, r Result) {
+	h := c.Writer.Header()
+	if prev, err := strconv.Atoi(h.Get("RateLimit-Remaining")); err == nil && prev < r.Remaining && r.Allowed {
+		return
+	}
+	h.Set("RateLimit-Limit", strconv.Itoa(r.Limit))
+	h.Set("RateLimit-Remaining", strconv.Itoa(r.Remaining))
+	h.Set("RateLimit-Reset", ceilSeconds(r.Reset))
+	h.Set("RateLimit-Policy", strconv.Itoa(p.Limit)+";w="+ceilSeconds(p.Period))
+	if !r.Allowed {
+		h.Set("Retry-After", ceilSeconds(r.RetryAfter))
+	}
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
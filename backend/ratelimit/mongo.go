@@ -0,0 +1,69 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoStore keeps buckets in a MongoDB collection so every API instance
+// shares them. Each take is a single atomic update, and a TTL index on
+// expires_at removes buckets once they have refilled.
+type MongoStore struct {
+	coll *mongo.Collection
+}
+
+// NewMongoStore stores buckets in coll
+func NewMongoStore(coll *mongo.Collection) *MongoStore {
+	return &MongoStore{coll: coll}
+}
+
+// Take implements Store
+func (s *MongoStore) Take(ctx context.Context, key string, p Policy) (Result, error) {
+	result, err := s.take(ctx, key, p)
+	if mongo.IsDuplicateKeyError(err) {
+		// คำขอแรกของ key เดียวกันสองคำขอ upsert พร้อมกัน ลองใหม่จะเจอเอกสารที่สร้างแล้ว
+		result, err = s.take(ctx, key, p)
+	}
+	return result, err
+}
+
+func (s *MongoStore) take(ctx context.Context, key string, p Policy) (Result, error) {
+	now := time.Now()
+	capacity := float64(p.Capacity())
+	perMilli := p.perSecond() / 1000
+	updated := bson.M{"$ifNull": bson.A{"$updated_at", now}}
+
+	// same arithmetic as refill, evaluated by the server so concurrent takes
+	// on one bucket cannot both spend the same token
+	pipeline := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"tokens": bson.M{"$min": bson.A{capacity, bson.M{"$add": bson.A{
+				bson.M{"$ifNull": bson.A{"$tokens", capacity}},
+				bson.M{"$multiply": bson.A{
+					bson.M{"$max": bson.A{0, bson.M{"$subtract": bson.A{now, updated}}}},
+					perMilli,
+				}},
+			}}}},
+			"updated_at": bson.M{"$max": bson.A{now, updated}},
+		}}},
+		{{Key: "$set", Value: bson.M{"allowed": bson.M{"$gte": bson.A{"$tokens", 1}}}}},
+		{{Key: "$set", Value: bson.M{
+			"tokens":     bson.M{"$cond": bson.A{"$allowed", bson.M{"$subtract": bson.A{"$tokens", 1}}, "$tokens"}},
+			"expires_at": now.Add(p.fillTime()),
+		}}},
+	}
+
+	var doc struct {
+		Tokens  float64 `bson:"tokens"`
+		Allowed bool    `bson:"allowed"`
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	if err := s.coll.FindOneAndUpdate(ctx, bson.M{"_id": key}, pipeline, opts).Decode(&doc); err != nil {
+		return Result{}, err
+	}
+	return newResult(p, doc.Tokens, doc.Allowed), nil
+}
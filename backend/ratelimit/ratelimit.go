@@ -0,0 +1,141 @@
+// Package ratelimit throttles requests with token buckets. Each Policy names
+// a bucket family with a refill rate and a burst size; buckets are kept per
+// caller in a Store, either in memory for a single instance or in MongoDB so
+// every instance shares the same budget.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Policy is a token bucket: Limit tokens are added every Period and the
+// bucket holds at most Burst. A request takes one token.
+type Policy struct {
+	// Name identifies the policy in bucket keys, metrics and overrides
+	Name   string
+	Limit  int
+	Period time.Duration
+	// Burst is the bucket size; zero means Limit
+	Burst int
+}
+
+// Capacity is the bucket size
+func (p Policy) Capacity() int {
+	if p.Burst > 0 {
+		return p.Burst
+	}
+	return p.Limit
+}
+
+// perSecond is the refill rate
+func (p Policy) perSecond() float64 {
+	return float64(p.Limit) / p.Period.Seconds()
+}
+
+// fillTime is how long an empty bucket takes to fill up; after that a
+// bucket is the same as a new one and can be forgotten
+func (p Policy) fillTime() time.Duration {
+	return seconds(float64(p.Capacity()) / p.perSecond())
+}
+
+func (p Policy) String() string {
+	s := fmt.Sprintf("%d/%s", p.Limit, p.Period)
+	if p.Burst > 0 {
+		s += "/" + strconv.Itoa(p.Burst)
+	}
+	return s
+}
+
+// Result is the outcome of taking a token
+type Result struct {
+	Allowed bool
+	// Limit is the bucket size and Remaining the whole tokens left in it
+	Limit     int
+	Remaining int
+	// Reset is when the bucket will be full again
+	Reset time.Duration
+	// RetryAfter is when the next token is available; zero when allowed
+	RetryAfter time.Duration
+}
+
+// Store keeps token buckets
+type Store interface {
+	// Take removes a token from the bucket at key, refilled up to now
+	Take(ctx context.Context, key string, p Policy) (Result, error)
+}
+
+// refill returns the tokens in a bucket that held tokens elapsed ago
+func refill(p Policy, tokens float64, elapsed time.Duration) float64 {
+	if elapsed < 0 {
+		elapsed = 0 // clocks of different instances may disagree slightly
+	}
+	return math.Min(float64(p.Capacity()), tokens+elapsed.Seconds()*p.perSecond())
+}
+
+// newResult describes a bucket left with tokens after a take
+func newResult(p Policy, tokens float64, allowed bool) Result {
+	r := Result{
+		Allowed:   allowed,
+		Limit:     p.Capacity(),
+		Remaining: int(math.Floor(tokens)),
+		Reset:     seconds((float64(p.Capacity()) - tokens) / p.perSecond()),
+	}
+	if !allowed {
+		r.RetryAfter = seconds((1 - tokens) / p.perSecond())
+	}
+	return r
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// ParsePolicy reads a policy written as LIMIT/PERIOD or LIMIT/PERIOD/BURST,
+// e.g. "10/1m" or "100/1h/20"
+func ParsePolicy(name, s string) (Policy, error) {
+	parts := strings.Split(s, "/")
+	if len(parts) < 2 || len(parts) > 3 {
+		return Policy{}, fmt.Errorf("rate limit %s: %q is not LIMIT/PERIOD[/BURST]", name, s)
+	}
+	p := Policy{Name: name}
+	var err error
+	if p.Limit, err = strconv.Atoi(parts[0]); err != nil || p.Limit <= 0 {
+		return Policy{}, fmt.Errorf("rate limit %s: limit %q must be a positive integer", name, parts[0])
+	}
+	if p.Period, err = time.ParseDuration(parts[1]); err != nil || p.Period <= 0 {
+		return Policy{}, fmt.Errorf("rate limit %s: period %q must be a positive duration", name, parts[1])
+	}
+	if len(parts) == 3 {
+		if p.Burst, err = strconv.Atoi(parts[2]); err != nil || p.Burst <= 0 {
+			return Policy{}, fmt.Errorf("rate limit %s: burst %q must be a positive integer", name, parts[2])
+		}
+	}
+	return p, nil
+}
+
+// ParseOverrides reads a comma separated list of NAME=POLICY entries, e.g.
+// "login=20/1m,report=5/1h/2"
+func ParseOverrides(s string) (map[string]Policy, error) {
+	overrides := map[string]Policy{}
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("rate limit override %q is not NAME=LIMIT/PERIOD[/BURST]", entry)
+		}
+		p, err := ParsePolicy(strings.TrimSpace(name), strings.TrimSpace(value))
+		if err != nil {
+			return nil, err
+		}
+		overrides[p.Name] = p
+	}
+	return overrides, nil
+}
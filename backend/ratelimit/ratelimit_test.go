@@ -0,0 +1,71 @@
+package ratelimit
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParsePolicy(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Policy
+		ok   bool
+	}{
+		{"10/1m", Policy{Name: "login", Limit: 10, Period: time.Minute}, true},
+		{"100/1h/20", Policy{Name: "login", Limit: 100, Period: time.Hour, Burst: 20}, true},
+		{"5/30s", Policy{Name: "login", Limit: 5, Period: 30 * time.Second}, true},
+		{"10", Policy{}, false},
+		{"10/1m/2/3", Policy{}, false},
+		{"ten/1m", Policy{}, false},
+		{"0/1m", Policy{}, false},
+		{"-1/1m", Policy{}, false},
+		{"10/minute", Policy{}, false},
+		{"10/0s", Policy{}, false},
+		{"10/1m/0", Policy{}, false},
+		{"10/1m/x", Policy{}, false},
+		{"", Policy{}, false},
+	}
+	for _, tt := range tests {
+		got, err := ParsePolicy("login", tt.in)
+		if (err == nil) != tt.ok {
+			t.Errorf("ParsePolicy(%q) error = %v, want ok %v", tt.in, err, tt.ok)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParsePolicy(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseOverrides(t *testing.T) {
+	tests := []struct {
+		in   string
+		want map[string]Policy
+		ok   bool
+	}{
+		{"", map[string]Policy{}, true},
+		{"login=20/1m", map[string]Policy{
+			"login": {Name: "login", Limit: 20, Period: time.Minute},
+		}, true},
+		{" login = 20/1m , report=5/1h/2,", map[string]Policy{
+			"login":  {Name: "login", Limit: 20, Period: time.Minute},
+			"report": {Name: "report", Limit: 5, Period: time.Hour, Burst: 2},
+		}, true},
+		{"login=20/1m,login=30/1m", map[string]Policy{
+			"login": {Name: "login", Limit: 30, Period: time.Minute},
+		}, true},
+		{"login", nil, false},
+		{"login=20/1m,report=5", nil, false},
+	}
+	for _, tt := range tests {
+		got, err := ParseOverrides(tt.in)
+		if (err == nil) != tt.ok {
+			t.Errorf("ParseOverrides(%q) error = %v, want ok %v", tt.in, err, tt.ok)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseOverrides(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
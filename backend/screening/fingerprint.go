@@ -0,0 +1,114 @@
+package screening
+
+import (
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// Near-duplicates are found with MinHash over character shingles, which
+// works for Thai, written without spaces, as well as for English. The
+// signature is split into bands for locality-sensitive hashing: texts that
+// share a band hash are candidates, found through an index, and texts with a
+// similarity of 0.8 share at least one band with a probability above 99.9%.
+//
+// Signatures are stored, so changing the shingle size, band layout or seeds
+// makes existing ones meaningless.
+const (
+	shingleSize = 5
+	bands       = 16
+	rows        = 4
+
+	// SignatureSize is the number of hashes in a Signature
+	SignatureSize = bands * rows
+
+	// minLength is the shortest text, in letters and digits, that is
+	// fingerprinted; short texts like "great food" are repeated innocently
+	minLength = 30
+
+	// DuplicateSimilarity is the estimated share of shingles from which two
+	// texts count as duplicates
+	DuplicateSimilarity = 0.8
+)
+
+// Signature is a MinHash sketch of a text's shingles
+type Signature []uint32
+
+// seeds derive the SignatureSize hash functions from one shingle hash
+var seeds = func() [SignatureSize]uint64 {
+	var s [SignatureSize]uint64
+	for i := range s {
+		s[i] = mix(uint64(i+1) * 0x9e3779b97f4a7c15)
+	}
+	return s
+}()
+
+// Fingerprint returns the signature of text, or nil if it is too short to
+// tell a copy from a coincidence
+func Fingerprint(text string) Signature {
+	var runes []rune
+	for _, r := range strings.ToLower(text) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r) {
+			runes = append(runes, r)
+		}
+	}
+	if len(runes) < minLength {
+		return nil
+	}
+	sig := make(Signature, SignatureSize)
+	for i := range sig {
+		sig[i] = math.MaxUint32
+	}
+	for i := 0; i+shingleSize <= len(runes); i++ {
+		h := fnv.New64a()
+		h.Write([]byte(string(runes[i : i+shingleSize])))
+		shingle := h.Sum64()
+		for j, seed := range seeds {
+			if v := uint32(mix(shingle^seed) >> 32); v < sig[j] {
+				sig[j] = v
+			}
+		}
+	}
+	return sig
+}
+
+// Bands returns a hash of each band of s, for looking up candidates
+func (s Signature) Bands() []int64 {
+	if len(s) != SignatureSize {
+		return nil
+	}
+	out := make([]int64, bands)
+	for b := range out {
+		h := mix(uint64(b + 1))
+		for _, v := range s[b*rows : (b+1)*rows] {
+			h = mix(h ^ uint64(v))
+		}
+		out[b] = int64(h)
+	}
+	return out
+}
+
+// Similarity estimates the share of shingles two texts have in common
+func Similarity(a, b Signature) float64 {
+	if len(a) != SignatureSize || len(b) != SignatureSize {
+		return 0
+	}
+	same := 0
+	for i := range a {
+		if a[i] == b[i] {
+			same++
+		}
+	}
+	return float64(same) / SignatureSize
+}
+
+// mix is the splitmix64 finalizer
+func mix(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
@@ -0,0 +1,188 @@
+// Package screening checks reviews and comments for spam and abuse before
+// they are published. The checks here only look at the text and the numbers
+// they are given; the handlers gather the history they need (what others
+// posted, how fast a user posts, how a place is rated) and decide what to
+// do with the flags.
+package screening
+
+import (
+	_ "embed"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Rules a review or comment can be held for, recorded with the held content
+const (
+	RuleProfanity     = "profanity"
+	RuleLink          = "link"
+	RulePhone         = "phone"
+	RuleDuplicate     = "duplicate"
+	RuleBurst         = "burst"
+	RuleRatingOutlier = "rating_outlier"
+)
+
+// Flag is a rule that a text broke and what triggered it
+type Flag struct {
+	Rule   string
+	Detail string
+}
+
+// bundledWords is the built-in Thai and English word list. Deployments add
+// their own with SCREENING_WORDS_FILE.
+//
+//go:embed data/words.txt
+var bundledWords string
+
+// Screener checks text against a word list and for contact details
+type Screener struct {
+	latin map[string]bool
+	thai  []string
+}
+
+// Default returns a Screener with the bundled word list
+func Default() *Screener {
+	s := &Screener{latin: map[string]bool{}}
+	s.add(bundledWords)
+	return s
+}
+
+// Load returns a Screener with the bundled word list and the words in file,
+// which has the same format; file may be empty.
+func Load(file string) (*Screener, error) {
+	s := Default()
+	if file == "" {
+		return s, nil
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("screening word list: %w", err)
+	}
+	s.add(string(data))
+	return s, nil
+}
+
+// add adds each non-comment line of list
+func (s *Screener) add(list string) {
+	for _, line := range strings.Split(list, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if thai := thaiLetters(line); thai != "" {
+			s.thai = append(s.thai, thai)
+		} else {
+			s.latin[foldLatin(line)] = true
+		}
+	}
+}
+
+// Check returns the rules text breaks among listed words, links and phone
+// numbers. Each rule is reported once, with its first match.
+func (s *Screener) Check(text string) []Flag {
+	var flags []Flag
+	if word, ok := s.listedWord(text); ok {
+		flags = append(flags, Flag{Rule: RuleProfanity, Detail: word})
+	}
+	if link := linkPattern.FindString(text); link != "" {
+		flags = append(flags, Flag{Rule: RuleLink, Detail: detail(link)})
+	} else if id := lineIDPattern.FindString(text); id != "" {
+		flags = append(flags, Flag{Rule: RuleLink, Detail: detail(id)})
+	}
+	if phone := phonePattern.FindString(thaiDigits.Replace(text)); phone != "" {
+		flags = append(flags, Flag{Rule: RulePhone, Detail: phone})
+	}
+	return flags
+}
+
+func (s *Screener) listedWord(text string) (string, bool) {
+	for _, token := range strings.FieldsFunc(foldLatin(text), func(r rune) bool { return r < 'a' || r > 'z' }) {
+		if s.latin[token] {
+			return token, true
+		}
+	}
+	if len(s.thai) > 0 {
+		letters := thaiLetters(text)
+		for _, word := range s.thai {
+			if strings.Contains(letters, word) {
+				return word, true
+			}
+		}
+	}
+	return "", false
+}
+
+var (
+	// linkPattern matches URLs and bare domains under common spam TLDs
+	linkPattern = regexp.MustCompile(`(?i)\b(?:https?://|www\.)\S+|\b[a-z0-9][a-z0-9-]*(?:\.[a-z0-9-]+)*\.(?:com|net|org|info|biz|xyz|top|site|online|shop|store|club|live|link|io|co|me|ly|th|cc|vip)\b(?:/\S*)?`)
+	// lineIDPattern matches a LINE contact, the usual way to take a sale off-site
+	lineIDPattern = regexp.MustCompile(`(?i)(?:\bline\s*id|ไอดีไลน์|ไลน์ไอดี)\s*[:：]?\s*@?[a-z0-9._-]{3,}|(?:\bline|ไลน์|แอดไลน์)\s*[:：]?\s*@[a-z0-9._-]{3,}`)
+	// phonePattern matches Thai mobile and landline numbers, with or without
+	// separators and the +66 country code
+	phonePattern = regexp.MustCompile(`(?:\+66|\b66|\b0)(?:[ .-]?\d){8,9}\b`)
+
+	thaiDigits = strings.NewReplacer("๐", "0", "๑", "1", "๒", "2", "๓", "3", "๔", "4", "๕", "5", "๖", "6", "๗", "7", "๘", "8", "๙", "9")
+	// leet undoes the usual substitutions in words written to dodge a filter
+	leet = strings.NewReplacer("0", "o", "1", "i", "3", "e", "4", "a", "5", "s", "7", "t", "@", "a", "$", "s")
+)
+
+// foldLatin lowercases s, undoes leet substitutions and shortens runs of
+// three or more of the same letter to one, so "SH1IIIT" becomes "shit"
+// while "hell" keeps its double l
+func foldLatin(s string) string {
+	runes := []rune(leet.Replace(strings.ToLower(s)))
+	var b strings.Builder
+	for i := 0; i < len(runes); {
+		j := i
+		for j < len(runes) && runes[j] == runes[i] {
+			j++
+		}
+		n := j - i
+		if n >= 3 {
+			n = 1
+		}
+		for k := 0; k < n; k++ {
+			b.WriteRune(runes[i])
+		}
+		i = j
+	}
+	return b.String()
+}
+
+// thaiLetters returns the Thai letters, vowels and tone marks of s with
+// everything else removed, so spacing and punctuation cannot split a word
+func thaiLetters(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= 'ก' && r <= '๎' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// A rating stands out when it is at least outlierDistance stars from the
+// average of a place with at least outlierMinReviews reviews
+const (
+	outlierMinReviews = 5
+	outlierDistance   = 2.5
+)
+
+// RatingOutlier reports whether rating is far from the average of a place
+// with count reviews
+func RatingOutlier(rating int, average float64, count int) bool {
+	return count >= outlierMinReviews && math.Abs(float64(rating)-average) >= outlierDistance
+}
+
+// maxDetail caps how much of a match is recorded as a flag's detail
+const maxDetail = 100
+
+func detail(match string) string {
+	if utf8.RuneCountInString(match) <= maxDetail {
+		return match
+	}
+	return string([]rune(match)[:maxDetail]) + "…"
+}
@@ -0,0 +1,77 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"gosmooth/config"
+	"gosmooth/middleware"
+	"gosmooth/ratelimit"
+)
+
+// Rate limit policies by route group. RATE_LIMIT_POLICIES overrides them by
+// name; routes before RequireAuth are limited per client IP.
+var (
+	apiLimit      = ratelimit.Policy{Name: "api", Limit: 600, Period: time.Minute, Burst: 200}
+	loginLimit    = ratelimit.Policy{Name: "login", Limit: 10, Period: time.Minute}
+	registerLimit = ratelimit.Policy{Name: "register", Limit: 10, Period: time.Hour}
+	oidcLimit     = ratelimit.Policy{Name: "oidc", Limit: 20, Period: time.Minute}
+	passwordLimit = ratelimit.Policy{Name: "password", Limit: 5, Period: 15 * time.Minute}
+	writeLimit    = ratelimit.Policy{Name: "write", Limit: 60, Period: time.Minute, Burst: 20}
+	commentLimit  = ratelimit.Policy{Name: "comment", Limit: 20, Period: time.Minute, Burst: 5}
+	reportLimit   = ratelimit.Policy{Name: "report", Limit: 10, Period: time.Hour}
+	uploadLimit   = ratelimit.Policy{Name: "upload", Limit: 60, Period: time.Hour, Burst: 20}
+	apiKeysLimit  = ratelimit.Policy{Name: "api_keys", Limit: 20, Period: time.Hour}
+	exportLimit   = ratelimit.Policy{Name: "export", Limit: 5, Period: time.Hour}
+	claimLimit    = ratelimit.Policy{Name: "claim", Limit: 5, Period: 24 * time.Hour}
+)
+
+var routePolicies = []ratelimit.Policy{
+	apiLimit, loginLimit, registerLimit, oidcLimit, passwordLimit, writeLimit,
+	commentLimit, reportLimit, uploadLimit, apiKeysLimit, exportLimit, claimLimit,
+}
+
+// newRateLimiter builds the route limiter and returns the store it uses, which
+// API keys share for their own per-key limits.
+func newRateLimiter(cfg *config.Config, db *mongo.Database) (*ratelimit.Limiter, ratelimit.Store, error) {
+	overrides, err := ratelimit.ParseOverrides(cfg.RateLimitPolicies)
+	if err != nil {
+		return nil, nil, err
+	}
+	known := map[string]bool{}
+	var names []string
+	for _, p := range routePolicies {
+		known[p.Name] = true
+		names = append(names, p.Name)
+	}
+	sort.Strings(names)
+	for name := range overrides {
+		if !known[name] {
+			return nil, nil, fmt.Errorf("RATE_LIMIT_POLICIES: unknown policy %q (known: %s)", name, strings.Join(names, ", "))
+		}
+	}
+
+	var store ratelimit.Store = ratelimit.NewMemoryStore()
+	if cfg.RateLimitStore == "mongodb" {
+		store = ratelimit.NewMongoStore(db.Collection("rate_limits"))
+	}
+
+	var exempt func(*gin.Context) bool
+	if cfg.RateLimitExemptAdmins {
+		// API keys keep their limits even when the owner is an admin
+		exempt = func(c *gin.Context) bool {
+			return c.GetString("apiKeyID") == "" && c.GetString("userID") != "" && middleware.IsAdmin(c)
+		}
+	}
+
+	routeStore := store
+	if !cfg.RateLimitEnabled {
+		routeStore = nil
+	}
+	return ratelimit.NewLimiter(routeStore, overrides, exempt), store, nil
+}
@@ -0,0 +1,172 @@
+package server
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"gosmooth/handlers"
+	"gosmooth/middleware"
+	"gosmooth/models"
+	"gosmooth/ratelimit"
+)
+
+func setupRoutes(router *gin.Engine, limiter *ratelimit.Limiter) {
+	// Probes for the orchestrator, outside /api so they skip auth and CORS concerns
+	router.GET("/healthz", handlers.Healthz)
+	router.GET("/readyz", handlers.Readyz)
+
+	api := router.Group("/api", limiter.Limit(apiLimit))
+	{
+		// Public route for getting all places
+		api.GET("/places", middleware.OptionalAuth(), handlers.ListPlaces)
+
+		// Public route for getting reviews
+		api.GET("/reviews", handlers.GetReviews)
+		api.GET("/reviews/:id/comments", middleware.OptionalAuth(), handlers.ListComments)
+
+		// Auth routes
+		auth := api.Group("/auth")
+		{
+			auth.POST("/register", limiter.Limit(registerLimit), handlers.Register)
+			auth.POST("/login", limiter.Limit(loginLimit), handlers.Login)
+			auth.POST("/refresh", handlers.RefreshToken)
+			auth.POST("/logout", middleware.RequireAuth(middleware.AllowPasswordChange), handlers.Logout)
+			auth.POST("/change-password", middleware.RequireAuth(middleware.AllowPasswordChange), limiter.Limit(passwordLimit), handlers.ChangePassword)
+			auth.GET("/oidc/providers", handlers.ListOIDCProviders)
+			auth.GET("/oidc/:provider/login", limiter.Limit(oidcLimit), handlers.OIDCLogin)
+			auth.GET("/oidc/:provider/callback", handlers.OIDCCallback)
+		}
+
+		// The profile is readable while a password change is pending so the
+		// client can still show who is signed in
+		api.GET("/profile", middleware.RequireAuth(middleware.AllowPasswordChange, middleware.Scope(models.ScopeProfileRead)), handlers.GetProfile)
+
+		// Routes that integrations may also call with an API key holding the scope
+		api.GET("/reviews/:id", middleware.RequireAuth(middleware.Scope(models.ScopeReviewsRead)), handlers.GetReview)
+		placesRead := api.Group("/admin/places", middleware.RequireAuth(middleware.Scope(models.ScopePlacesRead, models.ScopePlacesWrite)), middleware.RequireAdmin())
+		{
+			placesRead.GET("", handlers.GetPlaces)
+			placesRead.GET("/export", handlers.ExportPlaces)
+		}
+		placesWrite := api.Group("/admin/places", middleware.RequireAuth(middleware.Scope(models.ScopePlacesWrite)), middleware.RequireAdmin())
+		{
+			placesWrite.POST("", handlers.CreatePlace)
+			placesWrite.POST("/import", handlers.ImportPlacesHandler)
+			placesWrite.PUT("/:id", handlers.UpdatePlace)
+			placesWrite.DELETE("/:id", handlers.DeletePlace)
+		}
+
+		// Protected routes
+		protected := api.Group("/")
+		protected.Use(middleware.RequireAuth())
+		{
+			// User routes
+			protected.PUT("/profile", handlers.UpdateProfile)
+			protected.DELETE("/profile", limiter.Limit(passwordLimit), handlers.DeleteAccount)
+			protected.POST("/profile/restore", handlers.CancelAccountDeletion)
+			protected.GET("/profile/export", limiter.Limit(exportLimit), handlers.ExportProfile)
+			protected.POST("/profile/identities/:provider", limiter.Limit(oidcLimit), handlers.LinkIdentity)
+			protected.DELETE("/profile/identities/:provider", handlers.UnlinkIdentity)
+			protected.GET("/profile/api-keys", handlers.ListAPIKeys)
+			protected.POST("/profile/api-keys", limiter.Limit(apiKeysLimit), handlers.CreateAPIKey)
+			protected.POST("/profile/api-keys/:id/rotate", limiter.Limit(apiKeysLimit), handlers.RotateAPIKey)
+			protected.DELETE("/profile/api-keys/:id", handlers.RevokeAPIKey)
+			protected.GET("/profile/place-claims", handlers.GetMyPlaceClaims)
+			protected.GET("/profile/check-ins", handlers.GetMyCheckIns)
+
+			// Saved places; :id may be "favorites" on the place routes
+			protected.GET("/profile/collections", handlers.ListMyCollections)
+			protected.POST("/profile/collections", limiter.Limit(writeLimit), handlers.CreateCollection)
+			protected.GET("/profile/collections/:id", handlers.GetMyCollection)
+			protected.PATCH("/profile/collections/:id", limiter.Limit(writeLimit), handlers.UpdateCollection)
+			protected.DELETE("/profile/collections/:id", limiter.Limit(writeLimit), handlers.DeleteCollection)
+			protected.PUT("/profile/collections/:id/order", limiter.Limit(writeLimit), handlers.ReorderCollection)
+			protected.PUT("/profile/collections/:id/places/:placeId", limiter.Limit(writeLimit), handlers.SaveCollectionPlace)
+			protected.DELETE("/profile/collections/:id/places/:placeId", limiter.Limit(writeLimit), handlers.RemoveCollectionPlace)
+
+			// Trip plans
+			protected.GET("/profile/trips", handlers.ListMyTrips)
+			protected.POST("/profile/trips", limiter.Limit(writeLimit), handlers.CreateTrip)
+			protected.GET("/profile/trips/:id", handlers.GetMyTrip)
+			protected.PUT("/profile/trips/:id", limiter.Limit(writeLimit), handlers.UpdateTrip)
+			protected.DELETE("/profile/trips/:id", limiter.Limit(writeLimit), handlers.DeleteTrip)
+			protected.POST("/profile/trips/:id/duplicate", limiter.Limit(writeLimit), handlers.DuplicateTrip)
+
+			// Check-ins verify visits for review badges
+			protected.POST("/places/:id/check-ins", limiter.Limit(writeLimit), handlers.CheckInPlace)
+
+			// Place claims and the owner tools they unlock
+			protected.POST("/places/:id/claims", limiter.Limit(claimLimit), handlers.ClaimPlace)
+			protected.GET("/owner/places", handlers.GetOwnedPlaces)
+			protected.PATCH("/owner/places/:id", limiter.Limit(writeLimit), handlers.UpdateOwnedPlace)
+			protected.GET("/owner/places/:id/analytics", handlers.GetPlaceAnalytics)
+
+			// Route planning routes
+			protected.POST("/routes/suggest", handlers.SuggestRoute)
+			protected.GET("/routes/cost", handlers.EstimateCost)
+
+			// Reviews routes (protected)
+			protected.POST("/reviews", limiter.Limit(writeLimit), handlers.CreateReview)
+			protected.PUT("/reviews/:id", limiter.Limit(writeLimit), handlers.UpdateReview)
+			protected.DELETE("/reviews/:id", limiter.Limit(writeLimit), handlers.DeleteReview)
+			protected.PUT("/reviews/:id/like", limiter.Limit(writeLimit), handlers.LikeReview)
+			protected.DELETE("/reviews/:id/like", limiter.Limit(writeLimit), handlers.UnlikeReview)
+			protected.PUT("/reviews/:id/not-helpful", limiter.Limit(writeLimit), handlers.MarkNotHelpful)
+			protected.DELETE("/reviews/:id/not-helpful", limiter.Limit(writeLimit), handlers.UnmarkNotHelpful)
+			protected.POST("/reviews/:id/comments", limiter.Limit(commentLimit), handlers.AddComment)
+			protected.PUT("/reviews/:id/comments/:commentId", limiter.Limit(writeLimit), handlers.UpdateComment)
+			protected.DELETE("/reviews/:id/comments/:commentId", limiter.Limit(writeLimit), handlers.DeleteComment)
+			protected.POST("/reviews/:id/comments/:commentId/flag", limiter.Limit(reportLimit), handlers.FlagComment)
+			protected.PUT("/reviews/:id/comments/:commentId/like", limiter.Limit(writeLimit), handlers.LikeComment)
+			protected.DELETE("/reviews/:id/comments/:commentId/like", limiter.Limit(writeLimit), handlers.UnlikeComment)
+			protected.POST("/reviews/:id/photos", limiter.Limit(uploadLimit), handlers.AddReviewPhoto)
+			protected.PATCH("/reviews/:id/photos/:photoId", limiter.Limit(writeLimit), handlers.UpdateReviewPhoto)
+			protected.DELETE("/reviews/:id/photos/:photoId", limiter.Limit(writeLimit), handlers.DeleteReviewPhoto)
+			protected.POST("/reviews/:id/report", limiter.Limit(reportLimit), handlers.ReportReview)
+			protected.PUT("/reviews/:id/response", limiter.Limit(writeLimit), handlers.RespondToReview)
+			protected.DELETE("/reviews/:id/response", limiter.Limit(writeLimit), handlers.DeleteReviewResponse)
+
+			// Admin routes
+			admin := protected.Group("/admin")
+			admin.Use(middleware.RequireAdmin())
+			{
+				admin.GET("/status", handlers.GetSystemStatus)
+				admin.GET("/users", handlers.GetUsers)
+				admin.GET("/users/:id", handlers.GetUser)
+				admin.PUT("/users/:id", handlers.UpdateUser)
+				admin.DELETE("/users/:id", handlers.DeleteUser)
+				admin.POST("/users/:id/ban", handlers.BanUser)
+				admin.POST("/users/:id/unban", handlers.UnbanUser)
+				admin.POST("/users/:id/require-password-change", handlers.RequirePasswordChange)
+				admin.POST("/users/:id/api-keys", handlers.CreateUserAPIKey)
+				admin.GET("/api-keys", handlers.GetAllAPIKeys)
+				admin.DELETE("/api-keys/:id", handlers.AdminRevokeAPIKey)
+				admin.GET("/stats", handlers.GetStats)
+				admin.POST("/upload-image", limiter.Limit(uploadLimit), handlers.UploadImage)
+				admin.GET("/review-reports", handlers.GetAllReviewReports)
+				admin.PATCH("/review-reports/:id/status", handlers.UpdateReviewReportStatus)
+				admin.GET("/reviews/held", handlers.GetHeldReviews)
+				admin.PATCH("/reviews/:id/status", handlers.ModerateReview)
+				admin.DELETE("/reviews/:id/photos/:photoId", handlers.RemoveReviewPhoto)
+				admin.DELETE("/reviews/:id/response", handlers.RemoveReviewResponse)
+				admin.GET("/place-claims", handlers.GetPlaceClaims)
+				admin.POST("/place-claims/:id/approve", handlers.ApprovePlaceClaim)
+				admin.POST("/place-claims/:id/reject", handlers.RejectPlaceClaim)
+				admin.DELETE("/places/:id/owner", handlers.RemovePlaceOwner)
+				admin.GET("/comments/flagged", handlers.GetFlaggedComments)
+				admin.PATCH("/comments/:id/status", handlers.ModerateComment)
+			}
+		}
+
+		// New endpoint for getting all locations
+		api.GET("/locations", handlers.GetLocations)
+
+		// New endpoint for getting a place by place_id or _id
+		api.GET("/places/:id", middleware.OptionalAuth(), handlers.GetPlace)
+
+		// Shared collections are public; private ones are not found
+		api.GET("/collections/:id", handlers.GetSharedCollection)
+
+		// Shared trips are public too, with their plan
+		api.GET("/trips/:id", handlers.GetSharedTrip)
+	}
+}
@@ -0,0 +1,177 @@
+// Package server wires the HTTP router and runs the API server.
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+
+	"gosmooth/config"
+	"gosmooth/handlers"
+	"gosmooth/metrics"
+	"gosmooth/middleware"
+	"gosmooth/problem"
+	"gosmooth/ratelimit"
+	"gosmooth/tracing"
+)
+
+// ErrorLogger logs errors attached with c.Error. If the handler did not
+// respond itself, a generic problem response is written; otherwise the
+// handler's response is left untouched.
+func ErrorLogger() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		for _, e := range c.Errors {
+			slog.ErrorContext(c.Request.Context(), "request error", "error", e.Err)
+		}
+		if len(c.Errors) > 0 && !c.Writer.Written() {
+			problem.Error(c, c.Errors.Last().Err)
+		}
+	}
+}
+
+// NewRouter builds the gin engine with middleware and all API routes. A nil
+// limiter leaves the routes unthrottled.
+func NewRouter(cfg *config.Config, limiter *ratelimit.Limiter) *gin.Engine {
+	router := gin.New() // Use gin.New() instead of gin.Default() to customize middleware
+	// Only proxies in TRUSTED_PROXIES may set the client IP used for rate limits;
+	// the entries were validated with the config
+	_ = router.SetTrustedProxies(cfg.TrustedProxies)
+	router.HandleMethodNotAllowed = true
+	router.NoRoute(problem.NoRoute)
+	router.NoMethod(problem.NoMethod)
+	// Let handlers pass c straight to the driver and still get the request's span and deadline
+	router.ContextWithFallback = true
+	router.Use(otelgin.Middleware(tracing.ServiceName, otelgin.WithFilter(func(r *http.Request) bool {
+		return !isProbe(r.URL.Path)
+	})))
+	router.Use(middleware.RequestID())
+	router.Use(middleware.AccessLog())
+	router.Use(middleware.Metrics())
+	router.Use(gin.CustomRecoveryWithWriter(nil, func(c *gin.Context, err any) {
+		slog.ErrorContext(c.Request.Context(), "panic recovered", "panic", fmt.Sprint(err), "stack", string(debug.Stack()))
+		if !c.Writer.Written() {
+			problem.Abort(c, http.StatusInternalServerError, problem.CodeInternal)
+		}
+	}))
+	router.Use(ErrorLogger())
+
+	// Configure CORS with more specific settings
+	router.Use(cors.New(cors.Config{
+		AllowOrigins: cfg.CORSOrigins,
+		AllowMethods: []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowHeaders: []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Requested-With", "X-API-Key"},
+		ExposeHeaders: []string{"Content-Length", "Content-Range", middleware.RequestIDHeader,
+			"RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "RateLimit-Policy", "Retry-After"},
+		AllowCredentials: true,
+		MaxAge:           12 * time.Hour,
+	}))
+
+	// Routes
+	setupRoutes(router, limiter)
+	if cfg.MetricsEnabled {
+		router.GET("/metrics", gin.WrapH(metrics.Handler(cfg.MetricsToken)))
+	}
+
+	// Serve static files for uploads with security headers
+	router.Static("/uploads", cfg.UploadDir)
+	return router
+}
+
+// Run serves the API until ctx is cancelled, then shuts down gracefully
+func Run(ctx context.Context, db *mongo.Database, cfg *config.Config) error {
+	// Initialize handlers and middleware with database and settings
+	handlers.SetDB(db)
+	handlers.Configure(cfg)
+	middleware.SetDB(db)
+	middleware.SetAuthConfig(cfg.JWTSecret, cfg.AccessTokenTTL, cfg.RememberMeTokenTTL)
+	limiter, limitStore, err := newRateLimiter(cfg, db)
+	if err != nil {
+		return err
+	}
+	middleware.SetAPIKeyConfig(int(cfg.APIKeyRateLimit), limitStore)
+	if cfg.IsProduction() {
+		gin.SetMode(gin.ReleaseMode)
+	}
+	port := cfg.Port
+
+	// Anonymize accounts whose deletion grace period has expired
+	purgeCtx, stopPurge := context.WithCancel(ctx)
+	defer stopPurge()
+	go runAccountPurger(purgeCtx, time.Hour)
+
+	// Create a server with custom timeouts
+	srv := &http.Server{
+		Addr:         ":" + port,
+		Handler:      NewRouter(cfg, limiter),
+		ReadTimeout:  cfg.ReadTimeout,
+		WriteTimeout: cfg.WriteTimeout,
+		IdleTimeout:  cfg.IdleTimeout,
+	}
+
+	// Start server in a goroutine
+	serveErr := make(chan error, 1)
+	go func() {
+		slog.Info("server starting", "port", port)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serveErr <- err
+		}
+		close(serveErr)
+	}()
+
+	// Wait for cancellation (interrupt signal) or a listen failure
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+	slog.Info("shutting down server")
+
+	// Create shutdown context with timeout
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+
+	slog.Info("server exited")
+	return nil
+}
+
+// runAccountPurger periodically anonymizes accounts past their deletion grace period
+func runAccountPurger(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		purgeCtx, cancel := context.WithTimeout(ctx, time.Minute)
+		n, err := handlers.PurgeDeletedAccounts(purgeCtx)
+		cancel()
+		if err != nil {
+			slog.Error("purge deleted accounts failed", "error", err)
+		} else if n > 0 {
+			slog.Info("anonymized deleted accounts", "count", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// isProbe reports whether path is scraped or polled by infrastructure; such
+// requests are not traced.
+func isProbe(path string) bool {
+	return path == "/metrics" || path == "/healthz" || path == "/readyz"
+}
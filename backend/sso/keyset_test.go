@@ -0,0 +1,102 @@
+package sso
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	jose "github.com/go-jose/go-jose/v4"
+)
+
+// sign returns a compact JWT over payload
+func sign(t *testing.T, alg jose.SignatureAlgorithm, key interface{}, payload string) string {
+	t.Helper()
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: alg, Key: key},
+		(&jose.SignerOptions{}).WithType("JWT").WithHeader("kid", "test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	jws, err := signer.Sign([]byte(payload))
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwt, err := jws.CompactSerialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return jwt
+}
+
+// newKeySet returns a keySet with secret whose JWKS serves the public half of key
+func newKeySet(t *testing.T, key *rsa.PrivateKey, secret string) *keySet {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{
+			Key: &key.PublicKey, KeyID: "test", Algorithm: string(jose.RS256), Use: "sig",
+		}}})
+	}))
+	t.Cleanup(srv.Close)
+	return &keySet{remote: oidc.NewRemoteKeySet(context.Background(), srv.URL), secret: []byte(secret)}
+}
+
+func TestKeySetVerifySignature(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const payload = `{"sub":"u1"}`
+	// HS256 keys must be at least 32 bytes
+	const secret = "client-secret-0123456789abcdefghij"
+
+	tests := []struct {
+		name   string
+		secret string
+		jwt    string
+		ok     bool
+	}{
+		{"HS256 with the client secret", secret, sign(t, jose.HS256, []byte(secret), payload), true},
+		{"HS256 with another secret", secret, sign(t, jose.HS256, []byte("another-secret-0123456789abcdefghij"), payload), false},
+		{"HS256 without a client secret", "", sign(t, jose.HS256, []byte(secret), payload), false},
+		{"RS256 from the JWKS", secret, sign(t, jose.RS256, key, payload), true},
+		{"RS256 with an unknown key", secret, sign(t, jose.RS256, other, payload), false},
+		{"malformed", secret, "not.a.jwt", false},
+	}
+	for _, tt := range tests {
+		got, err := newKeySet(t, key, tt.secret).VerifySignature(context.Background(), tt.jwt)
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: error = %v, want ok %v", tt.name, err, tt.ok)
+			continue
+		}
+		if tt.ok && string(got) != payload {
+			t.Errorf("%s: payload = %s, want %s", tt.name, got, payload)
+		}
+	}
+}
+
+func TestTokenAlg(t *testing.T) {
+	tests := []struct {
+		jwt  string
+		want string
+	}{
+		{"eyJhbGciOiJIUzI1NiJ9.e30.x", "HS256"}, // {"alg":"HS256"}
+		{"eyJhbGciOiJSUzI1NiJ9.e30.x", "RS256"}, // {"alg":"RS256"}
+		{"e30.e30.x", ""},                       // {}
+		{"!!!.e30.x", ""},
+		{"eyJhbGciOiJIUzI1NiJ9", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := tokenAlg(tt.jwt); got != tt.want {
+			t.Errorf("tokenAlg(%q) = %q, want %q", tt.jwt, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,249 @@
+// Package sso signs users in through OpenID Connect providers using the
+// authorization code flow with PKCE. Providers are discovered lazily so the
+// API still starts when an identity provider is unreachable.
+package sso
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+
+	"gosmooth/config"
+)
+
+// ProviderConfig describes one OpenID Connect provider
+type ProviderConfig struct {
+	// Name identifies the provider in URLs and in linked identities
+	Name        string
+	DisplayName string
+	Issuer      string
+	ClientID    string
+	// ClientSecret authenticates the token request. Providers that sign ID
+	// tokens with HS256, such as LINE for web apps, also use it as the key.
+	ClientSecret string
+	Scopes       []string
+}
+
+// FromConfig returns the providers enabled in cfg, in a stable order.
+// Facebook is not listed: its web login is plain OAuth 2.0 without ID tokens,
+// so it has to be connected through an OIDC broker set as OIDC_ISSUER.
+func FromConfig(cfg *config.Config) []ProviderConfig {
+	var providers []ProviderConfig
+	if cfg.GoogleClientID != "" {
+		providers = append(providers, ProviderConfig{
+			Name:         "google",
+			DisplayName:  "Google",
+			Issuer:       "https://accounts.google.com",
+			ClientID:     cfg.GoogleClientID,
+			ClientSecret: cfg.GoogleClientSecret,
+		})
+	}
+	if cfg.LineClientID != "" {
+		providers = append(providers, ProviderConfig{
+			Name:         "line",
+			DisplayName:  "LINE",
+			Issuer:       "https://access.line.me",
+			ClientID:     cfg.LineClientID,
+			ClientSecret: cfg.LineClientSecret,
+		})
+	}
+	if cfg.OIDCIssuer != "" {
+		providers = append(providers, ProviderConfig{
+			Name:         cfg.OIDCName,
+			DisplayName:  cfg.OIDCDisplayName,
+			Issuer:       cfg.OIDCIssuer,
+			ClientID:     cfg.OIDCClientID,
+			ClientSecret: cfg.OIDCClientSecret,
+		})
+	}
+	return providers
+}
+
+// Provider is a configured OpenID Connect provider
+type Provider struct {
+	ProviderConfig
+	redirectURL string
+
+	mu       sync.Mutex
+	oauth    *oauth2.Config
+	verifier *oidc.IDTokenVerifier
+}
+
+// Registry holds the enabled providers by name
+type Registry struct {
+	providers []*Provider
+	byName    map[string]*Provider
+}
+
+// NewRegistry sets up providers whose callbacks live under publicURL
+func NewRegistry(publicURL string, configs []ProviderConfig) *Registry {
+	r := &Registry{byName: map[string]*Provider{}}
+	base := strings.TrimRight(publicURL, "/")
+	for _, pc := range configs {
+		p := &Provider{
+			ProviderConfig: pc,
+			redirectURL:    fmt.Sprintf("%s/api/auth/oidc/%s/callback", base, pc.Name),
+		}
+		r.providers = append(r.providers, p)
+		r.byName[pc.Name] = p
+	}
+	return r
+}
+
+// Lookup returns the provider called name
+func (r *Registry) Lookup(name string) (*Provider, bool) {
+	if r == nil {
+		return nil, false
+	}
+	p, ok := r.byName[name]
+	return p, ok
+}
+
+// Providers lists the enabled providers
+func (r *Registry) Providers() []*Provider {
+	if r == nil {
+		return nil
+	}
+	return r.providers
+}
+
+// setup runs discovery once; a failed attempt is retried on the next call
+func (p *Provider) setup(ctx context.Context) (*oauth2.Config, *oidc.IDTokenVerifier, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.oauth != nil {
+		return p.oauth, p.verifier, nil
+	}
+
+	provider, err := oidc.NewProvider(ctx, p.Issuer)
+	if err != nil {
+		return nil, nil, fmt.Errorf("sso: discovering %s: %w", p.Name, err)
+	}
+	var discovery struct {
+		JWKSURL string   `json:"jwks_uri"`
+		Algs    []string `json:"id_token_signing_alg_values_supported"`
+	}
+	if err := provider.Claims(&discovery); err != nil {
+		return nil, nil, fmt.Errorf("sso: reading %s discovery document: %w", p.Name, err)
+	}
+
+	// keys are refreshed in the background, so they must not use the request context
+	keys := &keySet{
+		remote: oidc.NewRemoteKeySet(context.Background(), discovery.JWKSURL),
+		secret: []byte(p.ClientSecret),
+	}
+	scopes := p.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{oidc.ScopeOpenID, "email", "profile"}
+	}
+	p.oauth = &oauth2.Config{
+		ClientID:     p.ClientID,
+		ClientSecret: p.ClientSecret,
+		Endpoint:     provider.Endpoint(),
+		RedirectURL:  p.redirectURL,
+		Scopes:       scopes,
+	}
+	p.verifier = oidc.NewVerifier(p.Issuer, keys, &oidc.Config{
+		ClientID:             p.ClientID,
+		SupportedSigningAlgs: discovery.Algs,
+	})
+	return p.oauth, p.verifier, nil
+}
+
+// AuthCodeURL returns where to send the browser to sign in. verifier is the
+// PKCE code verifier and nonce binds the ID token to this request; both must
+// be kept server-side until the callback.
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error) {
+	oauth, _, err := p.setup(ctx)
+	if err != nil {
+		return "", err
+	}
+	return oauth.AuthCodeURL(state,
+		oauth2.S256ChallengeOption(verifier),
+		oidc.Nonce(nonce),
+	), nil
+}
+
+// Claims are the ID token claims used to find or create an account
+type Claims struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// Exchange redeems an authorization code and verifies the returned ID token
+func (p *Provider) Exchange(ctx context.Context, code, verifier, nonce string) (Claims, error) {
+	oauth, idVerifier, err := p.setup(ctx)
+	if err != nil {
+		return Claims{}, err
+	}
+	token, err := oauth.Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return Claims{}, fmt.Errorf("sso: exchanging code with %s: %w", p.Name, err)
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return Claims{}, fmt.Errorf("sso: %s returned no id_token", p.Name)
+	}
+	idToken, err := idVerifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return Claims{}, fmt.Errorf("sso: verifying %s id_token: %w", p.Name, err)
+	}
+	if idToken.Nonce != nonce {
+		return Claims{}, fmt.Errorf("sso: %s id_token nonce does not match", p.Name)
+	}
+
+	var raw struct {
+		Email         string   `json:"email"`
+		EmailVerified flexBool `json:"email_verified"`
+		Name          string   `json:"name"`
+	}
+	if err := idToken.Claims(&raw); err != nil {
+		return Claims{}, fmt.Errorf("sso: decoding %s claims: %w", p.Name, err)
+	}
+	return Claims{
+		Subject:       idToken.Subject,
+		Email:         strings.TrimSpace(raw.Email),
+		EmailVerified: bool(raw.EmailVerified),
+		Name:          raw.Name,
+	}, nil
+}
+
+// flexBool accepts true and "true"; some providers send email_verified as a string
+type flexBool bool
+
+func (b *flexBool) UnmarshalJSON(data []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	switch t := v.(type) {
+	case bool:
+		*b = flexBool(t)
+	case string:
+		*b = flexBool(strings.EqualFold(t, "true"))
+	}
+	return nil
+}
+
+// NewVerifier returns a fresh PKCE code verifier
+func NewVerifier() string {
+	return oauth2.GenerateVerifier()
+}
+
+// RandomToken returns an unguessable value for state and nonce parameters
+func RandomToken() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
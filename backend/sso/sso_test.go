@@ -0,0 +1,156 @@
+package sso
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"gosmooth/sso/ssotest"
+)
+
+// newTestProvider starts an ssotest provider and returns it with the
+// Provider that signs in through it
+func newTestProvider(t *testing.T) (*Provider, *ssotest.Provider) {
+	t.Helper()
+	srv, idp, err := ssotest.NewServer("client", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(srv.Close)
+	r := NewRegistry("http://api.test/", []ProviderConfig{{
+		Name:         "mock",
+		Issuer:       srv.URL,
+		ClientID:     "client",
+		ClientSecret: "secret",
+	}})
+	p, _ := r.Lookup("mock")
+	return p, idp
+}
+
+// authorize visits authURL like a browser and returns where the provider
+// redirects back to
+func authorize(t *testing.T, authURL string) *url.URL {
+	t.Helper()
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.Get(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("authorize status = %d, want 302", resp.StatusCode)
+	}
+	callback, err := resp.Location()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return callback
+}
+
+// signIn runs the flow up to the callback and returns the code
+func signIn(t *testing.T, p *Provider, nonce, verifier string) string {
+	t.Helper()
+	authURL, err := p.AuthCodeURL(context.Background(), "state-1", nonce, verifier)
+	if err != nil {
+		t.Fatalf("AuthCodeURL: %v", err)
+	}
+	callback := authorize(t, authURL)
+	if got, want := callback.Scheme+"://"+callback.Host+callback.Path, "http://api.test/api/auth/oidc/mock/callback"; got != want {
+		t.Errorf("redirected to %s, want %s", got, want)
+	}
+	if got := callback.Query().Get("state"); got != "state-1" {
+		t.Errorf("state = %q, want state-1", got)
+	}
+	return callback.Query().Get("code")
+}
+
+func TestExchange(t *testing.T) {
+	p, _ := newTestProvider(t)
+	nonce, verifier := RandomToken(), NewVerifier()
+
+	claims, err := p.Exchange(context.Background(), signIn(t, p, nonce, verifier), verifier, nonce)
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	want := Claims{Subject: "mock-user-1", Email: "mock.user@example.com", EmailVerified: true, Name: "Mock User"}
+	if claims != want {
+		t.Errorf("claims = %+v, want %+v", claims, want)
+	}
+}
+
+func TestExchangeCodeIsSingleUse(t *testing.T) {
+	p, _ := newTestProvider(t)
+	nonce, verifier := RandomToken(), NewVerifier()
+	code := signIn(t, p, nonce, verifier)
+
+	if _, err := p.Exchange(context.Background(), code, verifier, nonce); err != nil {
+		t.Fatalf("first Exchange: %v", err)
+	}
+	if _, err := p.Exchange(context.Background(), code, verifier, nonce); err == nil {
+		t.Error("second Exchange with the same code succeeded")
+	}
+}
+
+func TestExchangeWrongVerifier(t *testing.T) {
+	p, _ := newTestProvider(t)
+	nonce := RandomToken()
+	code := signIn(t, p, nonce, NewVerifier())
+
+	// ผู้ที่ดักได้แค่ code แต่ไม่มี verifier ต้องแลก token ไม่ได้
+	if _, err := p.Exchange(context.Background(), code, NewVerifier(), nonce); err == nil {
+		t.Error("Exchange with another PKCE verifier succeeded")
+	}
+}
+
+func TestExchangeWrongNonce(t *testing.T) {
+	p, _ := newTestProvider(t)
+	verifier := NewVerifier()
+	code := signIn(t, p, RandomToken(), verifier)
+
+	_, err := p.Exchange(context.Background(), code, verifier, RandomToken())
+	if err == nil || !strings.Contains(err.Error(), "nonce") {
+		t.Errorf("Exchange with another nonce: err = %v, want a nonce mismatch", err)
+	}
+}
+
+func TestExchangeUnverifiedEmail(t *testing.T) {
+	p, idp := newTestProvider(t)
+	idp.SetUser(ssotest.User{Subject: "sub-2", Email: " someone@example.com ", Name: "Someone"})
+	nonce, verifier := RandomToken(), NewVerifier()
+
+	claims, err := p.Exchange(context.Background(), signIn(t, p, nonce, verifier), verifier, nonce)
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	if claims.EmailVerified || claims.Email != "someone@example.com" {
+		t.Errorf("claims = %+v, want unverified someone@example.com", claims)
+	}
+}
+
+func TestFlexBool(t *testing.T) {
+	tests := []struct {
+		in   string
+		want bool
+	}{
+		{`true`, true},
+		{`false`, false},
+		{`"true"`, true},
+		{`"TRUE"`, true},
+		{`"false"`, false},
+		{`null`, false},
+	}
+	for _, tt := range tests {
+		var b flexBool
+		if err := b.UnmarshalJSON([]byte(tt.in)); err != nil {
+			t.Errorf("UnmarshalJSON(%s) error: %v", tt.in, err)
+			continue
+		}
+		if bool(b) != tt.want {
+			t.Errorf("UnmarshalJSON(%s) = %v, want %v", tt.in, b, tt.want)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+// Package store opens the MongoDB connection shared by the server and the CLI.
+package store
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"go.mongodb.org/mongo-driver/event"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/contrib/instrumentation/go.mongodb.org/mongo-driver/mongo/otelmongo"
+
+	"gosmooth/metrics"
+)
+
+// maxRetries is how many times Connect retries before giving up
+const maxRetries = 3
+
+// Connect connects to MongoDB with retry logic, pings it and returns the
+// client together with the named database. Callers must Disconnect the client.
+func Connect(ctx context.Context, uri, dbName string) (*mongo.Client, *mongo.Database, error) {
+	var client *mongo.Client
+	var err error
+	for i := 0; i < maxRetries; i++ {
+		client, err = mongo.Connect(ctx, options.Client().ApplyURI(uri).
+			SetMonitor(chainMonitors(metrics.MongoMonitor(), otelmongo.NewMonitor())))
+		if err == nil {
+			break
+		}
+		slog.Warn("MongoDB connection attempt failed", "attempt", i+1, "error", err)
+		time.Sleep(time.Second * time.Duration(i+1))
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to MongoDB after %d attempts: %w", maxRetries, err)
+	}
+
+	// Ping MongoDB to verify connection
+	if err := client.Ping(ctx, nil); err != nil {
+		_ = client.Disconnect(context.Background())
+		return nil, nil, fmt.Errorf("failed to ping MongoDB: %w", err)
+	}
+
+	return client, client.Database(dbName), nil
+}
+
+// chainMonitors fans command events out to several monitors, since the driver
+// accepts only one (metrics and tracing both need them).
+func chainMonitors(monitors ...*event.CommandMonitor) *event.CommandMonitor {
+	return &event.CommandMonitor{
+		Started: func(ctx context.Context, e *event.CommandStartedEvent) {
+			for _, m := range monitors {
+				if m.Started != nil {
+					m.Started(ctx, e)
+				}
+			}
+		},
+		Succeeded: func(ctx context.Context, e *event.CommandSucceededEvent) {
+			for _, m := range monitors {
+				if m.Succeeded != nil {
+					m.Succeeded(ctx, e)
+				}
+			}
+		},
+		Failed: func(ctx context.Context, e *event.CommandFailedEvent) {
+			for _, m := range monitors {
+				if m.Failed != nil {
+					m.Failed(ctx, e)
+				}
+			}
+		},
+	}
+}
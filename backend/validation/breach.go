@@ -0,0 +1,189 @@
+package validation
+
+import (
+	"bytes"
+	"crypto/sha1"
+	_ "embed"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// BreachChecker reports whether a password is known from a data breach
+type BreachChecker interface {
+	Breached(password string) bool
+}
+
+// breachedSample is a small bundled sample of frequently breached passwords
+// as uppercase SHA-1 hashes, one per line and sorted. Production deployments
+// should point PASSWORD_BREACH_FILE at the full Pwned Passwords corpus.
+//
+//go:embed data/breached-sha1.txt
+var breachedSample string
+
+//go:embed data/common-passwords.txt
+var commonPasswordList string
+
+func commonPasswords() map[string]bool {
+	deny := map[string]bool{}
+	addLines(deny, commonPasswordList)
+	return deny
+}
+
+// addLines adds each non-comment line of list to deny, lowercased
+func addLines(deny map[string]bool, list string) {
+	for _, line := range strings.Split(list, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		deny[strings.ToLower(line)] = true
+	}
+}
+
+// hashPassword returns the uppercase hex SHA-1 used by breach corpora
+func hashPassword(password string) string {
+	sum := sha1.Sum([]byte(password))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+// hashList is an in-memory sorted list of SHA-1 hashes
+type hashList []string
+
+func embeddedCorpus() hashList {
+	var list hashList
+	for _, line := range strings.Split(breachedSample, "\n") {
+		if hash, _, _ := strings.Cut(strings.TrimSpace(line), ":"); hash != "" {
+			list = append(list, strings.ToUpper(hash))
+		}
+	}
+	sort.Strings(list)
+	return list
+}
+
+func (l hashList) Breached(password string) bool {
+	hash := hashPassword(password)
+	i := sort.SearchStrings(l, hash)
+	return i < len(l) && l[i] == hash
+}
+
+// hashFile searches a corpus file on disk without loading it. Lines are
+// "HASH" or "HASH:COUNT" ordered by hash, which is the layout of the Pwned
+// Passwords download, so a multi-gigabyte file costs a few reads per check.
+type hashFile struct {
+	r    io.ReaderAt
+	size int64
+}
+
+// maxLineLength bounds one "HASH:COUNT\r\n" line
+const maxLineLength = 128
+
+// OpenBreachFile opens a sorted SHA-1 corpus for binary search. The file
+// stays open for the life of the process.
+func OpenBreachFile(path string) (BreachChecker, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &hashFile{r: f, size: info.Size()}, nil
+}
+
+func (h *hashFile) Breached(password string) bool {
+	target := []byte(hashPassword(password))
+	lo, hi := int64(0), h.size
+	// invariant: a matching line, if any, starts in [lo, hi)
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		start, line, err := h.lineFrom(mid)
+		if err != nil || start >= hi {
+			hi = mid
+			continue
+		}
+		hash, _, _ := bytes.Cut(bytes.TrimRight(line, "\r"), []byte(":"))
+		switch bytes.Compare(bytes.ToUpper(hash), target) {
+		case 0:
+			return true
+		case -1:
+			lo = start + int64(len(line)) + 1
+		default:
+			hi = mid
+		}
+	}
+	return false
+}
+
+// lineFrom returns the first line that starts at or after off
+func (h *hashFile) lineFrom(off int64) (int64, []byte, error) {
+	start := off
+	if off > 0 {
+		// ถอยไปหนึ่งไบต์ ถ้าเป็น \n แปลว่า off คือต้นบรรทัดพอดี
+		buf := make([]byte, maxLineLength)
+		n, err := h.r.ReadAt(buf, off-1)
+		if err != nil && err != io.EOF {
+			return 0, nil, err
+		}
+		i := bytes.IndexByte(buf[:n], '\n')
+		if i < 0 {
+			return h.size, nil, nil
+		}
+		start = off + int64(i)
+	}
+	if start >= h.size {
+		return h.size, nil, nil
+	}
+	buf := make([]byte, maxLineLength)
+	n, err := h.r.ReadAt(buf, start)
+	if err != nil && err != io.EOF {
+		return 0, nil, err
+	}
+	line := buf[:n]
+	if i := bytes.IndexByte(line, '\n'); i >= 0 {
+		line = line[:i]
+	}
+	return start, line, nil
+}
+
+// breachCheckers consults each checker in turn
+type breachCheckers []BreachChecker
+
+func (cs breachCheckers) Breached(password string) bool {
+	for _, c := range cs {
+		if c.Breached(password) {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadPasswordPolicy builds the policy from configuration. denyFile adds
+// entries to the built-in deny-list and breachFile adds a corpus on disk to
+// the bundled sample; either may be empty.
+func LoadPasswordPolicy(minLength, minClasses int, denyFile, breachFile string) (*PasswordPolicy, error) {
+	p := DefaultPasswordPolicy()
+	p.MinLength = minLength
+	p.MinClasses = minClasses
+
+	if denyFile != "" {
+		data, err := os.ReadFile(denyFile)
+		if err != nil {
+			return nil, fmt.Errorf("password deny-list: %w", err)
+		}
+		addLines(p.DenyList, string(data))
+	}
+	if breachFile != "" {
+		corpus, err := OpenBreachFile(breachFile)
+		if err != nil {
+			return nil, fmt.Errorf("password breach corpus: %w", err)
+		}
+		p.Breached = breachCheckers{p.Breached, corpus}
+	}
+	return p, nil
+}
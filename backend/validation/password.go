@@ -0,0 +1,164 @@
+package validation
+
+import (
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Reasons a password can be rejected, reported to clients as-is
+const (
+	ReasonTooShort     = "too_short"
+	ReasonTooLong      = "too_long"
+	ReasonTooFewKinds  = "too_few_character_classes"
+	ReasonCommon       = "common"
+	ReasonBreached     = "breached"
+	ReasonPersonalInfo = "contains_personal_info"
+)
+
+// maxPasswordLength caps input before hashing; bcrypt ignores bytes past 72
+const maxPasswordLength = 72
+
+// PasswordPolicy decides which new passwords are accepted. Existing hashes
+// are never re-checked; the policy only applies when a password is set.
+type PasswordPolicy struct {
+	// MinLength is the minimum number of characters
+	MinLength int
+	// MinClasses is how many of lowercase, uppercase, digits and other
+	// characters (symbols, Thai letters, ...) must appear
+	MinClasses int
+	// DenyList holds lowercased passwords that are rejected outright
+	DenyList map[string]bool
+	// Breached reports whether a password appears in a breach corpus; nil
+	// skips the check
+	Breached BreachChecker
+}
+
+// DefaultPasswordPolicy is used until SetPasswordPolicy is called
+func DefaultPasswordPolicy() *PasswordPolicy {
+	return &PasswordPolicy{
+		MinLength:  10,
+		MinClasses: 2,
+		DenyList:   commonPasswords(),
+		Breached:   embeddedCorpus(),
+	}
+}
+
+var (
+	policyMu sync.RWMutex
+	policy   = DefaultPasswordPolicy()
+)
+
+// SetPasswordPolicy replaces the policy used by CheckPassword and the
+// `password` tag
+func SetPasswordPolicy(p *PasswordPolicy) {
+	policyMu.Lock()
+	policy = p
+	policyMu.Unlock()
+}
+
+// CurrentPasswordPolicy returns the policy in effect
+func CurrentPasswordPolicy() *PasswordPolicy {
+	policyMu.RLock()
+	defer policyMu.RUnlock()
+	return policy
+}
+
+// PasswordError lists every rule a password broke
+type PasswordError struct {
+	Reasons []string
+}
+
+func (e *PasswordError) Error() string {
+	return "password rejected: " + strings.Join(e.Reasons, ", ")
+}
+
+// CheckPassword checks password against the current policy. personal holds
+// values the password must not contain, such as the email and name.
+func CheckPassword(password string, personal ...string) error {
+	return CurrentPasswordPolicy().Check(password, personal...)
+}
+
+// Check returns a *PasswordError when password breaks the policy
+func (p *PasswordPolicy) Check(password string, personal ...string) error {
+	var reasons []string
+	n := len([]rune(password))
+	if n < p.MinLength {
+		reasons = append(reasons, ReasonTooShort)
+	}
+	if len(password) > maxPasswordLength {
+		reasons = append(reasons, ReasonTooLong)
+	}
+	if characterClasses(password) < p.MinClasses {
+		reasons = append(reasons, ReasonTooFewKinds)
+	}
+
+	lower := strings.ToLower(password)
+	if p.DenyList[lower] || p.DenyList[strings.TrimRightFunc(lower, unicode.IsDigit)] {
+		reasons = append(reasons, ReasonCommon)
+	}
+	if containsPersonalInfo(lower, personal) {
+		reasons = append(reasons, ReasonPersonalInfo)
+	}
+	// ค้น corpus หลังสุดเพราะอาจต้องอ่านไฟล์ และไม่ต้องค้นถ้าผิดกฎอื่นแล้ว
+	if len(reasons) == 0 && p.Breached != nil && p.Breached.Breached(password) {
+		reasons = append(reasons, ReasonBreached)
+	}
+
+	if len(reasons) > 0 {
+		return &PasswordError{Reasons: reasons}
+	}
+	return nil
+}
+
+func characterClasses(password string) int {
+	var lower, upper, digit, other bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			other = true
+		}
+	}
+	n := 0
+	for _, has := range []bool{lower, upper, digit, other} {
+		if has {
+			n++
+		}
+	}
+	return n
+}
+
+func containsPersonalInfo(lower string, personal []string) bool {
+	for _, value := range personal {
+		for _, part := range personalParts(value) {
+			if strings.Contains(lower, part) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// personalParts splits an email or name into the pieces worth matching,
+// skipping short ones that would reject too many passwords.
+func personalParts(value string) []string {
+	value = strings.ToLower(value)
+	if at := strings.IndexByte(value, '@'); at >= 0 {
+		value = value[:at]
+	}
+	var parts []string
+	for _, part := range strings.FieldsFunc(value, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if len([]rune(part)) >= 4 {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
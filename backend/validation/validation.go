@@ -0,0 +1,151 @@
+// Package validation is the single validation layer for request DTOs. Rules
+// are declared with `validate` struct tags and checked when a handler binds
+// a request body through gin.
+package validation
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"gosmooth/models"
+)
+
+// TagName is the struct tag that holds validation rules
+const TagName = "validate"
+
+func init() {
+	// ShouldBindJSON และ binding อื่นของ gin จะตรวจ tag validate ผ่าน validator ตัวนี้
+	binding.Validator = &ginValidator{}
+}
+
+var (
+	once     sync.Once
+	instance *validator.Validate
+)
+
+// Validator returns the shared validator with every custom rule registered
+func Validator() *validator.Validate {
+	once.Do(func() {
+		v := validator.New()
+		v.SetTagName(TagName)
+		v.RegisterTagNameFunc(JSONFieldName)
+		for tag, fn := range rules {
+			if err := v.RegisterValidation(tag, fn); err != nil {
+				panic(err)
+			}
+		}
+		instance = v
+	})
+	return instance
+}
+
+// Struct validates s against its `validate` tags
+func Struct(s interface{}) error {
+	return Validator().Struct(s)
+}
+
+// JSONFieldName names struct fields by their json tag in validation errors,
+// so clients see the same names they sent.
+func JSONFieldName(f reflect.StructField) string {
+	name := strings.SplitN(f.Tag.Get("json"), ",", 2)[0]
+	switch name {
+	case "-":
+		return ""
+	case "":
+		return f.Name
+	}
+	return name
+}
+
+// rules are the custom tags available on top of validator's built-ins.
+// Passwords have no tag: the policy needs the account's email and name and
+// reports its reasons, so handlers call CheckPassword instead.
+var rules = map[string]validator.Func{
+	"objectid":       isObjectID,
+	"th_postcode":    isThaiPostcode,
+	"th_phone":       isThaiPhone,
+	"place_category": isPlaceCategory,
+	"api_scope":      isAPIKeyScope,
+}
+
+func isObjectID(fl validator.FieldLevel) bool {
+	return primitive.IsValidObjectID(fl.Field().String())
+}
+
+// isThaiPostcode accepts five digits whose province prefix is 10-96
+func isThaiPostcode(fl validator.FieldLevel) bool {
+	return ValidPostcode(fl.Field().String())
+}
+
+func isThaiPhone(fl validator.FieldLevel) bool {
+	return ValidPhone(fl.Field().String())
+}
+
+func isPlaceCategory(fl validator.FieldLevel) bool {
+	return models.IsValidPlaceCategory(fl.Field().String())
+}
+
+func isAPIKeyScope(fl validator.FieldLevel) bool {
+	return models.IsValidAPIKeyScope(fl.Field().String())
+}
+
+var postcodePattern = regexp.MustCompile(`^[1-9][0-9]{4}$`)
+
+// ValidPostcode reports whether s is a Thai postal code
+func ValidPostcode(s string) bool {
+	if !postcodePattern.MatchString(s) {
+		return false
+	}
+	prefix := int(s[0]-'0')*10 + int(s[1]-'0')
+	return prefix >= 10 && prefix <= 96
+}
+
+// phonePattern matches landline (0 + 8 digits) and mobile (0 + 9 digits)
+// numbers, with either a leading 0 or the +66 country code.
+var phonePattern = regexp.MustCompile(`^(?:\+66|0)[1-9][0-9]{7,8}$`)
+
+// phoneSeparators are stripped before matching, e.g. "02-623-5500"
+var phoneSeparators = strings.NewReplacer(" ", "", "-", "", "(", "", ")", "", ".", "")
+
+// ValidPhone reports whether s is a Thai phone number
+func ValidPhone(s string) bool {
+	return phonePattern.MatchString(phoneSeparators.Replace(s))
+}
+
+// ginValidator plugs the shared validator into gin's binding
+type ginValidator struct{}
+
+// ValidateStruct validates structs, pointers to structs and slices of them;
+// other kinds are left alone like gin's default validator does.
+func (ginValidator) ValidateStruct(obj interface{}) error {
+	if obj == nil {
+		return nil
+	}
+	value := reflect.ValueOf(obj)
+	switch value.Kind() {
+	case reflect.Ptr:
+		if value.IsNil() {
+			return nil
+		}
+		return ginValidator{}.ValidateStruct(value.Elem().Interface())
+	case reflect.Struct:
+		return Struct(obj)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			if err := (ginValidator{}).ValidateStruct(value.Index(i).Interface()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (ginValidator) Engine() interface{} {
+	return Validator()
+}
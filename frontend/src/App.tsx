@@ -16,6 +16,7 @@ import { useAuth } from './hooks/useAuth';
 // Lazy-loaded pages
 const Login = lazy(() => import('./pages/auth/Login'));
 const Register = lazy(() => import('./pages/auth/Register'));
+const OIDCCallback = lazy(() => import('./pages/auth/OIDCCallback'));
 const RoutePlanner = lazy(() => import('./pages/RoutePlanner'));
 // const CostEstimator = lazy(() => import('./pages/CostEstimator'));
 const Reviews = lazy(() => import('./pages/Reviews'));
@@ -24,6 +25,9 @@ const AdminDashboard = lazy(() => import('./pages/admin/Dashboard'));
 const NotFound = lazy(() => import('./pages/NotFound'));
 const PlacesPage = lazy(() => import('./pages/PlacesPage'));
 const PlaceDetailPage = lazy(() => import('./pages/PlaceDetailPage'));
+const SharedCollection = lazy(() => import('./pages/SharedCollection'));
+const Trips = lazy(() => import('./pages/Trips'));
+const SharedTrip = lazy(() => import('./pages/SharedTrip'));
 const PlacesManager = lazy(() => import('./pages/admin/PlacesManager'));
 
 // Protected route component
@@ -66,6 +70,7 @@ function App() {
             <Route element={<AuthLayout />}>
               <Route path="/login" element={<Login />} />
               <Route path="/register" element={<Register />} />
+              <Route path="/auth/callback" element={<OIDCCallback />} />
             </Route>
 
             {/* Main app routes */}
@@ -110,6 +115,16 @@ function App() {
               {/* Places routes */}
               <Route path="/places" element={<PlacesPage />} />
               <Route path="/places/:id" element={<PlaceDetailPage />} />
+              <Route path="/collections/:id" element={<SharedCollection />} />
+              <Route
+                path="/trips"
+                element={
+                  <ProtectedRoute>
+                    <Trips />
+                  </ProtectedRoute>
+                }
+              />
+              <Route path="/trips/:id" element={<SharedTrip />} />
               
               {/* 404 route */}
               <Route path="*" element={<NotFound />} />
@@ -396,6 +396,9 @@ const Navbar = () => {
             <NavItem>
               <NavLinkStyled to="/route-planner">Route Planner</NavLinkStyled>
             </NavItem>
+            <NavItem>
+              <NavLinkStyled to="/trips">Trips</NavLinkStyled>
+            </NavItem>
             <NavItem>
               <NavLinkStyled to="/reviews">Reviews</NavLinkStyled>
             </NavItem>
@@ -497,6 +500,9 @@ const Navbar = () => {
                   <MobileNavItem>
                     <MobileNavLink to="/route-planner">Route Planner</MobileNavLink>
                   </MobileNavItem>
+                  <MobileNavItem>
+                    <MobileNavLink to="/trips">Trips</MobileNavLink>
+                  </MobileNavItem>
                   <MobileNavItem>
                     <MobileNavLink to="/cost-estimator">Cost Estimator</MobileNavLink>
                   </MobileNavItem>
@@ -0,0 +1,106 @@
+import { Link } from 'react-router-dom';
+import { AlertTriangle, Clock, MapPin } from 'lucide-react';
+import type { Trip, TripPlan, TripTotals, TripWarning } from '../../types/place';
+
+const warningText = (w: TripWarning) => {
+  switch (w.code) {
+    case 'closed':
+      return 'สถานที่ปิดในเวลาที่ไปถึง';
+    case 'opens_later':
+      return `ไปถึงก่อนเปิด ต้องรอถึง ${w.at}`;
+    case 'closes_early':
+      return `สถานที่ปิด ${w.at} ก่อนเวลาที่จะออก`;
+    case 'hours_vary':
+      return 'เวลาเปิดต่างกันแต่ละวัน ใส่วันเริ่มทริปเพื่อตรวจสอบ';
+    case 'no_route':
+      return 'ไม่มีเส้นทางในระบบ ประมาณจากระยะทาง';
+    case 'no_coordinates':
+      return 'ไม่มีพิกัด คำนวณการเดินทางไม่ได้';
+    case 'past_midnight':
+      return 'แผนวันนี้เลยเที่ยงคืน';
+  }
+};
+
+const Warnings = ({ warnings }: { warnings: TripWarning[] }) => (
+  <>
+    {warnings.map((w, i) => (
+      <div key={i} className="text-xs text-amber-600 flex items-center gap-1">
+        <AlertTriangle className="w-3 h-3" /> {warningText(w)}
+      </div>
+    ))}
+  </>
+);
+
+export const formatMinutes = (minutes: number) => {
+  const h = Math.floor(minutes / 60);
+  const m = minutes % 60;
+  return h > 0 ? `${h} ชม. ${m} นาที` : `${m} นาที`;
+};
+
+const dayDate = (startDate: string | undefined, index: number) => {
+  if (!startDate) return '';
+  const d = new Date(`${startDate}T00:00:00`);
+  d.setDate(d.getDate() + index);
+  return d.toLocaleDateString('th-TH', { weekday: 'short', day: 'numeric', month: 'short' });
+};
+
+export const TotalsLine = ({ totals }: { totals: TripTotals }) => (
+  <div className="text-sm text-gray-600">
+    รวม {formatMinutes(totals.duration)} · เดินทาง {formatMinutes(totals.travelTime)} · {totals.distance} กม. · ประมาณ ฿{totals.cost.toLocaleString()}
+  </div>
+);
+
+// แผนการเดินทางทีละวัน: เวลาถึง/ออก ช่วงเดินทาง และคำเตือนเรื่องเวลาเปิดปิด
+const TripPlanView = ({ trip, plan }: { trip: Trip; plan: TripPlan }) => (
+  <div className="space-y-6">
+    <TotalsLine totals={plan.totals} />
+    {trip.days.map((day, d) => {
+      const dayPlan = plan.days[d];
+      if (!dayPlan) return null;
+      return (
+        <div key={d} className="bg-white rounded-xl shadow p-4">
+          <div className="flex justify-between items-baseline mb-3">
+            <h3 className="font-semibold">วันที่ {d + 1} {dayDate(trip.startDate, d)}</h3>
+            <span className="text-sm text-gray-500">{dayPlan.start} – {dayPlan.end}</span>
+          </div>
+          <Warnings warnings={dayPlan.warnings} />
+          {day.stops.length === 0 && <div className="text-gray-400 text-sm">ยังไม่มีสถานที่</div>}
+          {day.stops.map((stop, s) => {
+            const visit = dayPlan.visits[s];
+            const leg = s > 0 ? dayPlan.legs[s - 1] : undefined;
+            return (
+              <div key={s}>
+                {leg && (
+                  <div className="ml-4 pl-4 border-l-2 border-dashed border-gray-200 py-2 text-xs text-gray-500">
+                    {leg.modes.join(' → ') || 'เดินทาง'} · {formatMinutes(leg.duration)} · {leg.distance} กม.
+                    {leg.cost > 0 && ` · ฿${leg.cost}`}
+                    {leg.estimated && ' (ประมาณ)'}
+                    <Warnings warnings={leg.warnings} />
+                  </div>
+                )}
+                <div className="flex gap-3 items-start">
+                  <div className="text-sm font-mono text-blue-600 w-24 shrink-0 flex items-center gap-1">
+                    <Clock className="w-3 h-3" /> {visit?.arrive}–{visit?.depart}
+                  </div>
+                  <div>
+                    <Link to={`/places/${stop.placeId}`} className="font-medium hover:underline flex items-center gap-1">
+                      <MapPin className="w-4 h-4 text-gray-400" /> {stop.placeName}
+                    </Link>
+                    <div className="text-xs text-gray-400">
+                      อยู่ {formatMinutes(stop.duration)}{visit && visit.wait > 0 ? ` · รอเปิด ${formatMinutes(visit.wait)}` : ''}
+                    </div>
+                    {stop.note && <div className="text-sm text-gray-600">{stop.note}</div>}
+                    {visit && <Warnings warnings={visit.warnings} />}
+                  </div>
+                </div>
+              </div>
+            );
+          })}
+          <div className="mt-3"><TotalsLine totals={dayPlan.totals} /></div>
+        </div>
+      );
+    })}
+  </div>
+);
+
+export default TripPlanView;
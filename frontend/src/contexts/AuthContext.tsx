@@ -23,8 +23,20 @@ interface User {
   role: string;
   address?: Address;
   created_at?: string;
+  mustChangePassword?: boolean;
+  identities?: { provider: string; email?: string; linkedAt: string }[];
 }
 
+// ข้อความภาษาไทยสำหรับเหตุผลที่ backend ปฏิเสธรหัสผ่านใหม่ (weak_password)
+const passwordReasonMessages: Record<string, string> = {
+  too_short: 'password สั้นเกินไป',
+  too_long: 'password ยาวเกินไป',
+  too_few_character_classes: 'password ต้องผสมตัวพิมพ์เล็ก ตัวพิมพ์ใหญ่ ตัวเลข หรือสัญลักษณ์',
+  common: 'password นี้ถูกใช้บ่อยเกินไป',
+  breached: 'password นี้เคยรั่วไหลจากเว็บไซต์อื่น',
+  contains_personal_info: 'password ต้องไม่มีชื่อหรืออีเมลของคุณ',
+};
+
 export interface AuthContextType {
   token: string | null;
   user: User | null;
@@ -52,6 +64,7 @@ export interface AuthContextType {
   ) => Promise<any>;
   updateProfile: (data: { name: string; address: Address }) => Promise<any>;
   changePassword: (data: { currentPassword: string; newPassword: string }) => Promise<any>;
+  loginWithToken: (token: string, passwordChangeRequired?: boolean) => Promise<void>;
 }
 
 export const AuthContext = createContext<AuthContextType>({
@@ -68,6 +81,7 @@ export const AuthContext = createContext<AuthContextType>({
   registerUser: async () => {},
   updateProfile: async () => {},
   changePassword: async () => {},
+  loginWithToken: async () => {},
 });
 
 // Check token expiration
@@ -99,6 +113,7 @@ export const AuthProvider: React.FC<{ children: React.ReactNode }> = ({ children
     registerUser: async () => {},
     updateProfile: async () => {},
     changePassword: async () => {},
+    loginWithToken: async () => {},
   });
   
   const navigate = useNavigate();
@@ -145,6 +160,7 @@ export const AuthProvider: React.FC<{ children: React.ReactNode }> = ({ children
             registerUser: async () => {},
             updateProfile: async () => {},
             changePassword: async () => {},
+            loginWithToken: async () => {},
           });
           resetInactivityTimer();
         } catch (error) {
@@ -165,6 +181,7 @@ export const AuthProvider: React.FC<{ children: React.ReactNode }> = ({ children
             registerUser: async () => {},
             updateProfile: async () => {},
             changePassword: async () => {},
+            loginWithToken: async () => {},
           });
         }
       } else {
@@ -185,6 +202,7 @@ export const AuthProvider: React.FC<{ children: React.ReactNode }> = ({ children
           registerUser: async () => {},
           updateProfile: async () => {},
           changePassword: async () => {},
+          loginWithToken: async () => {},
         });
       }
     };
@@ -211,8 +229,12 @@ export const AuthProvider: React.FC<{ children: React.ReactNode }> = ({ children
       Cookies.remove('token');
       sessionStorage.removeItem('token');
       const response = await api.post('/api/auth/login', { email, password, rememberMe });
-      const { token, user } = response.data;
-      if (rememberMe) {
+      const { token, user, passwordChangeRequired } = response.data;
+      if (passwordChangeRequired) {
+        // token นี้ใช้ได้แค่เปลี่ยนรหัสผ่านและหมดอายุเร็ว จึงไม่เก็บลง cookie
+        user.mustChangePassword = true;
+        sessionStorage.setItem('token', token);
+      } else if (rememberMe) {
         Cookies.set('token', token, { expires: 7 }); // 7 days
       } else {
         sessionStorage.setItem('token', token);
@@ -232,16 +254,17 @@ export const AuthProvider: React.FC<{ children: React.ReactNode }> = ({ children
         registerUser: async () => {},
         updateProfile: async () => {},
         changePassword: async () => {},
+        loginWithToken: async () => {},
       });
       resetInactivityTimer();
-      navigate('/');
+      navigate(passwordChangeRequired ? '/profile?changePassword=1' : '/');
     } catch (error: any) {
       if (axios.isAxiosError(error) && error.response) {
         // ดักกรณีถูกแบน
         if (error.response.status === 403 && error.response.data?.banReason) {
           setAuthState({
             ...authState,
-            error: error.response.data.error,
+            error: error.response.data.detail,
             isLoading: false,
           });
           throw { banned: true, banReason: error.response.data.banReason };
@@ -253,8 +276,8 @@ export const AuthProvider: React.FC<{ children: React.ReactNode }> = ({ children
           errorMessage = 'อีเมลที่กรอกไม่ถูกต้อง';
         } else if (error.response.status === 401) {
           errorMessage = 'รหัสผ่านไม่ถูกต้อง';
-        } else if (error.response.data?.error) {
-          errorMessage = error.response.data.error;
+        } else if (error.response.data?.detail) {
+          errorMessage = error.response.data.detail;
         }
 
         setAuthState({
@@ -274,6 +297,36 @@ export const AuthProvider: React.FC<{ children: React.ReactNode }> = ({ children
     }
   };
 
+  // เข้าสู่ระบบด้วย token ที่ได้จาก social login (OIDC callback)
+  // passwordChangeRequired มาจาก fragment เมื่อบัญชีต้องเปลี่ยนรหัสผ่านก่อน
+  const loginWithToken = async (token: string, passwordChangeRequired?: boolean) => {
+    Cookies.remove('token');
+    sessionStorage.setItem('token', token);
+    api.defaults.headers.common['Authorization'] = `Bearer ${token}`;
+    try {
+      const response = await api.get('/api/profile');
+      const user = response.data.user || response.data;
+      if (passwordChangeRequired) {
+        user.mustChangePassword = true;
+      }
+      setAuthState(prev => ({
+        ...prev,
+        token,
+        user,
+        isAuthenticated: true,
+        isAdmin: user.role === 'admin',
+        isLoading: false,
+        error: null,
+      }));
+      resetInactivityTimer();
+      navigate(passwordChangeRequired ? '/profile?changePassword=1' : '/');
+    } catch (error) {
+      sessionStorage.removeItem('token');
+      delete api.defaults.headers.common['Authorization'];
+      navigate('/login?oidcError=oidc_failed');
+    }
+  };
+
   // Register function
   const register = async (name: string, email: string, password: string): Promise<string | void> => {
     try {
@@ -289,7 +342,7 @@ export const AuthProvider: React.FC<{ children: React.ReactNode }> = ({ children
       if (axios.isAxiosError(error) && error.response) {
         setAuthState({
           ...authState,
-          error: error.response.data.message || 'Failed to register',
+          error: error.response.data.detail || 'Failed to register',
           isLoading: false,
         });
       } else {
@@ -321,6 +374,7 @@ export const AuthProvider: React.FC<{ children: React.ReactNode }> = ({ children
       registerUser: async () => {},
       updateProfile: async () => {},
       changePassword: async () => {},
+      loginWithToken: async () => {},
     });
     navigate('/login');
     window.location.reload();
@@ -357,7 +411,11 @@ export const AuthProvider: React.FC<{ children: React.ReactNode }> = ({ children
       });
       return response.data;
     } catch (error: any) {
-      throw new Error(error.response?.data?.error || 'Registration failed');
+      const data = error.response?.data;
+      if (data?.code === 'weak_password' && Array.isArray(data.reasons)) {
+        throw new Error(data.reasons.map((r: string) => passwordReasonMessages[r] || r).join(', '));
+      }
+      throw new Error(data?.detail || 'Registration failed');
     }
   };
 
@@ -370,20 +428,34 @@ export const AuthProvider: React.FC<{ children: React.ReactNode }> = ({ children
       }));
       return response.data;
     } catch (error: any) {
-      throw new Error(error.response?.data?.error || 'Update profile failed');
+      throw new Error(error.response?.data?.detail || 'Update profile failed');
     }
   };
 
   const changePassword = async (data: { currentPassword: string; newPassword: string }) => {
     try {
       const response = await api.post('/api/auth/change-password', data);
+      // หลังเปลี่ยนรหัสผ่านที่ถูกบังคับ backend จะออก token ปกติให้ใหม่
+      const { token } = response.data;
+      if (token) {
+        sessionStorage.setItem('token', token);
+        api.defaults.headers.common['Authorization'] = `Bearer ${token}`;
+      }
+      setAuthState(prev => ({
+        ...prev,
+        token: token || prev.token,
+        user: prev.user ? { ...prev.user, mustChangePassword: false } : prev.user,
+      }));
       return response.data;
     } catch (error: any) {
       console.log('DEBUG: changePassword error', error, error.response, error.message);
       // ตรวจสอบ error message จาก backend
-      let msg = error.response?.data?.error || 'Change password failed';
-      if (msg.toLowerCase().includes('current password') || msg.toLowerCase().includes('รหัสผ่านเดิม')) {
+      const data = error.response?.data;
+      let msg = data?.detail || 'Change password failed';
+      if (data?.code === 'password_incorrect') {
         msg = 'รหัสผ่านเดิมไม่ถูกต้อง';
+      } else if (data?.code === 'weak_password' && Array.isArray(data.reasons)) {
+        msg = data.reasons.map((r: string) => passwordReasonMessages[r] || r).join(', ');
       }
       throw new Error(msg);
     }
@@ -400,6 +472,7 @@ export const AuthProvider: React.FC<{ children: React.ReactNode }> = ({ children
         registerUser,
         updateProfile,
         changePassword,
+        loginWithToken,
       }}
     >
       {children}
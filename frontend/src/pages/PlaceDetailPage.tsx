@@ -2,15 +2,14 @@ import React, { useState, useEffect } from 'react';
 import { useParams, Link, useNavigate } from 'react-router-dom';
 import { motion } from 'framer-motion';
 import { FaStar, FaMapMarkerAlt, FaRoute, FaHeart, FaRegHeart, FaShareAlt, FaMoneyBillWave, FaPhone, FaGlobe, FaClock, FaInfo } from 'react-icons/fa';
-import axios from 'axios';
 import { useAuth } from '../contexts/AuthContext';
 import ReactStars from 'react-stars';
-import { Place, Review } from '../types/place';
+import { Place, PlaceClaimInput, Review, ReviewVotes } from '../types/place';
 import { MapContainer, TileLayer, Marker, Popup } from 'react-leaflet';
 import 'leaflet/dist/leaflet.css';
 import { api } from '../services/api';
-import { UserCircle, Heart, AlertTriangle, CheckCircle2 } from 'lucide-react';
-import { reviewsAPI } from '../services/api';
+import { UserCircle, Heart, ThumbsDown, AlertTriangle, CheckCircle2 } from 'lucide-react';
+import { reviewsAPI, placesAPI, collectionsAPI } from '../services/api';
 import { LuFlag, LuMapPin } from 'react-icons/lu';
 import { toast } from 'react-hot-toast';
 import ReviewOptionsMenu from '../components/ui/ReviewOptionsMenu';
@@ -86,6 +85,12 @@ const PlaceDetailPage: React.FC = () => {
   const [reportLoading, setReportLoading] = useState(false);
   const [showSuccessModal, setShowSuccessModal] = useState(false);
   const [successMessage, setSuccessMessage] = useState('');
+  const [respondingTo, setRespondingTo] = useState<string | null>(null);
+  const [responseText, setResponseText] = useState('');
+  const [showClaimForm, setShowClaimForm] = useState(false);
+  const [claim, setClaim] = useState<PlaceClaimInput>({ businessName: '', position: '', contactPhone: '', evidence: '' });
+  const [claimSubmitting, setClaimSubmitting] = useState(false);
+  const [checkingIn, setCheckingIn] = useState(false);
   const navigate = useNavigate();
 
   useEffect(() => { fetchPlaceDetails(); }, [id]);
@@ -106,7 +111,8 @@ const PlaceDetailPage: React.FC = () => {
   const fetchPlaceDetails = async () => {
     try {
       console.log('[DEBUG] PlaceDetailPage id:', id);
-      const response = await axios.get(`${API_URL}/api/places/${id}`);
+      // ส่ง token ไปด้วยเพื่อให้รู้ว่าผู้ใช้บันทึกสถานที่นี้ไว้หรือยัง
+      const response = await api.get(`/api/places/${id}`);
       console.log('[DEBUG] API response:', response.data);
       const p = response.data.place || response.data;
       const getPlaceId = (p: any) => (typeof p.place_id === 'string' && p.place_id) || (typeof p.PlaceID === 'string' && p.PlaceID) || (typeof p.id === 'string' && p.id) || '';
@@ -137,11 +143,13 @@ const PlaceDetailPage: React.FC = () => {
         highlights: p.highlights || [],
         reviews: p.reviews || [],
         nearbyPlaces: p.nearbyPlaces || [],
+        ownerId: p.OwnerID || p.ownerId || undefined,
       };
       // Debug coverImage and url
       console.log('[DEBUG] coverImage:', placeData.coverImage);
       console.log('[DEBUG] coverImage url:', getImageUrl(placeData.coverImage));
       setPlace(placeData);
+      setIsFavorite(Boolean(p.Saved));
       setLoading(false);
     } catch (error) {
       console.error('[DEBUG] Error loading place details:', error);
@@ -175,9 +183,17 @@ const PlaceDetailPage: React.FC = () => {
     }
   }, [place]);
 
+  // หัวใจบันทึกลงรายการโปรด (favorites) ส่วนคอลเลกชันอื่นจัดการในหน้าโปรไฟล์
   const toggleFavorite = async () => {
-    if (!user) return;
-    setIsFavorite(!isFavorite);
+    if (!user || !place) return;
+    try {
+      const response = isFavorite
+        ? await collectionsAPI.removePlace('favorites', place.id)
+        : await collectionsAPI.savePlace('favorites', place.id);
+      setIsFavorite(Boolean(response.data.saved));
+    } catch (e: any) {
+      toast.error(e.response?.data?.detail || 'ไม่สามารถบันทึกรายการโปรดได้');
+    }
   };
 
   const handleReviewSubmit = () => {
@@ -220,10 +236,15 @@ const PlaceDetailPage: React.FC = () => {
       await fetchReviews(place.id);
       setReviewRating(0);
       setReviewText('');
-      showSuccess('โพสต์รีวิวสำเร็จ!');
+      // รีวิวได้สถานที่ละครั้ง ถ้าเคยรีวิวแล้วเซิร์ฟเวอร์จะแก้ไขรีวิวเดิมแทน
+      if (response.data.status === 'held') {
+        showSuccess('ส่งรีวิวแล้ว รอผู้ดูแลตรวจสอบก่อนเผยแพร่');
+      } else {
+        showSuccess(response.data.updated ? 'แก้ไขรีวิวเดิมของคุณสำหรับสถานที่นี้แล้ว' : 'โพสต์รีวิวสำเร็จ!');
+      }
     } catch (error: any) {
       console.error('Error posting review:', error);
-      toast.error(error.response?.data?.error || error.message || 'เกิดข้อผิดพลาด');
+      toast.error(error.response?.data?.detail || error.message || 'เกิดข้อผิดพลาด');
     } finally {
       setIsSubmittingReview(false);
       setShowWarning(false);
@@ -231,20 +252,116 @@ const PlaceDetailPage: React.FC = () => {
     }
   };
 
-  const handleLikeReview = async (reviewId: string) => {
-    setReviews(prev => prev.map(r => {
+  // Like or mark not helpful (toggle). The two exclude each other, so update
+  // both optimistically, then take the counts the server stored
+  const handleVote = async (reviewId: string, vote: 'like' | 'notHelpful') => {
+    const review = reviews.find(r => r.id === reviewId);
+    if (!review) return;
+    const liked = (review.liked_by || []).includes(userId);
+    const notHelpful = (review.not_helpful_by || []).includes(userId);
+    const notHelpfulCount = review.notHelpful || 0;
+    const apply = (votes: ReviewVotes) => setReviews(prev => prev.map(r => {
       if (r.id !== reviewId) return r;
-      const likedBy = r.liked_by || [];
-      const liked = likedBy.includes(userId);
+      const likedBy = (r.liked_by || []).filter((id: string) => id !== userId);
+      const notHelpfulBy = (r.not_helpful_by || []).filter((id: string) => id !== userId);
       return {
         ...r,
-        likes: liked ? r.likes - 1 : r.likes + 1,
-        liked_by: liked ? likedBy.filter((id: string) => id !== userId) : [...likedBy, userId],
+        likes: votes.likes,
+        liked_by: votes.liked ? [...likedBy, userId] : likedBy,
+        notHelpful: votes.notHelpfulCount,
+        not_helpful_by: votes.notHelpful ? [...notHelpfulBy, userId] : notHelpfulBy,
       };
     }));
+    const before = { liked, likes: review.likes, notHelpful, notHelpfulCount };
+    if (vote === 'like') {
+      apply({
+        liked: !liked,
+        likes: liked ? review.likes - 1 : review.likes + 1,
+        notHelpful: false,
+        notHelpfulCount: !liked && notHelpful ? notHelpfulCount - 1 : notHelpfulCount,
+      });
+    } else {
+      apply({
+        liked: false,
+        likes: !notHelpful && liked ? review.likes - 1 : review.likes,
+        notHelpful: !notHelpful,
+        notHelpfulCount: notHelpful ? notHelpfulCount - 1 : notHelpfulCount + 1,
+      });
+    }
+    try {
+      const res = vote === 'like'
+        ? await (liked ? reviewsAPI.unlikeReview(reviewId) : reviewsAPI.likeReview(reviewId))
+        : await (notHelpful ? reviewsAPI.unmarkNotHelpful(reviewId) : reviewsAPI.markNotHelpful(reviewId));
+      apply(res.data);
+    } catch {
+      apply(before);
+    }
+  };
+
+  const handleSaveResponse = async (reviewId: string) => {
+    if (!responseText.trim()) return;
+    try {
+      const res = await reviewsAPI.respondToReview(reviewId, responseText.trim());
+      setReviews(prev => prev.map(r => (r.id === reviewId ? { ...r, response: res.data.response } : r)));
+      setRespondingTo(null);
+      setResponseText('');
+    } catch (e) {
+      console.error('Error saving owner response:', e);
+      toast.error('ไม่สามารถบันทึกคำตอบได้');
+    }
+  };
+
+  const handleDeleteResponse = async (reviewId: string) => {
+    try {
+      await reviewsAPI.deleteResponse(reviewId);
+      setReviews(prev => prev.map(r => (r.id === reviewId ? { ...r, response: undefined } : r)));
+    } catch (e) {
+      console.error('Error deleting owner response:', e);
+      toast.error('ไม่สามารถลบคำตอบได้');
+    }
+  };
+
+  const handleCheckIn = () => {
+    if (!place) return;
+    if (!navigator.geolocation) {
+      toast.error('เบราว์เซอร์นี้ไม่รองรับการระบุตำแหน่ง');
+      return;
+    }
+    setCheckingIn(true);
+    navigator.geolocation.getCurrentPosition(
+      async ({ coords }) => {
+        try {
+          await placesAPI.checkIn(place.id, coords.latitude, coords.longitude, coords.accuracy);
+          await fetchReviews(place.id);
+          showSuccess('เช็กอินสำเร็จ! รีวิวของคุณจะแสดงป้ายไปมาจริง');
+        } catch (e: any) {
+          toast.error(e.response?.data?.detail || 'ไม่สามารถเช็กอินได้');
+        } finally {
+          setCheckingIn(false);
+        }
+      },
+      () => {
+        toast.error('ไม่สามารถระบุตำแหน่งของคุณได้');
+        setCheckingIn(false);
+      },
+      { enableHighAccuracy: true, timeout: 15000 },
+    );
+  };
+
+  const handleClaimPlace = async (e: React.FormEvent) => {
+    e.preventDefault();
+    if (!place) return;
+    setClaimSubmitting(true);
     try {
-      await reviewsAPI.toggleLike(reviewId);
-    } catch {}
+      await placesAPI.claimPlace(place.id, claim);
+      setShowClaimForm(false);
+      setClaim({ businessName: '', position: '', contactPhone: '', evidence: '' });
+      showSuccess('ส่งคำขอยืนยันความเป็นเจ้าของแล้ว รอผู้ดูแลระบบตรวจสอบ');
+    } catch (e: any) {
+      toast.error(e.response?.data?.detail || 'ไม่สามารถส่งคำขอได้');
+    } finally {
+      setClaimSubmitting(false);
+    }
   };
 
   const handleDeleteReview = async (reviewId: string) => {
@@ -268,6 +385,8 @@ const PlaceDetailPage: React.FC = () => {
     return finalUrl;
   };
 
+  const isPlaceOwner = !!(user && place?.ownerId && place.ownerId === user.id);
+
   if (loading) {
     return (
       <div className="loading-container">
@@ -363,6 +482,26 @@ const PlaceDetailPage: React.FC = () => {
                   <FaRoute size={18} style={{ color: '#2563eb', marginBottom: -2 }} />
                   <span>Find Route to <span style={{ color: '#1e293b', fontWeight: 700 }}>{place.name}</span></span>
                 </button>
+                {user && (
+                  <button
+                    onClick={toggleFavorite}
+                    title={isFavorite ? 'นำออกจากรายการโปรด' : 'บันทึกลงรายการโปรด'}
+                    style={{
+                      display: 'flex', alignItems: 'center', gap: 8,
+                      background: '#fee2e2',
+                      color: '#ef4444',
+                      fontWeight: 600,
+                      fontSize: 16,
+                      border: 'none',
+                      borderRadius: 8,
+                      padding: '10px 20px',
+                      cursor: 'pointer',
+                    }}
+                  >
+                    {isFavorite ? <FaHeart size={18} /> : <FaRegHeart size={18} />}
+                    <span>{isFavorite ? 'บันทึกแล้ว' : 'บันทึก'}</span>
+                  </button>
+                )}
               </div>
             </div>
             {/* Tabs Navigation */}
@@ -575,6 +714,7 @@ const PlaceDetailPage: React.FC = () => {
                           reviews.map((review) => {
                             const likedBy = Array.isArray(review.liked_by) ? review.liked_by : [];
                             const isLiked: boolean = !!(user && user.id && likedBy.includes(user.id));
+                            const isNotHelpful: boolean = !!(user && user.id && (review.not_helpful_by || []).includes(user.id));
                             const isOwner: boolean = !!(user && review.user_id === user.id);
                             const reviewDate = review.createdAt;
                             return (
@@ -585,7 +725,14 @@ const PlaceDetailPage: React.FC = () => {
                                       <UserCircle size={36} />
                                     </div>
                                     <div>
-                                      <div style={{ fontWeight: 600 }}>{review.username || 'Unknown'}</div>
+                                      <div style={{ fontWeight: 600 }}>
+                                        {review.username || 'Unknown'}
+                                        {review.verifiedVisit && (
+                                          <span className="ml-2 inline-flex items-center gap-1 text-xs text-green-700 bg-green-50 rounded px-1.5 py-0.5" title="ผู้รีวิวเช็กอินที่สถานที่นี้แล้ว">
+                                            <CheckCircle2 size={12} /> ไปมาจริง
+                                          </span>
+                                        )}
+                                      </div>
                                       <div style={{ fontSize: 13, color: '#6b7280' }}>
                                         {reviewDate && !isNaN(new Date(reviewDate).getTime())
                                           ? new Date(reviewDate).toLocaleString('th-TH')
@@ -609,6 +756,48 @@ const PlaceDetailPage: React.FC = () => {
                                   </div>
                                 </div>
                                 <p className="text-neutral-700">{review.comment}</p>
+                                {review.photos && review.photos.length > 0 && (
+                                  <div className="flex gap-2 overflow-x-auto mt-2">
+                                    {review.photos.map(photo => (
+                                      <a key={photo.id} href={getImageUrl(photo.url)} target="_blank" rel="noopener noreferrer">
+                                        <img src={getImageUrl(photo.thumbnailUrl)} alt={photo.alt} loading="lazy" className="w-24 h-24 object-cover rounded-md" />
+                                      </a>
+                                    ))}
+                                  </div>
+                                )}
+                                {review.response && respondingTo !== review.id && (
+                                  <div className="mt-3 ml-6 p-3 rounded-md bg-neutral-50 border-l-4 border-primary-500">
+                                    <div className="text-sm font-semibold text-neutral-700">คำตอบจากเจ้าของสถานที่</div>
+                                    <p className="text-neutral-700 whitespace-pre-line">{review.response.text}</p>
+                                    {isPlaceOwner && (
+                                      <div className="flex gap-3 mt-1 text-sm">
+                                        <button className="text-primary-600" onClick={() => { setRespondingTo(review.id); setResponseText(review.response?.text || ''); }}>แก้ไข</button>
+                                        <button className="text-red-500" onClick={() => handleDeleteResponse(review.id)}>ลบ</button>
+                                      </div>
+                                    )}
+                                  </div>
+                                )}
+                                {isPlaceOwner && respondingTo === review.id && (
+                                  <div className="mt-3 ml-6">
+                                    <textarea
+                                      value={responseText}
+                                      onChange={e => setResponseText(e.target.value)}
+                                      maxLength={2000}
+                                      rows={3}
+                                      className="w-full border rounded-md p-2"
+                                      placeholder="ตอบกลับรีวิวนี้ในนามเจ้าของสถานที่"
+                                    />
+                                    <div className="flex gap-2 justify-end mt-1">
+                                      <button className="px-3 py-1 rounded bg-neutral-200" onClick={() => setRespondingTo(null)}>ยกเลิก</button>
+                                      <button className="px-3 py-1 rounded bg-blue-600 text-white" onClick={() => handleSaveResponse(review.id)}>บันทึก</button>
+                                    </div>
+                                  </div>
+                                )}
+                                {isPlaceOwner && !review.response && respondingTo !== review.id && (
+                                  <button className="mt-2 text-sm text-primary-600" onClick={() => { setRespondingTo(review.id); setResponseText(''); }}>
+                                    ตอบกลับในนามเจ้าของ
+                                  </button>
+                                )}
                                 <div style={{ display: 'flex', alignItems: 'center', gap: 18, marginTop: 8 }}>
                                   <span
                                     style={{
@@ -617,11 +806,23 @@ const PlaceDetailPage: React.FC = () => {
                                       cursor: user ? 'pointer' : 'not-allowed',
                                       opacity: user ? 1 : 0.5
                                     }}
-                                    onClick={user ? () => handleLikeReview(review.id || '') : undefined}
+                                    onClick={user ? () => handleVote(review.id || '', 'like') : undefined}
                                     title={user ? '' : 'เข้าสู่ระบบเพื่อกดไลก์'}
                                   >
                                     <Heart size={18} fill={isLiked ? '#ef4444' : 'none'} color={isLiked ? '#ef4444' : '#aaa'} />{review.likes}
                                   </span>
+                                  <span
+                                    style={{
+                                      display: 'flex', alignItems: 'center', gap: 4,
+                                      color: isNotHelpful ? '#64748b' : '#aaa',
+                                      cursor: user ? 'pointer' : 'not-allowed',
+                                      opacity: user ? 1 : 0.5
+                                    }}
+                                    onClick={user ? () => handleVote(review.id || '', 'notHelpful') : undefined}
+                                    title={user ? 'ไม่เป็นประโยชน์' : 'เข้าสู่ระบบเพื่อโหวต'}
+                                  >
+                                    <ThumbsDown size={18} fill={isNotHelpful ? '#64748b' : 'none'} color={isNotHelpful ? '#64748b' : '#aaa'} />{review.notHelpful || 0}
+                                  </span>
                                 </div>
                               </div>
                             );
@@ -685,6 +886,44 @@ const PlaceDetailPage: React.FC = () => {
                 </div>
               </div>
             </div>
+            {/* Check-in */}
+            {user && (
+              <div className="card p-8 rounded-xl shadow-lg bg-white mb-6">
+                <h2 className="text-xl font-bold mb-2">อยู่ที่นี่ตอนนี้?</h2>
+                <p className="text-neutral-600 mb-4">เช็กอินเพื่อยืนยันว่าคุณมาที่นี่จริง รีวิวของคุณจะมีป้าย "ไปมาจริง"</p>
+                <button className="btn btn-primary w-full flex items-center justify-center gap-2" onClick={handleCheckIn} disabled={checkingIn}>
+                  <LuMapPin /> {checkingIn ? 'กำลังเช็กอิน...' : 'เช็กอิน'}
+                </button>
+              </div>
+            )}
+            {/* Place claim */}
+            {user && !place.ownerId && (
+              <div className="card p-8 rounded-xl shadow-lg bg-white mb-6">
+                <h2 className="text-xl font-bold mb-2">เป็นเจ้าของสถานที่นี้?</h2>
+                <p className="text-neutral-600 mb-4">ยืนยันความเป็นเจ้าของเพื่อตอบกลับรีวิวและแก้ไขข้อมูลติดต่อของสถานที่</p>
+                {!showClaimForm ? (
+                  <button className="btn btn-primary w-full" onClick={() => setShowClaimForm(true)}>ขอยืนยันความเป็นเจ้าของ</button>
+                ) : (
+                  <form onSubmit={handleClaimPlace} className="space-y-3">
+                    <input className="w-full border rounded-md p-2" placeholder="ชื่อกิจการ" required maxLength={200}
+                      value={claim.businessName} onChange={e => setClaim({ ...claim, businessName: e.target.value })} />
+                    <input className="w-full border rounded-md p-2" placeholder="ตำแหน่ง เช่น เจ้าของ ผู้จัดการ" required maxLength={100}
+                      value={claim.position} onChange={e => setClaim({ ...claim, position: e.target.value })} />
+                    <input className="w-full border rounded-md p-2" placeholder="เบอร์โทรติดต่อ" required
+                      value={claim.contactPhone} onChange={e => setClaim({ ...claim, contactPhone: e.target.value })} />
+                    <textarea className="w-full border rounded-md p-2" rows={4} required minLength={20} maxLength={2000}
+                      placeholder="หลักฐาน เช่น เลขทะเบียนพาณิชย์ หรือช่องทางที่ผู้ดูแลระบบใช้ตรวจสอบได้"
+                      value={claim.evidence} onChange={e => setClaim({ ...claim, evidence: e.target.value })} />
+                    <div className="flex gap-2 justify-end">
+                      <button type="button" className="px-3 py-1 rounded bg-neutral-200" onClick={() => setShowClaimForm(false)}>ยกเลิก</button>
+                      <button type="submit" className="px-3 py-1 rounded bg-blue-600 text-white disabled:opacity-50" disabled={claimSubmitting}>
+                        {claimSubmitting ? 'กำลังส่ง...' : 'ส่งคำขอ'}
+                      </button>
+                    </div>
+                  </form>
+                )}
+              </div>
+            )}
           </div>
         </div>
       </div>
@@ -755,7 +994,7 @@ const PlaceDetailPage: React.FC = () => {
               showSuccess('รายงานรีวิวสำเร็จ!');
               setShowReportModal(false);
             } catch (e: any) {
-              toast.error(e?.response?.data?.error || 'เกิดข้อผิดพลาด');
+              toast.error(e?.response?.data?.detail || 'เกิดข้อผิดพลาด');
             } finally {
               setReportLoading(false);
             }
@@ -2,12 +2,14 @@ import { motion } from 'framer-motion';
 import { useAuth } from '../hooks/useAuth';
 import { Card } from '../components/ui/Card';
 import { Button } from '../components/ui/Button';
-import { Settings, User, Loader2, Eye, EyeOff, Mail, User as UserIcon, MapPin, Calendar, Edit, KeyRound, Map as MapIcon, Landmark, Globe2, Hash } from 'lucide-react';
+import { Settings, User, Loader2, Eye, EyeOff, Mail, User as UserIcon, MapPin, Calendar, Edit, KeyRound, Map as MapIcon, Landmark, Globe2, Hash, Link2, KeySquare, Bookmark } from 'lucide-react';
 import { MapContainer, TileLayer, Marker, Popup, useMapEvent } from 'react-leaflet';
 import 'leaflet/dist/leaflet.css';
 import L from 'leaflet';
-import { useState } from 'react';
+import { useEffect, useState } from 'react';
 import { toast } from 'react-hot-toast';
+import { api, collectionsAPI } from '../services/api';
+import type { CollectionVisibility, PlaceCollection } from '../types/place';
 
 const EditProfileModal = ({ open, onClose, user, onSave, onAddressChange }: { open: boolean, onClose: () => void, user: any, onSave: (data: any) => void, onAddressChange?: (address: any) => void }) => {
   const [name, setName] = useState(user?.name || '');
@@ -144,7 +146,7 @@ const EditProfileModal = ({ open, onClose, user, onSave, onAddressChange }: { op
   );
 };
 
-const ChangePasswordModal = ({ open, onClose, onSave }: { open: boolean, onClose: () => void, onSave: (data: any) => void }) => {
+const ChangePasswordModal = ({ open, onClose, onSave, requireOld = true }: { open: boolean, onClose: () => void, onSave: (data: any) => void, requireOld?: boolean }) => {
   const [oldPassword, setOldPassword] = useState('');
   const [newPassword, setNewPassword] = useState('');
   const [confirmPassword, setConfirmPassword] = useState('');
@@ -158,15 +160,13 @@ const ChangePasswordModal = ({ open, onClose, onSave }: { open: boolean, onClose
     e.preventDefault();
     let hasError = false;
     const newErrors: typeof errors = {};
-    if (!oldPassword) { 
+    if (requireOld && !oldPassword) { 
       newErrors.old = "กรุณากรอกรหัสผ่านเดิม";
       hasError = true;  
     }
-    if (!newPassword || newPassword.length < 7) {
-      newErrors.new = "รหัสผ่านใหม่ต้องมีอย่างน้อย 7 ตัวอักษร";
-      hasError = true;
-    } else if (!/[a-zA-Z]/.test(newPassword)) {
-      newErrors.new = "รหัสผ่านใหม่ต้องมีตัวอักษรอย่างน้อย 1 ตัว";
+    // กฎที่เหลือ (รหัสผ่านที่ใช้บ่อย/เคยรั่วไหล) ตรวจที่ backend
+    if (!newPassword || newPassword.length < 10) {
+      newErrors.new = "รหัสผ่านใหม่ต้องมีอย่างน้อย 10 ตัวอักษร";
       hasError = true;
     }
     if (newPassword !== confirmPassword) {
@@ -191,7 +191,7 @@ const ChangePasswordModal = ({ open, onClose, onSave }: { open: boolean, onClose
         )
       ) {
         setErrors({ ...newErrors, old: "รหัสผ่านเดิมไม่ถูกต้อง" });
-      } else if (err.message && err.message.toLowerCase().includes("password")) {
+      } else if (err.message && (err.message.toLowerCase().includes("password") || err.message.includes("รหัสผ่าน"))) {
         setErrors({ ...newErrors, new: err.message });
       } else {
         setErrors({ ...newErrors, confirm: err.message || "เกิดข้อผิดพลาด" });
@@ -207,6 +207,7 @@ const ChangePasswordModal = ({ open, onClose, onSave }: { open: boolean, onClose
       <div style={{ background: '#fff', borderRadius: 12, padding: 32, minWidth: 340, maxWidth: 420, boxShadow: '0 4px 24px rgba(0,0,0,0.15)' }}>
         <h2 className="text-xl font-semibold mb-4">Change Password</h2>
         <form onSubmit={handleSubmit}>
+          {requireOld && (
           <div className="mb-3 relative">
             <label className="block text-sm mb-1">Old Password</label>
             <input className="w-full border rounded px-3 py-2 pr-10" type={showOld ? 'text' : 'password'} value={oldPassword} onChange={e => setOldPassword(e.target.value)} required />
@@ -215,6 +216,7 @@ const ChangePasswordModal = ({ open, onClose, onSave }: { open: boolean, onClose
             </span>
             {errors.old && <p className="text-red-500 text-xs mt-1">{errors.old}</p>}
           </div>
+          )}
           <div className="mb-3 relative">
             <label className="block text-sm mb-1">New Password</label>
             <input className="w-full border rounded px-3 py-2 pr-10" type={showNew ? 'text' : 'password'} value={newPassword} onChange={e => setNewPassword(e.target.value)} required />
@@ -241,8 +243,323 @@ const ChangePasswordModal = ({ open, onClose, onSave }: { open: boolean, onClose
   );
 };
 
+// ข้อความสำหรับ ?oidcError= หลังเชื่อมบัญชีภายนอกไม่สำเร็จ
+const linkErrorMessages: Record<string, string> = {
+  identity_in_use: 'บัญชีนี้ถูกเชื่อมกับผู้ใช้อื่นแล้ว',
+  state_invalid: 'การเชื่อมบัญชีหมดเวลา กรุณาลองใหม่อีกครั้ง',
+  oidc_failed: 'เชื่อมบัญชีไม่สำเร็จ',
+};
+
+// บัญชีภายนอก (Google, LINE, ...) ที่ใช้เข้าสู่ระบบได้
+type Identity = { provider: string, email?: string, linkedAt: string };
+
+const LinkedAccounts = ({ identities, hasPassword, onChange }: { identities: Identity[], hasPassword: boolean, onChange: () => void }) => {
+  const [providers, setProviders] = useState<{ name: string, displayName: string }[]>([]);
+  const [busy, setBusy] = useState<string | null>(null);
+
+  useEffect(() => {
+    api.get('/api/auth/oidc/providers')
+      .then(res => setProviders(res.data.providers || []))
+      .catch(() => setProviders([]));
+  }, []);
+
+  if (providers.length === 0) return null;
+  const linked = (name: string) => identities.find(i => i.provider === name);
+
+  const link = async (name: string) => {
+    setBusy(name);
+    try {
+      const res = await api.post(`/api/profile/identities/${name}`);
+      window.location.href = res.data.authorizationUrl;
+    } catch (err: any) {
+      toast.error(err.response?.data?.detail || 'เชื่อมบัญชีไม่สำเร็จ');
+      setBusy(null);
+    }
+  };
+
+  const unlink = async (name: string) => {
+    setBusy(name);
+    try {
+      await api.delete(`/api/profile/identities/${name}`);
+      toast.success('ยกเลิกการเชื่อมบัญชีแล้ว');
+      onChange();
+    } catch (err: any) {
+      toast.error(err.response?.data?.detail || 'ยกเลิกการเชื่อมบัญชีไม่สำเร็จ');
+    } finally {
+      setBusy(null);
+    }
+  };
+
+  return (
+    <div className="md:col-span-3 bg-white rounded-xl shadow p-6">
+      <h3 className="text-blue-700 text-lg font-semibold flex items-center gap-2 mb-4"><Link2 className="w-5 h-5" /> Linked Accounts</h3>
+      <div className="space-y-3">
+        {providers.map(p => {
+          const identity = linked(p.name);
+          return (
+            <div key={p.name} className="flex items-center justify-between border rounded px-4 py-2">
+              <div>
+                <div className="font-medium">{p.displayName}</div>
+                <div className="text-xs text-gray-400">{identity ? identity.email || 'Linked' : 'Not linked'}</div>
+              </div>
+              {identity ? (
+                <button className="bg-gray-200 px-3 py-1 rounded text-sm" disabled={busy === p.name} onClick={() => unlink(p.name)}>Unlink</button>
+              ) : (
+                <button className="bg-blue-600 hover:bg-blue-700 text-white px-3 py-1 rounded text-sm" disabled={busy === p.name} onClick={() => link(p.name)}>Link</button>
+              )}
+            </div>
+          );
+        })}
+      </div>
+      {!hasPassword && (
+        <p className="text-xs text-gray-500 mt-3">บัญชีนี้ยังไม่มีรหัสผ่าน ตั้งรหัสผ่านได้ที่ Change Password ก่อนยกเลิกการเชื่อมบัญชีสุดท้าย</p>
+      )}
+    </div>
+  );
+};
+
+type ApiKey = {
+  id: string,
+  name: string,
+  prefix: string,
+  scopes: string[],
+  expiresAt: string,
+  lastUsedAt?: string,
+  revokedAt?: string,
+};
+
+const apiKeyScopes = [
+  { value: 'reviews:read', label: 'อ่านรีวิว' },
+  { value: 'places:read', label: 'อ่านสถานที่ (admin)' },
+  { value: 'places:write', label: 'เพิ่ม/แก้ไขสถานที่ (admin)' },
+  { value: 'profile:read', label: 'อ่านโปรไฟล์' },
+];
+
+// API key สำหรับเชื่อมต่อระบบภายนอก ตัว key จะแสดงแค่ครั้งเดียวตอนสร้างหรือ rotate
+const ApiKeys = () => {
+  const [keys, setKeys] = useState<ApiKey[]>([]);
+  const [name, setName] = useState('');
+  const [scopes, setScopes] = useState<string[]>(['reviews:read']);
+  const [expiresInDays, setExpiresInDays] = useState(90);
+  const [newKey, setNewKey] = useState<string | null>(null);
+
+  const load = () => {
+    api.get('/api/profile/api-keys')
+      .then(res => setKeys(res.data.apiKeys || []))
+      .catch(() => setKeys([]));
+  };
+  useEffect(load, []);
+
+  const create = async (e: any) => {
+    e.preventDefault();
+    try {
+      const res = await api.post('/api/profile/api-keys', { name, scopes, expiresInDays });
+      setNewKey(res.data.key);
+      setName('');
+      load();
+    } catch (err: any) {
+      toast.error(err.response?.data?.detail || 'สร้าง API key ไม่สำเร็จ');
+    }
+  };
+
+  const rotate = async (id: string) => {
+    try {
+      const res = await api.post(`/api/profile/api-keys/${id}/rotate`);
+      setNewKey(res.data.key);
+      load();
+    } catch (err: any) {
+      toast.error(err.response?.data?.detail || 'Rotate API key ไม่สำเร็จ');
+    }
+  };
+
+  const revoke = async (id: string) => {
+    if (!window.confirm('เพิกถอน API key นี้? ระบบที่ใช้อยู่จะเรียก API ไม่ได้ทันที')) return;
+    try {
+      await api.delete(`/api/profile/api-keys/${id}`);
+      load();
+    } catch (err: any) {
+      toast.error(err.response?.data?.detail || 'เพิกถอน API key ไม่สำเร็จ');
+    }
+  };
+
+  const toggleScope = (scope: string) =>
+    setScopes(prev => prev.includes(scope) ? prev.filter(s => s !== scope) : [...prev, scope]);
+
+  return (
+    <div className="md:col-span-3 bg-white rounded-xl shadow p-6">
+      <h3 className="text-blue-700 text-lg font-semibold flex items-center gap-2 mb-4"><KeySquare className="w-5 h-5" /> API Keys</h3>
+      {newKey && (
+        <div className="bg-yellow-50 border border-yellow-300 rounded p-3 mb-4 text-sm">
+          <div className="font-medium mb-1">คัดลอก key นี้เก็บไว้ จะไม่แสดงอีก</div>
+          <code className="break-all">{newKey}</code>
+          <div className="mt-2 flex gap-2">
+            <button className="bg-blue-600 text-white px-3 py-1 rounded" onClick={() => { navigator.clipboard.writeText(newKey); toast.success('คัดลอกแล้ว'); }}>Copy</button>
+            <button className="bg-gray-200 px-3 py-1 rounded" onClick={() => setNewKey(null)}>Done</button>
+          </div>
+        </div>
+      )}
+      <div className="space-y-2 mb-4">
+        {keys.length === 0 && <div className="text-sm text-gray-400">ยังไม่มี API key</div>}
+        {keys.map(k => (
+          <div key={k.id} className="flex items-center justify-between border rounded px-4 py-2">
+            <div>
+              <div className="font-medium">{k.name} <span className="text-xs text-gray-400">{k.prefix}…</span></div>
+              <div className="text-xs text-gray-500">
+                {k.scopes.join(', ')} · หมดอายุ {new Date(k.expiresAt).toLocaleDateString()}
+                {k.lastUsedAt ? ` · ใช้ล่าสุด ${new Date(k.lastUsedAt).toLocaleString()}` : ' · ยังไม่เคยใช้'}
+              </div>
+            </div>
+            {k.revokedAt ? (
+              <span className="text-xs text-red-500">Revoked</span>
+            ) : (
+              <div className="flex gap-2">
+                <button className="bg-gray-200 px-3 py-1 rounded text-sm" onClick={() => rotate(k.id)}>Rotate</button>
+                <button className="bg-red-500 text-white px-3 py-1 rounded text-sm" onClick={() => revoke(k.id)}>Revoke</button>
+              </div>
+            )}
+          </div>
+        ))}
+      </div>
+      <form onSubmit={create} className="flex flex-wrap items-end gap-3">
+        <div>
+          <label className="block text-sm mb-1">Name</label>
+          <input className="border rounded px-3 py-1" value={name} onChange={e => setName(e.target.value)} required maxLength={100} />
+        </div>
+        <div>
+          <label className="block text-sm mb-1">Expires in (days)</label>
+          <input className="border rounded px-3 py-1 w-24" type="number" min={1} value={expiresInDays} onChange={e => setExpiresInDays(Number(e.target.value))} />
+        </div>
+        <div className="flex flex-wrap gap-3">
+          {apiKeyScopes.map(s => (
+            <label key={s.value} className="text-sm flex items-center gap-1">
+              <input type="checkbox" checked={scopes.includes(s.value)} onChange={() => toggleScope(s.value)} /> {s.label}
+            </label>
+          ))}
+        </div>
+        <button type="submit" className="bg-blue-600 hover:bg-blue-700 text-white px-4 py-1 rounded" disabled={scopes.length === 0}>Create key</button>
+      </form>
+    </div>
+  );
+};
+
+const Collections = () => {
+  const [collections, setCollections] = useState<PlaceCollection[]>([]);
+  const [name, setName] = useState('');
+  const [visibility, setVisibility] = useState<CollectionVisibility>('private');
+
+  const load = () => {
+    collectionsAPI.getMyCollections()
+      .then(res => setCollections(res.data.collections || []))
+      .catch(() => setCollections([]));
+  };
+  useEffect(load, []);
+
+  const run = async (action: () => Promise<unknown>, failure: string) => {
+    try {
+      await action();
+      load();
+    } catch (err: any) {
+      toast.error(err.response?.data?.detail || failure);
+    }
+  };
+
+  const create = (e: any) => {
+    e.preventDefault();
+    run(async () => {
+      await collectionsAPI.createCollection({ name, visibility });
+      setName('');
+    }, 'สร้างคอลเลกชันไม่สำเร็จ');
+  };
+
+  const remove = (c: PlaceCollection) => {
+    if (!window.confirm(`ลบคอลเลกชัน "${c.name}"?`)) return;
+    run(() => collectionsAPI.deleteCollection(c.id), 'ลบคอลเลกชันไม่สำเร็จ');
+  };
+
+  // เลื่อนสถานที่ขึ้นหรือลงหนึ่งตำแหน่ง แล้วส่งลำดับทั้งหมดไปบันทึก
+  const move = (c: PlaceCollection, index: number, delta: number) => {
+    const ids = c.places.map(p => p.placeId);
+    const target = index + delta;
+    if (target < 0 || target >= ids.length) return;
+    [ids[index], ids[target]] = [ids[target], ids[index]];
+    run(() => collectionsAPI.reorderCollection(c.id, ids), 'จัดลำดับไม่สำเร็จ');
+  };
+
+  return (
+    <div className="md:col-span-3 bg-white rounded-xl shadow p-6">
+      <h3 className="text-blue-700 text-lg font-semibold flex items-center gap-2 mb-4"><Bookmark className="w-5 h-5" /> Saved Places</h3>
+      <div className="space-y-4 mb-4">
+        {collections.map(c => (
+          <div key={c.id} className="border rounded px-4 py-3">
+            <div className="flex items-center justify-between">
+              <div>
+                <div className="font-medium">{c.isDefault ? 'รายการโปรด' : c.name} <span className="text-xs text-gray-400">{c.places.length} แห่ง</span></div>
+                {c.description && <div className="text-xs text-gray-500">{c.description}</div>}
+              </div>
+              <div className="flex gap-2 items-center">
+                <button className="bg-gray-200 px-3 py-1 rounded text-sm"
+                  onClick={() => run(() => collectionsAPI.updateCollection(c.id, { visibility: c.visibility === 'public' ? 'private' : 'public' }), 'เปลี่ยนการแชร์ไม่สำเร็จ')}>
+                  {c.visibility === 'public' ? 'Public' : 'Private'}
+                </button>
+                {c.shareUrl && (
+                  <button className="bg-blue-600 text-white px-3 py-1 rounded text-sm" onClick={() => { navigator.clipboard.writeText(c.shareUrl!); toast.success('คัดลอกลิงก์แล้ว'); }}>Copy link</button>
+                )}
+                {!c.isDefault && <button className="bg-red-500 text-white px-3 py-1 rounded text-sm" onClick={() => remove(c)}>Delete</button>}
+              </div>
+            </div>
+            {c.places.length === 0 && <div className="text-sm text-gray-400 mt-2">ยังไม่มีสถานที่</div>}
+            <ul className="mt-2 space-y-1">
+              {c.places.map((p, i) => (
+                <li key={p.placeId} className="flex items-center justify-between text-sm">
+                  <a href={`/places/${p.placeId}`} className="text-blue-700 hover:underline">{p.placeName}</a>
+                  {p.note && <span className="text-gray-500 ml-2 flex-1 truncate">— {p.note}</span>}
+                  <span className="flex gap-1 ml-2">
+                    <button className="px-2 text-gray-500" disabled={i === 0} onClick={() => move(c, i, -1)}>↑</button>
+                    <button className="px-2 text-gray-500" disabled={i === c.places.length - 1} onClick={() => move(c, i, 1)}>↓</button>
+                    <button className="px-2 text-red-500" onClick={() => run(() => collectionsAPI.removePlace(c.id, p.placeId), 'นำออกไม่สำเร็จ')}>×</button>
+                  </span>
+                </li>
+              ))}
+            </ul>
+          </div>
+        ))}
+      </div>
+      <form onSubmit={create} className="flex flex-wrap items-end gap-3">
+        <div>
+          <label className="block text-sm mb-1">New collection</label>
+          <input className="border rounded px-3 py-1" value={name} onChange={e => setName(e.target.value)} required maxLength={100} />
+        </div>
+        <select className="border rounded px-3 py-1" value={visibility} onChange={e => setVisibility(e.target.value as CollectionVisibility)}>
+          <option value="private">Private</option>
+          <option value="public">Public</option>
+        </select>
+        <button type="submit" className="bg-blue-600 hover:bg-blue-700 text-white px-4 py-1 rounded">Create</button>
+      </form>
+    </div>
+  );
+};
+
 const Profile = () => {
   const { user, updateProfile, changePassword } = useAuth();
+  const [hasPassword, setHasPassword] = useState(true);
+  const [identities, setIdentities] = useState<Identity[]>(user?.identities || []);
+  const refreshProfile = () => {
+    api.get('/api/profile')
+      .then(res => {
+        setHasPassword(res.data.hasPassword !== false);
+        setIdentities(res.data.user?.identities || []);
+      })
+      .catch(() => {});
+  };
+  useEffect(() => {
+    refreshProfile();
+    const params = new URLSearchParams(window.location.search);
+    if (params.get('linked')) {
+      toast.success('เชื่อมบัญชีเรียบร้อยแล้ว');
+    } else if (params.get('oidcError')) {
+      toast.error(linkErrorMessages[params.get('oidcError') as string] || linkErrorMessages.oidc_failed);
+    }
+  }, []);
   const homeIcon = new L.Icon({
     iconUrl: 'https://raw.githubusercontent.com/pointhi/leaflet-color-markers/master/img/marker-icon-green.png',
     shadowUrl: 'https://cdnjs.cloudflare.com/ajax/libs/leaflet/1.7.1/images/marker-shadow.png',
@@ -252,7 +569,10 @@ const Profile = () => {
     shadowSize: [41, 41],
   });
   const [editOpen, setEditOpen] = useState(false);
-  const [changePwOpen, setChangePwOpen] = useState(false);
+  // เปิดหน้าต่างเปลี่ยนรหัสผ่านทันทีถ้า login มาด้วยรหัสผ่านที่หมดอายุหรือถูกรีเซ็ต
+  const [changePwOpen, setChangePwOpen] = useState(
+    !!user?.mustChangePassword || new URLSearchParams(window.location.search).has('changePassword')
+  );
   const [addressPreview, setAddressPreview] = useState<any>(user?.address);
   const memberSince = user?.created_at ? new Date(user.created_at).toLocaleDateString() : 'N/A';
   const address = editOpen ? addressPreview : user?.address;
@@ -368,6 +688,9 @@ const Profile = () => {
             </div>
           </div>
         </div>
+        <LinkedAccounts identities={identities} hasPassword={hasPassword} onChange={refreshProfile} />
+        <Collections />
+        <ApiKeys />
       </div>
       <EditProfileModal open={editOpen} onClose={() => { setEditOpen(false); setAddressPreview(user?.address); }} user={user} onSave={async (data) => {
         try {
@@ -378,8 +701,9 @@ const Profile = () => {
           toast.error(err.message || 'Update failed');
         }
       }} onAddressChange={setAddressPreview} />
-      <ChangePasswordModal open={changePwOpen} onClose={() => setChangePwOpen(false)} onSave={async (data) => {
+      <ChangePasswordModal open={changePwOpen} requireOld={hasPassword} onClose={() => setChangePwOpen(false)} onSave={async (data) => {
         await changePassword({ currentPassword: data.currentPassword, newPassword: data.newPassword });
+        setHasPassword(true);
         // success จะถูก handle ใน modal
       }} />
     </div>
@@ -1,11 +1,12 @@
 import { useState, useEffect, useRef } from 'react';
 import styled from 'styled-components';
 import { motion } from 'framer-motion';
-import { Star, Filter, ChevronDown, ChevronUp, Image, UserCircle, Heart, Share2, MoreHorizontal, X, AlertTriangle, CheckCircle2 } from 'lucide-react';
+import { Star, Filter, ChevronDown, ChevronUp, Image, UserCircle, Heart, ThumbsDown, Share2, MoreHorizontal, X, AlertTriangle, CheckCircle2 } from 'lucide-react';
 import Card from '../components/ui/Card';
 import Button from '../components/ui/Button';
 import Input from '../components/ui/Input';
 import { reviewsAPI } from '../services/api';
+import type { ReviewVotes } from '../types/place';
 import { placesAPI } from '../services/api';
 import toast from 'react-hot-toast';
 import Modal from '../components/ui/Modal';
@@ -26,16 +27,6 @@ interface Place {
   name: string;
 }
 
-interface Comment {
-  id: string;
-  user_id: string;
-  username: string;
-  text: string;
-  likes: number;
-  liked_by?: string[];
-  createdAt: string;
-}
-
 interface Review {
   id: string;
   user_id: string;
@@ -45,20 +36,32 @@ interface Review {
   rating: number;
   comment: string;
   likes: number;
-  comments: Comment[];
+  commentCount: number;
   createdAt: string;
   created_at?: string;
-  images?: ReviewImage[];
+  photos?: ReviewPhoto[];
   liked_by?: string[];
+  notHelpful?: number;
+  not_helpful_by?: string[];
+  response?: { text: string; createdAt: string; updatedAt: string };
+  verifiedVisit?: boolean;
 }
 
-interface ReviewImage {
+interface ReviewPhoto {
   id: string;
-  filename: string;
-  path: string;
+  url: string;
+  thumbnailUrl: string;
+  alt: string;
+  width: number;
+  height: number;
   createdAt: string;
 }
 
+// Matches REVIEW_PHOTO_MAX on the server; the server enforces it
+const MAX_REVIEW_PHOTOS = 6;
+
+const uploadUrl = (path: string) => `${import.meta.env.VITE_API_URL}/${path}`;
+
 const PageContainer = styled.div`
   display: flex;
   flex-direction: column;
@@ -255,6 +258,15 @@ const ReviewRoute = styled.div`
   border-top: 1px solid ${({ theme }) => theme.colors.neutral[100]};
 `;
 
+const OwnerResponse = styled.div`
+  margin: 0.75rem 0 0.75rem 1.5rem;
+  padding: 0.75rem 1rem;
+  background: #f8fafc;
+  border-left: 3px solid #6366f1;
+  border-radius: 6px;
+  font-size: 15px;
+`;
+
 const ReviewImages = styled.div`
   display: flex;
   gap: 0.5rem;
@@ -526,6 +538,7 @@ const Reviews = () => {
   const [places, setPlaces] = useState<{id: string, name: string}[]>([]);
   const [selectedPlace, setSelectedPlace] = useState('');
   const [reviewText, setReviewText] = useState('');
+  const [reviewPhotos, setReviewPhotos] = useState<{ file: File; alt: string }[]>([]);
   const [reviewRating, setReviewRating] = useState(0);
   const [posting, setPosting] = useState(false);
   const [placeInput, setPlaceInput] = useState('');
@@ -677,16 +690,30 @@ const Reviews = () => {
       if (!response.data || !response.data.id) {
         throw new Error('ไม่สามารถสร้างรีวิวได้');
       }
+      // รีวิวถูกสร้างแล้ว ถ้ารูปไหนอัปโหลดไม่สำเร็จก็แจ้งเตือนแต่ไม่ยกเลิกรีวิว
+      for (const photo of reviewPhotos) {
+        try {
+          await reviewsAPI.addReviewPhoto(response.data.id, photo.file, photo.alt);
+        } catch (e: any) {
+          toast.error(`${photo.file.name}: ${e.response?.data?.detail || 'อัปโหลดรูปไม่สำเร็จ'}`);
+        }
+      }
       await fetchReviews(1, true);
       setShowWrite(false);
       setSelectedPlace('');
       setPlaceInput('');
       setReviewText('');
+      setReviewPhotos([]);
       setReviewRating(0);
-      showSuccess('โพสต์รีวิวสำเร็จ!');
+      // รีวิวได้สถานที่ละครั้ง ถ้าเคยรีวิวแล้วเซิร์ฟเวอร์จะแก้ไขรีวิวเดิมแทน
+      if (response.data.status === 'held') {
+        showSuccess('ส่งรีวิวแล้ว รอผู้ดูแลตรวจสอบก่อนเผยแพร่');
+      } else {
+        showSuccess(response.data.updated ? 'แก้ไขรีวิวเดิมของคุณสำหรับสถานที่นี้แล้ว' : 'โพสต์รีวิวสำเร็จ!');
+      }
     } catch (e: any) {
       console.error('Error posting review:', e);
-      toast.error(e.response?.data?.error || e.message || 'เกิดข้อผิดพลาด');
+      toast.error(e.response?.data?.detail || e.message || 'เกิดข้อผิดพลาด');
     } finally {
       setPosting(false);
       setShowWarning(false);
@@ -694,22 +721,51 @@ const Reviews = () => {
     }
   };
 
-  // Like review (toggle)
-  const handleLikeReview = async (reviewId: string) => {
+  // Like or mark not helpful (toggle). The two exclude each other, so update
+  // both optimistically, then take the counts the server stored
+  const handleVote = async (reviewId: string, vote: 'like' | 'notHelpful') => {
     if (!user) return;
-    setReviews(prev => prev.map(r => {
+    const review = reviews.find(r => r.id === reviewId);
+    if (!review) return;
+    const liked = (review.liked_by || []).includes(user.id);
+    const notHelpful = (review.not_helpful_by || []).includes(user.id);
+    const notHelpfulCount = review.notHelpful || 0;
+    const apply = (votes: ReviewVotes) => setReviews(prev => prev.map(r => {
       if (r.id !== reviewId) return r;
-      const likedBy = r.liked_by || [];
-      const liked = likedBy.includes(user.id);
+      const likedBy = (r.liked_by || []).filter((id: string) => id !== user.id);
+      const notHelpfulBy = (r.not_helpful_by || []).filter((id: string) => id !== user.id);
       return {
         ...r,
-        likes: liked ? r.likes - 1 : r.likes + 1,
-        liked_by: liked ? likedBy.filter((id: string) => id !== user.id) : [...likedBy, user.id],
+        likes: votes.likes,
+        liked_by: votes.liked ? [...likedBy, user.id] : likedBy,
+        notHelpful: votes.notHelpfulCount,
+        not_helpful_by: votes.notHelpful ? [...notHelpfulBy, user.id] : notHelpfulBy,
       };
     }));
+    const before = { liked, likes: review.likes, notHelpful, notHelpfulCount };
+    if (vote === 'like') {
+      apply({
+        liked: !liked,
+        likes: liked ? review.likes - 1 : review.likes + 1,
+        notHelpful: false,
+        notHelpfulCount: !liked && notHelpful ? notHelpfulCount - 1 : notHelpfulCount,
+      });
+    } else {
+      apply({
+        liked: false,
+        likes: !notHelpful && liked ? review.likes - 1 : review.likes,
+        notHelpful: !notHelpful,
+        notHelpfulCount: notHelpful ? notHelpfulCount - 1 : notHelpfulCount + 1,
+      });
+    }
     try {
-      await reviewsAPI.toggleLike(reviewId);
-    } catch {}
+      const res = vote === 'like'
+        ? await (liked ? reviewsAPI.unlikeReview(reviewId) : reviewsAPI.likeReview(reviewId))
+        : await (notHelpful ? reviewsAPI.unmarkNotHelpful(reviewId) : reviewsAPI.markNotHelpful(reviewId));
+      apply(res.data);
+    } catch {
+      apply(before);
+    }
   };
 
   const showSuccess = (message: string) => {
@@ -776,7 +832,8 @@ const Reviews = () => {
               <Filter size={16} />
               เรียงลำดับ: {sortOption === 'newest' ? 'ใหม่ล่าสุด' : 
                     sortOption === 'oldest' ? 'เก่าสุด' :
-                    sortOption === 'highest' ? 'คะแนนสูงสุด' : 'คะแนนต่ำสุด'}
+                    sortOption === 'highest' ? 'คะแนนสูงสุด' :
+                    sortOption === 'relevant' ? 'เกี่ยวข้องที่สุด' : 'คะแนนต่ำสุด'}
               {showSortMenu ? <ChevronUp size={16} /> : <ChevronDown size={16} />}
             </SortButton>
             {showSortMenu && (
@@ -786,6 +843,7 @@ const Reviews = () => {
                 exit={{ opacity: 0, y: -10 }}
                 transition={{ duration: 0.2 }}
               >
+                <SortMenuItem isActive={sortOption === 'relevant'} onClick={() => setSortOption('relevant')}>เกี่ยวข้องที่สุด</SortMenuItem>
                 <SortMenuItem isActive={sortOption === 'newest'} onClick={() => setSortOption('newest')}>ใหม่ล่าสุด</SortMenuItem>
                 <SortMenuItem isActive={sortOption === 'oldest'} onClick={() => setSortOption('oldest')}>เก่าสุด</SortMenuItem>
                 <SortMenuItem isActive={sortOption === 'highest'} onClick={() => setSortOption('highest')}>คะแนนสูงสุด</SortMenuItem>
@@ -893,6 +951,38 @@ const Reviews = () => {
                     style={{ width: '100%', minHeight: 90, borderRadius: 8, border: '1px solid #e5e7eb', padding: 12, fontSize: 17, resize: 'vertical' }}
                   />
                 </div>
+                <div style={{ marginBottom: 18 }}>
+                  <label style={{ display: 'inline-flex', alignItems: 'center', gap: 6, color: '#6366f1', cursor: reviewPhotos.length < MAX_REVIEW_PHOTOS ? 'pointer' : 'not-allowed' }}>
+                    <Image size={20} /> แนบรูป ({reviewPhotos.length}/{MAX_REVIEW_PHOTOS})
+                    <input
+                      type="file"
+                      accept="image/jpeg,image/png"
+                      multiple
+                      hidden
+                      disabled={reviewPhotos.length >= MAX_REVIEW_PHOTOS}
+                      onChange={e => {
+                        const files = Array.from(e.target.files || []);
+                        setReviewPhotos(prev => [...prev, ...files.map(file => ({ file, alt: '' }))].slice(0, MAX_REVIEW_PHOTOS));
+                        e.target.value = '';
+                      }}
+                    />
+                  </label>
+                  {reviewPhotos.map((photo, i) => (
+                    <div key={i} style={{ display: 'flex', alignItems: 'center', gap: 8, marginTop: 8 }}>
+                      <img src={URL.createObjectURL(photo.file)} alt="" style={{ width: 48, height: 48, objectFit: 'cover', borderRadius: 6 }} />
+                      <input
+                        value={photo.alt}
+                        maxLength={300}
+                        onChange={e => setReviewPhotos(prev => prev.map((p, j) => (j === i ? { ...p, alt: e.target.value } : p)))}
+                        placeholder="คำอธิบายรูป (สำหรับผู้ใช้โปรแกรมอ่านหน้าจอ)"
+                        style={{ flex: 1, borderRadius: 6, border: '1px solid #e5e7eb', padding: '6px 10px' }}
+                      />
+                      <button type="button" onClick={() => setReviewPhotos(prev => prev.filter((_, j) => j !== i))} style={{ background: 'none', border: 'none', cursor: 'pointer' }}>
+                        <X size={18} />
+                      </button>
+                    </div>
+                  ))}
+                </div>
                 <div style={{ display: 'flex', justifyContent: 'flex-end' }}>
                   <Button variant="primary" style={{ background: '#a5b4fc', color: '#fff', fontWeight: 600, minWidth: 130, fontSize: 17, padding: '10px 0', width: 160 }} onClick={handlePostReview} disabled={posting}>
                     {posting ? 'กำลังโพสต์...' : 'โพสต์รีวิว'}
@@ -906,6 +996,7 @@ const Reviews = () => {
         <div style={{ display: 'flex', flexDirection: 'column', gap: '2rem', margin: '0 auto', maxWidth: 600 }}>
           {displayedReviews.map((review, idx) => {
             const isLiked = user ? Array.isArray(review.liked_by) && review.liked_by.includes(user.id) : false;
+            const isNotHelpful = user ? Array.isArray(review.not_helpful_by) && review.not_helpful_by.includes(user.id) : false;
             const isOwner = !!(user && review.user_id === user.id);
             // ฟังก์ชัน normalize id เพื่อเปรียบเทียบ id ที่อาจมีรูปแบบต่างกัน
             const normalizeId = (id: string) => id?.toString()?.toLowerCase()?.replace(/[^a-z0-9]/gi, '');
@@ -929,7 +1020,14 @@ const Reviews = () => {
                     <UserCircle size={36} />
                   </div>
                   <div style={{ flex: 1 }}>
-                    <div style={{ fontWeight: 600 }}>{review.username || 'Unknown'}</div>
+                    <div style={{ fontWeight: 600 }}>
+                      {review.username || 'Unknown'}
+                      {review.verifiedVisit && (
+                        <span className="ml-2 inline-flex items-center gap-1 text-xs text-green-700 bg-green-50 rounded px-1.5 py-0.5" title="ผู้รีวิวเช็กอินที่สถานที่นี้แล้ว">
+                          <CheckCircle2 size={12} /> ไปมาจริง
+                        </span>
+                      )}
+                    </div>
                     <div style={{ fontSize: 13, color: '#6b7280' }}>@{review.username?.toLowerCase().replace(/\s/g, '_') || 'user'}</div>
                     <div style={{ fontSize: 13, color: '#6b7280' }}>
                       {reviewDate && !isNaN(new Date(reviewDate).getTime())
@@ -958,6 +1056,21 @@ const Reviews = () => {
                 </div>
                 {/* Review text */}
                 <div style={{ fontSize: 16, marginBottom: 10 }}>{review.comment}</div>
+                {review.photos && review.photos.length > 0 && (
+                  <ReviewImages>
+                    {review.photos.map(photo => (
+                      <a key={photo.id} href={uploadUrl(photo.url)} target="_blank" rel="noopener noreferrer">
+                        <ReviewImage src={uploadUrl(photo.thumbnailUrl)} alt={photo.alt} loading="lazy" />
+                      </a>
+                    ))}
+                  </ReviewImages>
+                )}
+                {review.response && (
+                  <OwnerResponse>
+                    <div style={{ fontWeight: 600, fontSize: 14, marginBottom: 4 }}>คำตอบจากเจ้าของสถานที่</div>
+                    <div style={{ whiteSpace: 'pre-line' }}>{review.response.text}</div>
+                  </OwnerResponse>
+                )}
                 {/* Rating & Actions */}
                 <div style={{ display: 'flex', alignItems: 'center', justifyContent: 'space-between', marginTop: 8 }}>
                   <div style={{ display: 'flex', alignItems: 'center', gap: 4 }}>
@@ -974,11 +1087,23 @@ const Reviews = () => {
                         cursor: user ? 'pointer' : 'not-allowed',
                         opacity: user ? 1 : 0.5
                       }}
-                      onClick={user ? () => handleLikeReview(review.id) : undefined}
+                      onClick={user ? () => handleVote(review.id, 'like') : undefined}
                       title={user ? '' : 'เข้าสู่ระบบเพื่อกดไลก์'}
                     >
                       <Heart size={18} fill={isLiked ? '#ef4444' : 'none'} />{review.likes}
                     </span>
+                    <span
+                      style={{
+                        display: 'flex', alignItems: 'center', gap: 4,
+                        color: isNotHelpful ? '#64748b' : '#aaa',
+                        cursor: user ? 'pointer' : 'not-allowed',
+                        opacity: user ? 1 : 0.5
+                      }}
+                      onClick={user ? () => handleVote(review.id, 'notHelpful') : undefined}
+                      title={user ? 'ไม่เป็นประโยชน์' : 'เข้าสู่ระบบเพื่อโหวต'}
+                    >
+                      <ThumbsDown size={18} fill={isNotHelpful ? '#64748b' : 'none'} />{review.notHelpful || 0}
+                    </span>
                   </div>
                 </div>
               </div>
@@ -1089,7 +1214,7 @@ const Reviews = () => {
               showSuccess('รายงานรีวิวสำเร็จ!');
               setShowReportModal(false);
             } catch (e: any) {
-              toast.error(e?.response?.data?.error || 'เกิดข้อผิดพลาด');
+              toast.error(e?.response?.data?.detail || 'เกิดข้อผิดพลาด');
             } finally {
               setReportLoading(false);
             }
@@ -0,0 +1,71 @@
+import { useEffect, useState } from 'react';
+import { Link, useParams } from 'react-router-dom';
+import { Bookmark } from 'lucide-react';
+import { collectionsAPI } from '../services/api';
+import type { PlaceCollection } from '../types/place';
+
+const API_URL = import.meta.env.VITE_API_URL;
+
+const coverUrl = (filename: string) => {
+  const clean = filename.startsWith('/') ? filename.slice(1) : filename;
+  return clean.startsWith('uploads/') ? `${API_URL}/${clean}` : `${API_URL}/uploads/${clean}`;
+};
+
+// สถานที่ตามที่ API ส่งมา (ชื่อฟิลด์แบบ PascalCase)
+interface SharedPlace {
+  PlaceID: string;
+  Name: string;
+  Description: string;
+  CoverImage: string;
+  Category: string;
+}
+
+// หน้าสาธารณะของคอลเลกชันที่เจ้าของเปิดแชร์ไว้
+const SharedCollection = () => {
+  const { id } = useParams<{ id: string }>();
+  const [collection, setCollection] = useState<PlaceCollection | null>(null);
+  const [places, setPlaces] = useState<SharedPlace[]>([]);
+  const [owner, setOwner] = useState('');
+  const [error, setError] = useState('');
+
+  useEffect(() => {
+    if (!id) return;
+    collectionsAPI.getSharedCollection(id)
+      .then(res => {
+        setCollection(res.data.collection);
+        setPlaces(res.data.places || []);
+        setOwner(res.data.owner || '');
+      })
+      .catch(err => setError(err.response?.data?.detail || 'ไม่พบคอลเลกชันนี้'));
+  }, [id]);
+
+  if (error) {
+    return <div className="container mx-auto px-4 py-16 text-center text-gray-500">{error}</div>;
+  }
+  if (!collection) {
+    return <div className="container mx-auto px-4 py-16 text-center text-gray-400">กำลังโหลด...</div>;
+  }
+
+  const notes = Object.fromEntries(collection.places.map(p => [p.placeId, p.note]));
+  return (
+    <div className="container mx-auto px-4 py-10">
+      <h1 className="text-2xl font-bold flex items-center gap-2"><Bookmark className="w-6 h-6 text-blue-600" /> {collection.isDefault ? 'รายการโปรด' : collection.name}</h1>
+      <p className="text-gray-500 mb-6">โดย {owner}{collection.description ? ` · ${collection.description}` : ''}</p>
+      {places.length === 0 && <div className="text-gray-400">ยังไม่มีสถานที่ในคอลเลกชันนี้</div>}
+      <div className="grid gap-4 md:grid-cols-2 lg:grid-cols-3">
+        {places.map(p => (
+          <Link key={p.PlaceID} to={`/places/${p.PlaceID}`} className="card bg-white rounded-xl shadow hover:shadow-lg overflow-hidden">
+            {p.CoverImage && <img src={coverUrl(p.CoverImage)} alt={p.Name} className="w-full h-40 object-cover" />}
+            <div className="p-4">
+              <div className="font-semibold">{p.Name}</div>
+              <div className="text-xs text-gray-400 mb-1">{p.Category}</div>
+              {notes[p.PlaceID] && <div className="text-sm text-gray-600">{notes[p.PlaceID]}</div>}
+            </div>
+          </Link>
+        ))}
+      </div>
+    </div>
+  );
+};
+
+export default SharedCollection;
@@ -0,0 +1,65 @@
+import { useEffect, useState } from 'react';
+import { useNavigate, useParams } from 'react-router-dom';
+import { Copy, Map as MapIcon } from 'lucide-react';
+import { tripsAPI } from '../services/api';
+import { useAuth } from '../hooks/useAuth';
+import TripPlanView from '../components/trip/TripPlanView';
+import type { Trip, TripPlan } from '../types/place';
+
+// หน้าสาธารณะของทริปที่เจ้าของเปิดแชร์ไว้ ผู้ที่เข้าสู่ระบบคัดลอกไปแก้ไขเองได้
+const SharedTrip = () => {
+  const { id } = useParams<{ id: string }>();
+  const navigate = useNavigate();
+  const { isAuthenticated } = useAuth();
+  const [trip, setTrip] = useState<Trip | null>(null);
+  const [plan, setPlan] = useState<TripPlan | null>(null);
+  const [owner, setOwner] = useState('');
+  const [error, setError] = useState('');
+
+  useEffect(() => {
+    if (!id) return;
+    tripsAPI.getSharedTrip(id)
+      .then(res => {
+        setTrip(res.data.trip);
+        setPlan(res.data.plan);
+        setOwner(res.data.owner || '');
+      })
+      .catch(err => setError(err.response?.data?.detail || 'ไม่พบทริปนี้'));
+  }, [id]);
+
+  const handleDuplicate = async () => {
+    if (!id) return;
+    try {
+      const res = await tripsAPI.duplicateTrip(id);
+      navigate(`/trips?id=${res.data.trip.id}`);
+    } catch (err: any) {
+      setError(err.response?.data?.detail || 'คัดลอกทริปไม่สำเร็จ');
+    }
+  };
+
+  if (error) {
+    return <div className="container mx-auto px-4 py-16 text-center text-gray-500">{error}</div>;
+  }
+  if (!trip || !plan) {
+    return <div className="container mx-auto px-4 py-16 text-center text-gray-400">กำลังโหลด...</div>;
+  }
+
+  return (
+    <div className="container mx-auto px-4 py-10 max-w-3xl">
+      <div className="flex justify-between items-start mb-6">
+        <div>
+          <h1 className="text-2xl font-bold flex items-center gap-2"><MapIcon className="w-6 h-6 text-blue-600" /> {trip.name}</h1>
+          <p className="text-gray-500">โดย {owner}{trip.description ? ` · ${trip.description}` : ''}</p>
+        </div>
+        {isAuthenticated && (
+          <button className="border border-blue-600 text-blue-600 hover:bg-blue-50 px-3 py-1 rounded flex items-center gap-1" onClick={handleDuplicate}>
+            <Copy className="w-4 h-4" /> คัดลอกไปแก้ไข
+          </button>
+        )}
+      </div>
+      <TripPlanView trip={trip} plan={plan} />
+    </div>
+  );
+};
+
+export default SharedTrip;
@@ -0,0 +1,282 @@
+import { useEffect, useState } from 'react';
+import { useSearchParams } from 'react-router-dom';
+import { toast } from 'react-hot-toast';
+import { ArrowDown, ArrowLeft, ArrowRight, ArrowUp, Copy, Link2, Map as MapIcon, Plus, Trash2 } from 'lucide-react';
+import { api, tripsAPI } from '../services/api';
+import TripPlanView from '../components/trip/TripPlanView';
+import type { Trip, TripDay, TripInput, TripPlan, TripVisibility } from '../types/place';
+
+// สถานที่ตามที่ API ส่งมา (ชื่อฟิลด์แบบ PascalCase)
+interface PlaceOption {
+  PlaceID: string;
+  Name: string;
+}
+
+interface Draft {
+  name: string;
+  description: string;
+  startDate: string;
+  visibility: TripVisibility;
+  days: TripDay[];
+}
+
+const emptyDraft = (): Draft => ({
+  name: '',
+  description: '',
+  startDate: '',
+  visibility: 'private',
+  days: [{ startTime: '09:00', stops: [] }],
+});
+
+const draftOf = (trip: Trip): Draft => ({
+  name: trip.name,
+  description: trip.description,
+  startDate: trip.startDate || '',
+  visibility: trip.visibility,
+  days: trip.days.map(d => ({ startTime: d.startTime, stops: d.stops.map(s => ({ ...s })) })),
+});
+
+const inputOf = (draft: Draft, updatedAt?: string): TripInput => ({
+  name: draft.name,
+  description: draft.description,
+  startDate: draft.startDate || undefined,
+  visibility: draft.visibility,
+  days: draft.days.map(d => ({
+    startTime: d.startTime,
+    stops: d.stops.map(s => ({ placeId: s.placeId, duration: s.duration, note: s.note })),
+  })),
+  updatedAt,
+});
+
+// วางแผนทริปหลายวัน: เลือกสถานที่ เรียงลำดับ แล้วดูเวลาเดินทาง ค่าใช้จ่าย และเวลาเปิดปิด
+const Trips = () => {
+  const [searchParams, setSearchParams] = useSearchParams();
+  const [trips, setTrips] = useState<Trip[]>([]);
+  const [places, setPlaces] = useState<PlaceOption[]>([]);
+  const [trip, setTrip] = useState<Trip | null>(null);
+  const [plan, setPlan] = useState<TripPlan | null>(null);
+  const [draft, setDraft] = useState<Draft>(emptyDraft());
+  const [adding, setAdding] = useState<Record<number, string>>({});
+  const [saving, setSaving] = useState(false);
+  const selectedId = searchParams.get('id');
+
+  const loadTrips = () =>
+    tripsAPI.getMyTrips()
+      .then(res => setTrips(res.data.trips || []))
+      .catch(() => toast.error('โหลดรายการทริปไม่สำเร็จ'));
+
+  useEffect(() => {
+    loadTrips();
+    api.get('/api/places')
+      .then(res => setPlaces(Array.isArray(res.data) ? res.data : []))
+      .catch(() => setPlaces([]));
+  }, []);
+
+  useEffect(() => {
+    if (!selectedId) {
+      setTrip(null);
+      setPlan(null);
+      setDraft(emptyDraft());
+      return;
+    }
+    tripsAPI.getMyTrip(selectedId)
+      .then(res => {
+        setTrip(res.data.trip);
+        setPlan(res.data.plan);
+        setDraft(draftOf(res.data.trip));
+      })
+      .catch(err => toast.error(err.response?.data?.detail || 'ไม่พบทริปนี้'));
+  }, [selectedId]);
+
+  const updateDay = (d: number, change: (day: TripDay) => TripDay) =>
+    setDraft(prev => ({ ...prev, days: prev.days.map((day, i) => (i === d ? change(day) : day)) }));
+
+  const moveStop = (d: number, s: number, by: number) =>
+    updateDay(d, day => {
+      const stops = [...day.stops];
+      const to = s + by;
+      if (to < 0 || to >= stops.length) return day;
+      [stops[s], stops[to]] = [stops[to], stops[s]];
+      return { ...day, stops };
+    });
+
+  // ย้ายสถานที่ไปท้ายวันก่อนหน้าหรือวันถัดไป
+  const moveStopToDay = (d: number, s: number, by: number) =>
+    setDraft(prev => {
+      const to = d + by;
+      if (to < 0 || to >= prev.days.length) return prev;
+      const days = prev.days.map(day => ({ ...day, stops: [...day.stops] }));
+      const [stop] = days[d].stops.splice(s, 1);
+      days[to].stops.push(stop);
+      return { ...prev, days };
+    });
+
+  const addStop = (d: number) => {
+    const place = places.find(p => p.PlaceID === adding[d]);
+    if (!place) return;
+    updateDay(d, day => ({ ...day, stops: [...day.stops, { placeId: place.PlaceID, placeName: place.Name, duration: 60 }] }));
+    setAdding(prev => ({ ...prev, [d]: '' }));
+  };
+
+  const handleSave = async () => {
+    if (!draft.name.trim()) {
+      toast.error('กรุณาตั้งชื่อทริป');
+      return;
+    }
+    setSaving(true);
+    try {
+      const res = trip
+        ? await tripsAPI.updateTrip(trip.id, inputOf(draft, trip.updatedAt))
+        : await tripsAPI.createTrip(inputOf(draft));
+      setTrip(res.data.trip);
+      setPlan(res.data.plan);
+      setDraft(draftOf(res.data.trip));
+      if (!trip) setSearchParams({ id: res.data.trip.id });
+      loadTrips();
+      toast.success('บันทึกทริปแล้ว');
+    } catch (err: any) {
+      if (err.response?.status === 409) {
+        toast.error('ทริปถูกแก้ไขจากที่อื่น กรุณาโหลดใหม่');
+      } else {
+        toast.error(err.response?.data?.detail || 'บันทึกทริปไม่สำเร็จ');
+      }
+    } finally {
+      setSaving(false);
+    }
+  };
+
+  const handleDuplicate = async () => {
+    if (!trip) return;
+    try {
+      const res = await tripsAPI.duplicateTrip(trip.id);
+      loadTrips();
+      setSearchParams({ id: res.data.trip.id });
+      toast.success('คัดลอกทริปแล้ว');
+    } catch (err: any) {
+      toast.error(err.response?.data?.detail || 'คัดลอกทริปไม่สำเร็จ');
+    }
+  };
+
+  const handleDelete = async () => {
+    if (!trip || !window.confirm(`ลบทริป "${trip.name}"?`)) return;
+    try {
+      await tripsAPI.deleteTrip(trip.id);
+      loadTrips();
+      setSearchParams({});
+    } catch (err: any) {
+      toast.error(err.response?.data?.detail || 'ลบทริปไม่สำเร็จ');
+    }
+  };
+
+  return (
+    <div className="container mx-auto px-4 py-10">
+      <h1 className="text-2xl font-bold flex items-center gap-2 mb-6"><MapIcon className="w-6 h-6 text-blue-600" /> แผนการเดินทาง</h1>
+      <div className="grid gap-6 lg:grid-cols-4">
+        <div className="space-y-2">
+          <button className="w-full bg-blue-600 hover:bg-blue-700 text-white px-4 py-2 rounded flex items-center justify-center gap-1" onClick={() => setSearchParams({})}>
+            <Plus className="w-4 h-4" /> ทริปใหม่
+          </button>
+          {trips.map(t => (
+            <button
+              key={t.id}
+              className={`w-full text-left p-3 rounded border ${t.id === selectedId ? 'border-blue-600 bg-blue-50' : 'border-gray-200 bg-white'}`}
+              onClick={() => setSearchParams({ id: t.id })}
+            >
+              <div className="font-medium">{t.name}</div>
+              <div className="text-xs text-gray-500">{t.days.length} วัน{t.startDate ? ` · เริ่ม ${t.startDate}` : ''}{t.visibility === 'public' ? ' · แชร์แล้ว' : ''}</div>
+            </button>
+          ))}
+        </div>
+
+        <div className="lg:col-span-3 space-y-6">
+          <div className="bg-white rounded-xl shadow p-4 space-y-3">
+            <input className="w-full border rounded px-3 py-2" placeholder="ชื่อทริป" maxLength={100} value={draft.name} onChange={e => setDraft({ ...draft, name: e.target.value })} />
+            <textarea className="w-full border rounded px-3 py-2" placeholder="รายละเอียด" maxLength={1000} value={draft.description} onChange={e => setDraft({ ...draft, description: e.target.value })} />
+            <div className="flex flex-wrap gap-4 items-center text-sm">
+              <label className="flex items-center gap-2">วันเริ่ม
+                <input type="date" className="border rounded px-2 py-1" value={draft.startDate} onChange={e => setDraft({ ...draft, startDate: e.target.value })} />
+              </label>
+              <label className="flex items-center gap-2">
+                <input type="checkbox" checked={draft.visibility === 'public'} onChange={e => setDraft({ ...draft, visibility: e.target.checked ? 'public' : 'private' })} />
+                แชร์เป็นสาธารณะ
+              </label>
+              {trip?.shareUrl && (
+                <button className="text-blue-600 flex items-center gap-1" onClick={() => { navigator.clipboard.writeText(trip.shareUrl!); toast.success('คัดลอกลิงก์แล้ว'); }}>
+                  <Link2 className="w-4 h-4" /> คัดลอกลิงก์
+                </button>
+              )}
+            </div>
+          </div>
+
+          {draft.days.map((day, d) => (
+            <div key={d} className="bg-white rounded-xl shadow p-4">
+              <div className="flex justify-between items-center mb-3">
+                <h3 className="font-semibold">วันที่ {d + 1}</h3>
+                <div className="flex items-center gap-3 text-sm">
+                  <label className="flex items-center gap-1">เริ่ม
+                    <input type="time" className="border rounded px-2 py-1" value={day.startTime} onChange={e => updateDay(d, prev => ({ ...prev, startTime: e.target.value }))} />
+                  </label>
+                  {draft.days.length > 1 && (
+                    <button className="text-red-500" title="ลบวันนี้" onClick={() => setDraft({ ...draft, days: draft.days.filter((_, i) => i !== d) })}>
+                      <Trash2 className="w-4 h-4" />
+                    </button>
+                  )}
+                </div>
+              </div>
+              {day.stops.map((stop, s) => (
+                <div key={s} className="flex flex-wrap items-center gap-2 py-2 border-b last:border-b-0">
+                  <span className="flex-1 min-w-[10rem]">{s + 1}. {stop.placeName}</span>
+                  <label className="text-sm flex items-center gap-1">
+                    <input type="number" min={5} max={720} step={5} className="border rounded px-2 py-1 w-20" value={stop.duration}
+                      onChange={e => updateDay(d, prev => ({ ...prev, stops: prev.stops.map((x, i) => (i === s ? { ...x, duration: Number(e.target.value) } : x)) }))} />
+                    นาที
+                  </label>
+                  <button title="ขึ้น" onClick={() => moveStop(d, s, -1)}><ArrowUp className="w-4 h-4" /></button>
+                  <button title="ลง" onClick={() => moveStop(d, s, 1)}><ArrowDown className="w-4 h-4" /></button>
+                  <button title="ย้ายไปวันก่อนหน้า" onClick={() => moveStopToDay(d, s, -1)}><ArrowLeft className="w-4 h-4" /></button>
+                  <button title="ย้ายไปวันถัดไป" onClick={() => moveStopToDay(d, s, 1)}><ArrowRight className="w-4 h-4" /></button>
+                  <button title="ลบ" className="text-red-500" onClick={() => updateDay(d, prev => ({ ...prev, stops: prev.stops.filter((_, i) => i !== s) }))}>
+                    <Trash2 className="w-4 h-4" />
+                  </button>
+                </div>
+              ))}
+              <div className="flex gap-2 mt-3">
+                <select className="flex-1 border rounded px-2 py-1" value={adding[d] || ''} onChange={e => setAdding({ ...adding, [d]: e.target.value })}>
+                  <option value="">เลือกสถานที่...</option>
+                  {places.map(p => <option key={p.PlaceID} value={p.PlaceID}>{p.Name}</option>)}
+                </select>
+                <button className="bg-blue-600 hover:bg-blue-700 text-white px-3 py-1 rounded text-sm" disabled={!adding[d]} onClick={() => addStop(d)}>เพิ่ม</button>
+              </div>
+            </div>
+          ))}
+
+          <div className="flex flex-wrap gap-2">
+            <button className="border border-blue-600 text-blue-600 hover:bg-blue-50 px-4 py-2 rounded flex items-center gap-1" disabled={draft.days.length >= 30}
+              onClick={() => setDraft({ ...draft, days: [...draft.days, { startTime: '09:00', stops: [] }] })}>
+              <Plus className="w-4 h-4" /> เพิ่มวัน
+            </button>
+            <button className="bg-blue-600 hover:bg-blue-700 text-white px-4 py-2 rounded" disabled={saving} onClick={handleSave}>
+              {saving ? 'กำลังบันทึก...' : 'บันทึกและคำนวณแผน'}
+            </button>
+            {trip && (
+              <>
+                <button className="border px-4 py-2 rounded flex items-center gap-1" onClick={handleDuplicate}><Copy className="w-4 h-4" /> คัดลอกทริป</button>
+                <button className="border border-red-500 text-red-500 px-4 py-2 rounded flex items-center gap-1" onClick={handleDelete}><Trash2 className="w-4 h-4" /> ลบทริป</button>
+              </>
+            )}
+          </div>
+
+          {trip && plan && (
+            <div>
+              <h2 className="text-xl font-semibold mb-2">แผนที่คำนวณแล้ว</h2>
+              <p className="text-xs text-gray-400 mb-2">คำนวณจากทริปที่บันทึกล่าสุด</p>
+              <TripPlanView trip={trip} plan={plan} />
+            </div>
+          )}
+        </div>
+      </div>
+    </div>
+  );
+};
+
+export default Trips;
@@ -74,7 +74,7 @@ const AdminDashboard = () => {
       setErrorMsg(null);
     } catch (error: any) {
       console.error('API /api/admin/users error:', error);
-      const msg = error?.response?.data?.error || error?.message || 'Failed to fetch users';
+      const msg = error?.response?.data?.detail || error?.message || 'Failed to fetch users';
       setErrorMsg(msg);
       toast.error(msg);
     } finally {
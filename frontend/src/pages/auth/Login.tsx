@@ -1,5 +1,5 @@
-import { useState } from 'react';
-import { Link } from 'react-router-dom';
+import { useEffect, useState } from 'react';
+import { Link, useSearchParams } from 'react-router-dom';
 import { useForm } from 'react-hook-form';
 import styled from 'styled-components';
 import { motion } from 'framer-motion';
@@ -7,6 +7,7 @@ import { Mail, Lock, AlertTriangle, Eye, EyeOff } from 'lucide-react';
 import Input from '../../components/ui/Input';
 import Button from '../../components/ui/Button';
 import { useAuth } from '../../hooks/useAuth';
+import { api } from '../../services/api';
 
 interface LoginFormValues {
   email: string;
@@ -26,6 +27,21 @@ interface AuthContextType {
   clearError: () => void;
 }
 
+interface OIDCProvider {
+  name: string;
+  displayName: string;
+  loginUrl: string;
+}
+
+// ข้อความสำหรับ ?oidcError= ที่ backend ส่งกลับมาหลัง social login ไม่สำเร็จ
+const oidcErrorMessages: Record<string, string> = {
+  state_invalid: 'การเข้าสู่ระบบหมดเวลา กรุณาลองใหม่อีกครั้ง',
+  oidc_failed: 'เข้าสู่ระบบด้วยบัญชีภายนอกไม่สำเร็จ',
+  email_unverified: 'อีเมลของบัญชีภายนอกยังไม่ได้รับการยืนยัน',
+  account_banned: 'บัญชีของคุณถูกระงับการใช้งาน',
+  provider_not_found: 'ไม่ได้เปิดใช้การเข้าสู่ระบบด้วยผู้ให้บริการนี้',
+};
+
 const Form = styled.form`
   width: 100%;
 `;
@@ -71,6 +87,28 @@ const RegisterLink = styled.div`
   }
 `;
 
+const Divider = styled.div`
+  display: flex;
+  align-items: center;
+  gap: 0.75rem;
+  margin: 1.5rem 0 1rem;
+  font-size: 0.875rem;
+  color: ${({ theme }) => theme.colors.neutral[500]};
+
+  &::before,
+  &::after {
+    content: '';
+    flex: 1;
+    border-top: 1px solid ${({ theme }) => theme.colors.neutral[200]};
+  }
+`;
+
+const ProviderButtons = styled.div`
+  display: flex;
+  flex-direction: column;
+  gap: 0.75rem;
+`;
+
 const RememberMeWrapper = styled.label`
   display: flex;
   align-items: center;
@@ -100,6 +138,21 @@ const Login = () => {
   const [formError, setFormError] = useState<string | null>(null);
   const [banReason, setBanReason] = useState<string | null>(null);
   const [showPassword, setShowPassword] = useState(false);
+  const [providers, setProviders] = useState<OIDCProvider[]>([]);
+  const [searchParams] = useSearchParams();
+
+  useEffect(() => {
+    api.get('/api/auth/oidc/providers')
+      .then((res) => setProviders(res.data.providers || []))
+      .catch(() => setProviders([]));
+  }, []);
+
+  useEffect(() => {
+    const oidcError = searchParams.get('oidcError');
+    if (oidcError) {
+      setFormError(oidcErrorMessages[oidcError] || oidcErrorMessages.oidc_failed);
+    }
+  }, [searchParams]);
   
   const {
     register,
@@ -241,6 +294,27 @@ const Login = () => {
           </Button>
         </FormActions>
 
+        {providers.length > 0 && (
+          <>
+            <Divider>หรือเข้าสู่ระบบด้วย</Divider>
+            <ProviderButtons>
+              {providers.map((p) => (
+                <Button
+                  key={p.name}
+                  type="button"
+                  variant="outline"
+                  fullWidth
+                  onClick={() => {
+                    window.location.href = api.getUri({ url: p.loginUrl });
+                  }}
+                >
+                  {p.displayName}
+                </Button>
+              ))}
+            </ProviderButtons>
+          </>
+        )}
+
         <RegisterLink>
           Don't have an account? <Link to="/register">Sign up</Link>
         </RegisterLink>
@@ -0,0 +1,30 @@
+import { useEffect, useRef } from 'react';
+import { useNavigate } from 'react-router-dom';
+import LoadingFallback from '../../components/ui/LoadingFallback';
+import { useAuth } from '../../hooks/useAuth';
+
+// หน้าที่ backend redirect กลับมาหลัง social login สำเร็จ โดยส่ง token มาใน fragment (#token=...)
+const OIDCCallback = () => {
+  const { loginWithToken } = useAuth();
+  const navigate = useNavigate();
+  const handled = useRef(false);
+
+  useEffect(() => {
+    if (handled.current) return;
+    handled.current = true;
+
+    const params = new URLSearchParams(window.location.hash.slice(1));
+    const token = params.get('token');
+    // ลบ token ออกจาก URL ไม่ให้ค้างใน history
+    window.history.replaceState(null, '', window.location.pathname);
+    if (!token) {
+      navigate('/login?oidcError=oidc_failed', { replace: true });
+      return;
+    }
+    loginWithToken(token, params.has('passwordChangeReason'));
+  }, []);
+
+  return <LoadingFallback />;
+};
+
+export default OIDCCallback;
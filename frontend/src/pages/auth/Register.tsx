@@ -222,10 +222,9 @@ const Register = () => {
             {...register('password', {
               required: 'Password is required',
               minLength: {
-                value: 7,
-                message: 'Password must be at least 7 characters',
+                value: 10,
+                message: 'Password must be at least 10 characters',
               },
-              validate: (value) => /[A-Za-z]/.test(value) || 'Password must contain at least one letter',
             })}
             onChange={() => error && clearError()}
             rightIcon={
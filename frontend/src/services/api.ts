@@ -1,4 +1,5 @@
 import axios from 'axios';
+import type { CollectionVisibility, PlaceClaimInput, ReviewVotes, TripInput } from '../types/place';
 
 // Base API instance
 export const api = axios.create({
@@ -6,6 +7,8 @@ export const api = axios.create({
   timeout: 10000,
   headers: {
     'Content-Type': 'application/json',
+    // backend error messages (problem+json detail) are localized from this
+    'Accept-Language': 'th',
   },
   withCredentials: true,
 });
@@ -127,21 +130,62 @@ export const reviewsAPI = {
     return axios.delete(`/api/reviews/${reviewId}`);
   },
 
-  // Like/Unlike review
-  toggleLike: async (reviewId: string) => {
-    return axios.post(`/api/reviews/${reviewId}/like`);
-  },
+  // Votes on a review; all four respond with ReviewVotes. A like and a
+  // not-helpful mark exclude each other, casting one withdraws the other.
+  likeReview: (reviewId: string) => api.put<ReviewVotes>(`/api/reviews/${reviewId}/like`),
+
+  unlikeReview: (reviewId: string) => api.delete<ReviewVotes>(`/api/reviews/${reviewId}/like`),
+
+  markNotHelpful: (reviewId: string) => api.put<ReviewVotes>(`/api/reviews/${reviewId}/not-helpful`),
+
+  unmarkNotHelpful: (reviewId: string) => api.delete<ReviewVotes>(`/api/reviews/${reviewId}/not-helpful`),
+
+  // Comments are paged per review; pass parentId to list the replies to a comment
+  getComments: (reviewId: string, page = 1, limit = 20, parentId?: string) =>
+    api.get(`/api/reviews/${reviewId}/comments`, { params: { page, limit, parentId } }),
+
+  addComment: (reviewId: string, text: string, parentId?: string) =>
+    api.post(`/api/reviews/${reviewId}/comments`, { text, parentId }),
+
+  updateComment: (reviewId: string, commentId: string, text: string) =>
+    api.put(`/api/reviews/${reviewId}/comments/${commentId}`, { text }),
+
+  deleteComment: (reviewId: string, commentId: string) =>
+    api.delete(`/api/reviews/${reviewId}/comments/${commentId}`),
+
+  likeComment: (reviewId: string, commentId: string) =>
+    api.put(`/api/reviews/${reviewId}/comments/${commentId}/like`),
 
-  // Add comment to review
-  addComment: async (reviewId: string, comment: string) => {
-    return axios.post(`/api/reviews/${reviewId}/comments`, { text: comment });
+  unlikeComment: (reviewId: string, commentId: string) =>
+    api.delete(`/api/reviews/${reviewId}/comments/${commentId}/like`),
+
+  flagComment: (reviewId: string, commentId: string, data: { reason: string; detail?: string }) =>
+    api.post(`/api/reviews/${reviewId}/comments/${commentId}/flag`, data),
+
+  // Photos are sent as multipart form data; alt describes the photo for screen readers
+  addReviewPhoto: (reviewId: string, photo: File, alt = '') => {
+    const form = new FormData();
+    form.append('photo', photo);
+    form.append('alt', alt);
+    return api.post(`/api/reviews/${reviewId}/photos`, form);
   },
 
-  likeReview: (reviewId: string) => api.post(`/api/reviews/${reviewId}/like`),
+  updateReviewPhoto: (reviewId: string, photoId: string, alt: string) =>
+    api.patch(`/api/reviews/${reviewId}/photos/${photoId}`, { alt }),
+
+  deleteReviewPhoto: (reviewId: string, photoId: string) =>
+    api.delete(`/api/reviews/${reviewId}/photos/${photoId}`),
 
   reportReview: (reviewId: string, data: { type: string; detail: string }) => {
     return api.post(`/api/reviews/${reviewId}/report`, data);
   },
+
+  // คำตอบจากเจ้าของสถานที่ (รีวิวละหนึ่งคำตอบ, PUT ใช้ได้ทั้งเพิ่มและแก้ไข)
+  respondToReview: (reviewId: string, text: string) =>
+    api.put(`/api/reviews/${reviewId}/response`, { text }),
+
+  deleteResponse: (reviewId: string) =>
+    api.delete(`/api/reviews/${reviewId}/response`),
 };
 
 // Admin API
@@ -169,6 +213,37 @@ export const adminAPI = {
   
   getStats: () => 
     api.get('/admin/stats'),
+
+  // Reviews held by screening, oldest first, with the rules that held them
+  getHeldReviews: (page = 1, limit = 20) =>
+    api.get('/admin/reviews/held', { params: { page, limit } }),
+
+  moderateReview: (id: string, status: 'published' | 'hidden') =>
+    api.patch(`/admin/reviews/${id}/status`, { status }),
+
+  removeReviewPhoto: (reviewId: string, photoId: string) =>
+    api.delete(`/admin/reviews/${reviewId}/photos/${photoId}`),
+
+  getFlaggedComments: (page = 1, limit = 20) =>
+    api.get('/admin/comments/flagged', { params: { page, limit } }),
+
+  moderateComment: (id: string, status: 'visible' | 'hidden') =>
+    api.patch(`/admin/comments/${id}/status`, { status }),
+
+  removeReviewResponse: (reviewId: string) =>
+    api.delete(`/admin/reviews/${reviewId}/response`),
+
+  getPlaceClaims: (status?: 'pending' | 'approved' | 'rejected') =>
+    api.get('/admin/place-claims', { params: { status } }),
+
+  approvePlaceClaim: (id: string) =>
+    api.post(`/admin/place-claims/${id}/approve`),
+
+  rejectPlaceClaim: (id: string, note: string) =>
+    api.post(`/admin/place-claims/${id}/reject`, { note }),
+
+  removePlaceOwner: (placeId: string) =>
+    api.delete(`/admin/places/${placeId}/owner`),
 };
 
 export const placesAPI = {
@@ -177,5 +252,57 @@ export const placesAPI = {
   },
   getPlaceById: async (id: string) => {
     return api.get(`/api/places/${id}`);
-  }
+  },
+  claimPlace: (id: string, data: PlaceClaimInput) =>
+    api.post(`/api/places/${id}/claims`, data),
+  // Only the distance from the place and the accuracy are stored, not the position
+  checkIn: (id: string, lat: number, lng: number, accuracy?: number) =>
+    api.post(`/api/places/${id}/check-ins`, { lat, lng, accuracy }),
+  getMyCheckIns: (page = 1, limit = 20) =>
+    api.get('/api/profile/check-ins', { params: { page, limit } }),
+};
+
+// Saved places. Collection id "favorites" names the default collection.
+export const collectionsAPI = {
+  getMyCollections: () => api.get('/api/profile/collections'),
+  getMyCollection: (id: string) => api.get(`/api/profile/collections/${id}`),
+  createCollection: (data: { name: string; description?: string; visibility?: CollectionVisibility }) =>
+    api.post('/api/profile/collections', data),
+  updateCollection: (id: string, data: { name?: string; description?: string; visibility?: CollectionVisibility }) =>
+    api.patch(`/api/profile/collections/${id}`, data),
+  deleteCollection: (id: string) => api.delete(`/api/profile/collections/${id}`),
+  reorderCollection: (id: string, placeIds: string[]) =>
+    api.put(`/api/profile/collections/${id}/order`, { placeIds }),
+  savePlace: (id: string, placeId: string, note = '') =>
+    api.put(`/api/profile/collections/${id}/places/${placeId}`, { note }),
+  removePlace: (id: string, placeId: string) =>
+    api.delete(`/api/profile/collections/${id}/places/${placeId}`),
+  // Public view of a shared collection, no sign-in needed
+  getSharedCollection: (id: string) => api.get(`/api/collections/${id}`),
+};
+
+// Trip plans; every trip response comes with its plan
+export const tripsAPI = {
+  getMyTrips: () => api.get('/api/profile/trips'),
+  getMyTrip: (id: string) => api.get(`/api/profile/trips/${id}`),
+  createTrip: (data: TripInput) => api.post('/api/profile/trips', data),
+  // Replaces the whole trip, which is also how stops are reordered
+  updateTrip: (id: string, data: TripInput) => api.put(`/api/profile/trips/${id}`, data),
+  deleteTrip: (id: string) => api.delete(`/api/profile/trips/${id}`),
+  duplicateTrip: (id: string) => api.post(`/api/profile/trips/${id}/duplicate`),
+  // Public view of a shared trip, no sign-in needed
+  getSharedTrip: (id: string) => api.get(`/api/trips/${id}`),
+};
+
+// Tools for verified place owners
+export const ownerAPI = {
+  getMyClaims: () => api.get('/api/profile/place-claims'),
+
+  getPlaces: () => api.get('/api/owner/places'),
+
+  updatePlace: (id: string, data: { description?: string; phone?: string; website?: string; hours?: string }) =>
+    api.patch(`/api/owner/places/${id}`, data),
+
+  getAnalytics: (id: string, params?: { from?: string; to?: string; interval?: 'day' | 'week' | 'month' }) =>
+    api.get(`/api/owner/places/${id}/analytics`, { params }),
 };
\ No newline at end of file
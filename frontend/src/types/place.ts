@@ -29,6 +29,7 @@ export interface Place {
   address?: string;
   phone?: string;
   website?: string;
+  ownerId?: string;
 }
 
 export interface Review {
@@ -41,25 +42,91 @@ export interface Review {
   rating: number;
   comment: string;
   likes: number;
-  comments?: Comment[];
+  commentCount?: number;
   liked_by?: string[];
+  notHelpful?: number;
+  not_helpful_by?: string[];
   createdAt?: string;
   updatedAt?: string;
-  images?: ReviewImage[];
+  photos?: ReviewPhoto[];
+  response?: OwnerResponse;
+  status?: 'published' | 'held' | 'hidden';
+  verifiedVisit?: boolean;
+}
+
+// การเช็กอินที่สถานที่ ใช้ยืนยันว่าผู้รีวิวไปมาจริง
+export interface CheckIn {
+  id: string;
+  placeId: string;
+  placeName: string;
+  distance: number;
+  createdAt: string;
+}
+
+// กฎการคัดกรองที่ทำให้รีวิวหรือความคิดเห็นถูกพักไว้รอผู้ดูแลตรวจ
+export interface ScreeningFlag {
+  rule: 'profanity' | 'link' | 'phone' | 'duplicate' | 'burst' | 'rating_outlier';
+  detail?: string;
+}
+
+// สถานะโหวตของรีวิวหลังกดไลก์หรือกดไม่เป็นประโยชน์
+export interface ReviewVotes {
+  liked: boolean;
+  likes: number;
+  notHelpful: boolean;
+  notHelpfulCount: number;
+}
+
+// คำตอบสาธารณะจากเจ้าของสถานที่ที่ยืนยันแล้ว
+export interface OwnerResponse {
+  text: string;
+  userId: string;
+  createdAt: string;
+  updatedAt: string;
+}
+
+export interface PlaceClaimInput {
+  businessName: string;
+  position: string;
+  contactPhone: string;
+  evidence: string;
+  evidenceUrls?: string[];
+}
+
+export interface PlaceClaim extends PlaceClaimInput {
+  id: string;
+  placeId: string;
+  placeName: string;
+  userId: string;
+  username: string;
+  status: 'pending' | 'approved' | 'rejected';
+  reviewNote?: string;
+  reviewedAt?: string;
+  createdAt: string;
 }
 
 export interface Comment {
   id: string;
+  reviewId: string;
+  parentId?: string;
   user_id: string;
   username: string;
-  content: string;
-  createdAt: string;
+  text: string;
+  status: 'visible' | 'hidden' | 'deleted' | 'held';
+  likes: number;
+  liked_by: string[];
+  replyCount: number;
+  created_at: string;
+  editedAt?: string;
 }
 
-export interface ReviewImage {
+export interface ReviewPhoto {
   id: string;
-  filename: string;
-  path: string;
+  url: string;
+  thumbnailUrl: string;
+  alt: string;
+  width: number;
+  height: number;
   createdAt: string;
 }
 
@@ -78,4 +145,115 @@ export interface PlaceFormData {
   priceLevel: number;
   imageUrl: string[];
   tags: string[];
-} 
\ No newline at end of file
+} 
+// คอลเลกชันสถานที่ที่ผู้ใช้บันทึกไว้ ทุกคนมีรายการโปรด (isDefault) หนึ่งรายการ
+export type CollectionVisibility = 'private' | 'public';
+
+export interface CollectionPlace {
+  placeId: string;
+  placeName: string;
+  note?: string;
+  addedAt: string;
+}
+
+export interface PlaceCollection {
+  id: string;
+  userId: string;
+  name: string;
+  description: string;
+  visibility: CollectionVisibility;
+  isDefault: boolean;
+  places: CollectionPlace[];
+  createdAt: string;
+  updatedAt: string;
+  shareUrl?: string;
+}
+
+export type TripVisibility = 'private' | 'public';
+
+export interface TripStop {
+  placeId: string;
+  placeName: string;
+  duration: number; // นาที
+  note?: string;
+}
+
+export interface TripDay {
+  startTime: string; // HH:MM
+  stops: TripStop[];
+}
+
+export interface Trip {
+  id: string;
+  userId: string;
+  name: string;
+  description: string;
+  startDate?: string; // YYYY-MM-DD
+  visibility: TripVisibility;
+  days: TripDay[];
+  createdAt: string;
+  updatedAt: string;
+  shareUrl?: string;
+}
+
+export interface TripInput {
+  name: string;
+  description?: string;
+  startDate?: string;
+  visibility?: TripVisibility;
+  days: { startTime?: string; stops: { placeId: string; duration: number; note?: string }[] }[];
+  updatedAt?: string;
+}
+
+export type TripWarningCode =
+  | 'closed'
+  | 'opens_later'
+  | 'closes_early'
+  | 'hours_vary'
+  | 'no_route'
+  | 'no_coordinates'
+  | 'past_midnight';
+
+export interface TripWarning {
+  code: TripWarningCode;
+  at?: string;
+}
+
+export interface TripLeg {
+  modes: string[];
+  distance: number; // km
+  duration: number; // นาที
+  cost: number; // บาท
+  estimated: boolean;
+  warnings: TripWarning[];
+}
+
+export interface TripVisit {
+  arrive: string;
+  depart: string;
+  wait: number;
+  warnings: TripWarning[];
+}
+
+export interface TripTotals {
+  duration: number;
+  travelTime: number;
+  visitTime: number;
+  waitTime: number;
+  distance: number;
+  cost: number;
+}
+
+export interface TripDayPlan {
+  start: string;
+  end: string;
+  visits: TripVisit[];
+  legs: TripLeg[];
+  totals: TripTotals;
+  warnings: TripWarning[];
+}
+
+export interface TripPlan {
+  days: TripDayPlan[];
+  totals: TripTotals;
+}